@@ -15,6 +15,12 @@ token:
         type: badger
         opts:
           path: {{ NodeKVSPath }}
+  owner:
+    ownerdb:
+      persistence:
+        type: badger
+        opts:
+          path: {{ NodeKVSPath }}/ownerdb
   tms: {{ range TMSs }}
   - channel: {{ .Channel }}
     namespace: {{ .Namespace }}
@@ -28,6 +28,8 @@ const (
 	FabTokenBasics
 	ZKATDLogDVP
 	FabTokenDVP
+	ZKATDLogEscrow
+	FabTokenEscrow
 )
 
 // StartPortForNode On linux, the default ephemeral port range is 32768-60999 and can be
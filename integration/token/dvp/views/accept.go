@@ -10,7 +10,7 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/assert"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
-	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttx"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
@@ -18,11 +18,11 @@ type AcceptCashView struct{}
 
 func (a *AcceptCashView) Call(context view.Context) (interface{}, error) {
 	// Respond to a request for an identity
-	id, err := ttx.RespondRequestRecipientIdentity(context)
+	id, err := ttxcc.RespondRequestRecipientIdentity(context)
 	assert.NoError(err, "failed to respond to identity request")
 
 	// Expect a transaction
-	tx, err := ttx.ReceiveTransaction(context)
+	tx, err := ttxcc.ReceiveTransaction(context)
 	assert.NoError(err, "failed to receive tokens")
 
 	// Check that the transaction is as expected
@@ -31,16 +31,16 @@ func (a *AcceptCashView) Call(context view.Context) (interface{}, error) {
 	assert.True(outputs.Count() > 0)
 	assert.True(outputs.ByRecipient(id).Count() > 0)
 
-	unpsentTokens, err := ttx.MyWallet(context).ListTokens(ttx.WithType(outputs.At(0).Type))
+	unpsentTokens, err := ttxcc.MyWallet(context).ListTokens(ttxcc.WithType(outputs.At(0).Type))
 	assert.NoError(err, "failed retrieving the unspent tokens for type [%s]", outputs.At(0).Type)
 	assert.True(unpsentTokens.Sum(64).Cmp(token2.NewQuantityFromUInt64(220)) <= 0, "cannot have more than 220 unspent quantity for type [%s]", outputs.At(0).Type)
 
 	// Accept and send back
-	_, err = context.RunView(ttx.NewAcceptView(tx))
+	_, err = context.RunView(ttxcc.NewAcceptView(tx))
 	assert.NoError(err, "failed to accept new tokens")
 
 	// Wait for finality
-	_, err = context.RunView(ttx.NewFinalityView(tx))
+	_, err = context.RunView(ttxcc.NewFinalityView(tx))
 	assert.NoError(err, "new tokens were not committed")
 
 	return nil, nil
@@ -9,24 +9,18 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric/services/state"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/assert"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
-	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttx"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
 )
 
+// cashIssuers are the organizations whose members can issue cash
+var cashIssuers = ttxcc.MSPIssuers{"TokenOrg"}
+
 type TokenApproveView struct{}
 
 func (a *TokenApproveView) Call(context view.Context) (interface{}, error) {
-	tx, err := ttx.ReceiveTransaction(context)
-	assert.NoError(err)
-
-	// Validate transaction
-	assert.NoError(ttx.NewApprover(context).Validate(tx))
-
-	// Sign and send back
-	_, err = context.RunView(ttx.NewApproveView(tx))
-	assert.NoError(err)
-
-	// Wait for confirmation
-	return context.RunView(ttx.NewFinalityView(tx))
+	// Validate the token request, sign and send back
+	return context.RunView(ttxcc.NewApproveView(cashIssuers))
 }
 
 type HouseApproveView struct{}
@@ -10,29 +10,29 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/assert"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
-	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttx"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
 )
 
 type AuditView struct{}
 
 func (a *AuditView) Call(context view.Context) (interface{}, error) {
-	tx, err := ttx.ReceiveTransaction(context)
+	tx, err := ttxcc.ReceiveTransaction(context)
 	assert.NoError(err, "failed receiving transaction")
 
 	assert.NoError(tx.IsValid(), "failed verifying transaction")
 
-	w := ttx.MyAuditorWallet(context)
+	w := ttxcc.MyAuditorWallet(context)
 	assert.NotNil(w, "failed getting default auditor wallet")
-	assert.NoError(ttx.NewAuditor(context, w).Validate(tx), "failed auditing verification")
+	assert.NoError(ttxcc.NewAuditor(context, w).Validate(tx), "failed auditing verification")
 
-	return context.RunView(ttx.NewAuditApproveView(w, tx))
+	return context.RunView(ttxcc.NewAuditApproveView(w, tx))
 }
 
 type RegisterAuditorView struct {
 }
 
 func (r *RegisterAuditorView) Call(context view.Context) (interface{}, error) {
-	return context.RunView(ttx.NewRegisterAuditorView(
+	return context.RunView(ttxcc.NewRegisterAuditorView(
 		fabric.GetIdentityProvider(context).DefaultIdentity(),
 		&AuditView{},
 	))
@@ -11,39 +11,43 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/assert"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
-	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttx"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
 )
 
 type BuyHouseView struct{}
 
 func (b *BuyHouseView) Call(context view.Context) (interface{}, error) {
-	// 1. Respond to transfer request
-	_, other, err := ttx.ExchangeRecipientIdentitiesResponder(context)
+	// 1. Respond to a request for an identity for the house
+	me, err := state.RespondRequestRecipientIdentity(context)
+	assert.NoError(err, "failed to respond to identity request")
+
+	// 2. Respond to transfer request
+	_, other, err := ttxcc.ExchangeRecipientIdentitiesResponder(context)
 	assert.NoError(err, "failed getting identity")
 
-	tokenTx, action, err := ttx.ReceiveAction(context)
+	tokenTx, action, err := ttxcc.ReceiveAction(context)
 	assert.NoError(err, "failed receiving action")
 
 	err = tokenTx.Transfer(
-		ttx.MyWalletForChannel(context, tokenTx.Channel()),
+		ttxcc.MyWalletForChannel(context, tokenTx.Channel()),
 		action.Type, []uint64{action.Amount}, []view.Identity{action.Recipient},
 	)
 	assert.NoError(err, "failed appending transfer")
 
-	_, err = context.RunView(ttx.NewCollectActionsResponderView(tokenTx, action))
+	_, err = context.RunView(ttxcc.NewCollectActionsResponderView(tokenTx, action))
 	assert.NoError(err, "failed responding to action collect")
 
-	// 2. Endorse Sell Transaction
-	// Respond to a request for an identity
-	me, err := state.RespondRequestRecipientIdentity(context)
-	assert.NoError(err, "failed to respond to identity request")
+	// 3. Sign the token request
+	_, err = context.RunView(ttxcc.NewEndorseView(tokenTx))
+	assert.NoError(err, "failed signing token request")
 
+	// 4. Endorse Sell Transaction
 	txBoxed, err := context.RunView(endorser.NewReceiveTransactionView())
-	assert.NoError(err, "failed responding to action collect")
+	assert.NoError(err, "failed receiving transaction")
 	tx := txBoxed.(*endorser.Transaction)
 
-	// 3. Validate transaction: The transaction consists of two namespace (zkat and house)
-	tokenTx, err = ttx.Wrap(context, tx)
+	// 5. Validate transaction: The transaction consists of two namespace (zkat and house)
+	tokenTx, err = ttxcc.Wrap(context, tx)
 	assert.NoError(err)
 
 	inputs, err := tokenTx.Inputs()
@@ -65,10 +69,10 @@ func (b *BuyHouseView) Call(context view.Context) (interface{}, error) {
 	assert.Equal(action.Amount, house.Valuation)
 	assert.Equal(me, house.Owner)
 
-	// 4. Sign and send back
-	_, err = context.RunView(endorser.NewEndorseView(tx, append([]view.Identity{me}, tokenTx.Signers()...)...))
+	// 6. Sign and send back
+	_, err = context.RunView(endorser.NewEndorseView(tx, me))
 	assert.NoError(err)
 
-	// 5. Wait for confirmation
+	// 7. Wait for confirmation
 	return context.RunView(endorser.NewFinalityView(tx))
 }
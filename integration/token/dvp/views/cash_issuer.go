@@ -13,7 +13,7 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/assert"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
-	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttx"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
 )
 
 type IssueCash struct {
@@ -30,28 +30,30 @@ type IssueCashView struct {
 }
 
 func (p *IssueCashView) Call(context view.Context) (interface{}, error) {
-	recipient, err := ttx.RequestRecipientIdentity(context, p.Receiver)
+	recipient, err := ttxcc.RequestRecipientIdentity(context, p.Receiver)
 	assert.NoError(err, "failed getting recipient identity")
 
-	// Prepare transaction
-	tx, err := ttx.NewTransaction(context, ttx.WithAuditor(fabric.GetIdentityProvider(context).Identity("auditor")))
+	// Prepare transaction, the token request is approved by the approvers of the token namespace
+	tx, err := ttxcc.NewAnonymousTransaction(
+		context,
+		ttxcc.WithAuditor(fabric.GetIdentityProvider(context).Identity("auditor")),
+		ttxcc.WithNamespaceApprovers(p.Approver),
+		ttxcc.WithIssuingValidator(cashIssuers),
+	)
 	assert.NoError(err)
 
 	assert.NoError(tx.Issue(
-		ttx.GetIssuerWallet(context, p.Wallet),
+		ttxcc.GetIssuerWallet(context, p.Wallet),
 		recipient,
 		p.Typ,
 		p.Quantity,
 	), "failed issuing token")
 
-	_, err = context.RunView(ttx.NewCollectEndorsementsView(tx))
-	assert.NoError(err, "failed collecting endorsement")
-
-	_, err = context.RunView(ttx.NewCollectApprovesView(tx, p.Approver))
+	_, err = context.RunView(ttxcc.NewCollectEndorsementsView(tx))
 	assert.NoError(err, "failed collecting endorsement")
 
 	// Send to the ordering service and wait for confirmation
-	_, err = context.RunView(ttx.NewOrderingView(tx))
+	_, err = context.RunView(ttxcc.NewOrderingView(tx))
 	assert.NoError(err, "failed asking ordering")
 	return tx.ID(), nil
 }
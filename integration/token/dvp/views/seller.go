@@ -15,7 +15,7 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/assert"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
-	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttx"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
 )
 
 type Sell struct {
@@ -36,48 +36,49 @@ func (d *SellHouseView) Call(context view.Context) (interface{}, error) {
 	assert.NoError(err)
 	tx.SetProposal("house", "Version-0.0", "sell")
 
-	// Prepare Payment
-	tx, err = d.preparePayment(context, tx)
-	assert.NoError(err)
-
 	// Prepare House Transfer
-	var oldOwner view.Identity
-	var newOwner view.Identity
-	tx, oldOwner, newOwner, err = d.prepareHouseTransfer(context, tx)
+	oldOwner, newOwner, err := d.prepareHouseTransfer(context, tx)
 	assert.NoError(err)
 
-	// Collect signature from the parties
-	_, err = context.RunView(endorser.NewCollectEndorsementsView(tx, oldOwner, newOwner))
+	// Prepare Payment, the token request is carried by the same transaction
+	tokenTx, err := d.preparePayment(context, tx)
 	assert.NoError(err)
 
-	// Collect signature from zkat auditor signature
-	zkatTx, err := ttx.Wrap(context, tx, ttx.WithAuditor(fabric.GetIdentityProvider(context).Identity("auditor")))
-	assert.NoError(err)
-	_, err = context.RunView(ttx.NewCollectAuditorEndorsement(zkatTx))
+	// Collect the signatures on the token request and the auditor's one,
+	// then have the transaction approved by the approvers of both namespaces
+	_, err = context.RunView(ttxcc.NewCollectEndorsementsView(tokenTx))
+	assert.NoError(err, "failed collecting token endorsements")
+	tx, err = tokenTx.EndorserTransaction(context)
 	assert.NoError(err)
 
-	// Collect signatures from the approvers but without sending metadata
-	_, err = context.RunView(endorser.NewCollectApprovesView(tx, d.Approvers...))
+	// Collect signature from the parties
+	_, err = context.RunView(endorser.NewCollectEndorsementsView(tx, oldOwner, newOwner))
 	assert.NoError(err)
 
 	// Send to the ordering service and wait for confirmation
 	return context.RunView(endorser.NewOrderingView(tx))
 }
 
-func (d *SellHouseView) preparePayment(context view.Context, tx *endorser.Transaction) (*endorser.Transaction, error) {
+func (d *SellHouseView) preparePayment(context view.Context, tx *endorser.Transaction) (*ttxcc.Transaction, error) {
 	// we need house's valuation, let's load the state from the world state
 	house := &House{}
 	assert.NoError(state.GetWorldState(context).GetState("house", d.HouseID, house), "failed loading house with id %s", d.HouseID)
 
 	// exchange pseudonyms for the token transfer
-	me, other, err := ttx.ExchangeRecipientIdentitiesInitiator(context, d.Wallet, d.Buyer)
+	me, other, err := ttxcc.ExchangeRecipientIdentitiesInitiator(context, d.Wallet, d.Buyer)
 	assert.NoError(err, "failed exchanging identities")
 
 	// collect token transfer from the buyer
-	tokenTx, err := ttx.Wrap(context, tx)
+	tokenTx, err := ttxcc.Wrap(
+		context,
+		tx,
+		ttxcc.WithAuditor(fabric.GetIdentityProvider(context).Identity("auditor")),
+		ttxcc.WithNamespaceApprovers(d.Approvers...),
+		ttxcc.WithIssuingValidator(cashIssuers),
+	)
 	assert.NoError(err)
-	_, err = context.RunView(ttx.NewCollectActionsView(tokenTx,
-		&ttx.ActionTransfer{
+	_, err = context.RunView(ttxcc.NewCollectActionsView(tokenTx,
+		&ttxcc.ActionTransfer{
 			From:      other,
 			Type:      "USD",
 			Amount:    house.Valuation,
@@ -85,10 +86,10 @@ func (d *SellHouseView) preparePayment(context view.Context, tx *endorser.Transa
 		}))
 	assert.NoError(err, "failed collecting token action")
 
-	return tx, nil
+	return tokenTx, nil
 }
 
-func (d *SellHouseView) prepareHouseTransfer(context view.Context, tx *endorser.Transaction) (*endorser.Transaction, view.Identity, view.Identity, error) {
+func (d *SellHouseView) prepareHouseTransfer(context view.Context, tx *endorser.Transaction) (view.Identity, view.Identity, error) {
 	// let's use the state package to hide the complexity of the rws management
 	// with a state-oriented programming
 	stx, err := state.Wrap(tx)
@@ -105,7 +106,7 @@ func (d *SellHouseView) prepareHouseTransfer(context view.Context, tx *endorser.
 	house.Owner = buyer
 	assert.NoError(stx.AddOutput(house))
 
-	return tx, oldOwner, buyer, nil
+	return oldOwner, buyer, nil
 }
 
 type SellHouseViewFactory struct{}
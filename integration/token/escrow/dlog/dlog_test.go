@@ -0,0 +1,41 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dlog
+
+import (
+	"github.com/hyperledger-labs/fabric-smart-client/integration"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/integration/nwo/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/integration/token/escrow"
+)
+
+var _ = Describe("EndToEnd", func() {
+	var (
+		ii *integration.Infrastructure
+	)
+
+	AfterEach(func() {
+		ii.Stop()
+	})
+
+	Describe("ZKAT-DLog Escrow", func() {
+		BeforeEach(func() {
+			var err error
+			ii, err = integration.New(StartPort(), "", escrow.Topology("dlog")...)
+			Expect(err).NotTo(HaveOccurred())
+			ii.RegisterPlatformFactory(token.NewPlatformFactory())
+			ii.Generate()
+			ii.Start()
+		})
+
+		It("succeeded", func() {
+			escrow.TestAll(ii)
+		})
+	})
+})
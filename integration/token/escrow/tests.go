@@ -0,0 +1,111 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package escrow
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/fabric-smart-client/integration"
+	"github.com/hyperledger-labs/fabric-smart-client/integration/nwo/common"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/integration/token/escrow/views"
+	escrow2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/escrow"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/query"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+func TestAll(network *integration.Infrastructure) {
+	registerAuditor(network)
+
+	issueCash(network, "USD", 100, "alice")
+	checkBalance(network, "alice", "USD", 100)
+
+	// alice escrows 30 USD for bob, charlie is the arbiter
+	openEscrow(network, "USD", 30, "bob", "charlie")
+	checkBalance(network, "alice", "USD", 70)
+	for _, party := range []string{"alice", "bob", "charlie"} {
+		escrows := listEscrows(network, party, "USD")
+		Expect(len(escrows)).To(BeEquivalentTo(1))
+		q, err := token2.ToQuantity(escrows[0].Quantity, 64)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(q.Cmp(token2.NewQuantityFromUInt64(30))).To(BeEquivalentTo(0))
+	}
+
+	// alice and bob agree, alice releases the tokens to bob
+	settle(network, "alice", listEscrows(network, "alice", "USD")[0].TokenID, true)
+	checkBalance(network, "alice", "USD", 70)
+	checkBalance(network, "bob", "USD", 30)
+	for _, party := range []string{"alice", "bob", "charlie"} {
+		Expect(listEscrows(network, party, "USD")).To(BeEmpty())
+	}
+
+	// bob does not agree on refunds, charlie refunds alice alone
+	openEscrow(network, "USD", 20, "bob", "charlie")
+	checkBalance(network, "alice", "USD", 50)
+	settle(network, "charlie", listEscrows(network, "charlie", "USD")[0].TokenID, false)
+	checkBalance(network, "alice", "USD", 70)
+	checkBalance(network, "bob", "USD", 30)
+	for _, party := range []string{"alice", "bob", "charlie"} {
+		Expect(listEscrows(network, party, "USD")).To(BeEmpty())
+	}
+}
+
+func registerAuditor(network *integration.Infrastructure) {
+	_, err := network.Client("auditor").CallView("register", nil)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func issueCash(network *integration.Infrastructure, typ string, amount uint64, receiver string) {
+	txid, err := network.Client("issuer").CallView("issue", common.JSONMarshall(&views.IssueCash{
+		TokenType: typ,
+		Quantity:  amount,
+		Recipient: network.Identity(receiver),
+	}))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(network.Client(receiver).IsTxFinal(common.JSONUnmarshalString(txid))).NotTo(HaveOccurred())
+}
+
+func openEscrow(network *integration.Infrastructure, typ string, amount uint64, seller, arbiter string) {
+	txid, err := network.Client("alice").CallView("open", common.JSONMarshall(&views.OpenEscrow{
+		Type:    typ,
+		Amount:  amount,
+		Seller:  network.Identity(seller),
+		Arbiter: network.Identity(arbiter),
+	}))
+	Expect(err).NotTo(HaveOccurred())
+	for _, party := range []string{"alice", seller, arbiter} {
+		Expect(network.Client(party).IsTxFinal(common.JSONUnmarshalString(txid))).NotTo(HaveOccurred())
+	}
+}
+
+func settle(network *integration.Infrastructure, id string, tokenID *token2.Id, release bool) {
+	txid, err := network.Client(id).CallView("settle", common.JSONMarshall(&views.Settle{
+		TokenID: tokenID,
+		Release: release,
+	}))
+	Expect(err).NotTo(HaveOccurred())
+	for _, party := range []string{"alice", "bob", "charlie"} {
+		Expect(network.Client(party).IsTxFinal(common.JSONUnmarshalString(txid))).NotTo(HaveOccurred())
+	}
+}
+
+func listEscrows(network *integration.Infrastructure, id string, typ string) []*escrow2.State {
+	res, err := network.Client(id).CallView("escrows", common.JSONMarshall(&views.ListEscrows{Type: typ}))
+	Expect(err).NotTo(HaveOccurred())
+	var escrows []*escrow2.State
+	Expect(json.Unmarshal(res.([]byte), &escrows)).NotTo(HaveOccurred())
+	return escrows
+}
+
+func checkBalance(network *integration.Infrastructure, id string, typ string, expected uint64) {
+	b, err := query.NewClient(network.Client(id)).WalletBalance("", typ)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(len(b)).To(BeEquivalentTo(1))
+	q, err := token2.ToQuantity(b[0].Quantity, 64)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(token2.NewQuantityFromUInt64(expected).Cmp(q)).To(BeEquivalentTo(0), "[%d]!=[%s]", expected, q.Decimal())
+}
@@ -0,0 +1,80 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package escrow
+
+import (
+	"github.com/hyperledger-labs/fabric-smart-client/integration/nwo"
+	"github.com/hyperledger-labs/fabric-smart-client/integration/nwo/fabric"
+	"github.com/hyperledger-labs/fabric-smart-client/integration/nwo/fsc"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/integration/nwo/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/integration/token/escrow/views"
+)
+
+func Topology(tokenSDKDriver string) []nwo.Topology {
+	// Fabric
+	fabricTopology := fabric.NewDefaultTopology()
+	fabricTopology.EnableIdemix()
+	fabricTopology.AddOrganizationsByName("Org1", "Org2")
+	fabricTopology.SetNamespaceApproverOrgs("Org1")
+
+	// FSC
+	fscTopology := fsc.NewTopology()
+
+	issuer := fscTopology.AddNodeByName("issuer").AddOptions(
+		fabric.WithOrganization("Org1"),
+		fabric.WithAnonymousIdentity(),
+		token.WithIssuerIdentity("issuer.id1"),
+	)
+	issuer.RegisterViewFactory("issue", &views.IssueCashViewFactory{})
+
+	auditor := fscTopology.AddNodeByName("auditor").AddOptions(
+		fabric.WithOrganization("Org1"),
+		fabric.WithAnonymousIdentity(),
+	)
+	auditor.RegisterViewFactory("register", &views.RegisterAuditorViewFactory{})
+
+	// buyer
+	alice := fscTopology.AddNodeByName("alice").AddOptions(
+		fabric.WithOrganization("Org2"),
+		fabric.WithAnonymousIdentity(),
+		token.WithOwnerIdentity(tokenSDKDriver, "alice.id1"),
+	)
+	alice.RegisterResponder(&views.AcceptCashView{}, &views.IssueCashView{})
+	alice.RegisterResponder(&views.SettleResponderView{}, &views.SettleView{})
+	alice.RegisterViewFactory("open", &views.OpenEscrowViewFactory{})
+	alice.RegisterViewFactory("settle", &views.SettleViewFactory{})
+	alice.RegisterViewFactory("escrows", &views.ListEscrowsViewFactory{})
+
+	// seller
+	bob := fscTopology.AddNodeByName("bob").AddOptions(
+		fabric.WithOrganization("Org2"),
+		fabric.WithAnonymousIdentity(),
+		token.WithOwnerIdentity(tokenSDKDriver, "bob.id1"),
+	)
+	bob.RegisterResponder(&views.AcceptEscrowView{}, &views.OpenEscrowView{})
+	bob.RegisterResponder(&views.SellerSettleResponderView{}, &views.SettleView{})
+	bob.RegisterViewFactory("settle", &views.SettleViewFactory{})
+	bob.RegisterViewFactory("escrows", &views.ListEscrowsViewFactory{})
+
+	// arbiter
+	charlie := fscTopology.AddNodeByName("charlie").AddOptions(
+		fabric.WithOrganization("Org2"),
+		fabric.WithAnonymousIdentity(),
+		token.WithOwnerIdentity(tokenSDKDriver, "charlie.id1"),
+	)
+	charlie.RegisterResponder(&views.AcceptEscrowView{}, &views.OpenEscrowView{})
+	charlie.RegisterResponder(&views.SettleResponderView{}, &views.SettleView{})
+	charlie.RegisterViewFactory("settle", &views.SettleViewFactory{})
+	charlie.RegisterViewFactory("escrows", &views.ListEscrowsViewFactory{})
+
+	tokenTopology := token.NewTopology()
+	tokenTopology.SetDefaultSDK(fscTopology)
+	tms := tokenTopology.AddTMS(fabricTopology, tokenSDKDriver)
+	tms.SetNamespace([]string{"Org1"}, "100", "2")
+
+	return []nwo.Topology{fabricTopology, tokenTopology, fscTopology}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package views
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/assert"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/escrow"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+type OpenEscrow struct {
+	Wallet  string
+	Type    string
+	Amount  uint64
+	Seller  view.Identity
+	Arbiter view.Identity
+}
+
+type OpenEscrowView struct {
+	*OpenEscrow
+}
+
+func (o *OpenEscrowView) Call(context view.Context) (interface{}, error) {
+	return context.RunView(escrow.NewOpenEscrowView(
+		o.Wallet, o.Type, o.Amount, o.Seller, o.Arbiter,
+		ttxcc.WithAuditor(fabric.GetIdentityProvider(context).Identity("auditor")),
+	))
+}
+
+type OpenEscrowViewFactory struct{}
+
+func (p *OpenEscrowViewFactory) NewView(in []byte) (view.View, error) {
+	f := &OpenEscrowView{OpenEscrow: &OpenEscrow{}}
+	err := json.Unmarshal(in, f.OpenEscrow)
+	assert.NoError(err, "failed unmarshalling input")
+	return f, nil
+}
+
+// AcceptEscrowView is run by the seller and the arbiter of a new escrow
+type AcceptEscrowView struct{}
+
+func (a *AcceptEscrowView) Call(context view.Context) (interface{}, error) {
+	_, err := context.RunView(escrow.NewAcceptEscrowView())
+	assert.NoError(err, "failed accepting escrow")
+	return nil, nil
+}
+
+type Settle struct {
+	Wallet  string
+	TokenID *token.Id
+	// Release pays the seller if true, refunds the buyer otherwise
+	Release bool
+}
+
+type SettleView struct {
+	*Settle
+}
+
+func (s *SettleView) Call(context view.Context) (interface{}, error) {
+	opt := ttxcc.WithAuditor(fabric.GetIdentityProvider(context).Identity("auditor"))
+	if s.Release {
+		return context.RunView(escrow.NewReleaseView(s.Wallet, s.TokenID, opt))
+	}
+	return context.RunView(escrow.NewRefundView(s.Wallet, s.TokenID, opt))
+}
+
+type SettleViewFactory struct{}
+
+func (p *SettleViewFactory) NewView(in []byte) (view.View, error) {
+	f := &SettleView{Settle: &Settle{}}
+	err := json.Unmarshal(in, f.Settle)
+	assert.NoError(err, "failed unmarshalling input")
+	return f, nil
+}
+
+// SettleResponderView approves any settlement
+type SettleResponderView struct{}
+
+func (s *SettleResponderView) Call(context view.Context) (interface{}, error) {
+	_, err := context.RunView(escrow.NewSettleResponderView(func(tx *ttxcc.Transaction, settlement *escrow.Settlement) error {
+		return nil
+	}))
+	assert.NoError(err, "failed responding to settlement")
+	return nil, nil
+}
+
+// SellerSettleResponderView approves releases only, refunds need the arbiter
+type SellerSettleResponderView struct{}
+
+func (s *SellerSettleResponderView) Call(context view.Context) (interface{}, error) {
+	_, err := context.RunView(escrow.NewSettleResponderView(func(tx *ttxcc.Transaction, settlement *escrow.Settlement) error {
+		if !settlement.Release {
+			return errors.Errorf("refund of [%s] not agreed", settlement.State.TokenID)
+		}
+		return nil
+	}))
+	assert.NoError(err, "failed responding to settlement")
+	return nil, nil
+}
+
+type ListEscrows struct {
+	Type string
+}
+
+type ListEscrowsView struct {
+	*ListEscrows
+}
+
+func (l *ListEscrowsView) Call(context view.Context) (interface{}, error) {
+	escrows, err := escrow.GetQueryEngine(context).ListEscrows(ttxcc.WithType(l.Type))
+	assert.NoError(err, "failed listing escrows")
+	return escrows, nil
+}
+
+type ListEscrowsViewFactory struct{}
+
+func (p *ListEscrowsViewFactory) NewView(in []byte) (view.View, error) {
+	f := &ListEscrowsView{ListEscrows: &ListEscrows{}}
+	err := json.Unmarshal(in, f.ListEscrows)
+	assert.NoError(err, "failed unmarshalling input")
+	return f, nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package views
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/assert"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
+)
+
+type IssueCash struct {
+	TokenType string
+	Quantity  uint64
+	Recipient view.Identity
+}
+
+type IssueCashView struct {
+	*IssueCash
+}
+
+func (p *IssueCashView) Call(context view.Context) (interface{}, error) {
+	recipient, err := ttxcc.RequestRecipientIdentity(context, p.Recipient)
+	assert.NoError(err, "failed getting recipient identity")
+
+	tx, err := ttxcc.NewTransaction(
+		context,
+		fabric.GetIdentityProvider(context).DefaultIdentity(),
+		ttxcc.WithAuditor(fabric.GetIdentityProvider(context).Identity("auditor")),
+	)
+	assert.NoError(err, "failed creating issue transaction")
+
+	err = tx.Issue(ttxcc.GetIssuerWallet(context, ""), recipient, p.TokenType, p.Quantity)
+	assert.NoError(err, "failed adding new issued token")
+
+	_, err = context.RunView(ttxcc.NewCollectEndorsementsView(tx))
+	assert.NoError(err, "failed to sign issue transaction")
+
+	_, err = context.RunView(ttxcc.NewOrderingView(tx))
+	assert.NoError(err, "failed to commit issue transaction")
+
+	return tx.ID(), nil
+}
+
+type IssueCashViewFactory struct{}
+
+func (p *IssueCashViewFactory) NewView(in []byte) (view.View, error) {
+	f := &IssueCashView{IssueCash: &IssueCash{}}
+	err := json.Unmarshal(in, f.IssueCash)
+	assert.NoError(err, "failed unmarshalling input")
+	return f, nil
+}
+
+type AcceptCashView struct{}
+
+func (a *AcceptCashView) Call(context view.Context) (interface{}, error) {
+	_, err := ttxcc.RespondRequestRecipientIdentity(context)
+	assert.NoError(err, "failed to respond to identity request")
+
+	tx, err := ttxcc.ReceiveTransaction(context)
+	assert.NoError(err, "failed to receive tokens")
+
+	_, err = context.RunView(ttxcc.NewAcceptView(tx))
+	assert.NoError(err, "failed to accept new tokens")
+
+	_, err = context.RunView(ttxcc.NewFinalityView(tx))
+	assert.NoError(err, "new tokens were not committed")
+
+	return nil, nil
+}
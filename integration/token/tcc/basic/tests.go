@@ -316,7 +316,7 @@ func listIssuerHistory(network *integration.Infrastructure, wallet string, typ s
 
 	issuedTokens := &token2.IssuedTokens{}
 	common.JSONUnmarshal(res.([]byte), issuedTokens)
-	return issuedTokens
+	return issuedTokens.Issued()
 }
 
 func issueCashFail(network *integration.Infrastructure, typ string, amount uint64, receiver string) {
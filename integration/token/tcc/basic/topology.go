@@ -63,6 +63,12 @@ func Topology(tokenSDKDriver string) []nwo.Topology {
 	alice.RegisterViewFactory("transferWithSelector", &views.TransferWithSelectorViewFactory{})
 	alice.RegisterViewFactory("redeem", &views.RedeemViewFactory{})
 	alice.RegisterViewFactory("swap", &views.SwapInitiatorViewFactory{})
+	alice.RegisterViewFactory("lock", &views.LockViewFactory{})
+	alice.RegisterViewFactory("claim", &views.ClaimViewFactory{})
+	alice.RegisterResponder(&views.ClaimResponderView{}, &views.ClaimView{})
+	alice.RegisterViewFactory("jointTransfer", &views.JointTransferViewFactory{})
+	alice.RegisterViewFactory("jointSpend", &views.JointSpendViewFactory{})
+	alice.RegisterViewFactory("jointTokens", &views.ListJointTokensViewFactory{})
 	alice.RegisterViewFactory("history", &views.ListUnspentTokensViewFactory{})
 
 	bob := fscTopology.AddNodeByName("bob").AddOptions(
@@ -78,6 +84,12 @@ func Topology(tokenSDKDriver string) []nwo.Topology {
 	bob.RegisterViewFactory("transferWithSelector", &views.TransferWithSelectorViewFactory{})
 	bob.RegisterViewFactory("redeem", &views.RedeemViewFactory{})
 	bob.RegisterViewFactory("swap", &views.SwapInitiatorViewFactory{})
+	bob.RegisterViewFactory("claim", &views.ClaimViewFactory{})
+	bob.RegisterResponder(&views.LockResponderView{}, &views.LockView{})
+	bob.RegisterResponder(&views.ClaimResponderView{}, &views.ClaimView{})
+	bob.RegisterResponder(&views.JointTransferResponderView{}, &views.JointTransferView{})
+	bob.RegisterResponder(&views.CoOwnerView{}, &views.JointSpendView{})
+	bob.RegisterViewFactory("jointTokens", &views.ListJointTokensViewFactory{})
 	bob.RegisterViewFactory("history", &views.ListUnspentTokensViewFactory{})
 
 	charlie := fscTopology.AddNodeByName("charlie").AddOptions(
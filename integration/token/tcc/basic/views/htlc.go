@@ -0,0 +1,156 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package views
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/assert"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+type Lock struct {
+	Wallet    string
+	Type      string
+	Amount    uint64
+	Recipient view.Identity
+	Timeout   time.Duration
+	Hash      []byte
+}
+
+type LockView struct {
+	*Lock
+}
+
+func (l *LockView) Call(context view.Context) (interface{}, error) {
+	recipient, err := ttxcc.RequestRecipientIdentity(context, l.Recipient)
+	assert.NoError(err, "failed getting recipient")
+
+	tx, err := ttxcc.NewAnonymousTransaction(
+		context,
+		ttxcc.WithAuditor(fabric.GetIdentityProvider(context).Identity("auditor")),
+	)
+	assert.NoError(err, "failed creating transaction")
+
+	_, err = tx.Lock(ttxcc.GetWallet(context, l.Wallet), l.Type, l.Amount, recipient, l.Timeout, l.Hash)
+	assert.NoError(err, "failed locking tokens")
+
+	_, err = context.RunView(ttxcc.NewCollectEndorsementsView(tx))
+	assert.NoError(err, "failed to sign transaction")
+
+	_, err = context.RunView(ttxcc.NewOrderingView(tx))
+	assert.NoError(err, "failed asking ordering")
+
+	return tx.ID(), nil
+}
+
+type LockViewFactory struct{}
+
+func (p *LockViewFactory) NewView(in []byte) (view.View, error) {
+	f := &LockView{Lock: &Lock{}}
+	err := json.Unmarshal(in, f.Lock)
+	assert.NoError(err, "failed unmarshalling input")
+	return f, nil
+}
+
+type LockResponderView struct{}
+
+func (l *LockResponderView) Call(context view.Context) (interface{}, error) {
+	id, err := ttxcc.RespondRequestRecipientIdentity(context)
+	assert.NoError(err, "failed to respond to identity request")
+
+	tx, err := ttxcc.ReceiveTransaction(context)
+	assert.NoError(err, "failed to receive tokens")
+
+	// Check that a script locks tokens for me
+	outputs, err := tx.Outputs()
+	assert.NoError(err, "failed getting outputs")
+	found := false
+	for i := 0; i < outputs.Count(); i++ {
+		owner := outputs.At(i).Owner
+		if !htlc.IsScript(owner) {
+			continue
+		}
+		script := &htlc.Script{}
+		assert.NoError(script.Deserialize(owner), "failed deserializing script")
+		found = found || script.Recipient.Equal(id)
+	}
+	assert.True(found, "expected tokens locked for [%s]", id)
+
+	_, err = context.RunView(ttxcc.NewAcceptView(tx))
+	assert.NoError(err, "failed to accept locked tokens")
+
+	_, err = context.RunView(ttxcc.NewFinalityView(tx))
+	assert.NoError(err, "locked tokens were not committed")
+
+	return nil, nil
+}
+
+type Claim struct {
+	Wallet   string
+	TokenID  *token.Id
+	Preimage []byte
+}
+
+type ClaimView struct {
+	*Claim
+}
+
+func (c *ClaimView) Call(context view.Context) (interface{}, error) {
+	tx, err := ttxcc.NewAnonymousTransaction(
+		context,
+		ttxcc.WithAuditor(fabric.GetIdentityProvider(context).Identity("auditor")),
+	)
+	assert.NoError(err, "failed creating transaction")
+
+	wallet := ttxcc.GetWallet(context, c.Wallet)
+	if len(c.Preimage) != 0 {
+		err = tx.Claim(wallet, c.TokenID, c.Preimage)
+	} else {
+		err = tx.Reclaim(wallet, c.TokenID)
+	}
+	assert.NoError(err, "failed spending locked tokens")
+
+	_, err = context.RunView(ttxcc.NewCollectEndorsementsView(tx))
+	assert.NoError(err, "failed to sign transaction")
+
+	_, err = context.RunView(ttxcc.NewOrderingView(tx))
+	assert.NoError(err, "failed asking ordering")
+
+	return tx.ID(), nil
+}
+
+// ClaimViewFactory returns a view that claims a locked token, if a preimage is passed, or reclaims it otherwise
+type ClaimViewFactory struct{}
+
+func (p *ClaimViewFactory) NewView(in []byte) (view.View, error) {
+	f := &ClaimView{Claim: &Claim{}}
+	err := json.Unmarshal(in, f.Claim)
+	assert.NoError(err, "failed unmarshalling input")
+	return f, nil
+}
+
+// ClaimResponderView is run by the counterparty of a claim or reclaim, it just accepts the transaction
+type ClaimResponderView struct{}
+
+func (c *ClaimResponderView) Call(context view.Context) (interface{}, error) {
+	tx, err := ttxcc.ReceiveTransaction(context)
+	assert.NoError(err, "failed to receive transaction")
+
+	_, err = context.RunView(ttxcc.NewAcceptView(tx))
+	assert.NoError(err, "failed to accept transaction")
+
+	_, err = context.RunView(ttxcc.NewFinalityView(tx))
+	assert.NoError(err, "transaction was not committed")
+
+	return nil, nil
+}
@@ -68,6 +68,7 @@ func (p *IssueCashView) Call(context view.Context) (interface{}, error) {
 		// check limit
 		history, err := wallet.HistoryTokens(ttxcc.WithType(p.TokenType))
 		assert.NoError(err, "failed getting history for token type [%s]", p.TokenType)
+		history = history.Issued()
 		fmt.Printf("History [%s,%s]<[220]?\n", history.Sum(64).ToBigInt().Text(10), p.TokenType)
 		assert.True(history.Sum(64).Add(token2.NewQuantityFromUInt64(p.Quantity)).Cmp(token2.NewQuantityFromUInt64(230)) <= 0)
 	}
@@ -0,0 +1,191 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package views
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/assert"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/multisig"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+type JointTransfer struct {
+	Wallet    string
+	Type      string
+	Amount    uint64
+	Threshold uint64
+	// CoOwners are the co-owners other than the wallet owner
+	CoOwners []view.Identity
+}
+
+type JointTransferView struct {
+	*JointTransfer
+}
+
+func (j *JointTransferView) Call(context view.Context) (interface{}, error) {
+	wallet := ttxcc.GetWallet(context, j.Wallet)
+	me, err := wallet.GetRecipientIdentity()
+	assert.NoError(err, "failed getting my identity")
+	coOwners := []view.Identity{me}
+	for _, party := range j.CoOwners {
+		recipient, err := ttxcc.RequestRecipientIdentity(context, party)
+		assert.NoError(err, "failed getting recipient")
+		coOwners = append(coOwners, recipient)
+	}
+
+	tx, err := ttxcc.NewAnonymousTransaction(
+		context,
+		ttxcc.WithAuditor(fabric.GetIdentityProvider(context).Identity("auditor")),
+	)
+	assert.NoError(err, "failed creating transaction")
+
+	_, err = tx.TransferToMultisig(wallet, j.Type, j.Amount, j.Threshold, coOwners)
+	assert.NoError(err, "failed adding transfer to multisig")
+
+	_, err = context.RunView(ttxcc.NewCollectEndorsementsView(tx))
+	assert.NoError(err, "failed to sign transaction")
+
+	_, err = context.RunView(ttxcc.NewOrderingView(tx))
+	assert.NoError(err, "failed asking ordering")
+
+	return tx.ID(), nil
+}
+
+type JointTransferViewFactory struct{}
+
+func (p *JointTransferViewFactory) NewView(in []byte) (view.View, error) {
+	f := &JointTransferView{JointTransfer: &JointTransfer{}}
+	err := json.Unmarshal(in, f.JointTransfer)
+	assert.NoError(err, "failed unmarshalling input")
+	return f, nil
+}
+
+type JointTransferResponderView struct{}
+
+func (j *JointTransferResponderView) Call(context view.Context) (interface{}, error) {
+	id, err := ttxcc.RespondRequestRecipientIdentity(context)
+	assert.NoError(err, "failed to respond to identity request")
+
+	tx, err := ttxcc.ReceiveTransaction(context)
+	assert.NoError(err, "failed to receive tokens")
+
+	// Check that I am a co-owner of an output
+	outputs, err := tx.Outputs()
+	assert.NoError(err, "failed getting outputs")
+	found := false
+	for i := 0; i < outputs.Count(); i++ {
+		owner := outputs.At(i).Owner
+		if !multisig.IsMultisig(owner) {
+			continue
+		}
+		msID := &multisig.Identity{}
+		assert.NoError(msID.Deserialize(owner), "failed deserializing multisig identity")
+		for _, coOwner := range msID.Identities {
+			found = found || coOwner.Equal(id)
+		}
+	}
+	assert.True(found, "expected tokens owned jointly with [%s]", id)
+
+	_, err = context.RunView(ttxcc.NewAcceptView(tx))
+	assert.NoError(err, "failed to accept tokens")
+
+	_, err = context.RunView(ttxcc.NewFinalityView(tx))
+	assert.NoError(err, "tokens were not committed")
+
+	return nil, nil
+}
+
+type JointSpend struct {
+	Wallet    string
+	TokenID   *token.Id
+	Amount    uint64
+	Recipient view.Identity
+}
+
+type JointSpendView struct {
+	*JointSpend
+}
+
+func (j *JointSpendView) Call(context view.Context) (interface{}, error) {
+	recipient, err := ttxcc.RequestRecipientIdentity(context, j.Recipient)
+	assert.NoError(err, "failed getting recipient")
+
+	tx, err := ttxcc.NewAnonymousTransaction(
+		context,
+		ttxcc.WithAuditor(fabric.GetIdentityProvider(context).Identity("auditor")),
+	)
+	assert.NoError(err, "failed creating transaction")
+
+	err = tx.TransferFromMultisig(ttxcc.GetWallet(context, j.Wallet), j.TokenID, []uint64{j.Amount}, []view.Identity{recipient})
+	assert.NoError(err, "failed adding transfer from multisig")
+
+	// the co-owners are asked to sign while collecting endorsements
+	_, err = context.RunView(ttxcc.NewCollectEndorsementsView(tx))
+	assert.NoError(err, "failed to sign transaction")
+
+	_, err = context.RunView(ttxcc.NewOrderingView(tx))
+	assert.NoError(err, "failed asking ordering")
+
+	return tx.ID(), nil
+}
+
+type JointSpendViewFactory struct{}
+
+func (p *JointSpendViewFactory) NewView(in []byte) (view.View, error) {
+	f := &JointSpendView{JointSpend: &JointSpend{}}
+	err := json.Unmarshal(in, f.JointSpend)
+	assert.NoError(err, "failed unmarshalling input")
+	return f, nil
+}
+
+// CoOwnerView is run by a co-owner asked to sign the spending of a jointly-owned token
+type CoOwnerView struct{}
+
+func (c *CoOwnerView) Call(context view.Context) (interface{}, error) {
+	tx, err := ttxcc.ReceiveTransaction(context)
+	assert.NoError(err, "failed to receive transaction")
+
+	outputs, err := tx.Outputs()
+	assert.NoError(err, "failed getting outputs")
+	assert.True(outputs.Count() > 0, "expected outputs")
+
+	_, err = context.RunView(ttxcc.NewEndorseView(tx))
+	assert.NoError(err, "failed to endorse transaction")
+
+	_, err = context.RunView(ttxcc.NewFinalityView(tx))
+	assert.NoError(err, "transaction was not committed")
+
+	return nil, nil
+}
+
+type ListJointTokens struct {
+	Wallet string
+	Type   string
+}
+
+type ListJointTokensView struct {
+	*ListJointTokens
+}
+
+func (l *ListJointTokensView) Call(context view.Context) (interface{}, error) {
+	tokens, err := ttxcc.GetJointWallet(context, l.Wallet).ListTokens(ttxcc.WithType(l.Type))
+	assert.NoError(err, "failed listing joint tokens")
+	return tokens, nil
+}
+
+type ListJointTokensViewFactory struct{}
+
+func (p *ListJointTokensViewFactory) NewView(in []byte) (view.View, error) {
+	f := &ListJointTokensView{ListJointTokens: &ListJointTokens{}}
+	err := json.Unmarshal(in, f.ListJointTokens)
+	assert.NoError(err, "failed unmarshalling input")
+	return f, nil
+}
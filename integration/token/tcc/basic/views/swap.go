@@ -32,7 +32,7 @@ type SwapInitiatorView struct {
 }
 
 func (t *SwapInitiatorView) Call(context view.Context) (interface{}, error) {
-	me, other, err := ttxcc.ExchangeRecipientIdentitiesInitiator(context, t.Wallet, t.Recipient)
+	_, other, err := ttxcc.ExchangeRecipientIdentitiesInitiator(context, t.Wallet, t.Recipient)
 	assert.NoError(err, "failed exchanging identities")
 
 	// Prepare transaction
@@ -42,18 +42,11 @@ func (t *SwapInitiatorView) Call(context view.Context) (interface{}, error) {
 	)
 	assert.NoError(err, "failed creating transaction")
 
-	err = tx.Transfer(ttxcc.GetWallet(context, t.Wallet), t.TypeLeft, []uint64{t.AmountLeft}, []view.Identity{other})
-	assert.NoError(err, "failed adding output")
+	err = tx.Swap(ttxcc.GetWallet(context, t.Wallet), t.TypeLeft, t.AmountLeft, other, t.TypeRight, t.AmountRight)
+	assert.NoError(err, "failed adding swap")
 
-	_, err = context.RunView(ttxcc.NewCollectActionsView(tx,
-		&ttxcc.ActionTransfer{
-			From:      other,
-			Type:      t.TypeRight,
-			Amount:    t.AmountRight,
-			Recipient: me,
-		},
-	))
-	assert.NoError(err, "failed collecting actions")
+	_, err = context.RunView(ttxcc.NewSwapInitiatorView(tx))
+	assert.NoError(err, "failed negotiating swap")
 
 	// check the content of the transaction
 	assert.NoError(tx.Verify(), "failed verifying transaction")
@@ -64,10 +57,6 @@ func (t *SwapInitiatorView) Call(context view.Context) (interface{}, error) {
 	assert.Equal(0, os.Sum().Cmp(token2.NewQuantityFromUInt64(uint64(t.AmountLeft))))
 	assert.Equal(os.Count(), os.ByType(t.TypeLeft).Count())
 
-	os = outputs.ByRecipient(me)
-	assert.Equal(0, os.Sum().Cmp(token2.NewQuantityFromUInt64(uint64(t.AmountRight))))
-	assert.Equal(os.Count(), os.ByType(t.TypeRight).Count())
-
 	_, err = context.RunView(ttxcc.NewCollectEndorsementsView(tx))
 	assert.NoError(err, "failed collecting endorsement")
 
@@ -94,17 +83,11 @@ func (t *SwapResponderView) Call(context view.Context) (interface{}, error) {
 	_, _, err := ttxcc.ExchangeRecipientIdentitiesResponder(context)
 	assert.NoError(err, "failed getting identity")
 
-	tx, action, err := ttxcc.ReceiveAction(context)
-	assert.NoError(err, "failed receiving action")
-
-	err = tx.Transfer(
-		ttxcc.MyWalletForChannel(context, tx.Channel()),
-		action.Type, []uint64{action.Amount}, []view.Identity{action.Recipient},
-	)
-	assert.NoError(err, "failed appending transfer")
+	tx, terms, err := ttxcc.ReceiveSwap(context)
+	assert.NoError(err, "failed receiving swap")
 
-	_, err = context.RunView(ttxcc.NewCollectActionsResponderView(tx, action))
-	assert.NoError(err, "failed responding to action collect")
+	_, err = context.RunView(ttxcc.NewSwapResponderView(tx, terms, ttxcc.MyWalletForChannel(context, tx.Channel())))
+	assert.NoError(err, "failed responding to swap")
 
 	// Endorse and send back
 	_, err = context.RunView(ttxcc.NewEndorseView(tx))
@@ -15,5 +15,14 @@ type CertificationClient interface {
 type CertificationService interface {
 	NewCertificationRequest(ids []*token2.Id) ([]byte, error)
 	Certify(wallet CertifierWallet, ids []*token2.Id, tokens [][]byte, request []byte) ([][]byte, error)
-	VerifyCertifications(ids []*token2.Id, certifications [][]byte) error
+	// VerifyCertifications checks the certifications returned by the certifier for the passed ids.
+	// It returns the certifications, one for each id, in the form they must be stored by the owner.
+	// Certifications that are expired, or whose tokens have been revoked by the certifier, are rejected.
+	VerifyCertifications(ids []*token2.Id, certifications [][]byte) ([][]byte, error)
+}
+
+// RevocationListFetcher gives access to the certifications revoked by the certifier
+type RevocationListFetcher interface {
+	// Revoked returns, for each passed token, true if its certification has been revoked
+	Revoked(ids []*token2.Id) ([]bool, error)
 }
@@ -26,6 +26,9 @@ type Signer interface {
 
 type GetIdentityFunc func() (view.Identity, error)
 
+// NewOwnerWalletFunc creates the owner wallet for the passed long-term identity
+type NewOwnerWalletFunc func(info *IdentityInfo) (OwnerWallet, error)
+
 type IdentityInfo struct {
 	ID           string
 	EnrollmentID string
@@ -50,4 +53,25 @@ type IdentityProvider interface {
 	GetEnrollmentID(auditInfo []byte) (string, error)
 
 	GetIdentityMetadata(identity view.Identity) ([]byte, error)
+
+	// RegisterOwnerWallet registers, under the passed id, an owner wallet whose long-term identity is stored
+	// in the MSP folder at the passed path
+	RegisterOwnerWallet(id string, mspID string, path string) error
+
+	// RemoveOwnerWallet makes the owner wallet with the passed id no longer available, dropping it from the cache
+	RemoveOwnerWallet(id string) error
+
+	// OwnerWallet returns the owner wallet matching the passed identity or wallet id, nil if not found.
+	// The wallet is created with the passed function the first time, and then cached.
+	OwnerWallet(id interface{}, newWallet NewOwnerWalletFunc) OwnerWallet
+
+	// OwnerWalletIDs returns the ids of the owner wallets in use or registered at runtime, sorted
+	OwnerWalletIDs() ([]string, error)
+
+	// ExportOwnerWallet returns the key material of the passed owner wallet encrypted under the passed passphrase
+	ExportOwnerWallet(id string, passphrase []byte) ([]byte, error)
+
+	// ImportOwnerWallet registers the owner wallet exported by ExportOwnerWallet,
+	// storing its key material at the passed path. It returns the id of the wallet.
+	ImportOwnerWallet(raw []byte, passphrase []byte, path string) (string, error)
 }
@@ -0,0 +1,97 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
+)
+
+const memoTag = "memo"
+
+// MemoTarget tells what a memo is attached to
+type MemoTarget uint64
+
+const (
+	// RequestMemo is attached to the token request as a whole
+	RequestMemo MemoTarget = iota
+	// IssueMemo is attached to an output of an issue action
+	IssueMemo
+	// TransferMemo is attached to an output of a transfer action
+	TransferMemo
+)
+
+// Memo binds application data to the output at index Output of the action at index Action, or to the request itself.
+// The request carries only the hash of the data, the data travels in the request metadata.
+type Memo struct {
+	Target MemoTarget
+	Action int
+	Output int
+	Hash   []byte
+}
+
+func (m *Memo) Serialize() ([]byte, error) {
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteString(memoTag)
+	w.WriteUvarint(uint64(m.Target))
+	w.WriteUvarint(uint64(m.Action))
+	w.WriteUvarint(uint64(m.Output))
+	w.WriteBytes(m.Hash)
+	return w.Bytes(), nil
+}
+
+func (m *Memo) Deserialize(raw []byte) error {
+	r, err := encoding.NewReader(raw)
+	if err != nil {
+		return err
+	}
+	if tag := r.ReadString(); tag != memoTag {
+		return errors.Errorf("invalid encoding, expected tag [%s], got [%s]", memoTag, tag)
+	}
+	m.Target = MemoTarget(r.ReadUvarint())
+	m.Action = int(r.ReadUvarint())
+	m.Output = int(r.ReadUvarint())
+	m.Hash = r.ReadBytes()
+	if err := r.Close(); err != nil {
+		return errors.Wrap(err, "failed deserializing memo")
+	}
+	if m.Target > TransferMemo {
+		return errors.Errorf("invalid memo target [%d]", m.Target)
+	}
+	return nil
+}
+
+// MemoMetadata is the content of a Memo
+type MemoMetadata struct {
+	Key   string
+	Value []byte
+	// Salt hides the value to those who see only the hash
+	Salt []byte
+	// Recipient, if set, is the only party, besides the auditor, the value is disclosed to
+	Recipient view.Identity `json:",omitempty"`
+}
+
+// Hash returns the hash a Memo carries for this content
+func (m *MemoMetadata) Hash() []byte {
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteString(memoTag)
+	w.WriteString(m.Key)
+	w.WriteBytes(m.Value)
+	w.WriteBytes(m.Salt)
+	w.WriteBytes(m.Recipient)
+	h := sha256.Sum256(w.Bytes())
+	return h[:]
+}
+
+// Matches returns true if the passed memo carries the hash of this content
+func (m *MemoMetadata) Matches(memo *Memo) bool {
+	return bytes.Equal(m.Hash(), memo.Hash)
+}
@@ -5,18 +5,40 @@ SPDX-License-Identifier: Apache-2.0
 */
 package api
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
+)
 
 type SerializedPublicParameters struct {
 	Identifier string
 	Raw        []byte
 }
 
+// Serialize returns the compact binary encoding of the serialized public parameters
+func (pp *SerializedPublicParameters) Serialize() ([]byte, error) {
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteString(pp.Identifier)
+	w.WriteBytes(pp.Raw)
+	return w.Bytes(), nil
+}
+
+// Deserialize accepts both the compact binary encoding and the legacy JSON one
 func (pp *SerializedPublicParameters) Deserialize(raw []byte) error {
-	if err := json.Unmarshal(raw, pp); err != nil {
+	if !encoding.IsBinary(raw) {
+		if err := json.Unmarshal(raw, pp); err != nil {
+			return err
+		}
+		return nil
+	}
+	r, err := encoding.NewReader(raw)
+	if err != nil {
 		return err
 	}
-	return nil
+	pp.Identifier = r.ReadString()
+	pp.Raw = r.ReadBytes()
+	return r.Close()
 }
 
 type PublicParamsFetcher interface {
@@ -39,7 +61,11 @@ type PublicParamsManager interface {
 
 	PublicParameters() PublicParameters
 
-	SetCertifier(certifier []byte) ([]byte, error)
+	// SetCertifier sets the public key of the certifier, and the identity allowed to revoke certifications
+	SetCertifier(certifier []byte, identity []byte) ([]byte, error)
+
+	// CertifierIdentity returns the identity of the certifier, nil if no certifier is set
+	CertifierIdentity() []byte
 
 	NewCertifierKeyPair() ([]byte, []byte, error)
 
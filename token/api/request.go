@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
@@ -18,14 +19,54 @@ type TokenRequest struct {
 	Transfers        [][]byte
 	Signatures       [][]byte
 	AuditorSignature []byte
+	// Swaps are the serialized terms of the atomic swaps the transfers implement, see Swap
+	Swaps [][]byte `json:",omitempty"`
+	// Memos are the serialized application memos attached to the request and its outputs, see Memo
+	Memos [][]byte `json:",omitempty"`
 }
 
+// Bytes returns the compact binary encoding of the token request
 func (r *TokenRequest) Bytes() ([]byte, error) {
-	return json.Marshal(r)
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteBytesArray(r.Issues)
+	w.WriteBytesArray(r.Transfers)
+	w.WriteBytesArray(r.Signatures)
+	w.WriteBytes(r.AuditorSignature)
+	// swaps and memos are optional, requests without them keep the original encoding
+	if len(r.Swaps) != 0 || len(r.Memos) != 0 {
+		w.WriteBytesArray(r.Swaps)
+	}
+	if len(r.Memos) != 0 {
+		w.WriteBytesArray(r.Memos)
+	}
+	return w.Bytes(), nil
+}
+
+// MessageToSign returns the part of the request covered by the signatures of issuers, senders and auditor
+func (r *TokenRequest) MessageToSign() ([]byte, error) {
+	return json.Marshal(&TokenRequest{Issues: r.Issues, Transfers: r.Transfers, Swaps: r.Swaps, Memos: r.Memos})
 }
 
+// FromBytes accepts both the compact binary encoding and the legacy JSON one
 func (r *TokenRequest) FromBytes(raw []byte) error {
-	return json.Unmarshal(raw, r)
+	if !encoding.IsBinary(raw) {
+		return json.Unmarshal(raw, r)
+	}
+	reader, err := encoding.NewReader(raw)
+	if err != nil {
+		return err
+	}
+	r.Issues = reader.ReadBytesArray()
+	r.Transfers = reader.ReadBytesArray()
+	r.Signatures = reader.ReadBytesArray()
+	r.AuditorSignature = reader.ReadBytes()
+	if !reader.Done() {
+		r.Swaps = reader.ReadBytesArray()
+	}
+	if !reader.Done() {
+		r.Memos = reader.ReadBytesArray()
+	}
+	return reader.Close()
 }
 
 type IssueMetadata struct {
@@ -47,11 +88,15 @@ type TransferMetadata struct {
 	Receivers          []view.Identity
 	ReceiverIsSender   []bool
 	ReceiverAuditInfos [][]byte
+	// RedeemIssuer, if set, is the issuer the redeemed outputs of this transfer go back to
+	RedeemIssuer view.Identity `json:",omitempty"`
 }
 
 type TokenRequestMetadata struct {
 	Issues    []IssueMetadata
 	Transfers []TransferMetadata
+	// Memos are the contents of the memos of the request that this party can see
+	Memos []MemoMetadata `json:",omitempty"`
 }
 
 func (m *TokenRequestMetadata) TokenInfos() [][]byte {
@@ -0,0 +1,136 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// State is the state of a channel after an update, it is signed by both parties
+type State struct {
+	ChannelID string
+	SeqNumber int
+	Hash      []byte
+	Parties   []view.Identity
+	// Balances are the amounts per token type that each party, in the order of Parties, gets if the channel is settled.
+	// Pending locks are not taken into account: their value goes back to the sender, unless the receiver reveals
+	// the preimage during a dispute, before the lock times out (see Dispute).
+	Balances []map[string]uint64
+	// Locks are the pending conditional transfers
+	Locks []*Lock `json:",omitempty"`
+}
+
+// Lock is a pending conditional transfer, executed once the preimage of HashLock is revealed
+type Lock struct {
+	HashLock []byte
+	// Sender is the index in Parties of the party whose tokens are locked
+	Sender int
+	Type   string
+	Value  uint64
+	// Timeout is when the lock expires, after that it can no longer be executed, neither off-chain nor in a dispute
+	Timeout time.Time
+}
+
+// Payout is an output the deposit of a channel is released to when the channel is settled
+type Payout struct {
+	Owner view.Identity
+	Type  string
+	Value uint64
+}
+
+// Payouts returns the outputs the deposit is released to according to the balances of the state,
+// party by party in the order of Parties and, for each party, by token type. Zero balances are skipped.
+func (s *State) Payouts() []*Payout {
+	return payouts(s.Parties, s.Balances)
+}
+
+func (s *State) Bytes() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+func (s *State) FromBytes(raw []byte) error {
+	return json.Unmarshal(raw, s)
+}
+
+// Dispute is the record, on the ledger, of a dispute on an off-chain channel.
+// Until Deadline, a party can replace State with a state having a higher sequence number,
+// and the receiver of a pending lock of State can reveal its preimage, if the lock has not timed out.
+// While the dispute is open the deposit cannot be spent. Once closed, the deposit can only be released
+// to the payouts of the dispute, and the signature of either party is enough to do so.
+type Dispute struct {
+	Deposit  *token2.Id
+	State    *State
+	Deadline time.Time
+	Closed   bool
+	// Preimages are the preimages revealed for the locks of State
+	Preimages [][]byte `json:",omitempty"`
+}
+
+// Balances returns the balances of State with the locks whose preimage has been revealed executed
+func (d *Dispute) Balances() []map[string]uint64 {
+	balances := make([]map[string]uint64, len(d.State.Balances))
+	for i, b := range d.State.Balances {
+		balances[i] = make(map[string]uint64, len(b))
+		for typ, v := range b {
+			balances[i][typ] = v
+		}
+	}
+	for _, lock := range d.State.Locks {
+		if !d.Revealed(lock.HashLock) || lock.Sender < 0 || lock.Sender >= len(balances) || len(balances) != 2 {
+			continue
+		}
+		if balances[lock.Sender][lock.Type] < lock.Value {
+			continue
+		}
+		balances[lock.Sender][lock.Type] -= lock.Value
+		balances[1-lock.Sender][lock.Type] += lock.Value
+	}
+	return balances
+}
+
+// Payouts returns the outputs the deposit is released to according to the balances of the dispute, see State.Payouts
+func (d *Dispute) Payouts() []*Payout {
+	return payouts(d.State.Parties, d.Balances())
+}
+
+// Revealed returns true if the preimage of the passed hash lock has been revealed
+func (d *Dispute) Revealed(hashLock []byte) bool {
+	for _, preimage := range d.Preimages {
+		hash := sha256.Sum256(preimage)
+		if bytes.Equal(hash[:], hashLock) {
+			return true
+		}
+	}
+	return false
+}
+
+func payouts(parties []view.Identity, balances []map[string]uint64) []*Payout {
+	var res []*Payout
+	for i, party := range parties {
+		if i >= len(balances) {
+			break
+		}
+		types := make([]string, 0, len(balances[i]))
+		for typ := range balances[i] {
+			types = append(types, typ)
+		}
+		sort.Strings(types)
+		for _, typ := range types {
+			if v := balances[i][typ]; v != 0 {
+				res = append(res, &Payout{Owner: party, Type: typ, Value: v})
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,61 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// SwapLeg declares that the output at index Output of the transfer action at index Transfer
+// carries Quantity units of Type
+type SwapLeg struct {
+	Transfer int
+	Output   int
+	Type     string
+	Quantity uint64
+	// Proof is the driver-specific data a validator needs to check the output against Type and Quantity.
+	// It is public, it must not reveal more about the output than Type and Quantity.
+	Proof []byte
+}
+
+// Swap declares the terms of an atomic exchange between two parties.
+// Give and Want are the legs of the exchange, each in its own transfer action.
+type Swap struct {
+	Give *SwapLeg
+	Want *SwapLeg
+}
+
+func (s *Swap) Serialize() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+func (s *Swap) Deserialize(raw []byte) error {
+	return json.Unmarshal(raw, s)
+}
+
+// Validate checks that the legs are well-formed and refer to distinct transfer actions among the passed ones
+func (s *Swap) Validate(numTransfers int) error {
+	if s.Give == nil || s.Want == nil {
+		return errors.New("invalid swap, missing leg")
+	}
+	if s.Give.Transfer == s.Want.Transfer {
+		return errors.Errorf("invalid swap, legs must be in distinct transfer actions, got [%d]", s.Give.Transfer)
+	}
+	for _, leg := range []*SwapLeg{s.Give, s.Want} {
+		if leg.Transfer < 0 || leg.Transfer >= numTransfers {
+			return errors.Errorf("invalid swap, transfer action [%d] out of range [0,%d)", leg.Transfer, numTransfers)
+		}
+		if leg.Output < 0 {
+			return errors.Errorf("invalid swap, negative output index [%d]", leg.Output)
+		}
+		if leg.Quantity == 0 {
+			return errors.Errorf("invalid swap, zero quantity for transfer action [%d]", leg.Transfer)
+		}
+	}
+	return nil
+}
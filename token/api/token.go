@@ -13,4 +13,8 @@ import (
 type TokenService interface {
 	// DeserializeToken returns the token and its issuer (if any).
 	DeserializeToken(outputRaw []byte, tokenInfoRaw []byte) (*token2.Token, view.Identity, error)
+
+	// SwapProof returns the data a validator needs to check that the passed output carries
+	// the type and quantity declared by a swap leg, see SwapLeg.
+	SwapProof(outputRaw []byte, tokenInfoRaw []byte) ([]byte, error)
 }
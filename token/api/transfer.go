@@ -16,3 +16,10 @@ type TransferService interface {
 
 	DeserializeTransferAction(raw []byte) (TransferAction, error)
 }
+
+// SettlementService is implemented by drivers whose outputs are hidden.
+// Settle is like Transfer but the outputs can be checked by the validator against the payouts of the closed dispute
+// on the spent deposit. Drivers whose outputs are in the clear settle with Transfer.
+type SettlementService interface {
+	Settle(txID string, wallet OwnerWallet, ids []*token2.Id, Outputs ...*token2.Token) (TransferAction, *TransferMetadata, error)
+}
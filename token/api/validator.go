@@ -5,7 +5,11 @@ SPDX-License-Identifier: Apache-2.0
 */
 package api
 
-import "github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+import (
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+)
 
 type GetStateFnc = func(key string) ([]byte, error)
 
@@ -21,8 +25,20 @@ type SignatureProvider interface {
 	HasBeenSignedBy(id view.Identity, verifier Verifier) error
 }
 
+// Validator checks token requests.
+// txTime is the timestamp of the transaction carrying the request. Time-dependent checks, such as the deadlines
+// of locked tokens, use it in place of the local clock, so that all validators reach the same result.
 type Validator interface {
-	VerifyTokenRequest(ledger Ledger, signatureProvider SignatureProvider, binding string, tr *TokenRequest) ([]interface{}, error)
+	VerifyTokenRequest(ledger Ledger, signatureProvider SignatureProvider, binding string, txTime time.Time, tr *TokenRequest) ([]interface{}, error)
+
+	VerifyTokenRequestFromRaw(getState GetStateFnc, binding string, txTime time.Time, raw []byte) ([]interface{}, error)
+}
 
-	VerifyTokenRequestFromRaw(getState GetStateFnc, binding string, raw []byte) ([]interface{}, error)
+// OwnerVerifier is implemented by validators that can check the ownership of ledger tokens outside of a token request
+type OwnerVerifier interface {
+	// Owner returns the owner of the passed token, as stored on the ledger
+	Owner(raw []byte) (view.Identity, error)
+	// VerifySignature checks that sigma is a valid signature of owner on message.
+	// Multisig owners are supported.
+	VerifySignature(owner view.Identity, message, sigma []byte) error
 }
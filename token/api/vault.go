@@ -23,8 +23,14 @@ type CertificationStorage interface {
 type QueryEngine interface {
 	IsMine(id *token.Id) (bool, error)
 	ListUnspentTokens() (*token.UnspentTokens, error)
+	// UnspentTokensPage returns, in vault order, the unspent tokens accepted by filter, skipping the first offset of them.
+	// A zero limit selects all the remaining tokens. more tells if there are accepted tokens after the page.
+	UnspentTokensPage(filter func(*token.UnspentToken) bool, offset, limit int) (tokens *token.UnspentTokens, more bool, err error)
 	ListAuditTokens(ids ...*token.Id) ([]*token.Token, error)
 	ListHistoryIssuedTokens() (*token.IssuedTokens, error)
+	// Balance returns the sum of the unspent tokens of the passed type owned by the passed wallet.
+	// An empty type sums the tokens of all types.
+	Balance(wallet Wallet, tokenType string) (token.Quantity, error)
 	PublicParams() ([]byte, error)
 	GetTokenInfos(ids []*token.Id, callback QueryCallbackFunc) error
 	GetTokenCommitments(ids []*token.Id, callback QueryCallbackFunc) error
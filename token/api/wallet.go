@@ -44,8 +44,16 @@ type OwnerWallet interface {
 	// ListTokens returns the list of unspent tokens owned by this wallet filtered using the passed options.
 	ListTokens(opts *ListTokensOptions) (*token2.UnspentTokens, error)
 
+	// Balance returns the sum of the unspent tokens of the passed type owned by this wallet.
+	Balance(tokenType string) (token2.Quantity, error)
+
 	// GetTokenMetadata returns any information needed to implement the transfer
 	GetTokenMetadata(id view.Identity) ([]byte, error)
+
+	// Restore recovers the recipient identities of this wallet that own unspent tokens in the vault.
+	// It stops after gapLimit consecutive unused identities and returns the number of identities recovered.
+	// Wallets whose recipient identities cannot be recomputed recover none.
+	Restore(gapLimit int) (int, error)
 }
 
 // IssuerWallet models the wallet of an issuer as a container of issuer identities.
@@ -56,8 +64,12 @@ type IssuerWallet interface {
 	// Depending on the underlying wallet implementation, this can be a long-term or ephemeral identity.
 	GetIssuerIdentity(tokenType string) (view.Identity, error)
 
-	// HistoryTokens returns the list of tokens issued by this wallet filtered using the passed options.
+	// HistoryTokens returns the list of tokens issued by this wallet, and of those redeemed back to it,
+	// filtered using the passed options. The redeemed tokens are flagged as such.
 	HistoryTokens(opts *ListTokensOptions) (*token2.IssuedTokens, error)
+
+	// RedeemedTokens returns the list of tokens redeemed back to this wallet, filtered using the passed options.
+	RedeemedTokens(opts *ListTokensOptions) (*token2.IssuedTokens, error)
 }
 
 // AuditorWallet models the wallet of an auditor
@@ -116,6 +128,25 @@ type WalletService interface {
 	// The id can be: the wallet identifier or a unique id of a view identity belonging to the wallet.
 	CertifierWallet(id string) CertifierWallet
 
+	// RegisterOwnerWallet registers, under the passed id, an owner wallet whose long-term identity is stored
+	// in the MSP folder at the passed path
+	RegisterOwnerWallet(id string, mspID string, path string) error
+
+	// RemoveOwnerWallet makes the owner wallet with the passed id no longer available.
+	// The key material of the wallet is not deleted.
+	RemoveOwnerWallet(id string) error
+
+	// OwnerWalletIDs returns the ids of the owner wallets in use or registered at runtime, sorted
+	OwnerWalletIDs() ([]string, error)
+
+	// ExportOwnerWallet returns the key material of the passed owner wallet, registered at runtime,
+	// encrypted under the passed passphrase
+	ExportOwnerWallet(id string, passphrase []byte) ([]byte, error)
+
+	// ImportOwnerWallet registers the owner wallet exported by ExportOwnerWallet,
+	// storing its key material in the folder at the passed path. It returns the id of the wallet.
+	ImportOwnerWallet(raw []byte, passphrase []byte, path string) (string, error)
+
 	// CertifierWalletByIdentity returns an instance of the CertifierWallet interface that contains the passed identity.
 	CertifierWalletByIdentity(identity view.Identity) CertifierWallet
 }
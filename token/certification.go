@@ -22,7 +22,7 @@ func (c *CertificationManager) Certify(wallet *CertifierWallet, ids []*token2.Id
 	return c.c.Certify(wallet.w, ids, tokens, request)
 }
 
-func (c *CertificationManager) VerifyCertifications(ids []*token2.Id, certifications [][]byte) error {
+func (c *CertificationManager) VerifyCertifications(ids []*token2.Id, certifications [][]byte) ([][]byte, error) {
 	return c.c.VerifyCertifications(ids, certifications)
 }
 
@@ -5,8 +5,16 @@ SPDX-License-Identifier: Apache-2.0
 */
 package token
 
+import "time"
+
 type InteractiveCertification struct {
 	IDs []string `yaml:"ids,omitempty"`
+	// MaxBatchSize is the maximum number of tokens sent in a single certification request
+	MaxBatchSize int `yaml:"maxBatchSize,omitempty"`
+	// Validity is how long the certifications issued by a certifier are valid, 24 hours if not set
+	Validity time.Duration `yaml:"validity,omitempty"`
+	// RenewBefore is how long before expiry a certification is renewed, a quarter of its validity if not set
+	RenewBefore time.Duration `yaml:"renewBefore,omitempty"`
 }
 
 type Certification struct {
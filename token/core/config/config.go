@@ -5,23 +5,71 @@ SPDX-License-Identifier: Apache-2.0
 */
 package config
 
+import "time"
+
 type InteractiveCertification struct {
 	IDs []string `yaml:"ids,omitempty"`
+	// MaxBatchSize is the maximum number of tokens sent in a single certification request
+	MaxBatchSize int `yaml:"maxBatchSize,omitempty"`
+	// Validity is how long the certifications issued by a certifier are valid, 24 hours if not set
+	Validity time.Duration `yaml:"validity,omitempty"`
+	// RenewBefore is how long before expiry a certification is renewed, a quarter of its validity if not set
+	RenewBefore time.Duration `yaml:"renewBefore,omitempty"`
 }
 
 type Certification struct {
 	Interactive *InteractiveCertification `yaml:"interactive,omitempty"`
 }
 
+// PKCS11 locates an ECDSA secret key in a PKCS#11 token
+type PKCS11 struct {
+	// Library is the path of the PKCS#11 module
+	Library string `yaml:"library"`
+	// Label is the label of the token
+	Label string `yaml:"label"`
+	Pin   string `yaml:"pin"`
+	// KeyID is the hex-encoded CKA_ID of the key, the SKI of the certificate of the wallet if not set
+	KeyID string `yaml:"keyID,omitempty"`
+}
+
+// RemoteSigner locates an ECDSA secret key held by a remote signing service
+type RemoteSigner struct {
+	// Address is the host:port of the gRPC endpoint of the service
+	Address string `yaml:"address"`
+	// KeyID identifies the key at the service
+	KeyID string `yaml:"keyID"`
+	// TLSRootCert is the path of the PEM root certificate of the service
+	TLSRootCert string `yaml:"tlsRootCert,omitempty"`
+	// Insecure allows the connection in the clear, when TLSRootCert is not set. It is meant for testing only.
+	Insecure bool `yaml:"insecure,omitempty"`
+	// Timeout bounds each signing request, 10 seconds if not set
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// Signer tells where the secret key of an X.509 wallet is, when not in the keystore of its MSP folder.
+// Exactly one of the fields must be set.
+type Signer struct {
+	PKCS11 *PKCS11       `yaml:"pkcs11,omitempty"`
+	Remote *RemoteSigner `yaml:"remote,omitempty"`
+}
+
 type Identity struct {
 	ID      string `yaml:"id"`
 	MSPType string `yaml:"mspType"`
 	MSPID   string `yaml:"mspID"`
 	Path    string `yaml:"path"`
+	// Signer, if set, is used in place of the key in the MSP folder of an X.509 wallet
+	Signer *Signer `yaml:"signer,omitempty"`
 }
 
 type Wallets struct {
 	Certifiers []*Identity `yaml:"certifiers,omitempty"`
+	// Issuers lists the X.509 issuer wallets that sign with an external signer
+	Issuers []*Identity `yaml:"issuers,omitempty"`
+	// Owners lists the owner wallets that need additional configuration.
+	// The recipient identities of an Idemix wallet are derived deterministically, the path points to its MSP folder.
+	// An X.509 wallet with a signer signs with an external signer.
+	Owners []*Identity `yaml:"owners,omitempty"`
 }
 
 type TMS struct {
@@ -8,9 +8,11 @@ package driver
 import (
 	fabric2 "github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/pkg/errors"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/api"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/config"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/fabtoken"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
@@ -31,6 +33,32 @@ func (d *Driver) PublicParametersFromBytes(params []byte) (api.PublicParameters,
 func (d *Driver) NewTokenService(sp view2.ServiceProvider, publicParamsFetcher api.PublicParamsFetcher, network string, channel api.Channel, namespace string) (api.TokenManagerService, error) {
 	qe := vault.NewVault(sp, channel, namespace).QueryEngine()
 	nodeIdentity := view2.GetIdentityProvider(sp).DefaultIdentity()
+	wallets, err := fabric.LoadWallets(sp, channel.Name(), namespace)
+	if err != nil {
+		return nil, err
+	}
+	if wallets == nil {
+		wallets = &config.Wallets{}
+	}
+	issuerMapper, err := fabric.NewSignerMapper(sp, fabric.NewMapper(fabric.X509MSPIdentity, nodeIdentity, fabric2.GetFabricNetworkService(sp, network).LocalMembership()), wallets.Issuers)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed loading issuer wallets")
+	}
+	ownerMapper, err := fabric.NewSignerMapper(sp, fabric.NewMapper(fabric.X509MSPIdentity, nodeIdentity, fabric2.GetFabricNetworkService(sp, network).LocalMembership()), wallets.Owners)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed loading owner wallets")
+	}
+	ip := identity.NewProvider(
+		sp,
+		map[api.IdentityUsage]identity.Mapper{
+			api.IssuerRole:  issuerMapper,
+			api.AuditorRole: fabric.NewMapper(fabric.X509MSPIdentity, nodeIdentity, fabric2.GetFabricNetworkService(sp, network).LocalMembership()),
+			api.OwnerRole:   ownerMapper,
+		},
+	)
+	if err := ip.LoadOwnerWallets(); err != nil {
+		return nil, errors.WithMessage(err, "failed loading owner wallets")
+	}
 	return fabtoken.NewService(
 		sp,
 		channel,
@@ -41,14 +69,7 @@ func (d *Driver) NewTokenService(sp view2.ServiceProvider, publicParamsFetcher a
 			PublicParamsFetcher: publicParamsFetcher,
 		},
 		qe,
-		identity.NewProvider(
-			sp,
-			map[api.IdentityUsage]identity.Mapper{
-				api.IssuerRole:  fabric.NewMapper(fabric.X509MSPIdentity, nodeIdentity, fabric2.GetFabricNetworkService(sp, network).LocalMembership()),
-				api.AuditorRole: fabric.NewMapper(fabric.X509MSPIdentity, nodeIdentity, fabric2.GetFabricNetworkService(sp, network).LocalMembership()),
-				api.OwnerRole:   fabric.NewMapper(fabric.X509MSPIdentity, nodeIdentity, fabric2.GetFabricNetworkService(sp, network).LocalMembership()),
-			},
-		),
+		ip,
 	), nil
 }
 
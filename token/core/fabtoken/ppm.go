@@ -38,10 +38,14 @@ func (v *PublicParamsManager) AddIssuer(bytes []byte) ([]byte, error) {
 	panic("implement me")
 }
 
-func (v *PublicParamsManager) SetCertifier(bytes []byte) ([]byte, error) {
+func (v *PublicParamsManager) SetCertifier(bytes []byte, identity []byte) ([]byte, error) {
 	panic("SetCertifier cannot be called from fabtoken")
 }
 
+func (v *PublicParamsManager) CertifierIdentity() []byte {
+	return nil
+}
+
 func (v *PublicParamsManager) PublicParameters() api.PublicParameters {
 	return v.pp
 }
@@ -7,6 +7,7 @@ package fabtoken
 
 import (
 	"encoding/json"
+	"strings"
 	"sync"
 
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
@@ -18,6 +19,8 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/hash"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/multisig"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
 )
 
@@ -35,6 +38,7 @@ type QueryEngine interface {
 	ListUnspentTokens() (*token2.UnspentTokens, error)
 	ListAuditTokens(ids ...*token2.Id) ([]*token2.Token, error)
 	ListHistoryIssuedTokens() (*token2.IssuedTokens, error)
+	Balance(wallet api.Wallet, tokenType string) (token2.Quantity, error)
 	PublicParams() ([]byte, error)
 }
 
@@ -48,7 +52,6 @@ type service struct {
 	qe                  QueryEngine
 
 	identityProvider api.IdentityProvider
-	ownerWallets     []*ownerWallet
 	issuerWallets    []*issuerWallet
 	auditorWallets   []*auditorWallet
 	walletsLock      sync.Mutex
@@ -141,6 +144,30 @@ func (s *service) GetAuditInfo(id view.Identity) ([]byte, error) {
 }
 
 func (s *service) GetEnrollmentID(auditInfo []byte) (string, error) {
+	if htlc.IsScriptInfo(auditInfo) {
+		// tokens locked by an htlc script are accounted to the recipient
+		si := &htlc.ScriptInfo{}
+		if err := si.Deserialize(auditInfo); err != nil {
+			return "", err
+		}
+		return s.GetEnrollmentID(si.Recipient)
+	}
+	if multisig.IsInfo(auditInfo) {
+		// tokens owned by a multisig identity are accounted to all the co-owners
+		info := &multisig.Info{}
+		if err := info.Deserialize(auditInfo); err != nil {
+			return "", err
+		}
+		var eIDs []string
+		for _, ai := range info.AuditInfos {
+			eID, err := s.GetEnrollmentID(ai)
+			if err != nil {
+				return "", err
+			}
+			eIDs = append(eIDs, eID)
+		}
+		return strings.Join(eIDs, ","), nil
+	}
 	return string(auditInfo), nil
 }
 
@@ -354,6 +381,11 @@ func (s *service) DeserializeToken(outputRaw []byte, tokenInfoRaw []byte) (*toke
 	return tok, tokInfo.Issuer, nil
 }
 
+// SwapProof returns nil, fabtoken outputs are in the clear
+func (s *service) SwapProof(outputRaw []byte, tokenInfoRaw []byte) ([]byte, error) {
+	return nil, nil
+}
+
 func (s *service) AuditorCheck(tokenRequest *api.TokenRequest, tokenRequestMetadata *api.TokenRequestMetadata, txID string) error {
 	// TODO:
 	return nil
@@ -379,8 +411,8 @@ func (s *service) Certify(wallet api.CertifierWallet, ids []*token2.Id, tokens [
 	return nil, nil
 }
 
-func (s *service) VerifyCertifications(ids []*token2.Id, certifications [][]byte) error {
-	return nil
+func (s *service) VerifyCertifications(ids []*token2.Id, certifications [][]byte) ([][]byte, error) {
+	return certifications, nil
 }
 
 func (s *service) publicParams() *PublicParams {
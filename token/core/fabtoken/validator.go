@@ -7,6 +7,7 @@ package fabtoken
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -14,7 +15,9 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/htlc"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/multisig"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
@@ -26,7 +29,7 @@ func NewValidator(pp *PublicParams) *Validator {
 	return &Validator{pp: pp}
 }
 
-func (v *Validator) VerifyTokenRequest(ledger api.Ledger, signatureProvider api.SignatureProvider, binding string, tr *api.TokenRequest) ([]interface{}, error) {
+func (v *Validator) VerifyTokenRequest(ledger api.Ledger, signatureProvider api.SignatureProvider, binding string, txTime time.Time, tr *api.TokenRequest) ([]interface{}, error) {
 	if err := v.verifyAuditorSignature(signatureProvider); err != nil {
 		return nil, errors.Wrapf(err, "failed to verifier auditor's signature [%s]", binding)
 	}
@@ -42,10 +45,14 @@ func (v *Validator) VerifyTokenRequest(ledger api.Ledger, signatureProvider api.
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to verify issuers' signatures [%s]", binding)
 	}
-	err = v.verifyTransfers(ledger, ta, signatureProvider)
+	err = v.verifyTransfers(ledger, ta, signatureProvider, txTime)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to verify senders' signatures [%s]", binding)
 	}
+	err = v.verifySwaps(tr.Swaps, ta)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to verify swaps [%s]", binding)
+	}
 
 	var actions []interface{}
 	for _, action := range ia {
@@ -58,22 +65,18 @@ func (v *Validator) VerifyTokenRequest(ledger api.Ledger, signatureProvider api.
 	return actions, nil
 }
 
-func (v *Validator) VerifyTokenRequestFromRaw(getState api.GetStateFnc, binding string, raw []byte) ([]interface{}, error) {
+func (v *Validator) VerifyTokenRequestFromRaw(getState api.GetStateFnc, binding string, txTime time.Time, raw []byte) ([]interface{}, error) {
 	if len(raw) == 0 {
 		return nil, errors.New("empty token request")
 	}
 	tr := &api.TokenRequest{}
-	err := json.Unmarshal(raw, tr)
+	err := tr.FromBytes(raw)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to unmarshal token request")
 	}
 
 	// Prepare message expected to be signed
-	// TODO: encapsulate this somewhere
-	req := &api.TokenRequest{}
-	req.Transfers = tr.Transfers
-	req.Issues = tr.Issues
-	bytes, err := json.Marshal(req)
+	bytes, err := tr.MessageToSign()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to marshal signed token request"+err.Error())
 	}
@@ -93,7 +96,31 @@ func (v *Validator) VerifyTokenRequestFromRaw(getState api.GetStateFnc, binding
 		message:    signed,
 		signatures: signatures,
 	}
-	return v.VerifyTokenRequest(backend, backend, binding, tr)
+	return v.VerifyTokenRequest(backend, backend, binding, txTime, tr)
+}
+
+// Owner returns the owner of the passed ledger token
+func (v *Validator) Owner(raw []byte) (view.Identity, error) {
+	tok := &token2.Token{}
+	if err := json.Unmarshal(raw, tok); err != nil {
+		return nil, errors.Wrap(err, "failed to deserialize token")
+	}
+	if tok.Owner == nil {
+		return nil, errors.New("token owner not set")
+	}
+	return tok.Owner.Raw, nil
+}
+
+// VerifySignature checks that sigma is a signature of the passed owner on message
+func (v *Validator) VerifySignature(owner view.Identity, message, sigma []byte) error {
+	identityDeserializer := &fabric.MSPX509IdentityDeserializer{}
+	verifier, err := multisig.GetVerifier(owner, func(id view.Identity) (api.Verifier, error) {
+		return identityDeserializer.GetVerifier(id)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed getting verifier for [%s]", owner.UniqueID())
+	}
+	return verifier.Verify(message, sigma)
 }
 
 func (v *Validator) unmarshalTransferActions(raw [][]byte) ([]api.TransferAction, error) {
@@ -134,12 +161,19 @@ func (v *Validator) verifyAuditorSignature(signatureProvider api.SignatureProvid
 }
 
 func (v *Validator) verifyIssues(issues []api.IssueAction, signatureProvider api.SignatureProvider) error {
-	for _, issue := range issues {
+	for i, issue := range issues {
 		a := issue.(*IssueAction)
 
 		if err := v.verifyIssue(a); err != nil {
 			return errors.Wrapf(err, "failed to verify issue action")
 		}
+		var outputOwners [][]byte
+		for _, output := range a.Outputs {
+			outputOwners = append(outputOwners, output.Output.Owner.Raw)
+		}
+		if err := multisig.VerifyOwners(outputOwners); err != nil {
+			return errors.WithMessagef(err, "failed to verify multisig owners of issue [%d]", i)
+		}
 
 		identityDeserializer := &fabric.MSPX509IdentityDeserializer{}
 		verifier, err := identityDeserializer.GetVerifier(a.Issuer)
@@ -153,12 +187,19 @@ func (v *Validator) verifyIssues(issues []api.IssueAction, signatureProvider api
 	return nil
 }
 
-func (v *Validator) verifyTransfers(ledger api.Ledger, transferActions []api.TransferAction, signatureProvider api.SignatureProvider) error {
+func (v *Validator) verifyTransfers(ledger api.Ledger, transferActions []api.TransferAction, signatureProvider api.SignatureProvider, txTime time.Time) error {
 	identityDeserializer := &fabric.MSPX509IdentityDeserializer{}
+	getVerifier := func(id view.Identity) (api.Verifier, error) {
+		return multisig.GetVerifier(id, func(id view.Identity) (api.Verifier, error) {
+			return identityDeserializer.GetVerifier(id)
+		})
+	}
 	logger.Debugf("check sender start...")
 	defer logger.Debugf("check sender finished.")
 	for i, t := range transferActions {
 		var inputTokens [][]byte
+		var inputOwners [][]byte
+		var settlement *api.Dispute
 		inputs, err := t.GetInputs()
 		if err != nil {
 			return errors.Wrapf(err, "failed to retrieve input IDs")
@@ -180,7 +221,16 @@ func (v *Validator) verifyTransfers(ledger api.Ledger, transferActions []api.Tra
 			}
 			logger.Debugf("check sender [%d][%s]", i, view.Identity(tok.Owner.Raw).UniqueID())
 
-			verifier, err := identityDeserializer.GetVerifier(tok.Owner.Raw)
+			inputOwners = append(inputOwners, tok.Owner.Raw)
+			if settlement, err = multisig.DepositDispute(ledger.GetState, inputs, in, tok.Owner.Raw); err != nil {
+				return errors.WithMessagef(err, "invalid transfer [%d]", i)
+			}
+			var verifier api.Verifier
+			if settlement != nil {
+				verifier, err = multisig.SettlementVerifier(tok.Owner.Raw, getVerifier)
+			} else {
+				verifier, err = htlc.GetVerifier(tok.Owner.Raw, txTime, getVerifier)
+			}
 			if err != nil {
 				return errors.Wrapf(err, "failed deserializing owner [%d][%s][%s]", i, in, view.Identity(tok.Owner.Raw).UniqueID())
 			}
@@ -189,6 +239,21 @@ func (v *Validator) verifyTransfers(ledger api.Ledger, transferActions []api.Tra
 				return errors.Wrapf(err, "failed signature verification [%d][%s][%s]", i, in, view.Identity(tok.Owner.Raw).UniqueID())
 			}
 		}
+		var outputOwners [][]byte
+		for _, output := range t.(*TransferAction).Outputs {
+			outputOwners = append(outputOwners, output.Output.Owner.Raw)
+		}
+		if err := htlc.VerifyOwners(inputOwners, outputOwners, txTime); err != nil {
+			return errors.WithMessagef(err, "failed to verify htlc constraints of transfer [%d]", i)
+		}
+		if err := multisig.VerifyOwners(outputOwners); err != nil {
+			return errors.WithMessagef(err, "failed to verify multisig owners of transfer [%d]", i)
+		}
+		if settlement != nil {
+			if err := v.verifySettlement(settlement, t.(*TransferAction)); err != nil {
+				return errors.WithMessagef(err, "invalid transfer [%d]", i)
+			}
+		}
 		if err := v.verifyTransfer(inputTokens, t); err != nil {
 			return errors.Wrapf(err, "failed to verify transfer action")
 		}
@@ -204,6 +269,59 @@ func (v *Validator) verifyTransfer(inputTokens [][]byte, tr api.TransferAction)
 	return nil
 }
 
+// verifySettlement checks that the passed transfer releases the deposit of a closed dispute to the payouts of its state
+func (v *Validator) verifySettlement(dispute *api.Dispute, action *TransferAction) error {
+	payouts := dispute.Payouts()
+	if len(action.Outputs) != len(payouts) {
+		return errors.Errorf("the settlement of channel [%s] must have [%d] outputs, got [%d]", dispute.State.ChannelID, len(payouts), len(action.Outputs))
+	}
+	for i, payout := range payouts {
+		output := action.Outputs[i].Output
+		if action.Outputs[i].IsRedeem() || !payout.Owner.Equal(output.Owner.Raw) || output.Type != payout.Type {
+			return errors.Errorf("output [%d] of the settlement of channel [%s] does not pay party [%s]", i, dispute.State.ChannelID, payout.Owner.UniqueID())
+		}
+		q, err := token2.ToQuantity(output.Quantity, 64)
+		if err != nil {
+			return errors.Wrapf(err, "failed parsing quantity of output [%d]", i)
+		}
+		if q.Cmp(token2.NewQuantityFromUInt64(payout.Value)) != 0 {
+			return errors.Errorf("output [%d] of the settlement of channel [%s] does not carry [%d] of type [%s]", i, dispute.State.ChannelID, payout.Value, payout.Type)
+		}
+	}
+	return nil
+}
+
+// verifySwaps checks that the declared swap terms match the outputs of the transfer actions
+func (v *Validator) verifySwaps(swaps [][]byte, transferActions []api.TransferAction) error {
+	for i, raw := range swaps {
+		swap := &api.Swap{}
+		if err := swap.Deserialize(raw); err != nil {
+			return errors.Wrapf(err, "failed to deserialize swap [%d]", i)
+		}
+		if err := swap.Validate(len(transferActions)); err != nil {
+			return errors.WithMessagef(err, "invalid swap [%d]", i)
+		}
+		for _, leg := range []*api.SwapLeg{swap.Give, swap.Want} {
+			action := transferActions[leg.Transfer].(*TransferAction)
+			if leg.Output >= action.NumOutputs() || action.Outputs[leg.Output].IsRedeem() {
+				return errors.Errorf("invalid swap [%d]: output [%d] of transfer [%d] is not a valid output", i, leg.Output, leg.Transfer)
+			}
+			output := action.Outputs[leg.Output].Output
+			if output.Type != leg.Type {
+				return errors.Errorf("invalid swap [%d]: expected type [%s] at [%d:%d], got [%s]", i, leg.Type, leg.Transfer, leg.Output, output.Type)
+			}
+			q, err := token2.ToQuantity(output.Quantity, 64)
+			if err != nil {
+				return errors.Wrapf(err, "invalid swap [%d]: failed parsing quantity at [%d:%d]", i, leg.Transfer, leg.Output)
+			}
+			if q.Cmp(token2.NewQuantityFromUInt64(leg.Quantity)) != 0 {
+				return errors.Errorf("invalid swap [%d]: expected quantity [%d] at [%d:%d], got [%s]", i, leg.Quantity, leg.Transfer, leg.Output, q.Decimal())
+			}
+		}
+	}
+	return nil
+}
+
 type backend struct {
 	getState   api.GetStateFnc
 	message    []byte
@@ -33,31 +33,33 @@ func (s *service) OwnerWallet(walletID string) api2.OwnerWallet {
 }
 
 func (s *service) ownerWallet(id interface{}) api2.OwnerWallet {
-	s.walletsLock.Lock()
-	defer s.walletsLock.Unlock()
-
-	// check if there is already a wallet
-	identity, walletID := s.identityProvider.LookupIdentifier(api2.OwnerRole, id)
-	for _, w := range s.ownerWallets {
-		if w.Contains(identity) || w.ID() == walletID {
-			logger.Debugf("found owner wallet [%s]", identity.String())
-			return w
-		}
-	}
-
-	// Create the wallet
-	if idInfo := s.identityProvider.GetIdentityInfo(api2.OwnerRole, walletID); idInfo != nil {
+	return s.identityProvider.OwnerWallet(id, func(idInfo *api2.IdentityInfo) (api2.OwnerWallet, error) {
 		id, err := idInfo.GetIdentity()
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
-		w := newOwnerWallet(s, idInfo.ID, id)
-		s.ownerWallets = append(s.ownerWallets, w)
-		logger.Debugf("created owner wallet [%s]", walletID)
-		return w
-	}
+		return newOwnerWallet(s, idInfo.ID, id), nil
+	})
+}
 
-	return nil
+func (s *service) RegisterOwnerWallet(id string, mspID string, path string) error {
+	return s.identityProvider.RegisterOwnerWallet(id, mspID, path)
+}
+
+func (s *service) RemoveOwnerWallet(id string) error {
+	return s.identityProvider.RemoveOwnerWallet(id)
+}
+
+func (s *service) OwnerWalletIDs() ([]string, error) {
+	return s.identityProvider.OwnerWalletIDs()
+}
+
+func (s *service) ExportOwnerWallet(id string, passphrase []byte) ([]byte, error) {
+	return s.identityProvider.ExportOwnerWallet(id, passphrase)
+}
+
+func (s *service) ImportOwnerWallet(raw []byte, passphrase []byte, path string) (string, error) {
+	return s.identityProvider.ImportOwnerWallet(raw, passphrase, path)
 }
 
 func (s *service) IssuerWallet(id string) api2.IssuerWallet {
@@ -85,7 +87,8 @@ func (s *service) issuerWallet(id interface{}) api2.IssuerWallet {
 	if idInfo := s.identityProvider.GetIdentityInfo(api2.IssuerRole, walletID); idInfo != nil {
 		id, err := idInfo.GetIdentity()
 		if err != nil {
-			panic(err)
+			logger.Errorf("failed getting identity of issuer wallet [%s]: [%s]", idInfo.ID, err)
+			return nil
 		}
 		w := newIssuerWallet(s, idInfo.ID, id)
 		s.issuerWallets = append(s.issuerWallets, w)
@@ -122,7 +125,8 @@ func (s *service) auditorWallet(id interface{}) api2.AuditorWallet {
 	if idInfo := s.identityProvider.GetIdentityInfo(api2.AuditorRole, walletID); idInfo != nil {
 		id, err := idInfo.GetIdentity()
 		if err != nil {
-			panic(err)
+			logger.Errorf("failed getting identity of auditor wallet [%s]: [%s]", idInfo.ID, err)
+			return nil
 		}
 		w := newAuditorWallet(s, idInfo.ID, id)
 		s.auditorWallets = append(s.auditorWallets, w)
@@ -215,6 +219,15 @@ func (w *ownerWallet) ListTokens(opts *api2.ListTokensOptions) (*token2.UnspentT
 	return unspentTokens, nil
 }
 
+func (w *ownerWallet) Balance(tokenType string) (token2.Quantity, error) {
+	return w.tokenService.qe.Balance(w, tokenType)
+}
+
+// Restore recovers nothing, the wallet has a single recipient identity, its long-term identity
+func (w *ownerWallet) Restore(gapLimit int) (int, error) {
+	return 0, nil
+}
+
 type issuerWallet struct {
 	tokenService *service
 	id           string
@@ -252,8 +265,19 @@ func (w *issuerWallet) GetSigner(identity view.Identity) (api2.Signer, error) {
 	return si, nil
 }
 
+// HistoryTokens returns the tokens issued by this wallet and those redeemed back to it
 func (w *issuerWallet) HistoryTokens(opts *api2.ListTokensOptions) (*token2.IssuedTokens, error) {
-	logger.Debugf("issuer wallet [%s]: history tokens, type [%d]", w.ID(), opts.TokenType)
+	return w.history(opts, false)
+}
+
+// RedeemedTokens returns the tokens redeemed back to this wallet
+func (w *issuerWallet) RedeemedTokens(opts *api2.ListTokensOptions) (*token2.IssuedTokens, error) {
+	return w.history(opts, true)
+}
+
+// history returns the history of this wallet, the tokens redeemed back to it only if redeemedOnly is true
+func (w *issuerWallet) history(opts *api2.ListTokensOptions, redeemedOnly bool) (*token2.IssuedTokens, error) {
+	logger.Debugf("issuer wallet [%s]: history tokens, type [%s], redeemed only [%v]", w.ID(), opts.TokenType, redeemedOnly)
 	source, err := w.tokenService.HistoryIssuedTokens()
 	if err != nil {
 		return nil, errors.Wrap(err, "token selection failed")
@@ -261,6 +285,10 @@ func (w *issuerWallet) HistoryTokens(opts *api2.ListTokensOptions) (*token2.Issu
 
 	unspentTokens := &token2.IssuedTokens{}
 	for _, t := range source.Tokens {
+		if redeemedOnly && !t.Redeemed {
+			continue
+		}
+
 		if len(opts.TokenType) != 0 && t.Type != opts.TokenType {
 			logger.Debugf("issuer wallet [%s]: discarding token of type [%s]!=[%s]", w.ID(), t.Type, opts.TokenType)
 			continue
@@ -0,0 +1,67 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package fabtoken
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+type historyQueryEngine struct {
+	QueryEngine
+	history []*token2.IssuedToken
+}
+
+func (h *historyQueryEngine) ListHistoryIssuedTokens() (*token2.IssuedTokens, error) {
+	return &token2.IssuedTokens{Tokens: h.history}, nil
+}
+
+func historyToken(issuer, typ, q string, redeemed bool) *token2.IssuedToken {
+	return &token2.IssuedToken{
+		Issuer:   &token2.Owner{Raw: []byte(issuer)},
+		Type:     typ,
+		Quantity: q,
+		Redeemed: redeemed,
+	}
+}
+
+func TestIssuerWalletHistory(t *testing.T) {
+	qe := &historyQueryEngine{history: []*token2.IssuedToken{
+		historyToken("issuer", "USD", "10", false),
+		historyToken("issuer", "USD", "3", true),
+		historyToken("issuer", "EUR", "7", false),
+		historyToken("issuer", "EUR", "2", true),
+		historyToken("other", "USD", "100", false),
+		historyToken("other", "USD", "50", true),
+	}}
+	w := newIssuerWallet(&service{qe: qe}, "issuer", view.Identity("issuer"))
+
+	// the history has both the issued and the redeemed tokens of the wallet
+	history, err := w.HistoryTokens(&api2.ListTokensOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, history.Count())
+	assert.Equal(t, 2, history.Issued().Count())
+	assert.Equal(t, "17", history.Issued().Sum(64).Decimal())
+
+	history, err = w.HistoryTokens(&api2.ListTokensOptions{TokenType: "USD"})
+	assert.NoError(t, err)
+	assert.Equal(t, []*token2.IssuedToken{qe.history[0], qe.history[1]}, history.Tokens)
+
+	// the redeemed tokens only
+	redeemed, err := w.RedeemedTokens(&api2.ListTokensOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []*token2.IssuedToken{qe.history[1], qe.history[3]}, redeemed.Tokens)
+
+	redeemed, err = w.RedeemedTokens(&api2.ListTokensOptions{TokenType: "EUR"})
+	assert.NoError(t, err)
+	assert.Equal(t, []*token2.IssuedToken{qe.history[3]}, redeemed.Tokens)
+}
@@ -0,0 +1,284 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package htlc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
+)
+
+const (
+	scriptTag     = "htlc"
+	scriptInfoTag = "htlc.info"
+	signatureTag  = "htlc.sig"
+)
+
+// Script locks a token to a hash and a deadline.
+// Before the deadline, the recipient can spend the token by revealing the preimage of the hash.
+// From the deadline on, the sender can reclaim the token.
+type Script struct {
+	Sender    view.Identity
+	Recipient view.Identity
+	Hash      []byte
+	Deadline  time.Time
+}
+
+// IsScript returns true if the passed owner encodes a Script
+func IsScript(raw []byte) bool {
+	return hasTag(raw, scriptTag)
+}
+
+// Image returns the hash of the passed preimage as expected by a Script
+func Image(preimage []byte) []byte {
+	h := sha256.Sum256(preimage)
+	return h[:]
+}
+
+func (s *Script) Serialize() ([]byte, error) {
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteString(scriptTag)
+	w.WriteBytes(s.Sender)
+	w.WriteBytes(s.Recipient)
+	w.WriteBytes(s.Hash)
+	w.WriteUvarint(uint64(s.Deadline.Unix()))
+	return w.Bytes(), nil
+}
+
+func (s *Script) Deserialize(raw []byte) error {
+	r, err := readerWithTag(raw, scriptTag)
+	if err != nil {
+		return err
+	}
+	s.Sender = r.ReadBytes()
+	s.Recipient = r.ReadBytes()
+	s.Hash = r.ReadBytes()
+	s.Deadline = time.Unix(int64(r.ReadUvarint()), 0)
+	if err := r.Close(); err != nil {
+		return errors.Wrap(err, "failed deserializing htlc script")
+	}
+	return nil
+}
+
+// Validate checks that the script is well-formed and that its deadline has not passed yet
+func (s *Script) Validate(now time.Time) error {
+	if s.Sender.IsNone() {
+		return errors.New("sender not set")
+	}
+	if s.Recipient.IsNone() {
+		return errors.New("recipient not set")
+	}
+	if IsScript(s.Sender) || IsScript(s.Recipient) {
+		return errors.New("nested scripts are not supported")
+	}
+	if len(s.Hash) != sha256.Size {
+		return errors.Errorf("invalid hash length, expected [%d], got [%d]", sha256.Size, len(s.Hash))
+	}
+	if !now.Before(s.Deadline) {
+		return errors.Errorf("deadline [%s] already passed", s.Deadline)
+	}
+	return nil
+}
+
+// ExpectedOwner returns the identity that can spend the locked token at the passed time
+func (s *Script) ExpectedOwner(now time.Time) view.Identity {
+	if now.Before(s.Deadline) {
+		return s.Recipient
+	}
+	return s.Sender
+}
+
+// VerifyOwners checks the constraints that scripts impose on a transfer with the passed input and output owners.
+// An input owned by a script must be spent alone, into a single output owned by the party that
+// can spend the script at the passed time. An output owned by a script must carry a valid script.
+func VerifyOwners(inputOwners, outputOwners [][]byte, now time.Time) error {
+	for i, owner := range inputOwners {
+		if !IsScript(owner) {
+			continue
+		}
+		if len(inputOwners) != 1 || len(outputOwners) != 1 {
+			return errors.Errorf("input [%d] is owned by an htlc script, expected one input and one output, got [%d] and [%d]", i, len(inputOwners), len(outputOwners))
+		}
+		script := &Script{}
+		if err := script.Deserialize(owner); err != nil {
+			return errors.WithMessagef(err, "failed to deserialize htlc script of input [%d]", i)
+		}
+		if expected := script.ExpectedOwner(now); !expected.Equal(outputOwners[0]) {
+			return errors.Errorf("input [%d] is owned by an htlc script, output must be owned by [%s]", i, expected)
+		}
+	}
+	for i, owner := range outputOwners {
+		if !IsScript(owner) {
+			continue
+		}
+		script := &Script{}
+		if err := script.Deserialize(owner); err != nil {
+			return errors.WithMessagef(err, "failed to deserialize htlc script of output [%d]", i)
+		}
+		if err := script.Validate(now); err != nil {
+			return errors.WithMessagef(err, "invalid htlc script at output [%d]", i)
+		}
+	}
+	return nil
+}
+
+// ScriptInfo carries the audit info of the parties of a Script
+type ScriptInfo struct {
+	Sender    []byte
+	Recipient []byte
+}
+
+// IsScriptInfo returns true if the passed audit info encodes a ScriptInfo
+func IsScriptInfo(raw []byte) bool {
+	return hasTag(raw, scriptInfoTag)
+}
+
+func (si *ScriptInfo) Serialize() ([]byte, error) {
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteString(scriptInfoTag)
+	w.WriteBytes(si.Sender)
+	w.WriteBytes(si.Recipient)
+	return w.Bytes(), nil
+}
+
+func (si *ScriptInfo) Deserialize(raw []byte) error {
+	r, err := readerWithTag(raw, scriptInfoTag)
+	if err != nil {
+		return err
+	}
+	si.Sender = r.ReadBytes()
+	si.Recipient = r.ReadBytes()
+	if err := r.Close(); err != nil {
+		return errors.Wrap(err, "failed deserializing htlc script info")
+	}
+	return nil
+}
+
+// Signature is the signature of the party spending a token owned by a Script.
+// A claim carries the preimage of the script hash, a reclaim does not.
+type Signature struct {
+	Sigma    []byte
+	Preimage []byte
+}
+
+func (s *Signature) Serialize() ([]byte, error) {
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteString(signatureTag)
+	w.WriteBytes(s.Sigma)
+	w.WriteBytes(s.Preimage)
+	return w.Bytes(), nil
+}
+
+func (s *Signature) Deserialize(raw []byte) error {
+	r, err := readerWithTag(raw, signatureTag)
+	if err != nil {
+		return err
+	}
+	s.Sigma = r.ReadBytes()
+	s.Preimage = r.ReadBytes()
+	if err := r.Close(); err != nil {
+		return errors.Wrap(err, "failed deserializing htlc signature")
+	}
+	return nil
+}
+
+// Signer signs on behalf of a Script using the signer of either the recipient, together with the preimage,
+// or the sender, with no preimage
+type Signer struct {
+	Signer   api2.Signer
+	Preimage []byte
+}
+
+func (s *Signer) Sign(message []byte) ([]byte, error) {
+	sigma, err := s.Signer.Sign(message)
+	if err != nil {
+		return nil, err
+	}
+	return (&Signature{Sigma: sigma, Preimage: s.Preimage}).Serialize()
+}
+
+// Verifier checks signatures on behalf of a Script.
+// A signature carrying the preimage is valid only before the deadline and if signed by the recipient,
+// a signature with no preimage is valid only from the deadline on and if signed by the sender.
+type Verifier struct {
+	Script      *Script
+	Now         time.Time
+	GetVerifier func(id view.Identity) (api2.Verifier, error)
+}
+
+// GetVerifier returns the verifier for the passed owner.
+// If the owner is a script, the verifiers of its parties are obtained with the passed function.
+func GetVerifier(owner view.Identity, now time.Time, getVerifier func(id view.Identity) (api2.Verifier, error)) (api2.Verifier, error) {
+	if !IsScript(owner) {
+		return getVerifier(owner)
+	}
+	script := &Script{}
+	if err := script.Deserialize(owner); err != nil {
+		return nil, err
+	}
+	return &Verifier{Script: script, Now: now, GetVerifier: getVerifier}, nil
+}
+
+func (v *Verifier) Verify(message, sigma []byte) error {
+	sig := &Signature{}
+	if err := sig.Deserialize(sigma); err != nil {
+		return err
+	}
+	now := v.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	var signer view.Identity
+	if len(sig.Preimage) != 0 {
+		if !now.Before(v.Script.Deadline) {
+			return errors.Errorf("cannot claim, deadline [%s] already passed", v.Script.Deadline)
+		}
+		if !bytes.Equal(Image(sig.Preimage), v.Script.Hash) {
+			return errors.New("cannot claim, preimage does not match hash")
+		}
+		signer = v.Script.Recipient
+	} else {
+		if now.Before(v.Script.Deadline) {
+			return errors.Errorf("cannot reclaim, deadline [%s] not passed yet", v.Script.Deadline)
+		}
+		signer = v.Script.Sender
+	}
+
+	verifier, err := v.GetVerifier(signer)
+	if err != nil {
+		return errors.Wrapf(err, "failed getting verifier for [%s]", signer)
+	}
+	return verifier.Verify(message, sig.Sigma)
+}
+
+func hasTag(raw []byte, tag string) bool {
+	if !encoding.IsBinary(raw) {
+		return false
+	}
+	r, err := encoding.NewReader(raw)
+	if err != nil {
+		return false
+	}
+	return r.ReadString() == tag && r.Err() == nil
+}
+
+func readerWithTag(raw []byte, tag string) (*encoding.Reader, error) {
+	r, err := encoding.NewReader(raw)
+	if err != nil {
+		return nil, err
+	}
+	if t := r.ReadString(); t != tag {
+		return nil, errors.Errorf("invalid encoding, expected tag [%s], got [%s]", tag, t)
+	}
+	return r, nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package htlc
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
+)
+
+type prefixSigner struct {
+	id []byte
+}
+
+func (s *prefixSigner) Sign(message []byte) ([]byte, error) {
+	return append(append([]byte{}, s.id...), message...), nil
+}
+
+func (s *prefixSigner) Verify(message, sigma []byte) error {
+	if !bytes.Equal(sigma, append(append([]byte{}, s.id...), message...)) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func TestScript(t *testing.T) {
+	now := time.Unix(1000, 0)
+	preimage := []byte("secret")
+	script := &Script{
+		Sender:    view.Identity("alice"),
+		Recipient: view.Identity("bob"),
+		Hash:      Image(preimage),
+		Deadline:  now.Add(time.Hour),
+	}
+	raw, err := script.Serialize()
+	assert.NoError(t, err)
+	assert.True(t, IsScript(raw))
+	assert.False(t, IsScript([]byte("alice")))
+
+	script2 := &Script{}
+	assert.NoError(t, script2.Deserialize(raw))
+	assert.Equal(t, script, script2)
+	assert.Error(t, script2.Deserialize(append(raw, 0)))
+
+	assert.NoError(t, script.Validate(now))
+	assert.Error(t, script.Validate(script.Deadline))
+	assert.Equal(t, script.Recipient, script.ExpectedOwner(now))
+	assert.Equal(t, script.Sender, script.ExpectedOwner(script.Deadline))
+
+	info := &ScriptInfo{Sender: []byte("a"), Recipient: []byte("b")}
+	raw, err = info.Serialize()
+	assert.NoError(t, err)
+	assert.True(t, IsScriptInfo(raw))
+	assert.False(t, IsScript(raw))
+	info2 := &ScriptInfo{}
+	assert.NoError(t, info2.Deserialize(raw))
+	assert.Equal(t, info, info2)
+}
+
+func TestVerifyOwners(t *testing.T) {
+	now := time.Unix(1000, 0)
+	script := &Script{
+		Sender:    view.Identity("alice"),
+		Recipient: view.Identity("bob"),
+		Hash:      Image([]byte("secret")),
+		Deadline:  now.Add(time.Hour),
+	}
+	raw, err := script.Serialize()
+	assert.NoError(t, err)
+
+	// lock
+	assert.NoError(t, VerifyOwners([][]byte{[]byte("alice")}, [][]byte{raw, []byte("alice")}, now))
+	assert.Error(t, VerifyOwners([][]byte{[]byte("alice")}, [][]byte{raw}, script.Deadline))
+	// claim and reclaim
+	assert.NoError(t, VerifyOwners([][]byte{raw}, [][]byte{[]byte("bob")}, now))
+	assert.NoError(t, VerifyOwners([][]byte{raw}, [][]byte{[]byte("alice")}, script.Deadline))
+	assert.Error(t, VerifyOwners([][]byte{raw}, [][]byte{[]byte("alice")}, now))
+	assert.Error(t, VerifyOwners([][]byte{raw}, [][]byte{[]byte("bob")}, script.Deadline))
+	assert.Error(t, VerifyOwners([][]byte{raw}, [][]byte{[]byte("bob"), []byte("bob")}, now))
+	assert.Error(t, VerifyOwners([][]byte{raw, []byte("bob")}, [][]byte{[]byte("bob")}, now))
+}
+
+func TestVerifier(t *testing.T) {
+	now := time.Unix(1000, 0)
+	preimage := []byte("secret")
+	script := &Script{
+		Sender:    view.Identity("alice"),
+		Recipient: view.Identity("bob"),
+		Hash:      Image(preimage),
+		Deadline:  now.Add(time.Hour),
+	}
+	getVerifier := func(id view.Identity) (api2.Verifier, error) {
+		return &prefixSigner{id: id}, nil
+	}
+	msg := []byte("msg")
+
+	claim, err := (&Signer{Signer: &prefixSigner{id: script.Recipient}, Preimage: preimage}).Sign(msg)
+	assert.NoError(t, err)
+	reclaim, err := (&Signer{Signer: &prefixSigner{id: script.Sender}}).Sign(msg)
+	assert.NoError(t, err)
+	wrongPreimage, err := (&Signer{Signer: &prefixSigner{id: script.Recipient}, Preimage: []byte("guess")}).Sign(msg)
+	assert.NoError(t, err)
+	claimBySender, err := (&Signer{Signer: &prefixSigner{id: script.Sender}, Preimage: preimage}).Sign(msg)
+	assert.NoError(t, err)
+
+	before := &Verifier{Script: script, Now: now, GetVerifier: getVerifier}
+	assert.NoError(t, before.Verify(msg, claim))
+	assert.Error(t, before.Verify(msg, reclaim))
+	assert.Error(t, before.Verify(msg, wrongPreimage))
+	assert.Error(t, before.Verify(msg, claimBySender))
+
+	after := &Verifier{Script: script, Now: script.Deadline, GetVerifier: getVerifier}
+	assert.NoError(t, after.Verify(msg, reclaim))
+	assert.Error(t, after.Verify(msg, claim))
+}
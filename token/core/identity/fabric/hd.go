@@ -0,0 +1,271 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package fabric
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric/core/generic/csp"
+	idemix3 "github.com/hyperledger-labs/fabric-smart-client/platform/fabric/core/generic/csp/idemix"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric/core/generic/csp/idemix/bridge"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric/core/generic/csp/idemix/handlers"
+	idemix2 "github.com/hyperledger-labs/fabric-smart-client/platform/fabric/core/generic/msp/idemix"
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger/fabric-amcl/amcl"
+	m "github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/pkg/errors"
+)
+
+const (
+	hdSeedLabel  = "token-sdk.identity.idemix.hd.seed"
+	hdNymLabel   = "nym"
+	hdProofLabel = "proof"
+	rhIndex      = 3
+)
+
+// IdemixDeriver derives Idemix pseudonyms deterministically from an index.
+// The seed of the derivation is computed from the Idemix secret key of the long-term identity,
+// therefore the same index always gives the same identity, and a backup of the MSP folder
+// is enough to recover all the pseudonyms.
+type IdemixDeriver struct {
+	sp   view2.ServiceProvider
+	name string
+	conf *m.IdemixMSPConfig
+	seed []byte
+
+	// csp is used to import keys, sign and verify with the nyms
+	csp bccsp.BCCSP
+	// hdCSP derives nyms and proofs with the randomness in next
+	hdCSP           bccsp.BCCSP
+	issuerPublicKey bccsp.Key
+	userKey         bccsp.Key
+
+	lock sync.Mutex
+	next []byte
+}
+
+// NewIdemixDeriver returns an IdemixDeriver for the Idemix MSP folder at the passed path
+func NewIdemixDeriver(sp view2.ServiceProvider, path string, mspID string) (*IdemixDeriver, error) {
+	conf1, err := msp.GetLocalMspConfigWithType(path, nil, mspID, IdemixMSP)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed reading idemix msp configuration from [%s]", path)
+	}
+	conf := &m.IdemixMSPConfig{}
+	if err := proto.Unmarshal(conf1.Config, conf); err != nil {
+		return nil, errors.Wrap(err, "failed unmarshalling idemix msp configuration")
+	}
+	if conf.Signer == nil {
+		return nil, errors.Errorf("no idemix secret key found at [%s]", path)
+	}
+
+	d := &IdemixDeriver{
+		sp:   sp,
+		name: conf.Name,
+		conf: conf,
+		seed: hdMAC([]byte(hdSeedLabel), conf.Signer.Sk),
+	}
+
+	user := &bridge.User{NewRand: bridge.NewRandOrPanic}
+	d.csp, err = idemix3.New(handlers.NewStore(sp, user))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting crypto provider")
+	}
+	hdCSP, err := idemix3.New(handlers.NewStore(sp, user))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting crypto provider")
+	}
+	userKeyType := reflect.TypeOf(handlers.NewUserSecretKey(nil, false))
+	if err := hdCSP.AddWrapper(userKeyType, &handlers.NymKeyDerivation{
+		User: &bridge.User{NewRand: d.newRand},
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed setting nym derivation")
+	}
+	if err := hdCSP.AddWrapper(userKeyType, &handlers.Signer{
+		SignatureScheme: &bridge.SignatureScheme{NewRand: d.newRand},
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed setting signer")
+	}
+	d.hdCSP = hdCSP
+
+	d.issuerPublicKey, err = d.csp.KeyImport(conf.Ipk, &csp.IdemixIssuerPublicKeyImportOpts{
+		Temporary: true,
+		AttributeNames: []string{
+			msp.AttributeNameOU,
+			msp.AttributeNameRole,
+			msp.AttributeNameEnrollmentId,
+			msp.AttributeNameRevocationHandle,
+		},
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed importing issuer public key")
+	}
+	d.userKey, err = d.csp.KeyImport(conf.Signer.Sk, &csp.IdemixUserSecretKeyImportOpts{Temporary: true})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed importing signer secret key")
+	}
+	return d, nil
+}
+
+// EnrollmentID returns the enrollment id of the long-term identity
+func (d *IdemixDeriver) EnrollmentID() string {
+	return d.conf.Signer.EnrollmentId
+}
+
+// Identity returns the pseudonym, and its audit info, at the passed index of the sequence with the passed label.
+// The signer of the pseudonym is registered with the signature service.
+func (d *IdemixDeriver) Identity(label string, index uint64) (view.Identity, []byte, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.next = hdMAC(d.seed, []byte(hdNymLabel), []byte(label), hdIndex(index))
+	nymKey, err := d.hdCSP.KeyDeriv(d.userKey, &csp.IdemixNymKeyDerivationOpts{Temporary: false, IssuerPK: d.issuerPublicKey})
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed deriving nym [%d]", index)
+	}
+	nymPublicKey, err := nymKey.PublicKey()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed getting public nym key")
+	}
+
+	role := &m.MSPRole{MspIdentifier: d.name, Role: m.MSPRole_MEMBER}
+	idemixRole := idemix2.GetRoleMaskFromIdemixRole(idemix2.MEMBER)
+	if adminRole := idemix2.GetRoleMaskFromIdemixRole(idemix2.ADMIN); int(d.conf.Signer.Role)&adminRole == adminRole {
+		role.Role = m.MSPRole_ADMIN
+		idemixRole = adminRole
+	}
+	ou := &m.OrganizationUnit{
+		MspIdentifier:                d.name,
+		OrganizationalUnitIdentifier: d.conf.Signer.OrganizationalUnitIdentifier,
+		CertifiersIdentifier:         d.issuerPublicKey.SKI(),
+	}
+
+	d.next = hdMAC(d.seed, []byte(hdProofLabel), []byte(label), hdIndex(index))
+	opts := &csp.IdemixSignerOpts{
+		Nym:        nymKey,
+		IssuerPK:   d.issuerPublicKey,
+		Credential: d.conf.Signer.Cred,
+		Attributes: []csp.IdemixAttribute{
+			{Type: csp.IdemixBytesAttribute},
+			{Type: csp.IdemixIntAttribute},
+			{Type: csp.IdemixHiddenAttribute},
+			{Type: csp.IdemixHiddenAttribute},
+		},
+		RhIndex: rhIndex,
+		CRI:     d.conf.Signer.CredentialRevocationInformation,
+	}
+	proof, err := d.hdCSP.Sign(d.userKey, nil, opts)
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed computing proof for nym [%d]", index)
+	}
+
+	raw, err := serializeIdemix(d.name, nymPublicKey, ou, role, proof)
+	if err != nil {
+		return nil, nil, err
+	}
+	signer := &nymSigner{csp: d.csp, userKey: d.userKey, nymKey: nymKey, nymPublicKey: nymPublicKey, issuerPublicKey: d.issuerPublicKey}
+	if err := idemix2.GetSignerService(d.sp).RegisterSigner(raw, signer, signer); err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed registering signer for nym [%d]", index)
+	}
+
+	auditInfo, err := (&idemix2.AuditInfo{
+		IdemixSignatureInfo: opts.Info,
+		Attributes: [][]byte{
+			[]byte(d.conf.Signer.OrganizationalUnitIdentifier),
+			[]byte(strconv.Itoa(idemixRole)),
+			[]byte(d.conf.Signer.EnrollmentId),
+		},
+	}).Bytes()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed marshalling audit info")
+	}
+	return raw, auditInfo, nil
+}
+
+func (d *IdemixDeriver) newRand() *amcl.RAND {
+	rng := amcl.NewRAND()
+	rng.Clean()
+	rng.Seed(len(d.next), d.next)
+	return rng
+}
+
+type nymSigner struct {
+	csp             bccsp.BCCSP
+	userKey         bccsp.Key
+	nymKey          bccsp.Key
+	nymPublicKey    bccsp.Key
+	issuerPublicKey bccsp.Key
+}
+
+func (s *nymSigner) Sign(msg []byte) ([]byte, error) {
+	return s.csp.Sign(s.userKey, msg, &csp.IdemixNymSignerOpts{Nym: s.nymKey, IssuerPK: s.issuerPublicKey})
+}
+
+func (s *nymSigner) Verify(msg, sig []byte) error {
+	valid, err := s.csp.Verify(s.nymPublicKey, sig, msg, &csp.IdemixNymSignerOpts{IssuerPK: s.issuerPublicKey})
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// serializeIdemix serializes a pseudonym as the Idemix MSP does
+func serializeIdemix(mspID string, nymPublicKey bccsp.Key, ou *m.OrganizationUnit, role *m.MSPRole, proof []byte) ([]byte, error) {
+	raw, err := nymPublicKey.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling nym public key")
+	}
+	ouRaw, err := proto.Marshal(ou)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling ou")
+	}
+	roleRaw, err := proto.Marshal(role)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling role")
+	}
+	idRaw, err := proto.Marshal(&m.SerializedIdemixIdentity{
+		NymX:  raw[:len(raw)/2],
+		NymY:  raw[len(raw)/2:],
+		Ou:    ouRaw,
+		Role:  roleRaw,
+		Proof: proof,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling idemix identity")
+	}
+	sIDRaw, err := proto.Marshal(&m.SerializedIdentity{Mspid: mspID, IdBytes: idRaw})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling identity")
+	}
+	return sIDRaw, nil
+}
+
+func hdMAC(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	for _, d := range data {
+		// length-prefix each element so that different splits give different inputs
+		mac.Write(hdIndex(uint64(len(d))))
+		mac.Write(d)
+	}
+	return mac.Sum(nil)
+}
+
+func hdIndex(index uint64) []byte {
+	raw := make([]byte, 8)
+	binary.BigEndian.PutUint64(raw, index)
+	return raw
+}
@@ -0,0 +1,107 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package fabric
+
+import (
+	"testing"
+	"time"
+
+	idemix2 "github.com/hyperledger-labs/fabric-smart-client/platform/fabric/core/generic/msp/idemix"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/core/sig"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/kvs"
+	registry2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/registry"
+	msp2 "github.com/hyperledger/fabric/msp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdemixDeriver(t *testing.T) {
+	registry := registry2.New()
+	assert.NoError(t, registry.RegisterService(&fakeConfig{}))
+	kvss, err := kvs.New("memory", "", registry)
+	assert.NoError(t, err)
+	assert.NoError(t, registry.RegisterService(kvss))
+	sigService := sig.NewSignService(registry, nil)
+	assert.NoError(t, registry.RegisterService(sigService))
+
+	d, err := NewIdemixDeriver(registry, "./testdata/idemix", "idemix")
+	assert.NoError(t, err)
+
+	id0, auditInfo0, err := d.Identity("channel", 0)
+	assert.NoError(t, err)
+	id1, _, err := d.Identity("channel", 1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, id0, id1)
+	other, _, err := d.Identity("other", 0)
+	assert.NoError(t, err)
+	assert.NotEqual(t, id0, other)
+
+	// the same index gives the same identity, also with a new deriver
+	d2, err := NewIdemixDeriver(registry, "./testdata/idemix", "idemix")
+	assert.NoError(t, err)
+	again, auditInfoAgain, err := d2.Identity("channel", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, id0, again)
+	assert.Equal(t, auditInfo0, auditInfoAgain)
+
+	// the identity is a valid idemix identity, and its audit info matches it
+	conf, err := msp2.GetLocalMspConfigWithType("./testdata/idemix", nil, "idemix", IdemixMSP)
+	assert.NoError(t, err)
+	p, err := idemix2.NewProvider(conf, registry)
+	assert.NoError(t, err)
+	verifier, err := p.DeserializeVerifier(id0)
+	assert.NoError(t, err)
+
+	ai := &idemix2.AuditInfo{}
+	assert.NoError(t, ai.FromBytes(auditInfo0))
+	assert.NoError(t, ai.Match(id0))
+	assert.Equal(t, d.EnrollmentID(), ai.EnrollmentID())
+
+	signer, err := sigService.GetSigner(id0)
+	assert.NoError(t, err)
+	sigma, err := signer.Sign([]byte("hello world"))
+	assert.NoError(t, err)
+	assert.NoError(t, verifier.Verify([]byte("hello world"), sigma))
+	assert.Error(t, verifier.Verify([]byte("hello world!"), sigma))
+}
+
+type fakeConfig struct{}
+
+func (f *fakeConfig) GetString(key string) string {
+	return "memory"
+}
+
+func (f *fakeConfig) GetDuration(key string) time.Duration {
+	return 0
+}
+
+func (f *fakeConfig) GetBool(key string) bool {
+	return false
+}
+
+func (f *fakeConfig) GetStringSlice(key string) []string {
+	return nil
+}
+
+func (f *fakeConfig) IsSet(key string) bool {
+	return false
+}
+
+func (f *fakeConfig) UnmarshalKey(key string, rawVal interface{}) error {
+	*(rawVal.(*kvs.Opts)) = kvs.Opts{}
+	return nil
+}
+
+func (f *fakeConfig) ConfigFileUsed() string {
+	return ""
+}
+
+func (f *fakeConfig) GetPath(key string) string {
+	return ""
+}
+
+func (f *fakeConfig) TranslatePath(path string) string {
+	return ""
+}
@@ -12,6 +12,7 @@ import (
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity"
 )
@@ -39,6 +40,12 @@ type LocalMembership interface {
 	GetIdentityInfoByIdentity(mspType string, id view.Identity) *fabric.IdentityInfo
 }
 
+// MSPRegistry is implemented by the local memberships that can load new MSPs at runtime
+type MSPRegistry interface {
+	RegisterX509MSP(id string, path string, mspID string) error
+	RegisterIdemixMSP(id string, path string, mspID string) error
+}
+
 type Mapper struct {
 	nodeIdentity    view.Identity
 	localMembership LocalMembership
@@ -72,6 +79,27 @@ func (i *Mapper) Info(id string) (string, string, identity.GetFunc) {
 	}
 }
 
+// Register loads, under the passed id, the long-term identity stored in the MSP folder at the passed path
+func (i *Mapper) Register(id string, mspID string, path string) error {
+	registry, ok := i.localMembership.(MSPRegistry)
+	if !ok {
+		return errors.New("the local membership does not support loading new identities")
+	}
+	var err error
+	switch i.mspType {
+	case X509MSPIdentity:
+		err = registry.RegisterX509MSP(id, path, mspID)
+	case IdemixMSPIdentity:
+		err = registry.RegisterIdemixMSP(id, path, mspID)
+	default:
+		return errors.Errorf("msp type [%d] not supported", i.mspType)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed loading identity [%s] from [%s]", id, path)
+	}
+	return nil
+}
+
 func (i *Mapper) Map(v interface{}) (view.Identity, string) {
 	defaultID := i.localMembership.DefaultIdentity()
 
@@ -0,0 +1,196 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package fabric
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/config"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/pkcs11"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/remote"
+)
+
+// LoadWallets returns the wallets configured for the TMS of the passed channel and namespace, nil if none
+func LoadWallets(sp view2.ServiceProvider, channel string, namespace string) (*config.Wallets, error) {
+	var tmsConfigs []*config.TMS
+	if err := view2.GetConfigService(sp).UnmarshalKey("token.tms", &tmsConfigs); err != nil {
+		return nil, errors.WithMessagef(err, "cannot load token-sdk configuration")
+	}
+	for _, tms := range tmsConfigs {
+		if tms.Channel == channel && tms.Namespace == namespace {
+			return tms.Wallets, nil
+		}
+	}
+	return nil, nil
+}
+
+// signerWallet is an X.509 wallet whose secret key is held by an external signer
+type signerWallet struct {
+	conf *config.Identity
+	id   view.Identity
+	eid  string
+	pk   *ecdsa.PublicKey
+
+	lock       sync.Mutex
+	registered bool
+}
+
+// SignerMapper serves the X.509 wallets that sign with an external signer,
+// and delegates to the wrapped mapper for all the others
+type SignerMapper struct {
+	identity.Mapper
+
+	sp      view2.ServiceProvider
+	wallets []*signerWallet
+}
+
+// NewSignerMapper returns a SignerMapper for the passed wallets, those without a signer are ignored
+func NewSignerMapper(sp view2.ServiceProvider, mapper identity.Mapper, wallets []*config.Identity) (*SignerMapper, error) {
+	m := &SignerMapper{Mapper: mapper, sp: sp}
+	for _, conf := range wallets {
+		if conf.Signer == nil {
+			continue
+		}
+		if (conf.Signer.PKCS11 == nil) == (conf.Signer.Remote == nil) {
+			return nil, errors.Errorf("wallet [%s] must set exactly one signer", conf.ID)
+		}
+		id, cert, err := loadX509Identity(conf.MSPID, conf.Path)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed loading wallet [%s]", conf.ID)
+		}
+		pk, ok := cert.PublicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, errors.Errorf("wallet [%s] must have an ECDSA certificate", conf.ID)
+		}
+		m.wallets = append(m.wallets, &signerWallet{
+			conf: conf,
+			id:   id,
+			eid:  cert.Subject.CommonName,
+			pk:   pk,
+		})
+	}
+	return m, nil
+}
+
+func (m *SignerMapper) Info(id string) (string, string, identity.GetFunc) {
+	w := m.lookup(id)
+	if w == nil {
+		return m.Mapper.Info(id)
+	}
+	return w.conf.ID, w.eid, func() (view.Identity, []byte, error) {
+		if err := m.register(w); err != nil {
+			return nil, nil, err
+		}
+		return w.id, nil, nil
+	}
+}
+
+// Map returns the identity and the id of the wallet matching the passed label or identity.
+// The signer of the wallet is connected when the identity is retrieved with Info.
+func (m *SignerMapper) Map(v interface{}) (view.Identity, string) {
+	var w *signerWallet
+	switch vv := v.(type) {
+	case view.Identity:
+		w = m.lookup(string(vv))
+	case string:
+		w = m.lookup(vv)
+	}
+	if w == nil {
+		return m.Mapper.Map(v)
+	}
+	return w.id, w.conf.ID
+}
+
+// Register forwards to the wrapped mapper, if it supports registration at runtime
+func (m *SignerMapper) Register(id string, mspID string, path string) error {
+	registry, ok := m.Mapper.(identity.Registry)
+	if !ok {
+		return errors.New("the identities do not support registration at runtime")
+	}
+	return registry.Register(id, mspID, path)
+}
+
+// lookup returns the wallet with the passed id, or whose identity has the passed bytes
+func (m *SignerMapper) lookup(label string) *signerWallet {
+	for _, w := range m.wallets {
+		if w.conf.ID == label || string(w.id) == label {
+			return w
+		}
+	}
+	return nil
+}
+
+// register connects to the signer of the passed wallet and registers it, the first time only
+func (m *SignerMapper) register(w *signerWallet) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.registered {
+		return nil
+	}
+
+	var signer api.Signer
+	var err error
+	switch {
+	case w.conf.Signer.PKCS11 != nil:
+		signer, err = pkcs11.NewSigner(w.conf.Signer.PKCS11, w.pk)
+	default:
+		signer, err = remote.NewSigner(w.conf.Signer.Remote, w.pk)
+	}
+	if err != nil {
+		return errors.WithMessagef(err, "failed creating signer for wallet [%s]", w.conf.ID)
+	}
+	if err := view2.GetSigService(m.sp).RegisterSigner(w.id, signer, NewVerifier(w.pk)); err != nil {
+		return errors.WithMessagef(err, "failed registering signer for wallet [%s]", w.conf.ID)
+	}
+	w.registered = true
+	logger.Debugf("registered external signer for wallet [%s]", w.conf.ID)
+	return nil
+}
+
+// loadX509Identity returns the serialized identity, and its certificate, stored in the MSP folder at the passed path
+func loadX509Identity(mspID string, path string) (view.Identity, *x509.Certificate, error) {
+	dir := filepath.Join(path, "signcerts")
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed reading [%s]", dir)
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		raw, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed reading [%s]", file.Name())
+		}
+		block, _ := pem.Decode(raw)
+		if block == nil || block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed parsing certificate [%s]", file.Name())
+		}
+		id, err := proto.Marshal(&msp.SerializedIdentity{Mspid: mspID, IdBytes: raw})
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed marshalling serialized identity")
+		}
+		return id, cert, nil
+	}
+	return nil, nil, errors.Errorf("no certificate found in [%s]", dir)
+}
@@ -0,0 +1,139 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package fabric
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/core/sig"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/kvs"
+	registry2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/registry"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/config"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/remote"
+)
+
+func TestSignerMapper(t *testing.T) {
+	registry := registry2.New()
+	assert.NoError(t, registry.RegisterService(&fakeConfig{}))
+	kvss, err := kvs.New("memory", "", registry)
+	assert.NoError(t, err)
+	assert.NoError(t, registry.RegisterService(kvss))
+	sigService := sig.NewSignService(registry, nil)
+	assert.NoError(t, registry.RegisterService(sigService))
+
+	// an msp folder with the certificate only, the key is at the remote signer
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	dir, err := ioutil.TempDir("", "signer-mapper")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "signcerts"), 0755))
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "issuer.hsm"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &sk.PublicKey, sk)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "signcerts", "cert.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644))
+
+	server := remote.NewServer()
+	server.AddKey("issuer", sk)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	m, err := NewSignerMapper(registry, &fakeMapper{}, []*config.Identity{
+		{ID: "local", MSPID: "Org1MSP", Path: "/nowhere"},
+		{
+			ID:     "hsm",
+			MSPID:  "Org1MSP",
+			Path:   dir,
+			Signer: &config.Signer{Remote: &config.RemoteSigner{Address: lis.Addr().String(), KeyID: "issuer", Insecure: true}},
+		},
+	})
+	assert.NoError(t, err)
+
+	id, eid, getIdentity := m.Info("hsm")
+	assert.Equal(t, "hsm", id)
+	assert.Equal(t, "issuer.hsm", eid)
+	walletID, auditInfo, err := getIdentity()
+	assert.NoError(t, err)
+	assert.Nil(t, auditInfo)
+
+	// the wallet signs with the remote key
+	signer, err := sigService.GetSigner(walletID)
+	assert.NoError(t, err)
+	sigma, err := signer.Sign([]byte("hello world"))
+	assert.NoError(t, err)
+	verifier, err := (&MSPX509IdentityDeserializer{}).GetVerifier(walletID)
+	assert.NoError(t, err)
+	assert.NoError(t, verifier.Verify([]byte("hello world"), sigma))
+
+	mapped, label := m.Map("hsm")
+	assert.Equal(t, walletID, mapped)
+	assert.Equal(t, "hsm", label)
+	mapped, label = m.Map(walletID)
+	assert.Equal(t, walletID, mapped)
+	assert.Equal(t, "hsm", label)
+
+	// wallets without a signer are served by the wrapped mapper
+	_, _, getIdentity = m.Info("local")
+	assert.Nil(t, getIdentity)
+	mapped, label = m.Map("local")
+	assert.Nil(t, mapped)
+	assert.Equal(t, "local", label)
+
+	// a signer that cannot be created is reported when the identity is retrieved
+	m, err = NewSignerMapper(registry, &fakeMapper{}, []*config.Identity{
+		{
+			ID:     "nosigner",
+			MSPID:  "Org1MSP",
+			Path:   dir,
+			Signer: &config.Signer{Remote: &config.RemoteSigner{Address: lis.Addr().String(), KeyID: "issuer"}},
+		},
+	})
+	assert.NoError(t, err)
+	_, label = m.Map("nosigner")
+	assert.Equal(t, "nosigner", label)
+	_, _, getIdentity = m.Info("nosigner")
+	_, _, err = getIdentity()
+	assert.Error(t, err)
+
+	// a wallet must set exactly one signer
+	_, err = NewSignerMapper(registry, &fakeMapper{}, []*config.Identity{
+		{ID: "hsm", MSPID: "Org1MSP", Path: dir, Signer: &config.Signer{}},
+	})
+	assert.Error(t, err)
+}
+
+type fakeMapper struct{}
+
+func (f *fakeMapper) Info(id string) (string, string, identity.GetFunc) {
+	return "", "", nil
+}
+
+func (f *fakeMapper) Map(v interface{}) (view.Identity, string) {
+	return nil, v.(string)
+}
@@ -0,0 +1,159 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package identity
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	keyFileVersion = 1
+	keyFileSaltLen = 32
+)
+
+// keyFile carries the MSP folder of an owner wallet
+type keyFile struct {
+	ID    string
+	MSPID string
+	// Files maps the paths, relative to the MSP folder, to the content of the files
+	Files map[string][]byte
+}
+
+// sealedKeyFile is a keyFile encrypted with AES-GCM under a key derived from a passphrase with scrypt
+type sealedKeyFile struct {
+	Version    int
+	Salt       []byte
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+func sealKeyFile(kf *keyFile, passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	plaintext, err := json.Marshal(kf)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling key file")
+	}
+	salt := make([]byte, keyFileSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.Wrap(err, "failed generating salt")
+	}
+	aead, err := keyFileCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "failed generating nonce")
+	}
+	return json.Marshal(&sealedKeyFile{
+		Version:    keyFileVersion,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, nil),
+	})
+}
+
+func openKeyFile(raw []byte, passphrase []byte) (*keyFile, error) {
+	sealed := &sealedKeyFile{}
+	if err := json.Unmarshal(raw, sealed); err != nil {
+		return nil, errors.Wrap(err, "failed unmarshalling key file")
+	}
+	if sealed.Version != keyFileVersion {
+		return nil, errors.Errorf("key file version [%d] not supported", sealed.Version)
+	}
+	aead, err := keyFileCipher(passphrase, sealed.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid key file nonce")
+	}
+	plaintext, err := aead.Open(nil, sealed.Nonce, sealed.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("failed decrypting key file, wrong passphrase or corrupted file")
+	}
+	kf := &keyFile{}
+	if err := json.Unmarshal(plaintext, kf); err != nil {
+		return nil, errors.Wrap(err, "failed unmarshalling key file content")
+	}
+	return kf, nil
+}
+
+func keyFileCipher(passphrase, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed deriving key from passphrase")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed creating cipher")
+	}
+	return cipher.NewGCM(block)
+}
+
+// readFolder returns the content of the files under the passed folder, keyed by their relative path
+func readFolder(root string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = raw
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed reading folder [%s]", root)
+	}
+	return files, nil
+}
+
+// writeFolder writes the passed files under the passed folder, that must not exist or be empty
+func writeFolder(root string, files map[string][]byte) error {
+	if entries, err := ioutil.ReadDir(root); err == nil && len(entries) != 0 {
+		return errors.Errorf("folder [%s] is not empty", root)
+	}
+	for rel, raw := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if !isWithin(root, path) {
+			return errors.Errorf("invalid file path [%s]", rel)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return errors.Wrapf(err, "failed creating folder for [%s]", path)
+		}
+		if err := ioutil.WriteFile(path, raw, 0600); err != nil {
+			return errors.Wrapf(err, "failed writing [%s]", path)
+		}
+	}
+	return nil
+}
+
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
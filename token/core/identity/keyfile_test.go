@@ -0,0 +1,56 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package identity
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyfile")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	assert.NoError(t, os.MkdirAll(filepath.Join(src, "keystore"), 0700))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(src, "keystore", "priv_sk"), []byte("secret"), 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(src, "config.yaml"), []byte("config"), 0600))
+
+	files, err := readFolder(src)
+	assert.NoError(t, err)
+	raw, err := sealKeyFile(&keyFile{ID: "alice", MSPID: "OrgMSP", Files: files}, []byte("passphrase"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(raw), "secret")
+
+	_, err = openKeyFile(raw, []byte("wrong"))
+	assert.Error(t, err)
+
+	kf, err := openKeyFile(raw, []byte("passphrase"))
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", kf.ID)
+	assert.Equal(t, "OrgMSP", kf.MSPID)
+
+	dst := filepath.Join(dir, "dst")
+	assert.NoError(t, writeFolder(dst, kf.Files))
+	sk, err := ioutil.ReadFile(filepath.Join(dst, "keystore", "priv_sk"))
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(sk))
+
+	// the destination must be empty
+	assert.Error(t, writeFolder(dst, kf.Files))
+	// and files cannot escape it
+	assert.Error(t, writeFolder(filepath.Join(dir, "evil"), map[string][]byte{"../escaped": []byte("x")}))
+}
+
+func TestKeyFileEmptyPassphrase(t *testing.T) {
+	_, err := sealKeyFile(&keyFile{ID: "alice"}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,23 @@
+//go:build !pkcs11
+// +build !pkcs11
+
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package pkcs11
+
+import (
+	"crypto/ecdsa"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/config"
+)
+
+// NewSigner fails, PKCS#11 support requires building with the pkcs11 tag
+func NewSigner(conf *config.PKCS11, pk *ecdsa.PublicKey) (api.Signer, error) {
+	return nil, errors.New("pkcs11 is not supported, build with the pkcs11 tag")
+}
@@ -0,0 +1,163 @@
+//go:build pkcs11
+// +build pkcs11
+
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package pkcs11
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/fabric/bccsp/utils"
+	p11 "github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/config"
+)
+
+// Signer signs with an ECDSA key stored in a PKCS#11 token
+type Signer struct {
+	ctx     *p11.Ctx
+	session p11.SessionHandle
+	key     p11.ObjectHandle
+	pk      *ecdsa.PublicKey
+
+	// a session cannot be used concurrently
+	lock sync.Mutex
+}
+
+// NewSigner returns a Signer for the key, whose public part is the passed one, described by the passed configuration
+func NewSigner(conf *config.PKCS11, pk *ecdsa.PublicKey) (api.Signer, error) {
+	if len(conf.Library) == 0 {
+		return nil, errors.New("no pkcs11 library set")
+	}
+	keyID, err := keyID(conf, pk)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := p11.New(conf.Library)
+	if ctx == nil {
+		return nil, errors.Errorf("failed loading pkcs11 library [%s]", conf.Library)
+	}
+	if err := ctx.Initialize(); err != nil {
+		if pe, ok := err.(p11.Error); !ok || pe != p11.CKR_CRYPTOKI_ALREADY_INITIALIZED {
+			return nil, errors.Wrapf(err, "failed initializing pkcs11 library [%s]", conf.Library)
+		}
+	}
+	slot, err := findSlot(ctx, conf.Label)
+	if err != nil {
+		return nil, err
+	}
+	session, err := ctx.OpenSession(slot, p11.CKF_SERIAL_SESSION|p11.CKF_RW_SESSION)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed opening session with token [%s]", conf.Label)
+	}
+	if err := ctx.Login(session, p11.CKU_USER, conf.Pin); err != nil {
+		if pe, ok := err.(p11.Error); !ok || pe != p11.CKR_USER_ALREADY_LOGGED_IN {
+			ctx.CloseSession(session)
+			return nil, errors.Wrapf(err, "failed logging in token [%s]", conf.Label)
+		}
+	}
+	key, err := findPrivateKey(ctx, session, keyID)
+	if err != nil {
+		ctx.CloseSession(session)
+		return nil, err
+	}
+	return &Signer{ctx: ctx, session: session, key: key, pk: pk}, nil
+}
+
+// Sign returns the low-S ECDSA signature of the SHA-256 digest of the passed message
+func (s *Signer) Sign(message []byte) ([]byte, error) {
+	digest := sha256.Sum256(message)
+
+	s.lock.Lock()
+	if err := s.ctx.SignInit(s.session, []*p11.Mechanism{p11.NewMechanism(p11.CKM_ECDSA, nil)}, s.key); err != nil {
+		s.lock.Unlock()
+		return nil, errors.Wrap(err, "failed initializing pkcs11 signature")
+	}
+	raw, err := s.ctx.Sign(s.session, digest[:])
+	s.lock.Unlock()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed pkcs11 signature")
+	}
+
+	// the token returns the concatenation of r and s
+	if len(raw) == 0 || len(raw)%2 != 0 {
+		return nil, errors.Errorf("invalid pkcs11 signature length [%d]", len(raw))
+	}
+	r := new(big.Int).SetBytes(raw[:len(raw)/2])
+	ss := new(big.Int).SetBytes(raw[len(raw)/2:])
+	ss, err = utils.ToLowS(s.pk, ss)
+	if err != nil {
+		return nil, err
+	}
+	if !ecdsa.Verify(s.pk, digest[:], r, ss) {
+		return nil, errors.New("invalid pkcs11 signature, the key does not match the public key")
+	}
+	return utils.MarshalECDSASignature(r, ss)
+}
+
+// keyID returns the CKA_ID of the key, by default the SKI of the public key as computed by Fabric
+func keyID(conf *config.PKCS11, pk *ecdsa.PublicKey) ([]byte, error) {
+	if len(conf.KeyID) != 0 {
+		id, err := hex.DecodeString(conf.KeyID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pkcs11 key id [%s]", conf.KeyID)
+		}
+		return id, nil
+	}
+	ski := sha256.Sum256(elliptic.Marshal(pk.Curve, pk.X, pk.Y))
+	return ski[:], nil
+}
+
+func findSlot(ctx *p11.Ctx, label string) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed listing pkcs11 slots")
+	}
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			continue
+		}
+		if strings.TrimSpace(info.Label) == label {
+			return slot, nil
+		}
+	}
+	return 0, errors.Errorf("pkcs11 token with label [%s] not found", label)
+}
+
+func findPrivateKey(ctx *p11.Ctx, session p11.SessionHandle, id []byte) (p11.ObjectHandle, error) {
+	template := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, p11.CKO_PRIVATE_KEY),
+		p11.NewAttribute(p11.CKA_ID, id),
+	}
+	if err := ctx.FindObjectsInit(session, template); err != nil {
+		return 0, errors.Wrap(err, "failed searching pkcs11 key")
+	}
+	defer ctx.FindObjectsFinal(session)
+
+	objects, _, err := ctx.FindObjects(session, 2)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed searching pkcs11 key")
+	}
+	switch len(objects) {
+	case 0:
+		return 0, errors.Errorf("pkcs11 key [%x] not found", id)
+	case 1:
+		return objects[0], nil
+	default:
+		return 0, errors.Errorf("more than one pkcs11 key with id [%x]", id)
+	}
+}
@@ -0,0 +1,107 @@
+//go:build pkcs11
+// +build pkcs11
+
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package pkcs11
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/bccsp/utils"
+	p11 "github.com/miekg/pkcs11"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/config"
+)
+
+// TestSigner runs against a token, such as SoftHSM, set by PKCS11_LIB, PKCS11_LABEL and PKCS11_PIN.
+// It generates a P-256 key pair in the token.
+func TestSigner(t *testing.T) {
+	conf := &config.PKCS11{
+		Library: os.Getenv("PKCS11_LIB"),
+		Label:   os.Getenv("PKCS11_LABEL"),
+		Pin:     os.Getenv("PKCS11_PIN"),
+	}
+	if len(conf.Library) == 0 || len(conf.Label) == 0 {
+		t.Skip("PKCS11_LIB and PKCS11_LABEL not set")
+	}
+
+	pk, keyID := generateKey(t, conf)
+	conf.KeyID = hex.EncodeToString(keyID)
+	signer, err := NewSigner(conf, pk)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		sigma, err := signer.Sign([]byte("hello world"))
+		assert.NoError(t, err)
+		r, s, err := utils.UnmarshalECDSASignature(sigma)
+		assert.NoError(t, err)
+		lowS, err := utils.IsLowS(pk, s)
+		assert.NoError(t, err)
+		assert.True(t, lowS)
+		digest := sha256.Sum256([]byte("hello world"))
+		assert.True(t, ecdsa.Verify(pk, digest[:], r, s))
+	}
+
+	// an unknown key is rejected
+	conf.KeyID = "00"
+	_, err = NewSigner(conf, pk)
+	assert.Error(t, err)
+}
+
+func generateKey(t *testing.T, conf *config.PKCS11) (*ecdsa.PublicKey, []byte) {
+	ctx := p11.New(conf.Library)
+	assert.NotNil(t, ctx)
+	err := ctx.Initialize()
+	if err != nil {
+		assert.Equal(t, p11.CKR_CRYPTOKI_ALREADY_INITIALIZED, uint(err.(p11.Error)))
+	}
+	slot, err := findSlot(ctx, conf.Label)
+	assert.NoError(t, err)
+	session, err := ctx.OpenSession(slot, p11.CKF_SERIAL_SESSION|p11.CKF_RW_SESSION)
+	assert.NoError(t, err)
+	defer ctx.CloseSession(session)
+	err = ctx.Login(session, p11.CKU_USER, conf.Pin)
+	if err != nil {
+		assert.Equal(t, p11.CKR_USER_ALREADY_LOGGED_IN, uint(err.(p11.Error)))
+	}
+
+	// the DER encoding of the P-256 curve oid
+	p256, err := hex.DecodeString("06082a8648ce3d030107")
+	assert.NoError(t, err)
+	keyID := []byte("token-sdk-test")
+	pub, _, err := ctx.GenerateKeyPair(
+		session,
+		[]*p11.Mechanism{p11.NewMechanism(p11.CKM_EC_KEY_PAIR_GEN, nil)},
+		[]*p11.Attribute{
+			p11.NewAttribute(p11.CKA_EC_PARAMS, p256),
+			p11.NewAttribute(p11.CKA_ID, keyID),
+			p11.NewAttribute(p11.CKA_TOKEN, false),
+			p11.NewAttribute(p11.CKA_VERIFY, true),
+		},
+		[]*p11.Attribute{
+			p11.NewAttribute(p11.CKA_ID, keyID),
+			p11.NewAttribute(p11.CKA_TOKEN, false),
+			p11.NewAttribute(p11.CKA_PRIVATE, true),
+			p11.NewAttribute(p11.CKA_SIGN, true),
+		},
+	)
+	assert.NoError(t, err)
+	attrs, err := ctx.GetAttributeValue(session, pub, []*p11.Attribute{p11.NewAttribute(p11.CKA_EC_POINT, nil)})
+	assert.NoError(t, err)
+
+	// the point is a DER octet string wrapping the uncompressed encoding
+	point := attrs[0].Value
+	point = point[len(point)-65:]
+	x, y := elliptic.Unmarshal(elliptic.P256(), point)
+	assert.NotNil(t, x)
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, keyID
+}
@@ -7,6 +7,8 @@ package identity
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 
 	idemix2 "github.com/hyperledger-labs/fabric-smart-client/platform/fabric/core/generic/msp/idemix"
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
@@ -16,6 +18,8 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/multisig"
 )
 
 var logger = flogging.MustGetLogger("token-sdk.driver.identity.fabric")
@@ -31,12 +35,21 @@ type Provider struct {
 	sp view2.ServiceProvider
 
 	mappers map[api.IdentityUsage]Mapper
+
+	walletsLock sync.RWMutex
+	// removed contains the ids of the owner wallets removed at runtime
+	removed map[string]bool
+
+	ownerWalletsLock sync.Mutex
+	// ownerWallets caches the owner wallets created by the driver
+	ownerWallets []api.OwnerWallet
 }
 
 func NewProvider(sp view2.ServiceProvider, mappers map[api.IdentityUsage]Mapper) *Provider {
 	return &Provider{
 		sp:      sp,
 		mappers: mappers,
+		removed: map[string]bool{},
 	}
 }
 
@@ -45,6 +58,10 @@ func (i *Provider) GetIdentityInfo(usage api.IdentityUsage, id string) *api.Iden
 	if !ok {
 		panic(fmt.Sprintf("mapper not found for usage [%d]", usage))
 	}
+	if usage == api.OwnerRole && i.IsRemoved(id) {
+		logger.Debugf("owner wallet [%s] has been removed", id)
+		return nil
+	}
 	id, eid, getIdentity := mapper.Info(id)
 	if getIdentity == nil {
 		return nil
@@ -125,6 +142,30 @@ func (i *Provider) RegisterAuditInfo(id view.Identity, auditInfo []byte) error {
 }
 
 func (i *Provider) GetEnrollmentID(auditInfo []byte) (string, error) {
+	if htlc.IsScriptInfo(auditInfo) {
+		// tokens locked by an htlc script are accounted to the recipient
+		si := &htlc.ScriptInfo{}
+		if err := si.Deserialize(auditInfo); err != nil {
+			return "", err
+		}
+		return i.GetEnrollmentID(si.Recipient)
+	}
+	if multisig.IsInfo(auditInfo) {
+		// tokens owned by a multisig identity are accounted to all the co-owners
+		info := &multisig.Info{}
+		if err := info.Deserialize(auditInfo); err != nil {
+			return "", err
+		}
+		var eIDs []string
+		for _, ai := range info.AuditInfos {
+			eID, err := i.GetEnrollmentID(ai)
+			if err != nil {
+				return "", err
+			}
+			eIDs = append(eIDs, eID)
+		}
+		return strings.Join(eIDs, ","), nil
+	}
 	ai := &idemix2.AuditInfo{}
 	if err := ai.FromBytes(auditInfo); err != nil {
 		return "", errors.Wrapf(err, "failed unamrshalling audit info [%s]", auditInfo)
@@ -0,0 +1,69 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package remote
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"net"
+	"sync"
+
+	"github.com/hyperledger/fabric/bccsp/utils"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// Server is a local stand-in for a remote signing service, it keeps the keys in memory
+type Server struct {
+	grpcServer *grpc.Server
+
+	lock sync.RWMutex
+	keys map[string]*ecdsa.PrivateKey
+}
+
+func NewServer() *Server {
+	s := &Server{
+		grpcServer: grpc.NewServer(ServerOption()),
+		keys:       map[string]*ecdsa.PrivateKey{},
+	}
+	RegisterSigningService(s.grpcServer, s)
+	return s
+}
+
+// AddKey makes the passed key available under the passed id
+func (s *Server) AddKey(keyID string, sk *ecdsa.PrivateKey) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.keys[keyID] = sk
+}
+
+// Serve accepts connections on the passed listener, it returns when Stop is called
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpcServer.Serve(lis)
+}
+
+func (s *Server) Stop() {
+	s.grpcServer.Stop()
+}
+
+func (s *Server) Sign(ctx context.Context, req *SignRequest) (*SignResponse, error) {
+	s.lock.RLock()
+	sk, ok := s.keys[req.KeyID]
+	s.lock.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("key [%s] not found", req.KeyID)
+	}
+	r, ss, err := ecdsa.Sign(rand.Reader, sk, req.Digest)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed signing")
+	}
+	sigma, err := utils.MarshalECDSASignature(r, ss)
+	if err != nil {
+		return nil, err
+	}
+	return &SignResponse{Signature: sigma}, nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package remote
+
+import (
+	"context"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+)
+
+const signMethod = "/tokensdk.remote.Signer/Sign"
+
+// SignRequest asks for an ECDSA signature of a SHA-256 digest with the key with the given id
+type SignRequest struct {
+	KeyID  string
+	Digest []byte
+}
+
+// SignResponse carries the DER-encoded ECDSA signature
+type SignResponse struct {
+	Signature []byte
+}
+
+// SigningService is implemented by the remote signing services
+type SigningService interface {
+	Sign(ctx context.Context, req *SignRequest) (*SignResponse, error)
+}
+
+// RegisterSigningService registers the passed signing service with the passed gRPC server.
+// The server must be created with ServerOption.
+func RegisterSigningService(s *grpc.Server, srv SigningService) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+// ServerOption returns the option the gRPC server of a signing service must be created with
+func ServerOption() grpc.ServerOption {
+	return grpc.CustomCodec(codec{})
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: "tokensdk.remote.Signer",
+	HandlerType: (*SigningService)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Sign",
+			Handler:    signHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+func signHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := &SignRequest{}
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SigningService).Sign(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: signMethod}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SigningService).Sign(ctx, req.(*SignRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// codec encodes the messages of the signing service in JSON
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (codec) Name() string {
+	return "json"
+}
+
+func (c codec) String() string {
+	return c.Name()
+}
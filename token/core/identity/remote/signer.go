@@ -0,0 +1,98 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package remote
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
+	"github.com/hyperledger/fabric/bccsp/utils"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/config"
+)
+
+var logger = flogging.MustGetLogger("token-sdk.driver.identity.remote")
+
+const defaultTimeout = 10 * time.Second
+
+// Signer signs with an ECDSA key held by a remote signing service
+type Signer struct {
+	conn    *grpc.ClientConn
+	keyID   string
+	pk      *ecdsa.PublicKey
+	timeout time.Duration
+}
+
+// NewSigner returns a Signer for the key, whose public part is the passed one, described by the passed configuration
+func NewSigner(conf *config.RemoteSigner, pk *ecdsa.PublicKey) (*Signer, error) {
+	if len(conf.Address) == 0 {
+		return nil, errors.New("no address set for the remote signer")
+	}
+	if len(conf.KeyID) == 0 {
+		return nil, errors.New("no key id set for the remote signer")
+	}
+
+	opts := []grpc.DialOption{grpc.WithDefaultCallOptions(grpc.ForceCodec(codec{}))}
+	switch {
+	case len(conf.TLSRootCert) != 0:
+		creds, err := credentials.NewClientTLSFromFile(conf.TLSRootCert, "")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed loading tls root certificate from [%s]", conf.TLSRootCert)
+		}
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	case conf.Insecure:
+		logger.Warnf("connecting to remote signer at [%s] in the clear", conf.Address)
+		opts = append(opts, grpc.WithInsecure())
+	default:
+		return nil, errors.Errorf("no tls root certificate set for the remote signer at [%s], set insecure to connect in the clear", conf.Address)
+	}
+	conn, err := grpc.Dial(conf.Address, opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed connecting to remote signer at [%s]", conf.Address)
+	}
+
+	timeout := conf.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Signer{conn: conn, keyID: conf.KeyID, pk: pk, timeout: timeout}, nil
+}
+
+// Sign returns the low-S ECDSA signature of the SHA-256 digest of the passed message
+func (s *Signer) Sign(message []byte) ([]byte, error) {
+	digest := sha256.Sum256(message)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	resp := &SignResponse{}
+	if err := s.conn.Invoke(ctx, signMethod, &SignRequest{KeyID: s.keyID, Digest: digest[:]}, resp); err != nil {
+		return nil, errors.Wrapf(err, "remote signer failed signing with key [%s]", s.keyID)
+	}
+
+	r, ss, err := utils.UnmarshalECDSASignature(resp.Signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid signature from remote signer")
+	}
+	ss, err = utils.ToLowS(s.pk, ss)
+	if err != nil {
+		return nil, err
+	}
+	if !ecdsa.Verify(s.pk, digest[:], r, ss) {
+		return nil, errors.Errorf("invalid signature from remote signer, key [%s] does not match the public key", s.keyID)
+	}
+	return utils.MarshalECDSASignature(r, ss)
+}
+
+// Close closes the connection to the remote signing service
+func (s *Signer) Close() error {
+	return s.conn.Close()
+}
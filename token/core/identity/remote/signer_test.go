@@ -0,0 +1,76 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package remote
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"net"
+	"testing"
+
+	"github.com/hyperledger/fabric/bccsp/utils"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/config"
+)
+
+func TestSigner(t *testing.T) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	server := NewServer()
+	server.AddKey("alice", sk)
+	server.AddKey("bob", other)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	signer, err := NewSigner(&config.RemoteSigner{Address: lis.Addr().String(), KeyID: "alice", Insecure: true}, &sk.PublicKey)
+	assert.NoError(t, err)
+	defer signer.Close()
+	for i := 0; i < 10; i++ {
+		sigma, err := signer.Sign([]byte("hello world"))
+		assert.NoError(t, err)
+		r, s, err := utils.UnmarshalECDSASignature(sigma)
+		assert.NoError(t, err)
+		lowS, err := utils.IsLowS(&sk.PublicKey, s)
+		assert.NoError(t, err)
+		assert.True(t, lowS)
+		digest := sha256.Sum256([]byte("hello world"))
+		assert.True(t, ecdsa.Verify(&sk.PublicKey, digest[:], r, s))
+	}
+
+	// a key that does not match the public key is rejected
+	wrong, err := NewSigner(&config.RemoteSigner{Address: lis.Addr().String(), KeyID: "bob", Insecure: true}, &sk.PublicKey)
+	assert.NoError(t, err)
+	defer wrong.Close()
+	_, err = wrong.Sign([]byte("hello world"))
+	assert.Error(t, err)
+
+	// and so is an unknown key
+	unknown, err := NewSigner(&config.RemoteSigner{Address: lis.Addr().String(), KeyID: "charlie", Insecure: true}, &sk.PublicKey)
+	assert.NoError(t, err)
+	defer unknown.Close()
+	_, err = unknown.Sign([]byte("hello world"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "key [charlie] not found")
+}
+
+func TestNewSignerInvalidConfig(t *testing.T) {
+	_, err := NewSigner(&config.RemoteSigner{KeyID: "alice"}, nil)
+	assert.Error(t, err)
+	_, err = NewSigner(&config.RemoteSigner{Address: "127.0.0.1:7050"}, nil)
+	assert.Error(t, err)
+	// a connection in the clear must be asked for explicitly
+	_, err = NewSigner(&config.RemoteSigner{Address: "127.0.0.1:7050", KeyID: "alice"}, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no tls root certificate set")
+}
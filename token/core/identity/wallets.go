@@ -0,0 +1,247 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package identity
+
+import (
+	"sort"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/kvs"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/api"
+)
+
+const ownerWalletsPrefix = "token-sdk.identity.owner.wallets"
+
+// Registry is implemented by the mappers that can load new long-term identities at runtime
+type Registry interface {
+	Register(id string, mspID string, path string) error
+}
+
+// walletEntry records an owner wallet registered, or removed, at runtime
+type walletEntry struct {
+	ID      string
+	MSPID   string
+	Path    string
+	Removed bool
+}
+
+// LoadOwnerWallets registers again the owner wallets registered at runtime before a restart,
+// and hides those removed
+func (i *Provider) LoadOwnerWallets() error {
+	entries, err := i.walletEntries()
+	if err != nil {
+		return err
+	}
+
+	i.walletsLock.Lock()
+	defer i.walletsLock.Unlock()
+	for _, entry := range entries {
+		if entry.Removed {
+			i.removed[entry.ID] = true
+			continue
+		}
+		if err := i.register(entry.ID, entry.MSPID, entry.Path); err != nil {
+			return errors.WithMessagef(err, "failed loading owner wallet [%s]", entry.ID)
+		}
+	}
+	return nil
+}
+
+// RegisterOwnerWallet registers, under the passed id, an owner wallet whose long-term identity is stored
+// in the MSP folder at the passed path
+func (i *Provider) RegisterOwnerWallet(id string, mspID string, path string) error {
+	if len(id) == 0 {
+		return errors.New("empty wallet id")
+	}
+	i.walletsLock.Lock()
+	defer i.walletsLock.Unlock()
+
+	if err := i.register(id, mspID, path); err != nil {
+		return err
+	}
+	if err := kvs.GetService(i.sp).Put(walletKey(id), &walletEntry{ID: id, MSPID: mspID, Path: path}); err != nil {
+		return errors.Wrapf(err, "failed storing owner wallet [%s]", id)
+	}
+	delete(i.removed, id)
+	logger.Debugf("registered owner wallet [%s] from [%s]", id, path)
+	return nil
+}
+
+// OwnerWallet returns the owner wallet matching the passed identity or wallet id, nil if not found.
+// The wallet is created with the passed function the first time, and then cached.
+func (i *Provider) OwnerWallet(id interface{}, newWallet api.NewOwnerWalletFunc) api.OwnerWallet {
+	i.ownerWalletsLock.Lock()
+	defer i.ownerWalletsLock.Unlock()
+
+	// check if there is already a wallet
+	identity, walletID := i.LookupIdentifier(api.OwnerRole, id)
+	for _, w := range i.ownerWallets {
+		if w.Contains(identity) || w.ID() == walletID {
+			logger.Debugf("found owner wallet [%s:%s]", identity, walletID)
+			return w
+		}
+	}
+
+	// Create the wallet
+	idInfo := i.GetIdentityInfo(api.OwnerRole, walletID)
+	if idInfo == nil {
+		logger.Debugf("no owner wallet found for [%s:%s]", identity, walletID)
+		return nil
+	}
+	w, err := newWallet(idInfo)
+	if err != nil {
+		logger.Errorf("failed creating owner wallet [%s]: [%s]", idInfo.ID, err)
+		return nil
+	}
+	i.ownerWallets = append(i.ownerWallets, w)
+	logger.Debugf("created owner wallet [%s:%s]", identity, walletID)
+	return w
+}
+
+// RemoveOwnerWallet makes the owner wallet with the passed id no longer available.
+// The key material of the wallet is not deleted.
+func (i *Provider) RemoveOwnerWallet(id string) error {
+	if err := i.markRemoved(id); err != nil {
+		return err
+	}
+
+	i.ownerWalletsLock.Lock()
+	defer i.ownerWalletsLock.Unlock()
+	for j, w := range i.ownerWallets {
+		if w.ID() == id {
+			i.ownerWallets = append(i.ownerWallets[:j], i.ownerWallets[j+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// markRemoved records that the owner wallet with the passed id has been removed
+func (i *Provider) markRemoved(id string) error {
+	i.walletsLock.Lock()
+	defer i.walletsLock.Unlock()
+
+	entry := &walletEntry{ID: id}
+	kvss := kvs.GetService(i.sp)
+	if kvss.Exists(walletKey(id)) {
+		if err := kvss.Get(walletKey(id), entry); err != nil {
+			return errors.Wrapf(err, "failed loading owner wallet [%s]", id)
+		}
+	}
+	entry.Removed = true
+	if err := kvss.Put(walletKey(id), entry); err != nil {
+		return errors.Wrapf(err, "failed storing owner wallet [%s]", id)
+	}
+	i.removed[id] = true
+	logger.Debugf("removed owner wallet [%s]", id)
+	return nil
+}
+
+// OwnerWalletIDs returns the ids of the owner wallets in use or registered at runtime, and not removed, sorted
+func (i *Provider) OwnerWalletIDs() ([]string, error) {
+	entries, err := i.walletEntries()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, entry := range entries {
+		if !entry.Removed {
+			ids = append(ids, entry.ID)
+		}
+	}
+
+	i.ownerWalletsLock.Lock()
+	for _, w := range i.ownerWallets {
+		ids = append(ids, w.ID())
+	}
+	i.ownerWalletsLock.Unlock()
+	return sortedUnique(ids), nil
+}
+
+func sortedUnique(ids []string) []string {
+	sort.Strings(ids)
+	var res []string
+	for i, id := range ids {
+		if i == 0 || id != ids[i-1] {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// IsRemoved returns true if the owner wallet with the passed id has been removed
+func (i *Provider) IsRemoved(id string) bool {
+	i.walletsLock.RLock()
+	defer i.walletsLock.RUnlock()
+	return i.removed[id]
+}
+
+// ExportOwnerWallet returns the key material of the passed owner wallet, registered at runtime,
+// encrypted under the passed passphrase. The wallets of the node configuration cannot be exported.
+func (i *Provider) ExportOwnerWallet(id string, passphrase []byte) ([]byte, error) {
+	entry := &walletEntry{}
+	kvss := kvs.GetService(i.sp)
+	if !kvss.Exists(walletKey(id)) {
+		return nil, errors.Errorf("owner wallet [%s] has not been registered at runtime, only those can be exported", id)
+	}
+	if err := kvss.Get(walletKey(id), entry); err != nil {
+		return nil, errors.Wrapf(err, "failed loading owner wallet [%s]", id)
+	}
+	if entry.Removed {
+		return nil, errors.Errorf("owner wallet [%s] has been removed", id)
+	}
+	files, err := readFolder(entry.Path)
+	if err != nil {
+		return nil, err
+	}
+	return sealKeyFile(&keyFile{ID: entry.ID, MSPID: entry.MSPID, Files: files}, passphrase)
+}
+
+// ImportOwnerWallet decrypts, with the passed passphrase, an owner wallet exported by ExportOwnerWallet,
+// writes its key material in the folder at the passed path, and registers it. It returns the id of the wallet.
+func (i *Provider) ImportOwnerWallet(raw []byte, passphrase []byte, path string) (string, error) {
+	kf, err := openKeyFile(raw, passphrase)
+	if err != nil {
+		return "", err
+	}
+	if err := writeFolder(path, kf.Files); err != nil {
+		return "", err
+	}
+	if err := i.RegisterOwnerWallet(kf.ID, kf.MSPID, path); err != nil {
+		return "", err
+	}
+	return kf.ID, nil
+}
+
+func (i *Provider) register(id string, mspID string, path string) error {
+	registry, ok := i.mappers[api.OwnerRole].(Registry)
+	if !ok {
+		return errors.New("the owner identities do not support registration at runtime")
+	}
+	return registry.Register(id, mspID, path)
+}
+
+func (i *Provider) walletEntries() ([]*walletEntry, error) {
+	it, err := kvs.GetService(i.sp).GetByPartialCompositeID(ownerWalletsPrefix, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed iterating over owner wallets")
+	}
+	defer it.Close()
+	var entries []*walletEntry
+	for it.HasNext() {
+		entry := &walletEntry{}
+		if err := it.Next(entry); err != nil {
+			return nil, errors.Wrap(err, "failed loading owner wallet")
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func walletKey(id string) string {
+	return kvs.CreateCompositeKeyOrPanic(ownerWalletsPrefix, []string{id})
+}
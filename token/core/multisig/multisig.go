@@ -0,0 +1,260 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package multisig
+
+import (
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
+)
+
+const (
+	identityTag  = "multisig"
+	infoTag      = "multisig.info"
+	signatureTag = "multisig.sig"
+)
+
+// Identity is an M-of-N multisig owner: a token it owns can be spent with the signatures
+// of at least Threshold of the co-owners listed in Identities.
+type Identity struct {
+	Threshold  uint64
+	Identities []view.Identity
+}
+
+// IsMultisig returns true if the passed owner encodes a multisig Identity
+func IsMultisig(raw []byte) bool {
+	return hasTag(raw, identityTag)
+}
+
+func (id *Identity) Serialize() ([]byte, error) {
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteString(identityTag)
+	w.WriteUvarint(id.Threshold)
+	ids := make([][]byte, len(id.Identities))
+	for i, identity := range id.Identities {
+		ids[i] = identity
+	}
+	w.WriteBytesArray(ids)
+	return w.Bytes(), nil
+}
+
+func (id *Identity) Deserialize(raw []byte) error {
+	r, err := readerWithTag(raw, identityTag)
+	if err != nil {
+		return err
+	}
+	id.Threshold = r.ReadUvarint()
+	ids := r.ReadBytesArray()
+	if err := r.Close(); err != nil {
+		return errors.Wrap(err, "failed deserializing multisig identity")
+	}
+	id.Identities = make([]view.Identity, len(ids))
+	for i, identity := range ids {
+		id.Identities[i] = identity
+	}
+	return nil
+}
+
+// MaxDepth is the maximum nesting level of multisig identities
+const MaxDepth = 2
+
+// Validate checks that the threshold can be met and that the co-owners are distinct.
+// Co-owners can be multisig identities themselves, up to MaxDepth levels.
+func (id *Identity) Validate() error {
+	return id.validate(1)
+}
+
+func (id *Identity) validate(depth int) error {
+	if depth > MaxDepth {
+		return errors.Errorf("multisig identities cannot be nested more than [%d] levels", MaxDepth)
+	}
+	if id.Threshold == 0 || id.Threshold > uint64(len(id.Identities)) {
+		return errors.Errorf("invalid threshold [%d] for [%d] co-owners", id.Threshold, len(id.Identities))
+	}
+	for i, identity := range id.Identities {
+		if identity.IsNone() {
+			return errors.Errorf("co-owner [%d] not set", i)
+		}
+		for j := 0; j < i; j++ {
+			if identity.Equal(id.Identities[j]) {
+				return errors.Errorf("co-owners [%d] and [%d] are the same", j, i)
+			}
+		}
+		if IsMultisig(identity) {
+			nested := &Identity{}
+			if err := nested.Deserialize(identity); err != nil {
+				return errors.WithMessagef(err, "invalid co-owner [%d]", i)
+			}
+			if err := nested.validate(depth + 1); err != nil {
+				return errors.WithMessagef(err, "invalid co-owner [%d]", i)
+			}
+		}
+	}
+	return nil
+}
+
+// VerifyOwners checks that all the passed owners that are multisig identities are valid
+func VerifyOwners(owners [][]byte) error {
+	for i, owner := range owners {
+		if !IsMultisig(owner) {
+			continue
+		}
+		id := &Identity{}
+		if err := id.Deserialize(owner); err != nil {
+			return errors.WithMessagef(err, "failed to deserialize multisig identity of output [%d]", i)
+		}
+		if err := id.Validate(); err != nil {
+			return errors.WithMessagef(err, "invalid multisig identity at output [%d]", i)
+		}
+	}
+	return nil
+}
+
+// Info carries the audit info of the co-owners of a multisig Identity, in the same order
+type Info struct {
+	AuditInfos [][]byte
+}
+
+// IsInfo returns true if the passed audit info encodes an Info
+func IsInfo(raw []byte) bool {
+	return hasTag(raw, infoTag)
+}
+
+func (info *Info) Serialize() ([]byte, error) {
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteString(infoTag)
+	w.WriteBytesArray(info.AuditInfos)
+	return w.Bytes(), nil
+}
+
+func (info *Info) Deserialize(raw []byte) error {
+	r, err := readerWithTag(raw, infoTag)
+	if err != nil {
+		return err
+	}
+	info.AuditInfos = r.ReadBytesArray()
+	if err := r.Close(); err != nil {
+		return errors.Wrap(err, "failed deserializing multisig info")
+	}
+	return nil
+}
+
+// Signature carries, for each co-owner, its signature or nothing if the co-owner did not sign
+type Signature struct {
+	Signatures [][]byte
+}
+
+func (s *Signature) Serialize() ([]byte, error) {
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteString(signatureTag)
+	w.WriteBytesArray(s.Signatures)
+	return w.Bytes(), nil
+}
+
+func (s *Signature) Deserialize(raw []byte) error {
+	r, err := readerWithTag(raw, signatureTag)
+	if err != nil {
+		return err
+	}
+	s.Signatures = r.ReadBytesArray()
+	if err := r.Close(); err != nil {
+		return errors.Wrap(err, "failed deserializing multisig signature")
+	}
+	return nil
+}
+
+// Signer signs on behalf of a multisig Identity with the co-owners whose signer is available.
+// Signers has an entry for each co-owner, nil if the co-owner cannot sign locally.
+type Signer struct {
+	Signers []api2.Signer
+}
+
+func (s *Signer) Sign(message []byte) ([]byte, error) {
+	sig := &Signature{Signatures: make([][]byte, len(s.Signers))}
+	for i, signer := range s.Signers {
+		if signer == nil {
+			continue
+		}
+		sigma, err := signer.Sign(message)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "co-owner [%d] failed signing", i)
+		}
+		sig.Signatures[i] = sigma
+	}
+	return sig.Serialize()
+}
+
+// Verifier checks that a signature carries valid signatures of at least threshold co-owners.
+// The verifiers of co-owners that are multisig identities are built recursively.
+type Verifier struct {
+	Identity    *Identity
+	GetVerifier func(id view.Identity) (api2.Verifier, error)
+}
+
+// GetVerifier returns the verifier for the passed owner.
+// If the owner is a multisig identity, the verifiers of the co-owners are obtained with the passed function.
+func GetVerifier(owner view.Identity, getVerifier func(id view.Identity) (api2.Verifier, error)) (api2.Verifier, error) {
+	if !IsMultisig(owner) {
+		return getVerifier(owner)
+	}
+	id := &Identity{}
+	if err := id.Deserialize(owner); err != nil {
+		return nil, err
+	}
+	return &Verifier{Identity: id, GetVerifier: getVerifier}, nil
+}
+
+func (v *Verifier) Verify(message, sigma []byte) error {
+	sig := &Signature{}
+	if err := sig.Deserialize(sigma); err != nil {
+		return err
+	}
+	if len(sig.Signatures) != len(v.Identity.Identities) {
+		return errors.Errorf("expected [%d] signatures, got [%d]", len(v.Identity.Identities), len(sig.Signatures))
+	}
+	var valid uint64
+	for i, s := range sig.Signatures {
+		if len(s) == 0 {
+			continue
+		}
+		verifier, err := GetVerifier(v.Identity.Identities[i], v.GetVerifier)
+		if err != nil {
+			return errors.Wrapf(err, "failed getting verifier for co-owner [%d]", i)
+		}
+		if err := verifier.Verify(message, s); err != nil {
+			return errors.Wrapf(err, "invalid signature of co-owner [%d]", i)
+		}
+		valid++
+	}
+	if valid < v.Identity.Threshold {
+		return errors.Errorf("not enough signatures, expected at least [%d], got [%d]", v.Identity.Threshold, valid)
+	}
+	return nil
+}
+
+func hasTag(raw []byte, tag string) bool {
+	if !encoding.IsBinary(raw) {
+		return false
+	}
+	r, err := encoding.NewReader(raw)
+	if err != nil {
+		return false
+	}
+	return r.ReadString() == tag && r.Err() == nil
+}
+
+func readerWithTag(raw []byte, tag string) (*encoding.Reader, error) {
+	r, err := encoding.NewReader(raw)
+	if err != nil {
+		return nil, err
+	}
+	if t := r.ReadString(); t != tag {
+		return nil, errors.Errorf("invalid encoding, expected tag [%s], got [%s]", tag, t)
+	}
+	return r, nil
+}
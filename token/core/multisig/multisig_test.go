@@ -0,0 +1,199 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package multisig
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+type prefixSigner struct {
+	id []byte
+}
+
+func (s *prefixSigner) Sign(message []byte) ([]byte, error) {
+	return append(append([]byte{}, s.id...), message...), nil
+}
+
+func (s *prefixSigner) Verify(message, sigma []byte) error {
+	if !bytes.Equal(sigma, append(append([]byte{}, s.id...), message...)) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func TestIdentity(t *testing.T) {
+	id := &Identity{
+		Threshold:  2,
+		Identities: []view.Identity{view.Identity("alice"), view.Identity("bob"), view.Identity("charlie")},
+	}
+	raw, err := id.Serialize()
+	assert.NoError(t, err)
+	assert.True(t, IsMultisig(raw))
+	assert.False(t, IsMultisig([]byte("alice")))
+	assert.False(t, IsInfo(raw))
+
+	id2 := &Identity{}
+	assert.NoError(t, id2.Deserialize(raw))
+	assert.Equal(t, id, id2)
+	assert.NoError(t, id2.Validate())
+	assert.NoError(t, VerifyOwners([][]byte{raw, []byte("alice"), nil}))
+
+	assert.Error(t, (&Identity{Threshold: 0, Identities: id.Identities}).Validate())
+	assert.Error(t, (&Identity{Threshold: 4, Identities: id.Identities}).Validate())
+	assert.Error(t, (&Identity{Threshold: 1, Identities: []view.Identity{view.Identity("alice"), view.Identity("alice")}}).Validate())
+	assert.NoError(t, (&Identity{Threshold: 1, Identities: []view.Identity{raw}}).Validate())
+	nested, err := (&Identity{Threshold: 1, Identities: []view.Identity{raw}}).Serialize()
+	assert.NoError(t, err)
+	assert.Error(t, (&Identity{Threshold: 1, Identities: []view.Identity{nested}}).Validate())
+	invalid, err := (&Identity{Threshold: 3, Identities: []view.Identity{view.Identity("alice")}}).Serialize()
+	assert.NoError(t, err)
+	assert.Error(t, VerifyOwners([][]byte{invalid}))
+
+	info := &Info{AuditInfos: [][]byte{[]byte("a"), []byte("b"), []byte("c")}}
+	raw, err = info.Serialize()
+	assert.NoError(t, err)
+	assert.True(t, IsInfo(raw))
+	info2 := &Info{}
+	assert.NoError(t, info2.Deserialize(raw))
+	assert.Equal(t, info, info2)
+}
+
+func TestVerifier(t *testing.T) {
+	id := &Identity{
+		Threshold:  2,
+		Identities: []view.Identity{view.Identity("alice"), view.Identity("bob"), view.Identity("charlie")},
+	}
+	owner, err := id.Serialize()
+	assert.NoError(t, err)
+	verifier, err := GetVerifier(owner, func(id view.Identity) (api2.Verifier, error) {
+		return &prefixSigner{id: id}, nil
+	})
+	assert.NoError(t, err)
+	msg := []byte("msg")
+
+	two, err := (&Signer{Signers: []api2.Signer{&prefixSigner{id: id.Identities[0]}, nil, &prefixSigner{id: id.Identities[2]}}}).Sign(msg)
+	assert.NoError(t, err)
+	assert.NoError(t, verifier.Verify(msg, two))
+
+	one, err := (&Signer{Signers: []api2.Signer{nil, &prefixSigner{id: id.Identities[1]}, nil}}).Sign(msg)
+	assert.NoError(t, err)
+	assert.Error(t, verifier.Verify(msg, one))
+
+	wrong, err := (&Signer{Signers: []api2.Signer{&prefixSigner{id: id.Identities[1]}, &prefixSigner{id: id.Identities[1]}, nil}}).Sign(msg)
+	assert.NoError(t, err)
+	assert.Error(t, verifier.Verify(msg, wrong))
+
+	short, err := (&Signer{Signers: []api2.Signer{&prefixSigner{id: id.Identities[0]}, &prefixSigner{id: id.Identities[1]}}}).Sign(msg)
+	assert.NoError(t, err)
+	assert.Error(t, verifier.Verify(msg, short))
+}
+
+func TestNestedVerifier(t *testing.T) {
+	// either both alice and bob, or charlie alone
+	inner := &Identity{Threshold: 2, Identities: []view.Identity{view.Identity("alice"), view.Identity("bob")}}
+	innerRaw, err := inner.Serialize()
+	assert.NoError(t, err)
+	outer := &Identity{Threshold: 1, Identities: []view.Identity{innerRaw, view.Identity("charlie")}}
+	assert.NoError(t, outer.Validate())
+	owner, err := outer.Serialize()
+	assert.NoError(t, err)
+	verifier, err := GetVerifier(owner, func(id view.Identity) (api2.Verifier, error) {
+		return &prefixSigner{id: id}, nil
+	})
+	assert.NoError(t, err)
+	msg := []byte("msg")
+
+	both := &Signer{Signers: []api2.Signer{
+		&Signer{Signers: []api2.Signer{&prefixSigner{id: inner.Identities[0]}, &prefixSigner{id: inner.Identities[1]}}},
+		nil,
+	}}
+	sigma, err := both.Sign(msg)
+	assert.NoError(t, err)
+	assert.NoError(t, verifier.Verify(msg, sigma))
+
+	arbiter := &Signer{Signers: []api2.Signer{nil, &prefixSigner{id: outer.Identities[1]}}}
+	sigma, err = arbiter.Sign(msg)
+	assert.NoError(t, err)
+	assert.NoError(t, verifier.Verify(msg, sigma))
+
+	one := &Signer{Signers: []api2.Signer{
+		&Signer{Signers: []api2.Signer{&prefixSigner{id: inner.Identities[0]}, nil}},
+		nil,
+	}}
+	sigma, err = one.Sign(msg)
+	assert.NoError(t, err)
+	assert.Error(t, verifier.Verify(msg, sigma))
+}
+
+func TestDepositDispute(t *testing.T) {
+	parties := []view.Identity{view.Identity("alice"), view.Identity("bob")}
+	owner, err := (&Identity{Threshold: 2, Identities: parties}).Serialize()
+	assert.NoError(t, err)
+	input, err := keys.CreateTokenKey("channel", 0)
+	assert.NoError(t, err)
+	key, err := keys.CreateDisputeKey("channel")
+	assert.NoError(t, err)
+	dispute := &api2.Dispute{
+		Deposit: &token2.Id{TxId: "channel", Index: 0},
+		State:   &api2.State{ChannelID: "channel", Parties: parties, Balances: []map[string]uint64{{"ABC": 70}, {"ABC": 30}}},
+	}
+	state := map[string][]byte{}
+	getState := func(key string) ([]byte, error) {
+		return state[key], nil
+	}
+
+	// no dispute
+	d, err := DepositDispute(getState, []string{input}, input, owner)
+	assert.NoError(t, err)
+	assert.Nil(t, d)
+
+	// open dispute
+	state[key], err = json.Marshal(dispute)
+	assert.NoError(t, err)
+	_, err = DepositDispute(getState, []string{input}, input, owner)
+	assert.EqualError(t, err, "input ["+input+"] is the deposit of channel [channel], whose dispute is open")
+	d, err = DepositDispute(getState, []string{input}, input, view.Identity("alice"))
+	assert.NoError(t, err)
+	assert.Nil(t, d)
+
+	// closed dispute
+	dispute.Closed = true
+	state[key], err = json.Marshal(dispute)
+	assert.NoError(t, err)
+	d, err = DepositDispute(getState, []string{input}, input, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, dispute, d)
+	_, err = DepositDispute(getState, []string{input, input}, input, owner)
+	assert.EqualError(t, err, "the settlement of channel [channel] must spend its deposit only")
+	other, err := keys.CreateTokenKey("channel", 1)
+	assert.NoError(t, err)
+	d, err = DepositDispute(getState, []string{other}, other, owner)
+	assert.NoError(t, err)
+	assert.Nil(t, d)
+
+	// either party can sign the payout
+	verifier, err := SettlementVerifier(owner, func(id view.Identity) (api2.Verifier, error) {
+		return &prefixSigner{id: id}, nil
+	})
+	assert.NoError(t, err)
+	msg := []byte("msg")
+	sigma, err := (&Signer{Signers: []api2.Signer{nil, &prefixSigner{id: parties[1]}}}).Sign(msg)
+	assert.NoError(t, err)
+	assert.NoError(t, verifier.Verify(msg, sigma))
+	sigma, err = (&Signer{Signers: []api2.Signer{nil, nil}}).Sign(msg)
+	assert.NoError(t, err)
+	assert.Error(t, verifier.Verify(msg, sigma))
+}
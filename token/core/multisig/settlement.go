@@ -0,0 +1,65 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package multisig
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+)
+
+// DepositDispute returns the closed dispute on the channel whose deposit is the passed input, owned by the passed
+// identity, and nil if the input is not a disputed deposit. The deposit of a channel whose dispute is still open
+// cannot be spent, and the deposit of a closed dispute must be spent alone.
+// The id of a channel is the id of the transaction that created its deposit.
+func DepositDispute(getState api2.GetStateFnc, inputs []string, input string, owner view.Identity) (*api2.Dispute, error) {
+	if !IsMultisig(owner) {
+		return nil, nil
+	}
+	id, err := keys.GetTokenIdFromKey(input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed parsing input [%s]", input)
+	}
+	key, err := keys.CreateDisputeKey(id.TxId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed creating dispute key for [%s]", id.TxId)
+	}
+	raw, err := getState(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting dispute on [%s]", id.TxId)
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	dispute := &api2.Dispute{}
+	if err := json.Unmarshal(raw, dispute); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling dispute on [%s]", id.TxId)
+	}
+	if dispute.Deposit == nil || dispute.State == nil || dispute.Deposit.TxId != id.TxId || dispute.Deposit.Index != id.Index {
+		return nil, nil
+	}
+	if !dispute.Closed {
+		return nil, errors.Errorf("input [%s] is the deposit of channel [%s], whose dispute is open", input, id.TxId)
+	}
+	if len(inputs) != 1 {
+		return nil, errors.Errorf("the settlement of channel [%s] must spend its deposit only", id.TxId)
+	}
+	return dispute, nil
+}
+
+// SettlementVerifier returns the verifier for the payout of a closed dispute on a deposit owned by the passed multisig
+// identity. The balances are fixed by the dispute, therefore the signature of any one of the co-owners is enough.
+func SettlementVerifier(owner view.Identity, getVerifier func(id view.Identity) (api2.Verifier, error)) (api2.Verifier, error) {
+	id := &Identity{}
+	if err := id.Deserialize(owner); err != nil {
+		return nil, err
+	}
+	return &Verifier{Identity: &Identity{Threshold: 1, Identities: id.Identities}, GetVerifier: getVerifier}, nil
+}
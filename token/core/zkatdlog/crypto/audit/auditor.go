@@ -19,7 +19,9 @@ import (
 
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
 
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/htlc"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/multisig"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
 	issue2 "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
@@ -42,19 +44,16 @@ type AuditableToken struct {
 }
 
 func NewAuditableToken(token *token.Token, ownerInfo []byte, ttype string, value *bn256.Zr, bf *bn256.Zr) (*AuditableToken, error) {
-	auditInfo := &idemix.AuditInfo{}
+	owner := &ownerOpening{ownerInfo: &idemix.AuditInfo{}}
 	if !token.IsRedeem() {
 		// this is not a redeem
-		err := json.Unmarshal(ownerInfo, auditInfo)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed unmarshalling audit info")
+		if err := owner.Deserialize(ownerInfo); err != nil {
+			return nil, err
 		}
 	}
 	return &AuditableToken{
 		Token: token,
-		owner: &ownerOpening{
-			ownerInfo: auditInfo,
-		},
+		owner: owner,
 		data: &tokenDataOpening{
 			ttype: ttype,
 			value: value,
@@ -71,6 +70,81 @@ type tokenDataOpening struct {
 
 type ownerOpening struct {
 	ownerInfo *idemix.AuditInfo
+	// sender and recipient are set when the owner is an htlc script
+	sender    *ownerOpening
+	recipient *ownerOpening
+	// coOwners are set when the owner is a multisig identity
+	coOwners []*ownerOpening
+}
+
+func (o *ownerOpening) Deserialize(raw []byte) error {
+	switch {
+	case htlc.IsScriptInfo(raw):
+		si := &htlc.ScriptInfo{}
+		if err := si.Deserialize(raw); err != nil {
+			return err
+		}
+		o.sender = &ownerOpening{ownerInfo: &idemix.AuditInfo{}}
+		if err := o.sender.Deserialize(si.Sender); err != nil {
+			return errors.WithMessage(err, "invalid audit info of the htlc sender")
+		}
+		o.recipient = &ownerOpening{ownerInfo: &idemix.AuditInfo{}}
+		if err := o.recipient.Deserialize(si.Recipient); err != nil {
+			return errors.WithMessage(err, "invalid audit info of the htlc recipient")
+		}
+	case multisig.IsInfo(raw):
+		info := &multisig.Info{}
+		if err := info.Deserialize(raw); err != nil {
+			return err
+		}
+		for i, auditInfo := range info.AuditInfos {
+			coOwner := &ownerOpening{ownerInfo: &idemix.AuditInfo{}}
+			if err := coOwner.Deserialize(auditInfo); err != nil {
+				return errors.WithMessagef(err, "invalid audit info of co-owner [%d]", i)
+			}
+			o.coOwners = append(o.coOwners, coOwner)
+		}
+	default:
+		if err := json.Unmarshal(raw, o.ownerInfo); err != nil {
+			return errors.Wrap(err, "failed unmarshalling audit info")
+		}
+	}
+	return nil
+}
+
+// Match checks that the opening matches the passed owner.
+// For htlc scripts and multisig identities, all the involved parties must match.
+func (o *ownerOpening) Match(owner []byte) error {
+	switch {
+	case o.sender != nil:
+		script := &htlc.Script{}
+		if err := script.Deserialize(owner); err != nil {
+			return errors.WithMessage(err, "expected an htlc script")
+		}
+		if err := o.sender.Match(script.Sender); err != nil {
+			return errors.WithMessage(err, "htlc sender does not match")
+		}
+		if err := o.recipient.Match(script.Recipient); err != nil {
+			return errors.WithMessage(err, "htlc recipient does not match")
+		}
+		return nil
+	case o.coOwners != nil:
+		id := &multisig.Identity{}
+		if err := id.Deserialize(owner); err != nil {
+			return errors.WithMessage(err, "expected a multisig identity")
+		}
+		if len(id.Identities) != len(o.coOwners) {
+			return errors.Errorf("expected [%d] co-owners, got [%d]", len(o.coOwners), len(id.Identities))
+		}
+		for i, coOwner := range o.coOwners {
+			if err := coOwner.Match(id.Identities[i]); err != nil {
+				return errors.WithMessagef(err, "co-owner [%d] does not match", i)
+			}
+		}
+		return nil
+	default:
+		return o.ownerInfo.Match(owner)
+	}
 }
 
 type Auditor struct {
@@ -89,7 +163,7 @@ func NewAuditor(pp []*bn256.G1, nymparams []byte, signer SigningIdentity) *Audit
 
 func (a *Auditor) Endorse(tokenRequest *api.TokenRequest, txID string) ([]byte, error) {
 	// Prepare signature
-	bytes, err := json.Marshal(&api.TokenRequest{Issues: tokenRequest.Issues, Transfers: tokenRequest.Transfers})
+	bytes, err := tokenRequest.MessageToSign()
 	if err != nil {
 		return nil, errors.Errorf("audit of tx [%s] failed: error marshal token request for signature", txID)
 	}
@@ -156,7 +230,7 @@ func (a *Auditor) inspectOutputs(tokens []*AuditableToken) error {
 			return errors.Wrapf(err, "failed inspecting output [%d]", i)
 		}
 		if !t.Token.IsRedeem() { // this is not a redeemed output
-			err = t.owner.ownerInfo.Match(t.Token.Owner)
+			err = t.owner.Match(t.Token.Owner)
 			if err != nil {
 				return errors.Wrapf(err, "output at index [%d] does not match the provided opening", i)
 			}
@@ -188,7 +262,7 @@ func (a *Auditor) inspectInputs(inputs []*AuditableToken) error {
 
 		if !input.Token.IsRedeem() {
 			// this is not a redeem
-			err := input.owner.ownerInfo.Match(input.Token.Owner)
+			err := input.owner.Match(input.Token.Owner)
 			if err != nil {
 				return errors.Errorf("input at index [%d] does not match the provided opening", i)
 			}
@@ -204,7 +278,7 @@ func getAuditInfoForIssues(issues [][]byte, metadata []api.IssueMetadata) ([][]*
 	outputs := make([][]*AuditableToken, len(issues))
 	for k, issue := range metadata {
 		ia := &issue2.IssueAction{}
-		err := json.Unmarshal(issues[k], ia)
+		err := ia.Deserialize(issues[k])
 		if err != nil {
 			return nil, err
 		}
@@ -248,7 +322,7 @@ func getAuditInfoForTransfers(transfers [][]byte, metadata []api.TransferMetadat
 			auditableInputs[k] = append(auditableInputs[k], ai)
 		}
 		ta := &transfer.TransferAction{}
-		err := json.Unmarshal(transfers[k], ta)
+		err := ta.Deserialize(transfers[k])
 		if err != nil {
 			return nil, nil, err
 		}
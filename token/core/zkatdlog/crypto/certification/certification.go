@@ -0,0 +1,377 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package certification
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/elgamal"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/pssign"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/sigproof"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
+)
+
+// A certification is a Pointcheval-Sanders signature on the type and the value committed in a token.
+// The certifier computes it blindly, i.e. without learning the opening of the token commitment.
+// The messages signed are (H(type), value, expiry), therefore the certifier key-pair is generated for three messages.
+// The expiry, in unix seconds, is chosen by the certifier and disclosed in the proofs,
+// so that validators can reject expired certifications.
+const certifiedMessages = 3
+
+// Certification is the signature the owner of a token obtains from the certifier
+type Certification struct {
+	Signature *pssign.Signature
+	Hash      *bn256.Zr
+	// ExpiresAt is the unix time, in seconds, after which the certification is no longer valid
+	ExpiresAt int64
+}
+
+// Serialize returns the compact binary encoding of the certification
+func (c *Certification) Serialize() ([]byte, error) {
+	if c.Signature == nil {
+		return nil, errors.New("invalid certification, nil signature")
+	}
+	w := encoding.NewWriter(encoding.Version1)
+	common.WriteG1(w, c.Signature.R)
+	common.WriteG1(w, c.Signature.S)
+	common.WriteZr(w, c.Hash)
+	w.WriteUvarint(uint64(c.ExpiresAt))
+	return w.Bytes(), nil
+}
+
+func (c *Certification) Deserialize(raw []byte) error {
+	r, err := encoding.NewReader(raw)
+	if err != nil {
+		return err
+	}
+	c.Signature = &pssign.Signature{R: common.ReadG1(r), S: common.ReadG1(r)}
+	c.Hash = common.ReadZr(r)
+	c.ExpiresAt = int64(r.ReadUvarint())
+	return r.Close()
+}
+
+// GenerateKeyPair returns a fresh certifier key-pair
+func GenerateKeyPair() (*pssign.Signer, error) {
+	signer := &pssign.Signer{}
+	if err := signer.KeyGen(certifiedMessages); err != nil {
+		return nil, errors.Wrap(err, "failed generating certifier key-pair")
+	}
+	return signer, nil
+}
+
+// GetVerifier returns the public key of the certifier stored in the passed public parameters
+func GetVerifier(pp *crypto.PublicParams) (*pssign.SignVerifier, error) {
+	if len(pp.Certifier) == 0 {
+		return nil, errors.New("no certifier set in public parameters")
+	}
+	v := &pssign.SignVerifier{}
+	if err := v.Deserialize(pp.Certifier); err != nil {
+		return nil, errors.Wrap(err, "failed deserializing certifier public key")
+	}
+	if len(v.PK) != certifiedMessages+2 || v.Q == nil {
+		return nil, errors.Errorf("invalid certifier public key, expected [%d] elements, got [%d]", certifiedMessages+2, len(v.PK))
+	}
+	return v, nil
+}
+
+// Requester is run by the owner of the tokens to be certified
+type Requester struct {
+	PublicParams *crypto.PublicParams
+	recipients   []*pssign.Recipient
+}
+
+// NewRequester returns a Requester for the passed tokens whose openings are in inf
+func NewRequester(tokens []*token.Token, inf []*token.TokenInformation, pp *crypto.PublicParams) (*Requester, error) {
+	if len(tokens) != len(inf) {
+		return nil, errors.Errorf("number of tokens to be certified does not match number of openings")
+	}
+	verifier, err := GetVerifier(pp)
+	if err != nil {
+		return nil, err
+	}
+	rand, err := bn256.GetRand()
+	if err != nil {
+		return nil, errors.Errorf("failed to get RNG")
+	}
+	r := &Requester{PublicParams: pp}
+	for i, tok := range tokens {
+		// fresh encryption key for each token, it is used to get back the blind signature
+		x := bn256.RandModOrder(rand)
+		r.recipients = append(r.recipients, pssign.NewRecipient(
+			messages(inf[i]),
+			inf[i].BlindingFactor,
+			tok.Data,
+			x,
+			bn256.G1Gen(),
+			bn256.G1Gen().Mul(x),
+			pp.ZKATPedParams,
+			verifier.PK,
+			verifier.Q,
+		))
+	}
+	return r, nil
+}
+
+// GenerateRequest returns the serialized certification request to be sent to the certifier
+func (r *Requester) GenerateRequest() ([]byte, error) {
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteUvarint(uint64(len(r.recipients)))
+	for i, recipient := range r.recipients {
+		request, err := recipient.GenerateBlindSignRequest()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed generating certification request for token [%d]", i)
+		}
+		writeRequest(w, request)
+	}
+	return w.Bytes(), nil
+}
+
+// VerifyResponses unblinds and checks the responses of the certifier.
+// It returns the serialized certifications, one for each token.
+func (r *Requester) VerifyResponses(responses [][]byte) ([][]byte, error) {
+	if len(responses) != len(r.recipients) {
+		return nil, errors.Errorf("number of certifications does not match number of tokens: expect [%d], got [%d]", len(r.recipients), len(responses))
+	}
+	certifications := make([][]byte, len(responses))
+	for i, raw := range responses {
+		response, expiresAt, err := deserializeResponse(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed deserializing certification [%d]", i)
+		}
+		sig, err := r.recipients[i].VerifyResponseWithDisclosed(response, disclosed(expiresAt))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid certification [%d]", i)
+		}
+		certifications[i], err = (&Certification{Signature: sig, Hash: response.Hash, ExpiresAt: expiresAt}).Serialize()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return certifications, nil
+}
+
+// Certifier signs blindly the token commitments
+type Certifier struct {
+	*pssign.BlindSigner
+}
+
+func NewCertifier(signer *pssign.Signer, pp *crypto.PublicParams) *Certifier {
+	return &Certifier{BlindSigner: pssign.NewBlindSigner(signer.SK, signer.PK, signer.Q, pp.ZKATPedParams)}
+}
+
+// Certify checks that the passed request refers to the passed tokens, as stored on the ledger,
+// and returns the serialized blind signatures, one for each token, valid until expiresAt.
+func (c *Certifier) Certify(tokens []*token.Token, raw []byte, expiresAt time.Time) ([][]byte, error) {
+	rd, err := encoding.NewReader(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed deserializing certification request")
+	}
+	l := rd.ReadLength()
+	if rd.Err() == nil && l != len(tokens) {
+		return nil, errors.Errorf("number of requests does not match number of tokens: expect [%d], got [%d]", len(tokens), l)
+	}
+	requests := make([]*pssign.BlindSignRequest, l)
+	for i := range requests {
+		requests[i] = readRequest(rd)
+	}
+	if err := rd.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed deserializing certification request")
+	}
+
+	responses := make([][]byte, len(requests))
+	for i, request := range requests {
+		if request.Commitment == nil || !request.Commitment.Equals(tokens[i].Data) {
+			return nil, errors.Errorf("request [%d] does not refer to the token commitment", i)
+		}
+		response, err := c.BlindSignWithDisclosed(request, disclosed(expiresAt.Unix()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed certifying token [%d]", i)
+		}
+		responses[i] = serializeResponse(response, expiresAt.Unix())
+	}
+	return responses, nil
+}
+
+// Prover shows, in zero-knowledge, that a token commitment has been certified.
+// The expiry of the certification is disclosed.
+type Prover struct {
+	*sigproof.SigProver
+	expiresAt int64
+}
+
+func NewProver(certification *Certification, tok *token.Token, inf *token.TokenInformation, pp *crypto.PublicParams) (*Prover, error) {
+	verifier, err := GetVerifier(pp)
+	if err != nil {
+		return nil, err
+	}
+	return &Prover{
+		SigProver: sigproof.NewSigProver(
+			messages(inf),
+			disclosed(certification.ExpiresAt),
+			certification.Signature,
+			certification.Hash,
+			inf.BlindingFactor,
+			tok.Data,
+			hiddenIndices,
+			disclosedIndices,
+			pp.P,
+			verifier.Q,
+			verifier.PK,
+			pp.ZKATPedParams,
+		),
+		expiresAt: certification.ExpiresAt,
+	}, nil
+}
+
+// Prove returns the serialized proof
+func (p *Prover) Prove() ([]byte, error) {
+	proof, err := p.SigProver.Prove()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed generating certification proof")
+	}
+	raw, err := proof.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteUvarint(uint64(p.expiresAt))
+	w.WriteBytes(raw)
+	return w.Bytes(), nil
+}
+
+// Verifier checks that a token commitment has been certified
+type Verifier struct {
+	*sigproof.SigVerifier
+}
+
+func NewVerifier(commitment *bn256.G1, pp *crypto.PublicParams) (*Verifier, error) {
+	verifier, err := GetVerifier(pp)
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{
+		SigVerifier: sigproof.NewSigVerifier(
+			hiddenIndices,
+			disclosedIndices,
+			nil,
+			commitment,
+			pp.P,
+			verifier.Q,
+			verifier.PK,
+			pp.ZKATPedParams,
+		),
+	}, nil
+}
+
+// Verify checks the passed serialized proof, and that the certification has not expired at the passed time
+func (v *Verifier) Verify(raw []byte, now time.Time) error {
+	r, err := encoding.NewReader(raw)
+	if err != nil {
+		return errors.Wrap(err, "failed deserializing certification proof")
+	}
+	expiresAt := int64(r.ReadUvarint())
+	rawProof := r.ReadBytes()
+	if err := r.Close(); err != nil {
+		return errors.Wrap(err, "failed deserializing certification proof")
+	}
+	if now.Unix() >= expiresAt {
+		return errors.Errorf("certification expired at [%s]", time.Unix(expiresAt, 0))
+	}
+	proof := &sigproof.SigProof{}
+	if err := proof.Deserialize(rawProof); err != nil {
+		return errors.Wrap(err, "failed deserializing certification proof")
+	}
+	if proof.Commitment == nil || proof.Signature == nil || len(proof.Hidden) != len(hiddenIndices) {
+		return errors.New("invalid certification proof")
+	}
+	if !proof.Commitment.Equals(v.CommitmentToMessages) {
+		return errors.New("invalid certification proof, it does not refer to the token commitment")
+	}
+	v.Disclosed = disclosed(expiresAt)
+	return v.SigVerifier.Verify(proof)
+}
+
+// the hidden messages are the type and the value of the token, the expiry is disclosed
+var (
+	hiddenIndices    = []int{0, 1}
+	disclosedIndices = []int{2}
+)
+
+// messages returns the hidden messages signed by the certifier
+func messages(inf *token.TokenInformation) []*bn256.Zr {
+	return []*bn256.Zr{bn256.HashModOrder([]byte(inf.Type)), inf.Value}
+}
+
+// disclosed returns the disclosed messages signed by the certifier
+func disclosed(expiresAt int64) []*bn256.Zr {
+	return []*bn256.Zr{bn256.NewZrInt(0).SetUint64(uint64(expiresAt))}
+}
+
+func writeRequest(w *encoding.Writer, request *pssign.BlindSignRequest) {
+	common.WriteG1(w, request.Commitment)
+	w.WriteUvarint(uint64(len(request.Ciphertexts)))
+	for _, c := range request.Ciphertexts {
+		common.WriteG1(w, c.C1)
+		common.WriteG1(w, c.C2)
+	}
+	w.WriteBytes(request.Proof)
+	common.WriteG1(w, request.EncPK.Gen)
+	common.WriteG1(w, request.EncPK.H)
+}
+
+func readRequest(r *encoding.Reader) *pssign.BlindSignRequest {
+	request := &pssign.BlindSignRequest{}
+	request.Commitment = common.ReadG1(r)
+	l := r.ReadLength()
+	for i := 0; i < l; i++ {
+		request.Ciphertexts = append(request.Ciphertexts, &elgamal.Ciphertext{C1: common.ReadG1(r), C2: common.ReadG1(r)})
+	}
+	request.Proof = r.ReadBytes()
+	request.EncPK = &elgamal.PublicKey{Gen: common.ReadG1(r), H: common.ReadG1(r)}
+	if r.Err() == nil && (request.EncPK.Gen == nil || request.EncPK.H == nil) {
+		r.Fail(errors.New("invalid encoding, nil encryption key"))
+	}
+	for _, c := range request.Ciphertexts {
+		if r.Err() == nil && (c.C1 == nil || c.C2 == nil) {
+			r.Fail(errors.New("invalid encoding, nil ciphertext"))
+		}
+	}
+	return request
+}
+
+func serializeResponse(response *pssign.BlindSignResponse, expiresAt int64) []byte {
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteUvarint(uint64(expiresAt))
+	common.WriteZr(w, response.Hash)
+	common.WriteG1(w, response.Ciphertext.C1)
+	common.WriteG1(w, response.Ciphertext.C2)
+	return w.Bytes()
+}
+
+func deserializeResponse(raw []byte) (*pssign.BlindSignResponse, int64, error) {
+	r, err := encoding.NewReader(raw)
+	if err != nil {
+		return nil, 0, err
+	}
+	expiresAt := int64(r.ReadUvarint())
+	response := &pssign.BlindSignResponse{
+		Hash:       common.ReadZr(r),
+		Ciphertext: &elgamal.Ciphertext{C1: common.ReadG1(r), C2: common.ReadG1(r)},
+	}
+	if err := r.Close(); err != nil {
+		return nil, 0, err
+	}
+	if response.Hash == nil || response.Ciphertext.C1 == nil || response.Ciphertext.C2 == nil {
+		return nil, 0, errors.New("invalid encoding, incomplete response")
+	}
+	return response, expiresAt, nil
+}
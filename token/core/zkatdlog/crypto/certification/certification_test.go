@@ -0,0 +1,158 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package certification_test
+
+import (
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/certification"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Certification", func() {
+	var (
+		pp        *crypto.PublicParams
+		certifier *certification.Certifier
+		tokens    []*token.Token
+		infos     []*token.TokenInformation
+		expiresAt time.Time
+	)
+	BeforeEach(func() {
+		var err error
+		pp, err = crypto.Setup(100, 2, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		signer, err := certification.GenerateKeyPair()
+		Expect(err).NotTo(HaveOccurred())
+		pp.Certifier, err = signer.SignVerifier.Serialize()
+		Expect(err).NotTo(HaveOccurred())
+		certifier = certification.NewCertifier(signer, pp)
+
+		tokens, infos = prepareTokens(pp, []uint64{50, 35})
+		expiresAt = time.Now().Add(time.Hour)
+	})
+
+	Describe("Certify", func() {
+		Context("request is generated correctly", func() {
+			It("succeeds and the certifications can be proven", func() {
+				requester, err := certification.NewRequester(tokens, infos, pp)
+				Expect(err).NotTo(HaveOccurred())
+				request, err := requester.GenerateRequest()
+				Expect(err).NotTo(HaveOccurred())
+
+				responses, err := certifier.Certify(tokens, request, expiresAt)
+				Expect(err).NotTo(HaveOccurred())
+				certifications, err := requester.VerifyResponses(responses)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(certifications).To(HaveLen(2))
+
+				for i, raw := range certifications {
+					c := &certification.Certification{}
+					Expect(c.Deserialize(raw)).To(Succeed())
+					Expect(c.ExpiresAt).To(Equal(expiresAt.Unix()))
+					prover, err := certification.NewProver(c, tokens[i], infos[i], pp)
+					Expect(err).NotTo(HaveOccurred())
+					proof, err := prover.Prove()
+					Expect(err).NotTo(HaveOccurred())
+
+					verifier, err := certification.NewVerifier(tokens[i].Data, pp)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(verifier.Verify(proof, time.Now())).To(Succeed())
+
+					// the proof is rejected once the certification expired
+					err = verifier.Verify(proof, expiresAt)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("certification expired"))
+
+					// the proof does not certify another token
+					verifier, err = certification.NewVerifier(tokens[1-i].Data, pp)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(verifier.Verify(proof, time.Now())).NotTo(Succeed())
+				}
+			})
+		})
+		Context("the expiry in the proof is extended", func() {
+			It("fails", func() {
+				requester, err := certification.NewRequester(tokens[:1], infos[:1], pp)
+				Expect(err).NotTo(HaveOccurred())
+				request, err := requester.GenerateRequest()
+				Expect(err).NotTo(HaveOccurred())
+				responses, err := certifier.Certify(tokens[:1], request, expiresAt)
+				Expect(err).NotTo(HaveOccurred())
+				certifications, err := requester.VerifyResponses(responses)
+				Expect(err).NotTo(HaveOccurred())
+
+				c := &certification.Certification{}
+				Expect(c.Deserialize(certifications[0])).To(Succeed())
+				c.ExpiresAt = expiresAt.Add(time.Hour).Unix()
+				prover, err := certification.NewProver(c, tokens[0], infos[0], pp)
+				Expect(err).NotTo(HaveOccurred())
+				proof, err := prover.Prove()
+				Expect(err).NotTo(HaveOccurred())
+				verifier, err := certification.NewVerifier(tokens[0].Data, pp)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(verifier.Verify(proof, expiresAt)).NotTo(Succeed())
+
+				// nor can the expiry be replaced in a valid proof
+				c.ExpiresAt = expiresAt.Unix()
+				prover, err = certification.NewProver(c, tokens[0], infos[0], pp)
+				Expect(err).NotTo(HaveOccurred())
+				proof, err = prover.Prove()
+				Expect(err).NotTo(HaveOccurred())
+				r, err := encoding.NewReader(proof)
+				Expect(err).NotTo(HaveOccurred())
+				r.ReadUvarint()
+				w := encoding.NewWriter(encoding.Version1)
+				w.WriteUvarint(uint64(expiresAt.Add(time.Hour).Unix()))
+				w.WriteBytes(r.ReadBytes())
+				Expect(verifier.Verify(w.Bytes(), expiresAt)).NotTo(Succeed())
+			})
+		})
+		Context("request does not refer to the tokens on the ledger", func() {
+			It("fails", func() {
+				requester, err := certification.NewRequester(tokens, infos, pp)
+				Expect(err).NotTo(HaveOccurred())
+				request, err := requester.GenerateRequest()
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = certifier.Certify([]*token.Token{tokens[1], tokens[0]}, request, expiresAt)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("does not refer to the token commitment"))
+			})
+		})
+		Context("responses come from another certifier", func() {
+			It("fails", func() {
+				requester, err := certification.NewRequester(tokens, infos, pp)
+				Expect(err).NotTo(HaveOccurred())
+				request, err := requester.GenerateRequest()
+				Expect(err).NotTo(HaveOccurred())
+
+				other, err := certification.GenerateKeyPair()
+				Expect(err).NotTo(HaveOccurred())
+				responses, err := certification.NewCertifier(other, pp).Certify(tokens, request, expiresAt)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = requester.VerifyResponses(responses)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
+
+func prepareTokens(pp *crypto.PublicParams, values []uint64) ([]*token.Token, []*token.TokenInformation) {
+	commitments, tw, err := token.GetTokensWithWitness(values, "ABC", pp.ZKATPedParams)
+	Expect(err).NotTo(HaveOccurred())
+	tokens := make([]*token.Token, len(commitments))
+	infos := make([]*token.TokenInformation, len(commitments))
+	for i := range commitments {
+		tokens[i] = &token.Token{Owner: []byte("alice"), Data: commitments[i]}
+		infos[i] = &token.TokenInformation{Type: tw[i].Type, Value: tw[i].Value, BlindingFactor: tw[i].BlindingFactor}
+	}
+	return tokens, infos
+}
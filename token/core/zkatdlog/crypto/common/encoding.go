@@ -0,0 +1,136 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package common
+
+import (
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
+	"github.com/pkg/errors"
+)
+
+// Group elements and scalars are encoded as a presence flag followed, if present,
+// by their canonical (compressed) byte representation.
+
+func WriteG1(w *encoding.Writer, g *bn256.G1) {
+	w.WriteBool(g != nil)
+	if g != nil {
+		w.WriteBytes(g.Bytes())
+	}
+}
+
+func WriteG1Array(w *encoding.Writer, array []*bn256.G1) {
+	w.WriteUvarint(uint64(len(array)))
+	for _, g := range array {
+		WriteG1(w, g)
+	}
+}
+
+func WriteG2(w *encoding.Writer, g *bn256.G2) {
+	w.WriteBool(g != nil)
+	if g != nil {
+		w.WriteBytes(g.Bytes())
+	}
+}
+
+func WriteG2Array(w *encoding.Writer, array []*bn256.G2) {
+	w.WriteUvarint(uint64(len(array)))
+	for _, g := range array {
+		WriteG2(w, g)
+	}
+}
+
+func WriteZr(w *encoding.Writer, z *bn256.Zr) {
+	w.WriteBool(z != nil)
+	if z != nil {
+		w.WriteBytes(z.Bytes())
+	}
+}
+
+func WriteZrArray(w *encoding.Writer, array []*bn256.Zr) {
+	w.WriteUvarint(uint64(len(array)))
+	for _, z := range array {
+		WriteZr(w, z)
+	}
+}
+
+func ReadG1(r *encoding.Reader) *bn256.G1 {
+	if !r.ReadBool() {
+		return nil
+	}
+	raw := r.ReadBytes()
+	if r.Err() != nil {
+		return nil
+	}
+	g, err := bn256.NewG1FromBytes(raw)
+	if err != nil {
+		r.Fail(errors.Wrap(err, "invalid encoding, failed reading G1 element"))
+		return nil
+	}
+	return g
+}
+
+func ReadG1Array(r *encoding.Reader) []*bn256.G1 {
+	l := r.ReadLength()
+	if r.Err() != nil || l == 0 {
+		return nil
+	}
+	res := make([]*bn256.G1, l)
+	for i := range res {
+		res[i] = ReadG1(r)
+	}
+	return res
+}
+
+func ReadG2(r *encoding.Reader) *bn256.G2 {
+	if !r.ReadBool() {
+		return nil
+	}
+	raw := r.ReadBytes()
+	if r.Err() != nil {
+		return nil
+	}
+	g, err := bn256.NewG2FromBytes(raw)
+	if err != nil {
+		r.Fail(errors.Wrap(err, "invalid encoding, failed reading G2 element"))
+		return nil
+	}
+	return g
+}
+
+func ReadG2Array(r *encoding.Reader) []*bn256.G2 {
+	l := r.ReadLength()
+	if r.Err() != nil || l == 0 {
+		return nil
+	}
+	res := make([]*bn256.G2, l)
+	for i := range res {
+		res[i] = ReadG2(r)
+	}
+	return res
+}
+
+func ReadZr(r *encoding.Reader) *bn256.Zr {
+	if !r.ReadBool() {
+		return nil
+	}
+	raw := r.ReadBytes()
+	if r.Err() != nil {
+		return nil
+	}
+	return bn256.NewZrFromBytes(raw)
+}
+
+func ReadZrArray(r *encoding.Reader) []*bn256.Zr {
+	l := r.ReadLength()
+	if r.Err() != nil || l == 0 {
+		return nil
+	}
+	res := make([]*bn256.Zr, l)
+	for i := range res {
+		res[i] = ReadZr(r)
+	}
+	return res
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
 	rp "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/range"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
 	"github.com/pkg/errors"
 )
 
@@ -36,8 +37,21 @@ func (i *IssueAction) IsAnonymous() bool {
 	return i.Anonymous
 }
 
+// Serialize returns the compact binary encoding of the issue action
 func (i *IssueAction) Serialize() ([]byte, error) {
-	return json.Marshal(i)
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteBytes(i.Issuer)
+	w.WriteUvarint(uint64(len(i.OutputTokens)))
+	for j, output := range i.OutputTokens {
+		raw, err := output.Serialize()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed serializing output [%d]", j)
+		}
+		w.WriteBytes(raw)
+	}
+	w.WriteBytes(i.Proof)
+	w.WriteBool(i.Anonymous)
+	return w.Bytes(), nil
 }
 
 func (i *IssueAction) NumOutputs() int {
@@ -68,8 +82,30 @@ func (i *IssueAction) GetIssuer() []byte {
 	return i.Issuer
 }
 
+// Deserialize accepts both the compact binary encoding and the legacy JSON one
 func (i *IssueAction) Deserialize(raw []byte) error {
-	return json.Unmarshal(raw, i)
+	if !encoding.IsBinary(raw) {
+		return json.Unmarshal(raw, i)
+	}
+	r, err := encoding.NewReader(raw)
+	if err != nil {
+		return err
+	}
+	i.Issuer = r.ReadBytes()
+	outputs := r.ReadBytesArray()
+	i.Proof = r.ReadBytes()
+	i.Anonymous = r.ReadBool()
+	if err := r.Close(); err != nil {
+		return err
+	}
+	i.OutputTokens = make([]*token.Token, len(outputs))
+	for j, output := range outputs {
+		i.OutputTokens[j] = &token.Token{}
+		if err := i.OutputTokens[j].Deserialize(output); err != nil {
+			return errors.Wrapf(err, "failed deserializing output [%d]", j)
+		}
+	}
+	return nil
 }
 
 func (i *IssueAction) GetCommitments() []*bn256.G1 {
@@ -124,12 +160,24 @@ type Prover struct {
 
 // serialize
 func (p *Proof) Serialize() ([]byte, error) {
-	return json.Marshal(p)
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteBytes(p.WellFormedness)
+	w.WriteBytes(p.RangeCorrectness)
+	return w.Bytes(), nil
 }
 
-// deserialize
+// deserialize, both the compact binary encoding and the legacy JSON one are accepted
 func (p *Proof) Deserialize(bytes []byte) error {
-	return json.Unmarshal(bytes, p)
+	if !encoding.IsBinary(bytes) {
+		return json.Unmarshal(bytes, p)
+	}
+	r, err := encoding.NewReader(bytes)
+	if err != nil {
+		return err
+	}
+	p.WellFormedness = r.ReadBytes()
+	p.RangeCorrectness = r.ReadBytes()
+	return r.Close()
 }
 
 func NewProver(tw []*token.TokenDataWitness, tokens []*bn256.G1, anonymous bool, pp *crypto.PublicParams) *Prover {
@@ -11,6 +11,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
 	"github.com/pkg/errors"
 )
 
@@ -33,12 +34,32 @@ func NewTokenDataWitness(ttype string, values, bfs []*bn256.Zr) []*token.TokenDa
 	return witness
 }
 
+// Serialize returns the compact binary encoding of the proof
 func (wf *WellFormedness) Serialize() ([]byte, error) {
-	return json.Marshal(wf)
+	w := encoding.NewWriter(encoding.Version1)
+	common.WriteZr(w, wf.Type)
+	common.WriteZrArray(w, wf.Values)
+	common.WriteZrArray(w, wf.BlindingFactors)
+	w.WriteString(wf.TypeInTheClear)
+	common.WriteZr(w, wf.Challenge)
+	return w.Bytes(), nil
 }
 
+// Deserialize accepts both the compact binary encoding and the legacy JSON one
 func (wf *WellFormedness) Deserialize(bytes []byte) error {
-	return json.Unmarshal(bytes, wf)
+	if !encoding.IsBinary(bytes) {
+		return json.Unmarshal(bytes, wf)
+	}
+	r, err := encoding.NewReader(bytes)
+	if err != nil {
+		return err
+	}
+	wf.Type = common.ReadZr(r)
+	wf.Values = common.ReadZrArray(r)
+	wf.BlindingFactors = common.ReadZrArray(r)
+	wf.TypeInTheClear = r.ReadString()
+	wf.Challenge = common.ReadZr(r)
+	return r.Close()
 }
 
 // randomness used in well-formedness proof
@@ -9,6 +9,8 @@ import (
 	"encoding/json"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
 )
 
 type IssuingPolicy struct {
@@ -17,10 +19,26 @@ type IssuingPolicy struct {
 	BitLength     int
 }
 
+// Serialize returns the compact binary encoding of the issuing policy
 func (ip *IssuingPolicy) Serialize() ([]byte, error) {
-	return json.Marshal(ip)
+	w := encoding.NewWriter(encoding.Version1)
+	common.WriteG1Array(w, ip.Issuers)
+	w.WriteUvarint(uint64(ip.IssuersNumber))
+	w.WriteUvarint(uint64(ip.BitLength))
+	return w.Bytes(), nil
 }
 
+// Deserialize accepts both the compact binary encoding and the legacy JSON one
 func (ip *IssuingPolicy) Deserialize(raw []byte) error {
-	return json.Unmarshal(raw, ip)
+	if !encoding.IsBinary(raw) {
+		return json.Unmarshal(raw, ip)
+	}
+	r, err := encoding.NewReader(raw)
+	if err != nil {
+		return err
+	}
+	ip.Issuers = common.ReadG1Array(r)
+	ip.IssuersNumber = int(r.ReadUvarint())
+	ip.BitLength = int(r.ReadUvarint())
+	return r.Close()
 }
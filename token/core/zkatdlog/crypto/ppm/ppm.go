@@ -15,6 +15,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/certification"
 )
 
 var logger = flogging.MustGetLogger("token-sdk.zkatdlog")
@@ -60,12 +61,45 @@ func (v *PublicParamsManager) PublicParameters() api.PublicParameters {
 	return v.pp
 }
 
-func (v *PublicParamsManager) SetCertifier(bytes []byte) ([]byte, error) {
-	panic("SetCertifier cannot be called from zkatdlog without graph hiding")
+func (v *PublicParamsManager) SetCertifier(bytes []byte, identity []byte) ([]byte, error) {
+	// make sure the public key is well-formed before storing it
+	pp := *v.pp
+	pp.Certifier = bytes
+	if _, err := certification.GetVerifier(&pp); err != nil {
+		return nil, errors.Wrap(err, "failed to set certifier")
+	}
+	if len(identity) == 0 {
+		return nil, errors.New("failed to set certifier: empty identity")
+	}
+	v.pp.Certifier = bytes
+	v.pp.CertifierIdentity = identity
+	raw, err := v.pp.Serialize()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to serialize public parameters")
+	}
+	return raw, nil
 }
 
+// CertifierIdentity returns the identity of the certifier, nil if no certifier is set
+func (v *PublicParamsManager) CertifierIdentity() []byte {
+	return v.pp.CertifierIdentity
+}
+
+// NewCertifierKeyPair returns the serialized secret and public keys of a new certifier
 func (v *PublicParamsManager) NewCertifierKeyPair() ([]byte, []byte, error) {
-	panic("not supported")
+	signer, err := certification.GenerateKeyPair()
+	if err != nil {
+		return nil, nil, err
+	}
+	sk, err := signer.Serialize()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to serialize certifier secret key")
+	}
+	pk, err := signer.SignVerifier.Serialize()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to serialize certifier public key")
+	}
+	return sk, pk, nil
 }
 
 func (v *PublicParamsManager) ForceFetch() error {
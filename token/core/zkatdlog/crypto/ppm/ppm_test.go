@@ -84,6 +84,31 @@ var _ = Describe("PublicParamsManager", func() {
 		})
 	})
 
+	Describe("Set Certifier", func() {
+		When("SetCertifier is called with a freshly generated key-pair", func() {
+			It("succeeds", func() {
+				sk, pk, err := engine.NewCertifierKeyPair()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sk).NotTo(BeNil())
+				ppbytes, err := engine.SetCertifier(pk, []byte("certifier"))
+				Expect(err).NotTo(HaveOccurred())
+				pp := &crypto.PublicParams{}
+				err = pp.Deserialize(ppbytes)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bytes.Equal(pp.Certifier, pk)).To(Equal(true))
+				Expect(pp.CertifierIdentity).To(Equal([]byte("certifier")))
+			})
+		})
+		When("SetCertifier is called with an invalid public key", func() {
+			It("fails", func() {
+				ppbytes, err := engine.SetCertifier([]byte("invalid certifier"), []byte("certifier"))
+				Expect(err).To(HaveOccurred())
+				Expect(ppbytes).To(BeNil())
+				Expect(err.Error()).To(ContainSubstring("failed to set certifier"))
+			})
+		})
+	})
+
 	Describe("Add Issuer", func() {
 		Context("AddIssuer is called correctly to add a new anonymissuer", func() {
 			var (
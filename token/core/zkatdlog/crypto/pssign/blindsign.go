@@ -96,8 +96,13 @@ type EncProofCommitments struct {
 }
 
 func (s *BlindSigner) BlindSign(request *BlindSignRequest) (*BlindSignResponse, error) {
-	if len(request.Ciphertexts) != len(s.PK)-2 {
-		return nil, errors.Errorf("number of ciphertexts in blind signature request does not match number of public keys: expect [%d], got [%d]", len(s.PK)-2, len(request.Ciphertexts))
+	return s.BlindSignWithDisclosed(request, nil)
+}
+
+// BlindSignWithDisclosed signs blindly the messages encrypted in the request, followed by the passed disclosed messages
+func (s *BlindSigner) BlindSignWithDisclosed(request *BlindSignRequest, disclosed []*bn256.Zr) (*BlindSignResponse, error) {
+	if len(request.Ciphertexts)+len(disclosed) != len(s.PK)-2 {
+		return nil, errors.Errorf("number of messages in blind signature request does not match number of public keys: expect [%d], got [%d]", len(s.PK)-2, len(request.Ciphertexts)+len(disclosed))
 	}
 	v := &EncVerifier{Commitment: request.Commitment, Ciphertexts: request.Ciphertexts, EncPK: request.EncPK, PedersenParameters: s.PedersenParameters}
 	hash, err := bn256.HashToG1(request.Commitment.Bytes())
@@ -117,11 +122,20 @@ func (s *BlindSigner) BlindSign(request *BlindSignRequest) (*BlindSignResponse,
 		response.Ciphertext.C1.Add(request.Ciphertexts[i].C1.Mul(s.SK[i+1]))
 		response.Ciphertext.C2.Add(request.Ciphertexts[i].C2.Mul(s.SK[i+1]))
 	}
-	response.Ciphertext.C2.Add(hash.Mul(bn256.ModMul(response.Hash, s.SK[len(request.Ciphertexts)+1], bn256.Order)))
+	for i, m := range disclosed {
+		response.Ciphertext.C2.Add(hash.Mul(bn256.ModMul(m, s.SK[len(request.Ciphertexts)+i+1], bn256.Order)))
+	}
+	response.Ciphertext.C2.Add(hash.Mul(bn256.ModMul(response.Hash, s.SK[len(request.Ciphertexts)+len(disclosed)+1], bn256.Order)))
 	return response, nil
 }
 
 func (r *Recipient) VerifyResponse(response *BlindSignResponse) (*Signature, error) {
+	return r.VerifyResponseWithDisclosed(response, nil)
+}
+
+// VerifyResponseWithDisclosed unblinds the passed response and checks it is a signature
+// on the hidden messages followed by the passed disclosed ones
+func (r *Recipient) VerifyResponseWithDisclosed(response *BlindSignResponse, disclosed []*bn256.Zr) (*Signature, error) {
 	sig := &Signature{}
 	sig.S = r.EncSK.Decrypt(response.Ciphertext)
 	var err error
@@ -130,7 +144,10 @@ func (r *Recipient) VerifyResponse(response *BlindSignResponse) (*Signature, err
 		return nil, errors.Errorf("failed to hash commitment")
 	}
 
-	err = r.SignVerifier.Verify(append(r.Witness.messages, response.Hash), sig)
+	var m []*bn256.Zr
+	m = append(m, r.Witness.messages...)
+	m = append(m, disclosed...)
+	err = r.SignVerifier.Verify(append(m, response.Hash), sig)
 	if err != nil {
 		return nil, err
 	}
@@ -50,6 +50,25 @@ var _ = Describe("Pointcheval Sanders blind signatures", func() {
 				Expect(sig).NotTo(BeNil())
 			})
 		})
+		Context("the signer adds disclosed messages", func() {
+			BeforeEach(func() {
+				signer = &pssign.BlindSigner{Signer: getSigner(5), PedersenParameters: pp}
+			})
+			It("succeeds", func() {
+				req, err := recipient.GenerateBlindSignRequest()
+				Expect(err).NotTo(HaveOccurred())
+				disclosed := []*bn256.Zr{bn256.NewZrInt(42)}
+				res, err := signer.BlindSignWithDisclosed(req, disclosed)
+				Expect(err).NotTo(HaveOccurred())
+				recipient.SignVerifier = signer.SignVerifier
+				sig, err := recipient.VerifyResponseWithDisclosed(res, disclosed)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sig).NotTo(BeNil())
+
+				_, err = recipient.VerifyResponseWithDisclosed(res, []*bn256.Zr{bn256.NewZrInt(43)})
+				Expect(err).To(HaveOccurred())
+			})
+		})
 	})
 })
 
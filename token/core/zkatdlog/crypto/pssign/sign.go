@@ -150,3 +150,11 @@ func (s *Signer) Serialize() ([]byte, error) {
 func (s *Signer) Deserialize(raw []byte) error {
 	return json.Unmarshal(raw, s)
 }
+
+func (v *SignVerifier) Serialize() ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (v *SignVerifier) Deserialize(raw []byte) error {
+	return json.Unmarshal(raw, v)
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/pssign"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/sigproof"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
 	"github.com/pkg/errors"
 )
 
@@ -25,6 +26,56 @@ type Proof struct {
 	MembershipProofs []*MembershipProof
 }
 
+// Serialize returns the compact binary encoding of the proof
+func (p *Proof) Serialize() ([]byte, error) {
+	w := encoding.NewWriter(encoding.Version1)
+	common.WriteZr(w, p.Challenge)
+	w.WriteBool(p.EqualityProofs != nil)
+	if p.EqualityProofs != nil {
+		common.WriteZr(w, p.EqualityProofs.Type)
+		common.WriteZrArray(w, p.EqualityProofs.Value)
+		common.WriteZrArray(w, p.EqualityProofs.TokenBlindingFactor)
+		common.WriteZrArray(w, p.EqualityProofs.CommitmentBlindingFactor)
+	}
+	w.WriteUvarint(uint64(len(p.MembershipProofs)))
+	for _, mp := range p.MembershipProofs {
+		common.WriteG1Array(w, mp.Commitments)
+		w.WriteBytesArray(mp.SignatureProofs)
+	}
+	return w.Bytes(), nil
+}
+
+// Deserialize accepts both the compact binary encoding and the legacy JSON one
+func (p *Proof) Deserialize(raw []byte) error {
+	if !encoding.IsBinary(raw) {
+		return json.Unmarshal(raw, p)
+	}
+	r, err := encoding.NewReader(raw)
+	if err != nil {
+		return err
+	}
+	p.Challenge = common.ReadZr(r)
+	if r.ReadBool() {
+		p.EqualityProofs = &EqualityProofs{
+			Type:                     common.ReadZr(r),
+			Value:                    common.ReadZrArray(r),
+			TokenBlindingFactor:      common.ReadZrArray(r),
+			CommitmentBlindingFactor: common.ReadZrArray(r),
+		}
+	}
+	l := r.ReadLength()
+	if r.Err() == nil && l != 0 {
+		p.MembershipProofs = make([]*MembershipProof, l)
+		for i := range p.MembershipProofs {
+			p.MembershipProofs[i] = &MembershipProof{
+				Commitments:     common.ReadG1Array(r),
+				SignatureProofs: r.ReadBytesArray(),
+			}
+		}
+	}
+	return r.Close()
+}
+
 type EqualityProofs struct {
 	Type                     *bn256.Zr
 	Value                    []*bn256.Zr
@@ -142,13 +193,13 @@ func (p *Prover) Prove() ([]byte, error) {
 	proof.EqualityProofs.Type = bn256.ModMul(proof.Challenge, bn256.HashModOrder([]byte(p.tokenWitness[0].Type)), bn256.Order)
 	proof.EqualityProofs.Type = bn256.ModAdd(proof.EqualityProofs.Type, p.randomness.Type, bn256.Order)
 
-	return json.Marshal(proof)
+	return proof.Serialize()
 }
 
 func (v *Verifier) Verify(raw []byte) error {
 
 	proof := &Proof{}
-	err := json.Unmarshal(raw, proof)
+	err := proof.Deserialize(raw)
 	if err != nil {
 		return err
 	}
@@ -13,11 +13,15 @@ import (
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/api"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/pssign"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
 )
 
 const (
 	DLogPublicParameters = "zkatdlog"
+	// InteractiveCertification is the certification driver used when a certifier is set
+	InteractiveCertification = "interactive"
 )
 
 type PublicParams struct {
@@ -27,6 +31,11 @@ type PublicParams struct {
 	IdemixPK         []byte
 	IssuingPolicy    []byte
 	Auditor          []byte
+	// Certifier is the serialized Pointcheval-Sanders public key of the token certifier.
+	// When set, transfers must prove that their inputs have been certified.
+	Certifier []byte
+	// CertifierIdentity is the serialized identity of the certifier, the only one allowed to revoke certifications
+	CertifierIdentity []byte
 }
 
 type RangeProofParams struct {
@@ -49,6 +58,9 @@ func (pp *PublicParams) Identifier() string {
 }
 
 func (pp *PublicParams) CertificationDriver() string {
+	if len(pp.Certifier) != 0 {
+		return InteractiveCertification
+	}
 	return DLogPublicParameters
 }
 
@@ -68,27 +80,72 @@ func (pp *PublicParams) Bytes() ([]byte, error) {
 	return pp.Serialize()
 }
 
+// Serialize returns the compact binary encoding of the public parameters
 func (pp *PublicParams) Serialize() ([]byte, error) {
-	raw, err := json.Marshal(pp)
-	if err != nil {
-		return nil, err
+	w := encoding.NewWriter(encoding.Version1)
+	common.WriteG1(w, pp.P)
+	common.WriteG1Array(w, pp.ZKATPedParams)
+	w.WriteBool(pp.RangeProofParams != nil)
+	if pp.RangeProofParams != nil {
+		common.WriteG2Array(w, pp.RangeProofParams.SignPK)
+		w.WriteUvarint(uint64(len(pp.RangeProofParams.SignedValues)))
+		for _, sig := range pp.RangeProofParams.SignedValues {
+			common.WriteG1(w, sig.R)
+			common.WriteG1(w, sig.S)
+		}
+		common.WriteG2(w, pp.RangeProofParams.Q)
+		w.WriteUvarint(uint64(pp.RangeProofParams.Exponent))
 	}
-	return json.Marshal(&api.SerializedPublicParameters{
+	w.WriteBytes(pp.IdemixPK)
+	w.WriteBytes(pp.IssuingPolicy)
+	w.WriteBytes(pp.Auditor)
+	w.WriteBytes(pp.Certifier)
+	w.WriteBytes(pp.CertifierIdentity)
+
+	return (&api.SerializedPublicParameters{
 		Identifier: DLogPublicParameters,
-		Raw:        raw,
-	})
+		Raw:        w.Bytes(),
+	}).Serialize()
 }
 
+// Deserialize accepts both the compact binary encoding and the legacy JSON one
 func (pp *PublicParams) Deserialize(raw []byte) error {
 	publicParams := &api.SerializedPublicParameters{}
-	if err := json.Unmarshal(raw, publicParams); err != nil {
+	if err := publicParams.Deserialize(raw); err != nil {
 		return err
 	}
 	if publicParams.Identifier != DLogPublicParameters {
 		return errors.Errorf("invalid identifier, expecting 'dlog', got [%s]", publicParams.Identifier)
 	}
 	// logger.Debugf("unmarshall zkatdlog public params [%s]", string(publicParams.Raw))
-	return json.Unmarshal(publicParams.Raw, pp)
+	if !encoding.IsBinary(publicParams.Raw) {
+		return json.Unmarshal(publicParams.Raw, pp)
+	}
+	r, err := encoding.NewReader(publicParams.Raw)
+	if err != nil {
+		return err
+	}
+	pp.P = common.ReadG1(r)
+	pp.ZKATPedParams = common.ReadG1Array(r)
+	if r.ReadBool() {
+		pp.RangeProofParams = &RangeProofParams{}
+		pp.RangeProofParams.SignPK = common.ReadG2Array(r)
+		l := r.ReadLength()
+		if r.Err() == nil && l != 0 {
+			pp.RangeProofParams.SignedValues = make([]*pssign.Signature, l)
+			for i := range pp.RangeProofParams.SignedValues {
+				pp.RangeProofParams.SignedValues[i] = &pssign.Signature{R: common.ReadG1(r), S: common.ReadG1(r)}
+			}
+		}
+		pp.RangeProofParams.Q = common.ReadG2(r)
+		pp.RangeProofParams.Exponent = int(r.ReadUvarint())
+	}
+	pp.IdemixPK = r.ReadBytes()
+	pp.IssuingPolicy = r.ReadBytes()
+	pp.Auditor = r.ReadBytes()
+	pp.Certifier = r.ReadBytes()
+	pp.CertifierIdentity = r.ReadBytes()
+	return r.Close()
 }
 
 func (pp *PublicParams) GeneratePedersenParameters() error {
@@ -6,10 +6,13 @@ SPDX-License-Identifier: Apache-2.0
 package crypto
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"testing"
 	"time"
 
+	"github.com/hyperledger-labs/fabric-token-sdk/token/api"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,3 +23,39 @@ func TestSetup(t *testing.T) {
 	fmt.Printf("elapsed %d", e.Sub(s).Milliseconds())
 	assert.NoError(t, err)
 }
+
+func TestSerialization(t *testing.T) {
+	ipk, err := ioutil.ReadFile("./validator/testdata/idemix/msp/IssuerPublicKey")
+	assert.NoError(t, err)
+
+	// same parameters used by the integration tests
+	for _, exponent := range []int{1, 2} {
+		pp, err := Setup(100, exponent, ipk)
+		assert.NoError(t, err)
+
+		raw, err := pp.Serialize()
+		assert.NoError(t, err)
+
+		// legacy JSON encoding
+		legacyRaw, err := json.Marshal(pp)
+		assert.NoError(t, err)
+		legacy, err := json.Marshal(&api.SerializedPublicParameters{Identifier: DLogPublicParameters, Raw: legacyRaw})
+		assert.NoError(t, err)
+
+		fmt.Printf("public parameters (100,%d) size: binary [%d], json [%d]\n", exponent, len(raw), len(legacy))
+		assert.True(t, len(raw) < len(legacy)/2)
+
+		for _, r := range [][]byte{raw, legacy} {
+			pp2, err := NewPublicParamsFromBytes(r)
+			assert.NoError(t, err)
+			assert.True(t, pp.P.Equals(pp2.P))
+			assert.Equal(t, len(pp.ZKATPedParams), len(pp2.ZKATPedParams))
+			assert.Equal(t, len(pp.RangeProofParams.SignedValues), len(pp2.RangeProofParams.SignedValues))
+			assert.True(t, pp.RangeProofParams.SignedValues[99].S.Equals(pp2.RangeProofParams.SignedValues[99].S))
+			assert.True(t, pp.RangeProofParams.Q.Equals(pp2.RangeProofParams.Q))
+			assert.Equal(t, pp.RangeProofParams.Exponent, pp2.RangeProofParams.Exponent)
+			assert.Equal(t, pp.IdemixPK, pp2.IdemixPK)
+			assert.Equal(t, pp.MaxTokenValue(), pp2.MaxTokenValue())
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/pssign"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
 	"github.com/pkg/errors"
 )
 
@@ -25,12 +26,42 @@ type MembershipProof struct {
 	Commitment        *bn256.G1
 }
 
+// Serialize returns the compact binary encoding of the proof
 func (p *MembershipProof) Serialize() ([]byte, error) {
-	return json.Marshal(p)
+	w := encoding.NewWriter(encoding.Version1)
+	common.WriteZr(w, p.Challenge)
+	w.WriteBool(p.Signature != nil)
+	if p.Signature != nil {
+		common.WriteG1(w, p.Signature.R)
+		common.WriteG1(w, p.Signature.S)
+	}
+	common.WriteZr(w, p.Value)
+	common.WriteZr(w, p.ComBlindingFactor)
+	common.WriteZr(w, p.SigBlindingFactor)
+	common.WriteZr(w, p.Hash)
+	common.WriteG1(w, p.Commitment)
+	return w.Bytes(), nil
 }
 
+// Deserialize accepts both the compact binary encoding and the legacy JSON one
 func (p *MembershipProof) Deserialize(raw []byte) error {
-	return json.Unmarshal(raw, p)
+	if !encoding.IsBinary(raw) {
+		return json.Unmarshal(raw, p)
+	}
+	r, err := encoding.NewReader(raw)
+	if err != nil {
+		return err
+	}
+	p.Challenge = common.ReadZr(r)
+	if r.ReadBool() {
+		p.Signature = &pssign.Signature{R: common.ReadG1(r), S: common.ReadG1(r)}
+	}
+	p.Value = common.ReadZr(r)
+	p.ComBlindingFactor = common.ReadZr(r)
+	p.SigBlindingFactor = common.ReadZr(r)
+	p.Hash = common.ReadZr(r)
+	p.Commitment = common.ReadG1(r)
+	return r.Close()
 }
 
 // witness for membership proof
@@ -6,9 +6,12 @@ SPDX-License-Identifier: Apache-2.0
 package sigproof
 
 import (
+	"encoding/json"
+
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/pssign"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
 	"github.com/pkg/errors"
 )
 
@@ -22,6 +25,44 @@ type SigProof struct {
 	Commitment        *bn256.G1 // for hidden values
 }
 
+// Serialize returns the compact binary encoding of the proof
+func (p *SigProof) Serialize() ([]byte, error) {
+	w := encoding.NewWriter(encoding.Version1)
+	common.WriteZr(w, p.Challenge)
+	common.WriteZrArray(w, p.Hidden)
+	common.WriteZr(w, p.Hash)
+	w.WriteBool(p.Signature != nil)
+	if p.Signature != nil {
+		common.WriteG1(w, p.Signature.R)
+		common.WriteG1(w, p.Signature.S)
+	}
+	common.WriteZr(w, p.SigBlindingFactor)
+	common.WriteZr(w, p.ComBlindingFactor)
+	common.WriteG1(w, p.Commitment)
+	return w.Bytes(), nil
+}
+
+// Deserialize accepts both the compact binary encoding and the legacy JSON one
+func (p *SigProof) Deserialize(raw []byte) error {
+	if !encoding.IsBinary(raw) {
+		return json.Unmarshal(raw, p)
+	}
+	r, err := encoding.NewReader(raw)
+	if err != nil {
+		return err
+	}
+	p.Challenge = common.ReadZr(r)
+	p.Hidden = common.ReadZrArray(r)
+	p.Hash = common.ReadZr(r)
+	if r.ReadBool() {
+		p.Signature = &pssign.Signature{R: common.ReadG1(r), S: common.ReadG1(r)}
+	}
+	p.SigBlindingFactor = common.ReadZr(r)
+	p.ComBlindingFactor = common.ReadZr(r)
+	p.Commitment = common.ReadG1(r)
+	return r.Close()
+}
+
 // commitments how they are computed
 type SigProver struct {
 	*SigVerifier
@@ -241,12 +282,12 @@ func (v *SigVerifier) Verify(p *SigProof) error {
 
 	com, err := v.recomputeCommitments(p)
 	if err != nil {
-		return nil
+		return err
 	}
 
 	chal, err := v.computeChallenge(p.Commitment, p.Signature, com)
 	if err != nil {
-		return nil
+		return err
 	}
 	if chal.Cmp(p.Challenge) != 0 {
 		return errors.Errorf("invalid signature proof")
@@ -15,6 +15,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
 )
 
 type Token struct {
@@ -26,12 +27,26 @@ func (t *Token) IsRedeem() bool {
 	return len(t.Owner) == 0
 }
 
+// Serialize returns the compact binary encoding of the token
 func (t *Token) Serialize() ([]byte, error) {
-	return json.Marshal(t)
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteBytes(t.Owner)
+	common.WriteG1(w, t.Data)
+	return w.Bytes(), nil
 }
 
+// Deserialize accepts both the compact binary encoding and the legacy JSON one
 func (t *Token) Deserialize(bytes []byte) error {
-	return json.Unmarshal(bytes, t)
+	if !encoding.IsBinary(bytes) {
+		return json.Unmarshal(bytes, t)
+	}
+	r, err := encoding.NewReader(bytes)
+	if err != nil {
+		return err
+	}
+	t.Owner = r.ReadBytes()
+	t.Data = common.ReadG1(r)
+	return r.Close()
 }
 
 func (t *Token) GetCommitment() *bn256.G1 {
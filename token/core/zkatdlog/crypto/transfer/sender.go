@@ -13,7 +13,9 @@ import (
 	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
 	"github.com/pkg/errors"
 )
 
@@ -44,7 +46,33 @@ func (s *Sender) GenerateZKTransfer(values []uint64, owners [][]byte) (*Transfer
 	if err != nil {
 		return nil, nil, err
 	}
+	return s.generateZKTransfer(out, outtw, owners)
+}
+
+// GenerateZKSettlement is like GenerateZKTransfer but each output also carries a proof that it commits to its
+// declared value and type, so that the validator can check that the outputs match the payouts of a settlement.
+// The blinding factors stay secret.
+func (s *Sender) GenerateZKSettlement(values []uint64, owners [][]byte) (*TransferAction, []*token.TokenInformation, error) {
+	ttype := s.InputInformation[0].Type
+	out, outtw, err := token.GetTokensWithWitness(values, ttype, s.PublicParams.ZKATPedParams)
+	if err != nil {
+		return nil, nil, err
+	}
+	transfer, inf, err := s.generateZKTransfer(out, outtw, owners)
+	if err != nil {
+		return nil, nil, err
+	}
+	transfer.OutputProofs = make([][]byte, len(out))
+	for i := range out {
+		transfer.OutputProofs[i], err = NewSwapProver(out[i], ttype, values[i], outtw[i].BlindingFactor, s.PublicParams.ZKATPedParams).Prove()
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed proving the opening of output [%d]", i)
+		}
+	}
+	return transfer, inf, nil
+}
 
+func (s *Sender) generateZKTransfer(out []*bn256.G1, outtw []*token.TokenDataWitness, owners [][]byte) (*TransferAction, []*token.TokenInformation, error) {
 	in := getTokenData(s.Inputs)
 	intw := make([]*token.TokenDataWitness, len(s.InputInformation))
 	for i := 0; i < len(s.InputInformation); i++ {
@@ -94,6 +122,12 @@ type TransferAction struct {
 	OutputTokens []*token.Token
 	// ZK Proof
 	Proof []byte
+	// InputCertifications prove, for each input, that the input has been certified.
+	// They are required only when the public parameters carry a certifier.
+	InputCertifications [][]byte
+	// OutputProofs prove, for each output, the value and type it commits to.
+	// They are set only for the settlement of a channel, see GenerateZKSettlement.
+	OutputProofs [][]byte `json:",omitempty"`
 }
 
 func NewTransfer(inputs []string, inputCommitments []*bn256.G1, outputs []*bn256.G1, owners [][]byte, proof []byte) (*TransferAction, error) {
@@ -135,16 +169,59 @@ func (t *TransferAction) SerializeOutputAt(index int) ([]byte, error) {
 	return t.OutputTokens[index].Serialize()
 }
 
+// Serialize returns the compact binary encoding of the transfer action
 func (t *TransferAction) Serialize() ([]byte, error) {
-	return json.Marshal(t)
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteStringArray(t.Inputs)
+	common.WriteG1Array(w, t.InputCommitments)
+	w.WriteUvarint(uint64(len(t.OutputTokens)))
+	for i, output := range t.OutputTokens {
+		raw, err := output.Serialize()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed serializing output [%d]", i)
+		}
+		w.WriteBytes(raw)
+	}
+	w.WriteBytes(t.Proof)
+	w.WriteBytesArray(t.InputCertifications)
+	if len(t.OutputProofs) != 0 {
+		w.WriteBytesArray(t.OutputProofs)
+	}
+	return w.Bytes(), nil
 }
 
 func (t *TransferAction) GetProof() []byte {
 	return t.Proof
 }
 
+// Deserialize accepts both the compact binary encoding and the legacy JSON one
 func (t *TransferAction) Deserialize(raw []byte) error {
-	return json.Unmarshal(raw, t)
+	if !encoding.IsBinary(raw) {
+		return json.Unmarshal(raw, t)
+	}
+	r, err := encoding.NewReader(raw)
+	if err != nil {
+		return err
+	}
+	t.Inputs = r.ReadStringArray()
+	t.InputCommitments = common.ReadG1Array(r)
+	outputs := r.ReadBytesArray()
+	t.Proof = r.ReadBytes()
+	t.InputCertifications = r.ReadBytesArray()
+	if !r.Done() {
+		t.OutputProofs = r.ReadBytesArray()
+	}
+	if err := r.Close(); err != nil {
+		return err
+	}
+	t.OutputTokens = make([]*token.Token, len(outputs))
+	for i, output := range outputs {
+		t.OutputTokens[i] = &token.Token{}
+		if err := t.OutputTokens[i].Deserialize(output); err != nil {
+			return errors.Wrapf(err, "failed deserializing output [%d]", i)
+		}
+	}
+	return nil
 }
 
 func (t *TransferAction) GetSerializedOutputs() ([][]byte, error) {
@@ -126,6 +126,26 @@ var _ = Describe("Sender", func() {
 			})
 		})
 	})
+	Describe("Settlement", func() {
+		It("blinds the outputs and proves their values", func() {
+			transfer, inf, err := sender.GenerateZKSettlement(outvalues, owners)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(transfer.OutputProofs).To(HaveLen(2))
+			for i, v := range outvalues {
+				Expect(inf[i].BlindingFactor.IsZero()).To(BeFalse())
+				unblinded := PrepareTokens([]*bn256.Zr{bn256.NewZrInt(int(v))}, []*bn256.Zr{bn256.NewZrInt(0)}, "ABC", pp.ZKATPedParams)[0]
+				Expect(transfer.OutputTokens[i].Data.Equals(unblinded)).To(BeFalse())
+				Expect(transfer2.NewSwapVerifier(transfer.OutputTokens[i].Data, "ABC", v, pp.ZKATPedParams).Verify(transfer.OutputProofs[i])).To(Succeed())
+			}
+			Expect(transfer2.NewSwapVerifier(transfer.OutputTokens[0].Data, "ABC", outvalues[1], pp.ZKATPedParams).Verify(transfer.OutputProofs[0])).NotTo(Succeed())
+
+			raw, err := transfer.Serialize()
+			Expect(err).NotTo(HaveOccurred())
+			decoded := &transfer2.TransferAction{}
+			Expect(decoded.Deserialize(raw)).To(Succeed())
+			Expect(decoded.OutputProofs).To(Equal(transfer.OutputProofs))
+		})
+	})
 })
 
 func PrepareTokens(values, bf []*bn256.Zr, ttype string, pp []*bn256.G1) []*bn256.G1 {
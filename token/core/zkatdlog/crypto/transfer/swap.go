@@ -0,0 +1,113 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package transfer
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
+)
+
+// SwapProof shows that a token commits to the type and quantity declared by a swap leg,
+// without revealing its blinding factor
+type SwapProof struct {
+	Challenge      *bn256.Zr
+	BlindingFactor *bn256.Zr
+}
+
+func (p *SwapProof) Serialize() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+func (p *SwapProof) Deserialize(raw []byte) error {
+	return json.Unmarshal(raw, p)
+}
+
+// SwapVerifier checks a SwapProof for a token against the declared type and quantity
+type SwapVerifier struct {
+	Token     *bn256.G1
+	Type      string
+	Quantity  uint64
+	PedParams []*bn256.G1
+}
+
+// SwapProver produces a SwapProof given the blinding factor of the token
+type SwapProver struct {
+	*SwapVerifier
+	BlindingFactor *bn256.Zr
+}
+
+func NewSwapVerifier(tok *bn256.G1, ttype string, quantity uint64, pp []*bn256.G1) *SwapVerifier {
+	return &SwapVerifier{Token: tok, Type: ttype, Quantity: quantity, PedParams: pp}
+}
+
+func NewSwapProver(tok *bn256.G1, ttype string, quantity uint64, bf *bn256.Zr, pp []*bn256.G1) *SwapProver {
+	return &SwapProver{SwapVerifier: NewSwapVerifier(tok, ttype, quantity, pp), BlindingFactor: bf}
+}
+
+// Prove proves knowledge of the blinding factor of the token once type and quantity are removed
+func (p *SwapProver) Prove() ([]byte, error) {
+	if len(p.PedParams) != 3 {
+		return nil, errors.Errorf("invalid pedersen parameters, expected [3], got [%d]", len(p.PedParams))
+	}
+	rand, err := bn256.GetRand()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get random number generator")
+	}
+	randomness := bn256.RandModOrder(rand)
+	com := p.PedParams[2].Mul(randomness)
+
+	proof := &SwapProof{Challenge: p.challenge(com)}
+	sp := &common.SchnorrProver{Witness: []*bn256.Zr{p.BlindingFactor}, Randomness: []*bn256.Zr{randomness}, Challenge: proof.Challenge}
+	responses, err := sp.Prove()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compute swap proof")
+	}
+	proof.BlindingFactor = responses[0]
+	return proof.Serialize()
+}
+
+// Verify checks that the passed proof shows the token carries the declared type and quantity
+func (v *SwapVerifier) Verify(raw []byte) error {
+	if len(v.PedParams) != 3 {
+		return errors.Errorf("invalid pedersen parameters, expected [3], got [%d]", len(v.PedParams))
+	}
+	proof := &SwapProof{}
+	if err := proof.Deserialize(raw); err != nil {
+		return errors.Wrap(err, "failed to deserialize swap proof")
+	}
+	if proof.Challenge == nil || proof.BlindingFactor == nil {
+		return errors.New("invalid swap proof")
+	}
+	sv := &common.SchnorrVerifier{PedParams: v.PedParams[2:]}
+	com := sv.RecomputeCommitment(&common.SchnorrProof{
+		Statement: v.statement(),
+		Proof:     []*bn256.Zr{proof.BlindingFactor},
+		Challenge: proof.Challenge,
+	})
+	if v.challenge(com).Cmp(proof.Challenge) != 0 {
+		return errors.Errorf("token does not carry [%d] of type [%s]", v.Quantity, v.Type)
+	}
+	return nil
+}
+
+// statement returns the token without its type and quantity, that is a commitment to the blinding factor alone
+func (v *SwapVerifier) statement() *bn256.G1 {
+	statement := bn256.NewG1().Copy(v.Token)
+	statement.Sub(v.PedParams[0].Mul(bn256.HashModOrder([]byte(v.Type))))
+	statement.Sub(v.PedParams[1].Mul(bn256.NewZrInt(0).SetUint64(v.Quantity)))
+	return statement
+}
+
+func (v *SwapVerifier) challenge(com *bn256.G1) *bn256.Zr {
+	raw := common.GetG1Array(v.PedParams, []*bn256.G1{v.Token, com}).Bytes()
+	raw = append(raw, []byte(v.Type)...)
+	raw = append(raw, bn256.NewZrInt(0).SetUint64(v.Quantity).Bytes()...)
+	return bn256.HashModOrder(raw)
+}
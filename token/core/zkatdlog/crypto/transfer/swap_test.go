@@ -0,0 +1,59 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package transfer_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
+	transfer2 "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer"
+)
+
+var _ = Describe("Swap proof", func() {
+	var (
+		pp    *crypto.PublicParams
+		bf    *bn256.Zr
+		tok   *bn256.G1
+		proof []byte
+	)
+	BeforeEach(func() {
+		var err error
+		pp, err = crypto.Setup(100, 2, nil)
+		Expect(err).NotTo(HaveOccurred())
+		rand, err := bn256.GetRand()
+		Expect(err).NotTo(HaveOccurred())
+		bf = bn256.RandModOrder(rand)
+		tok, err = common.ComputePedersenCommitment([]*bn256.Zr{bn256.HashModOrder([]byte("ABC")), bn256.NewZrInt(65), bf}, pp.ZKATPedParams)
+		Expect(err).NotTo(HaveOccurred())
+		proof, err = transfer2.NewSwapProver(tok, "ABC", 65, bf, pp.ZKATPedParams).Prove()
+		Expect(err).NotTo(HaveOccurred())
+	})
+	It("succeeds for the declared type and quantity", func() {
+		Expect(transfer2.NewSwapVerifier(tok, "ABC", 65, pp.ZKATPedParams).Verify(proof)).To(Succeed())
+	})
+	Context("when the quantity does not match", func() {
+		It("fails", func() {
+			err := transfer2.NewSwapVerifier(tok, "ABC", 64, pp.ZKATPedParams).Verify(proof)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("token does not carry [64] of type [ABC]"))
+		})
+	})
+	Context("when the type does not match", func() {
+		It("fails", func() {
+			Expect(transfer2.NewSwapVerifier(tok, "DEF", 65, pp.ZKATPedParams).Verify(proof)).NotTo(Succeed())
+		})
+	})
+	Context("when the prover does not know the blinding factor", func() {
+		It("fails", func() {
+			forged, err := transfer2.NewSwapProver(tok, "ABC", 65, bn256.NewZrInt(0), pp.ZKATPedParams).Prove()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(transfer2.NewSwapVerifier(tok, "ABC", 65, pp.ZKATPedParams).Verify(forged)).NotTo(Succeed())
+		})
+	})
+})
@@ -13,6 +13,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
 	rangeproof "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/range"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
 	"github.com/pkg/errors"
 )
 
@@ -52,12 +53,26 @@ func NewVerifier(inputs, outputs []*bn256.G1, pp *crypto.PublicParams) *Verifier
 	return v
 }
 
+// Serialize returns the compact binary encoding of the proof
 func (p *Proof) Serialize() ([]byte, error) {
-	return json.Marshal(p)
+	w := encoding.NewWriter(encoding.Version1)
+	w.WriteBytes(p.WellFormedness)
+	w.WriteBytes(p.RangeCorrectness)
+	return w.Bytes(), nil
 }
 
+// Deserialize accepts both the compact binary encoding and the legacy JSON one
 func (p *Proof) Deserialize(bytes []byte) error {
-	return json.Unmarshal(bytes, p)
+	if !encoding.IsBinary(bytes) {
+		return json.Unmarshal(bytes, p)
+	}
+	r, err := encoding.NewReader(bytes)
+	if err != nil {
+		return err
+	}
+	p.WellFormedness = r.ReadBytes()
+	p.RangeCorrectness = r.ReadBytes()
+	return r.Close()
 }
 
 func (p *Prover) Prove() ([]byte, error) {
@@ -6,8 +6,13 @@ SPDX-License-Identifier: Apache-2.0
 package transfer_test
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	rangeproof "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/range"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/sigproof"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer"
 	. "github.com/onsi/ginkgo"
@@ -57,9 +62,55 @@ var _ = Describe("Transfer", func() {
 			})
 		})
 	})
-
+	Describe("Serialization", func() {
+		Context("proof is in the compact binary encoding", func() {
+			It("is smaller than the legacy JSON encoding", func() {
+				proof, err := prover.Prove()
+				Expect(err).NotTo(HaveOccurred())
+				legacy := toLegacyProof(proof)
+				fmt.Printf("transfer proof size: binary [%d], json [%d]\n", len(proof), len(legacy))
+				Expect(len(proof)).To(BeNumerically("<", len(legacy)/2))
+			})
+		})
+		Context("proof is in the legacy JSON encoding", func() {
+			It("is still accepted by the verifier", func() {
+				proof, err := prover.Prove()
+				Expect(err).NotTo(HaveOccurred())
+				err = verifier.Verify(toLegacyProof(proof))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
 })
 
+// toLegacyProof re-encodes the passed transfer proof, and all its nested proofs, in JSON
+func toLegacyProof(raw []byte) []byte {
+	proof := &transfer.Proof{}
+	Expect(proof.Deserialize(raw)).To(Succeed())
+
+	wf := &transfer.WellFormedness{}
+	Expect(wf.Deserialize(proof.WellFormedness)).To(Succeed())
+	wfRaw, err := json.Marshal(wf)
+	Expect(err).NotTo(HaveOccurred())
+
+	rp := &rangeproof.Proof{}
+	Expect(rp.Deserialize(proof.RangeCorrectness)).To(Succeed())
+	for _, mp := range rp.MembershipProofs {
+		for i, sp := range mp.SignatureProofs {
+			sigProof := &sigproof.MembershipProof{}
+			Expect(sigProof.Deserialize(sp)).To(Succeed())
+			mp.SignatureProofs[i], err = json.Marshal(sigProof)
+			Expect(err).NotTo(HaveOccurred())
+		}
+	}
+	rpRaw, err := json.Marshal(rp)
+	Expect(err).NotTo(HaveOccurred())
+
+	legacy, err := json.Marshal(&transfer.Proof{WellFormedness: wfRaw, RangeCorrectness: rpRaw})
+	Expect(err).NotTo(HaveOccurred())
+	return legacy
+}
+
 func prepareZKTransfer() (*transfer.Prover, *transfer.Verifier) {
 	pp, err := crypto.Setup(100, 2, nil)
 	Expect(err).NotTo(HaveOccurred())
@@ -11,6 +11,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
 	crypto "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/encoding"
 	"github.com/pkg/errors"
 )
 
@@ -27,12 +28,36 @@ type WellFormedness struct {
 	Challenge             *bn256.Zr
 }
 
+// Serialize returns the compact binary encoding of the proof
 func (wf *WellFormedness) Serialize() ([]byte, error) {
-	return json.Marshal(wf)
+	w := encoding.NewWriter(encoding.Version1)
+	crypto.WriteZrArray(w, wf.InputBlindingFactors)
+	crypto.WriteZrArray(w, wf.OutputBlindingFactors)
+	crypto.WriteZrArray(w, wf.InputValues)
+	crypto.WriteZrArray(w, wf.OutputValues)
+	crypto.WriteZr(w, wf.Type)
+	crypto.WriteZr(w, wf.Sum)
+	crypto.WriteZr(w, wf.Challenge)
+	return w.Bytes(), nil
 }
 
+// Deserialize accepts both the compact binary encoding and the legacy JSON one
 func (wf *WellFormedness) Deserialize(bytes []byte) error {
-	return json.Unmarshal(bytes, wf)
+	if !encoding.IsBinary(bytes) {
+		return json.Unmarshal(bytes, wf)
+	}
+	r, err := encoding.NewReader(bytes)
+	if err != nil {
+		return err
+	}
+	wf.InputBlindingFactors = crypto.ReadZrArray(r)
+	wf.OutputBlindingFactors = crypto.ReadZrArray(r)
+	wf.InputValues = crypto.ReadZrArray(r)
+	wf.OutputValues = crypto.ReadZrArray(r)
+	wf.Type = crypto.ReadZr(r)
+	wf.Sum = crypto.ReadZr(r)
+	wf.Challenge = crypto.ReadZr(r)
+	return r.Close()
 }
 
 // inputs and outputs witness for zkat proof
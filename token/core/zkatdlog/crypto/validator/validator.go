@@ -6,7 +6,7 @@ SPDX-License-Identifier: Apache-2.0
 package validator
 
 import (
-	"encoding/json"
+	"time"
 
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
 	"github.com/pkg/errors"
@@ -16,13 +16,17 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/htlc"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/multisig"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/certification"
 	issue2 "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue/anonym"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
 )
 
 var logger = flogging.MustGetLogger("token-sdk.zkatdlog")
@@ -35,22 +39,18 @@ func New(pp *crypto.PublicParams) *Validator {
 	return &Validator{pp: pp}
 }
 
-func (v *Validator) VerifyTokenRequestFromRaw(getState api.GetStateFnc, binding string, raw []byte) ([]interface{}, error) {
+func (v *Validator) VerifyTokenRequestFromRaw(getState api.GetStateFnc, binding string, txTime time.Time, raw []byte) ([]interface{}, error) {
 	if len(raw) == 0 {
 		return nil, errors.New("empty token request")
 	}
 	tr := &api.TokenRequest{}
-	err := json.Unmarshal(raw, tr)
+	err := tr.FromBytes(raw)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to unmarshal token request")
 	}
 
 	// Prepare message expected to be signed
-	// TODO: encapsulate this somewhere
-	req := &api.TokenRequest{}
-	req.Transfers = tr.Transfers
-	req.Issues = tr.Issues
-	bytes, err := json.Marshal(req)
+	bytes, err := tr.MessageToSign()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to marshal signed token request"+err.Error())
 	}
@@ -70,10 +70,10 @@ func (v *Validator) VerifyTokenRequestFromRaw(getState api.GetStateFnc, binding
 		message:    signed,
 		signatures: signatures,
 	}
-	return v.VerifyTokenRequest(backend, backend, binding, tr)
+	return v.VerifyTokenRequest(backend, backend, binding, txTime, tr)
 }
 
-func (v *Validator) VerifyTokenRequest(ledger api.Ledger, signatureProvider api.SignatureProvider, binding string, tr *api.TokenRequest) ([]interface{}, error) {
+func (v *Validator) VerifyTokenRequest(ledger api.Ledger, signatureProvider api.SignatureProvider, binding string, txTime time.Time, tr *api.TokenRequest) ([]interface{}, error) {
 	if err := v.verifyAuditorSignature(signatureProvider); err != nil {
 		return nil, errors.Wrapf(err, "failed to verifier auditor's signature [%s]", binding)
 	}
@@ -89,10 +89,14 @@ func (v *Validator) VerifyTokenRequest(ledger api.Ledger, signatureProvider api.
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to verify issuers' signatures [%s]", binding)
 	}
-	err = v.verifyTransfers(ledger, ta, signatureProvider)
+	err = v.verifyTransfers(ledger, ta, signatureProvider, txTime)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to verify senders' signatures [%s]", binding)
 	}
+	err = v.verifySwaps(tr.Swaps, ta)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to verify swaps [%s]", binding)
+	}
 
 	var actions []interface{}
 	for _, action := range ia {
@@ -105,6 +109,30 @@ func (v *Validator) VerifyTokenRequest(ledger api.Ledger, signatureProvider api.
 	return actions, nil
 }
 
+// Owner returns the owner of the passed ledger token
+func (v *Validator) Owner(raw []byte) (view.Identity, error) {
+	tok := &token.Token{}
+	if err := tok.Deserialize(raw); err != nil {
+		return nil, errors.Wrap(err, "failed to deserialize token")
+	}
+	return tok.Owner, nil
+}
+
+// VerifySignature checks that sigma is a signature of the passed owner on message
+func (v *Validator) VerifySignature(owner view.Identity, message, sigma []byte) error {
+	identityDeserializer, err := idemix2.NewDeserializer(v.pp.IdemixPK)
+	if err != nil {
+		return errors.Wrap(err, "failed instantiating deserializer")
+	}
+	verifier, err := multisig.GetVerifier(owner, func(id view.Identity) (api.Verifier, error) {
+		return identityDeserializer.DeserializeVerifier(id)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed getting verifier for [%s]", owner.UniqueID())
+	}
+	return verifier.Verify(message, sigma)
+}
+
 func (v *Validator) unmarshalTransferActions(raw [][]byte) ([]api.TransferAction, error) {
 	res := make([]api.TransferAction, len(raw))
 	for i := 0; i < len(raw); i++ {
@@ -143,12 +171,19 @@ func (v *Validator) verifyAuditorSignature(signatureProvider api.SignatureProvid
 }
 
 func (v *Validator) verifyIssues(issues []api.IssueAction, signatureProvider api.SignatureProvider) error {
-	for _, issue := range issues {
+	for i, issue := range issues {
 		a := issue.(*issue2.IssueAction)
 
 		if err := v.verifyIssue(a); err != nil {
 			return errors.Wrapf(err, "failed to verify issue action")
 		}
+		var outputOwners [][]byte
+		for _, output := range a.OutputTokens {
+			outputOwners = append(outputOwners, output.Owner)
+		}
+		if err := multisig.VerifyOwners(outputOwners); err != nil {
+			return errors.WithMessagef(err, "failed to verify multisig owners of issue [%d]", i)
+		}
 
 		if a.Anonymous {
 			verifier := &anonym.Verifier{}
@@ -178,16 +213,22 @@ func (v *Validator) verifyIssues(issues []api.IssueAction, signatureProvider api
 	return nil
 }
 
-func (v *Validator) verifyTransfers(ledger api.Ledger, transferActions []api.TransferAction, signatureProvider api.SignatureProvider) error {
+func (v *Validator) verifyTransfers(ledger api.Ledger, transferActions []api.TransferAction, signatureProvider api.SignatureProvider, txTime time.Time) error {
 	identityDeserializer, err := idemix2.NewDeserializer(v.pp.IdemixPK)
 	if err != nil {
 		return errors.Wrap(err, "failed instantiating deserializer")
 	}
-
+	getVerifier := func(id view.Identity) (api.Verifier, error) {
+		return multisig.GetVerifier(id, func(id view.Identity) (api.Verifier, error) {
+			return identityDeserializer.DeserializeVerifier(id)
+		})
+	}
 	logger.Debugf("check sender start...")
 	defer logger.Debugf("check sender finished.")
 	for i, t := range transferActions {
 		var inputTokens [][]byte
+		var inputOwners [][]byte
+		var settlement *api.Dispute
 		inputs, err := t.GetInputs()
 		if err != nil {
 			errors.Wrapf(err, "failed to retrieve inputs to spend")
@@ -208,7 +249,16 @@ func (v *Validator) verifyTransfers(ledger api.Ledger, transferActions []api.Tra
 				return errors.Wrapf(err, "failed to deserialize input to spend [%s]", in)
 			}
 			logger.Debugf("check sender [%d][%s]", i, view.Identity(tok.Owner).UniqueID())
-			verifier, err := identityDeserializer.DeserializeVerifier(tok.Owner)
+			inputOwners = append(inputOwners, tok.Owner)
+			if settlement, err = multisig.DepositDispute(ledger.GetState, inputs, in, tok.Owner); err != nil {
+				return errors.WithMessagef(err, "invalid transfer [%d]", i)
+			}
+			var verifier api.Verifier
+			if settlement != nil {
+				verifier, err = multisig.SettlementVerifier(tok.Owner, getVerifier)
+			} else {
+				verifier, err = htlc.GetVerifier(tok.Owner, txTime, getVerifier)
+			}
 			if err != nil {
 				return errors.Wrapf(err, "failed deserializing owner [%d][%s][%s]", i, in, view.Identity(tok.Owner).UniqueID())
 			}
@@ -217,7 +267,22 @@ func (v *Validator) verifyTransfers(ledger api.Ledger, transferActions []api.Tra
 				return errors.Wrapf(err, "failed signature verification [%d][%s][%s]", i, in, view.Identity(tok.Owner).UniqueID())
 			}
 		}
-		if err := v.verifyTransfer(inputTokens, t); err != nil {
+		var outputOwners [][]byte
+		for _, output := range t.(*transfer.TransferAction).OutputTokens {
+			outputOwners = append(outputOwners, output.Owner)
+		}
+		if err := htlc.VerifyOwners(inputOwners, outputOwners, txTime); err != nil {
+			return errors.WithMessagef(err, "failed to verify htlc constraints of transfer [%d]", i)
+		}
+		if err := multisig.VerifyOwners(outputOwners); err != nil {
+			return errors.WithMessagef(err, "failed to verify multisig owners of transfer [%d]", i)
+		}
+		if settlement != nil {
+			if err := v.verifySettlement(settlement, t.(*transfer.TransferAction)); err != nil {
+				return errors.WithMessagef(err, "invalid transfer [%d]", i)
+			}
+		}
+		if err := v.verifyTransfer(ledger, inputs, inputTokens, t, txTime); err != nil {
 			return errors.Wrapf(err, "failed to verify transfer action")
 		}
 	}
@@ -233,7 +298,7 @@ func (v *Validator) verifyIssue(issue api.IssueAction) error {
 		v.pp).Verify(action.GetProof())
 }
 
-func (v *Validator) verifyTransfer(inputTokens [][]byte, tr api.TransferAction) error {
+func (v *Validator) verifyTransfer(ledger api.Ledger, inputs []string, inputTokens [][]byte, tr api.TransferAction, txTime time.Time) error {
 	action := tr.(*transfer.TransferAction)
 
 	in := make([]*bn256.G1, len(inputTokens))
@@ -245,10 +310,101 @@ func (v *Validator) verifyTransfer(inputTokens [][]byte, tr api.TransferAction)
 		in[i] = tok.GetCommitment()
 	}
 
-	return transfer.NewVerifier(
+	if err := transfer.NewVerifier(
 		in,
 		action.GetOutputCommitments(),
-		v.pp).Verify(action.GetProof())
+		v.pp).Verify(action.GetProof()); err != nil {
+		return err
+	}
+
+	return v.verifyCertifications(ledger, inputs, in, action, txTime)
+}
+
+// verifyCertifications checks that all inputs have been certified, if a certifier is set.
+// Certifications must neither be expired at the passed time nor revoked on the ledger. The passed time is the
+// timestamp of the transaction, the caller bounds it by the local clock (see token.CheckTxTime).
+func (v *Validator) verifyCertifications(ledger api.Ledger, inputs []string, in []*bn256.G1, action *transfer.TransferAction, txTime time.Time) error {
+	if len(v.pp.Certifier) == 0 {
+		return nil
+	}
+	if len(action.InputCertifications) != len(in) || len(inputs) != len(in) {
+		return errors.Errorf("invalid transfer: expected [%d] input certifications, got [%d]", len(in), len(action.InputCertifications))
+	}
+	for i, commitment := range in {
+		id, err := keys.GetTokenIdFromKey(inputs[i])
+		if err != nil {
+			return errors.Wrapf(err, "invalid transfer: failed parsing input [%s]", inputs[i])
+		}
+		key, err := keys.CreateRevokedCertificationKey(id.TxId, int(id.Index))
+		if err != nil {
+			return errors.Wrapf(err, "invalid transfer: failed creating revocation key for input [%d]", i)
+		}
+		revoked, err := ledger.GetState(key)
+		if err != nil {
+			return errors.Wrapf(err, "invalid transfer: failed checking revocation of input [%d]", i)
+		}
+		if len(revoked) != 0 {
+			return errors.Errorf("invalid transfer: the certification of input [%d] has been revoked", i)
+		}
+		verifier, err := certification.NewVerifier(commitment, v.pp)
+		if err != nil {
+			return errors.Wrap(err, "invalid transfer: failed instantiating certification verifier")
+		}
+		if err := verifier.Verify(action.InputCertifications[i], txTime); err != nil {
+			return errors.Wrapf(err, "invalid transfer: input [%d] is not certified", i)
+		}
+	}
+	return nil
+}
+
+// verifySettlement checks that the passed transfer releases the deposit of a closed dispute to the payouts of its state.
+// Each output of a settlement carries a proof that it commits to the value and type of its payout.
+func (v *Validator) verifySettlement(dispute *api.Dispute, action *transfer.TransferAction) error {
+	payouts := dispute.Payouts()
+	if action.NumOutputs() != len(payouts) {
+		return errors.Errorf("the settlement of channel [%s] must have [%d] outputs, got [%d]", dispute.State.ChannelID, len(payouts), action.NumOutputs())
+	}
+	if len(action.OutputProofs) != len(payouts) {
+		return errors.Errorf("the settlement of channel [%s] must carry [%d] output proofs, got [%d]", dispute.State.ChannelID, len(payouts), len(action.OutputProofs))
+	}
+	for i, payout := range payouts {
+		if action.IsRedeemAt(i) || !payout.Owner.Equal(action.OutputTokens[i].Owner) {
+			return errors.Errorf("output [%d] of the settlement of channel [%s] does not pay party [%s]", i, dispute.State.ChannelID, payout.Owner.UniqueID())
+		}
+		verifier := transfer.NewSwapVerifier(action.OutputTokens[i].Data, payout.Type, payout.Value, v.pp.ZKATPedParams)
+		if err := verifier.Verify(action.OutputProofs[i]); err != nil {
+			return errors.Wrapf(err, "output [%d] of the settlement of channel [%s] does not carry [%d] of type [%s]", i, dispute.State.ChannelID, payout.Value, payout.Type)
+		}
+	}
+	return nil
+}
+
+// verifySwaps checks that the declared swap terms match the output commitments of the transfer actions.
+// The proof of each leg shows, in zero-knowledge, that the corresponding output carries the declared type and quantity.
+func (v *Validator) verifySwaps(swaps [][]byte, transferActions []api.TransferAction) error {
+	for i, raw := range swaps {
+		swap := &api.Swap{}
+		if err := swap.Deserialize(raw); err != nil {
+			return errors.Wrapf(err, "failed to deserialize swap [%d]", i)
+		}
+		if err := swap.Validate(len(transferActions)); err != nil {
+			return errors.WithMessagef(err, "invalid swap [%d]", i)
+		}
+		for _, leg := range []*api.SwapLeg{swap.Give, swap.Want} {
+			action := transferActions[leg.Transfer].(*transfer.TransferAction)
+			if leg.Output >= action.NumOutputs() || action.IsRedeemAt(leg.Output) {
+				return errors.Errorf("invalid swap [%d]: output [%d] of transfer [%d] is not a valid output", i, leg.Output, leg.Transfer)
+			}
+			if len(leg.Proof) == 0 {
+				return errors.Errorf("invalid swap [%d]: missing proof for [%d:%d]", i, leg.Transfer, leg.Output)
+			}
+			verifier := transfer.NewSwapVerifier(action.OutputTokens[leg.Output].Data, leg.Type, leg.Quantity, v.pp.ZKATPedParams)
+			if err := verifier.Verify(leg.Proof); err != nil {
+				return errors.Wrapf(err, "invalid swap [%d]: output [%d:%d] does not carry [%d] of type [%s]", i, leg.Transfer, leg.Output, leg.Quantity, leg.Type)
+			}
+		}
+	}
+	return nil
 }
 
 type backend struct {
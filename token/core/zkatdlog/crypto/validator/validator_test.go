@@ -23,16 +23,21 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/api"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/multisig"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/audit"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/certification"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/ecdsa"
 	issue2 "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue/anonym"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue/nonanonym"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/pssign"
 	tokn "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer"
 	enginedlog "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/validator"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/validator/mock"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 var fakeldger *mock.Ledger
@@ -148,7 +153,7 @@ var _ = Describe("validator", func() {
 				Expect(err).NotTo(HaveOccurred())
 			})
 			It("succeeds", func() {
-				actions, err := engine.VerifyTokenRequestFromRaw(fakeldger.GetStateStub, "1", raw)
+				actions, err := engine.VerifyTokenRequestFromRaw(fakeldger.GetStateStub, "1", time.Now(), raw)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(len(actions)).To(Equal(1))
 			})
@@ -164,7 +169,7 @@ var _ = Describe("validator", func() {
 				Expect(err).NotTo(HaveOccurred())
 			})
 			It("succeeds", func() {
-				actions, err := engine.VerifyTokenRequestFromRaw(fakeldger.GetStateStub, "1", raw)
+				actions, err := engine.VerifyTokenRequestFromRaw(fakeldger.GetStateStub, "1", time.Now(), raw)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(len(actions)).To(Equal(1))
 			})
@@ -199,11 +204,202 @@ var _ = Describe("validator", func() {
 				Expect(err).NotTo(HaveOccurred())
 			})
 			It("succeeds", func() {
-				actions, err := engine.VerifyTokenRequestFromRaw(getState, "1", raw)
+				actions, err := engine.VerifyTokenRequestFromRaw(getState, "1", time.Now(), raw)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(len(actions)).To(Equal(1))
 			})
 		})
+		Context("validator requires certifications and the transfer action carries none", func() {
+			var (
+				err error
+				raw []byte
+			)
+			BeforeEach(func() {
+				signer, err := certification.GenerateKeyPair()
+				Expect(err).NotTo(HaveOccurred())
+				pp.Certifier, err = signer.SignVerifier.Serialize()
+				Expect(err).NotTo(HaveOccurred())
+				engine = enginedlog.New(pp)
+
+				raw, err = inputsForTransfer[0].Serialize()
+				Expect(err).NotTo(HaveOccurred())
+				fakeldger.GetStateReturnsOnCall(0, raw, nil)
+
+				raw, err = inputsForTransfer[1].Serialize()
+				Expect(err).NotTo(HaveOccurred())
+				fakeldger.GetStateReturnsOnCall(1, raw, nil)
+
+				raw, err = json.Marshal(tr)
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("fails", func() {
+				_, err = engine.VerifyTokenRequestFromRaw(getState, "1", time.Now(), raw)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("expected [2] input certifications, got [0]"))
+			})
+		})
+		Context("validator requires certifications and the transfer action carries them", func() {
+			var (
+				raw       []byte
+				expiresAt time.Time
+				state     map[string][]byte
+			)
+			BeforeEach(func() {
+				signer, err := certification.GenerateKeyPair()
+				Expect(err).NotTo(HaveOccurred())
+				pp.Certifier, err = signer.SignVerifier.Serialize()
+				Expect(err).NotTo(HaveOccurred())
+				engine = enginedlog.New(pp)
+
+				expiresAt = time.Now().Add(time.Hour)
+				var sr *api.TokenRequest
+				sr, state = prepareCertifiedTransferRequest(pp, auditor, signer, expiresAt)
+				fakeldger.GetStateStub = func(key string) ([]byte, error) {
+					return state[key], nil
+				}
+				raw, err = json.Marshal(sr)
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("succeeds", func() {
+				actions, err := engine.VerifyTokenRequestFromRaw(getState, "1", time.Now(), raw)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(actions)).To(Equal(1))
+			})
+			Context("when the certifications expired at the time of the transaction", func() {
+				It("fails", func() {
+					_, err := engine.VerifyTokenRequestFromRaw(getState, "1", expiresAt, raw)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("certification expired"))
+				})
+			})
+			Context("when the certifications expired before the transaction was submitted", func() {
+				BeforeEach(func() {
+					signer, err := certification.GenerateKeyPair()
+					Expect(err).NotTo(HaveOccurred())
+					pp.Certifier, err = signer.SignVerifier.Serialize()
+					Expect(err).NotTo(HaveOccurred())
+					engine = enginedlog.New(pp)
+
+					expiresAt = time.Now().Add(-time.Minute)
+					var sr *api.TokenRequest
+					sr, state = prepareCertifiedTransferRequest(pp, auditor, signer, expiresAt)
+					raw, err = json.Marshal(sr)
+					Expect(err).NotTo(HaveOccurred())
+				})
+				It("fails", func() {
+					_, err := engine.VerifyTokenRequestFromRaw(getState, "1", time.Now(), raw)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("certification expired"))
+				})
+			})
+			Context("when a certification has been revoked", func() {
+				BeforeEach(func() {
+					key, err := keys.CreateRevokedCertificationKey("tx", 1)
+					Expect(err).NotTo(HaveOccurred())
+					state[key] = []byte{1}
+				})
+				It("fails", func() {
+					_, err := engine.VerifyTokenRequestFromRaw(getState, "1", time.Now(), raw)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("the certification of input [1] has been revoked"))
+				})
+			})
+		})
+		Context("validator is called with an issue action minting a multisig owner nested too deep", func() {
+			var raw []byte
+			BeforeEach(func() {
+				alice, _, _ := getIdemixInfo("./testdata/idemix")
+				bob, _, _ := getIdemixInfo("./testdata/idemix")
+				owner := []byte(bob)
+				for i := 0; i <= multisig.MaxDepth; i++ {
+					nested, err := (&multisig.Identity{Threshold: 1, Identities: []view.Identity{alice, owner}}).Serialize()
+					Expect(err).NotTo(HaveOccurred())
+					owner = nested
+				}
+				var err error
+				raw, err = json.Marshal(prepareIssueTo(pp, auditor, owner))
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("fails", func() {
+				_, err := engine.VerifyTokenRequestFromRaw(fakeldger.GetStateStub, "1", time.Now(), raw)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("failed to verify multisig owners of issue [0]"))
+			})
+		})
+		Context("validator is called with the payout of a disputed deposit", func() {
+			var (
+				raw     []byte
+				state   map[string][]byte
+				dispute *api.Dispute
+			)
+			BeforeEach(func() {
+				var sr *api.TokenRequest
+				sr, state, dispute = prepareSettlementRequest(pp, auditor, nil)
+				fakeldger.GetStateStub = func(key string) ([]byte, error) {
+					return state[key], nil
+				}
+				var err error
+				raw, err = json.Marshal(sr)
+				Expect(err).NotTo(HaveOccurred())
+			})
+			JustBeforeEach(func() {
+				key, err := keys.CreateDisputeKey("channel")
+				Expect(err).NotTo(HaveOccurred())
+				state[key], err = json.Marshal(dispute)
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("succeeds with the signature of one party once the dispute is closed", func() {
+				actions, err := engine.VerifyTokenRequestFromRaw(getState, "1", time.Now(), raw)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(actions)).To(Equal(1))
+			})
+			Context("when the dispute is open", func() {
+				BeforeEach(func() {
+					dispute.Closed = false
+				})
+				It("fails", func() {
+					_, err := engine.VerifyTokenRequestFromRaw(getState, "1", time.Now(), raw)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("whose dispute is open"))
+				})
+			})
+			Context("when the outputs do not match the disputed balances", func() {
+				BeforeEach(func() {
+					dispute.State.Balances = []map[string]uint64{{"ABC": 60}, {"ABC": 40}}
+				})
+				It("fails", func() {
+					_, err := engine.VerifyTokenRequestFromRaw(getState, "1", time.Now(), raw)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("does not carry [60] of type [ABC]"))
+				})
+			})
+			Context("when the outputs do not carry their proofs", func() {
+				BeforeEach(func() {
+					var sr *api.TokenRequest
+					sr, state, dispute = prepareSettlementRequest(pp, auditor, func(action *transfer.TransferAction) {
+						action.OutputProofs = nil
+					})
+					var err error
+					raw, err = json.Marshal(sr)
+					Expect(err).NotTo(HaveOccurred())
+				})
+				It("fails", func() {
+					_, err := engine.VerifyTokenRequestFromRaw(getState, "1", time.Now(), raw)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("must carry [2] output proofs, got [0]"))
+				})
+			})
+			Context("when the deposit is not disputed", func() {
+				BeforeEach(func() {
+					dispute.Deposit = &token2.Id{TxId: "channel", Index: 1}
+				})
+				It("fails without the signatures of both parties", func() {
+					_, err := engine.VerifyTokenRequestFromRaw(getState, "1", time.Now(), raw)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("not enough signatures"))
+				})
+			})
+		})
 		Context("validator is called correctly with a redeem action", func() {
 			var (
 				err error
@@ -234,7 +430,7 @@ var _ = Describe("validator", func() {
 
 			})
 			It("succeeds", func() {
-				actions, err := engine.VerifyTokenRequestFromRaw(getState, "1", raw)
+				actions, err := engine.VerifyTokenRequestFromRaw(getState, "1", time.Now(), raw)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(len(actions)).To(Equal(1))
 			})
@@ -271,7 +467,7 @@ var _ = Describe("validator", func() {
 
 			})
 			It("succeeds", func() {
-				actions, err := engine.VerifyTokenRequestFromRaw(getState, "2", raw)
+				actions, err := engine.VerifyTokenRequestFromRaw(getState, "2", time.Now(), raw)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(len(actions)).To(Equal(2))
 			})
@@ -286,7 +482,7 @@ var _ = Describe("validator", func() {
 					Expect(err).NotTo(HaveOccurred())
 				})
 				It("fails", func() {
-					_, err := engine.VerifyTokenRequestFromRaw(getState, "2", raw)
+					_, err := engine.VerifyTokenRequestFromRaw(getState, "2", time.Now(), raw)
 					Expect(err.Error()).To(ContainSubstring("failed to verify issuers' signatures"))
 				})
 			})
@@ -305,12 +501,134 @@ var _ = Describe("validator", func() {
 
 				})
 				It("fails", func() {
-					_, err := engine.VerifyTokenRequestFromRaw(getState, "2", raw)
+					_, err := engine.VerifyTokenRequestFromRaw(getState, "2", time.Now(), raw)
 					Expect(err.Error()).To(ContainSubstring("pseudonym signature invalid"))
 
 				})
 			})
 		})
+		Context("validator is called with a declared swap", func() {
+			var (
+				err  error
+				raw  []byte
+				swap *api.Swap
+				ins  []*tokn.Token
+				sr   *api.TokenRequest
+			)
+			BeforeEach(func() {
+				var md *api.TokenRequestMetadata
+				sender, sr, md, ins = prepareTransferRequest(pp, auditor)
+				ti := &tokn.TokenInformation{}
+				Expect(ti.Deserialize(md.Transfers[0].TokenInfo[0])).NotTo(HaveOccurred())
+				action := &transfer.TransferAction{}
+				Expect(action.Deserialize(sr.Transfers[0])).NotTo(HaveOccurred())
+				proof, err := transfer.NewSwapProver(action.OutputTokens[0].Data, "ABC", 65, ti.BlindingFactor, pp.ZKATPedParams).Prove()
+				Expect(err).NotTo(HaveOccurred())
+
+				// both legs refer to the same kind of output, each in its own transfer action
+				swap = &api.Swap{
+					Give: &api.SwapLeg{Transfer: 0, Output: 0, Type: "ABC", Quantity: 65, Proof: proof},
+					Want: &api.SwapLeg{Transfer: 1, Output: 0, Type: "ABC", Quantity: 65, Proof: proof},
+				}
+			})
+			JustBeforeEach(func() {
+				for i := 0; i < 4; i++ {
+					raw, err = ins[i%2].Serialize()
+					Expect(err).NotTo(HaveOccurred())
+					fakeldger.GetStateReturnsOnCall(i, raw, nil)
+				}
+
+				rawSwap, err := swap.Serialize()
+				Expect(err).NotTo(HaveOccurred())
+				sr = &api.TokenRequest{Transfers: [][]byte{sr.Transfers[0], sr.Transfers[0]}, Swaps: [][]byte{rawSwap}}
+				msg, err := sr.MessageToSign()
+				Expect(err).NotTo(HaveOccurred())
+				for i := 0; i < 2; i++ {
+					signatures, err := sender.SignTokenActions(msg, "1")
+					Expect(err).NotTo(HaveOccurred())
+					sr.Signatures = append(sr.Signatures, signatures...)
+				}
+				sr.AuditorSignature, err = auditor.Endorse(sr, "1")
+				Expect(err).NotTo(HaveOccurred())
+
+				raw, err = json.Marshal(sr)
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("succeeds", func() {
+				actions, err := engine.VerifyTokenRequestFromRaw(getState, "1", time.Now(), raw)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(actions)).To(Equal(2))
+			})
+			Context("when the declared quantity does not match the output", func() {
+				BeforeEach(func() {
+					swap.Want.Quantity = 35
+				})
+				It("fails", func() {
+					_, err := engine.VerifyTokenRequestFromRaw(getState, "1", time.Now(), raw)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("does not carry [35] of type [ABC]"))
+				})
+			})
+			Context("when both legs are in the same transfer action", func() {
+				BeforeEach(func() {
+					swap.Want.Transfer = 0
+				})
+				It("fails", func() {
+					_, err := engine.VerifyTokenRequestFromRaw(getState, "1", time.Now(), raw)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("legs must be in distinct transfer actions"))
+				})
+			})
+		})
+		Context("validator is called with memos", func() {
+			var (
+				err  error
+				raw  []byte
+				memo []byte
+				ins  []*tokn.Token
+				sr   *api.TokenRequest
+			)
+			BeforeEach(func() {
+				sender, sr, _, ins = prepareTransferRequest(pp, auditor)
+				content := &api.MemoMetadata{Key: "invoice", Value: []byte("42"), Salt: []byte("salt")}
+				memo, err = (&api.Memo{Target: api.TransferMemo, Action: 0, Output: 0, Hash: content.Hash()}).Serialize()
+				Expect(err).NotTo(HaveOccurred())
+				for i := 0; i < 2; i++ {
+					raw, err = ins[i].Serialize()
+					Expect(err).NotTo(HaveOccurred())
+					fakeldger.GetStateReturnsOnCall(i, raw, nil)
+				}
+
+				sr = &api.TokenRequest{Transfers: sr.Transfers, Memos: [][]byte{memo}}
+				msg, err := sr.MessageToSign()
+				Expect(err).NotTo(HaveOccurred())
+				sr.Signatures, err = sender.SignTokenActions(msg, "1")
+				Expect(err).NotTo(HaveOccurred())
+				sr.AuditorSignature, err = auditor.Endorse(sr, "1")
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("succeeds", func() {
+				raw, err = sr.Bytes()
+				Expect(err).NotTo(HaveOccurred())
+				actions, err := engine.VerifyTokenRequestFromRaw(getState, "1", time.Now(), raw)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(actions)).To(Equal(1))
+			})
+			Context("when a memo is replaced after signing", func() {
+				BeforeEach(func() {
+					content := &api.MemoMetadata{Key: "invoice", Value: []byte("43"), Salt: []byte("salt")}
+					memo, err = (&api.Memo{Target: api.TransferMemo, Action: 0, Output: 0, Hash: content.Hash()}).Serialize()
+					Expect(err).NotTo(HaveOccurred())
+					sr.Memos = [][]byte{memo}
+				})
+				It("fails", func() {
+					raw, err = sr.Bytes()
+					Expect(err).NotTo(HaveOccurred())
+					_, err := engine.VerifyTokenRequestFromRaw(getState, "1", time.Now(), raw)
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
 	})
 })
 
@@ -331,6 +649,29 @@ func prepareNonAnonymousIssueRequest(pp *crypto.PublicParams, auditor *audit.Aud
 	return issuer, ir, metadata
 }
 
+// prepareIssueTo returns a non-anonymous issue request, endorsed by the auditor, of a token owned by the passed owner
+func prepareIssueTo(pp *crypto.PublicParams, auditor *audit.Auditor, owner []byte) *api.TokenRequest {
+	signer, err := ecdsa.NewECDSASigner()
+	Expect(err).NotTo(HaveOccurred())
+	issuer := &nonanonym.Issuer{}
+	issuer.New("ABC", signer, pp)
+
+	issue, _, err := issuer.GenerateZKIssue([]uint64{40}, [][]byte{owner})
+	Expect(err).NotTo(HaveOccurred())
+	raw, err := issue.Serialize()
+	Expect(err).NotTo(HaveOccurred())
+
+	ir := &api.TokenRequest{Issues: [][]byte{raw}}
+	raw, err = json.Marshal(ir)
+	Expect(err).NotTo(HaveOccurred())
+	sig, err := issuer.SignTokenActions(raw, "1")
+	Expect(err).NotTo(HaveOccurred())
+	ir.Signatures = append(ir.Signatures, sig)
+	ir.AuditorSignature, err = auditor.Endorse(ir, "1")
+	Expect(err).NotTo(HaveOccurred())
+	return ir
+}
+
 func prepareAnonymousIssueRequest(sk *bn256.Zr, pp *crypto.PublicParams, auditor *audit.Auditor) (*anonym.Issuer, *api.TokenRequest, *api.TokenRequestMetadata) {
 	witness := anonym.NewWitness(sk, nil, nil, nil, nil, 1)
 
@@ -607,6 +948,114 @@ func prepareTransfer(pp *crypto.PublicParams, signer api2.SigningIdentity, audit
 	return sender, tr, transferMetadata, tokens
 }
 
+// prepareCertifiedTransferRequest returns a signed transfer whose inputs are certified until expiresAt,
+// and the ledger state holding the inputs
+func prepareCertifiedTransferRequest(pp *crypto.PublicParams, auditor *audit.Auditor, certifier *pssign.Signer, expiresAt time.Time) (*api.TokenRequest, map[string][]byte) {
+	id, _, signer := getIdemixInfo("./testdata/idemix")
+
+	rand, err := bn256.GetRand()
+	Expect(err).NotTo(HaveOccurred())
+	values := []*bn256.Zr{bn256.NewZrInt(70), bn256.NewZrInt(30)}
+	state := map[string][]byte{}
+	ids := make([]string, 2)
+	tokens := make([]*tokn.Token, 2)
+	infos := make([]*tokn.TokenInformation, 2)
+	for i := range tokens {
+		bf := bn256.RandModOrder(rand)
+		tokens[i] = &tokn.Token{Data: prepareToken(values[i], bf, "ABC", pp.ZKATPedParams), Owner: id}
+		infos[i] = &tokn.TokenInformation{Type: "ABC", Value: values[i], BlindingFactor: bf}
+		ids[i], err = keys.CreateTokenKey("tx", i)
+		Expect(err).NotTo(HaveOccurred())
+		state[ids[i]], err = tokens[i].Serialize()
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	requester, err := certification.NewRequester(tokens, infos, pp)
+	Expect(err).NotTo(HaveOccurred())
+	request, err := requester.GenerateRequest()
+	Expect(err).NotTo(HaveOccurred())
+	responses, err := certification.NewCertifier(certifier, pp).Certify(tokens, request, expiresAt)
+	Expect(err).NotTo(HaveOccurred())
+	certifications, err := requester.VerifyResponses(responses)
+	Expect(err).NotTo(HaveOccurred())
+
+	sender, err := transfer.NewSender([]view2.Signer{signer, signer}, tokens, ids, infos, pp)
+	Expect(err).NotTo(HaveOccurred())
+	action, _, err := sender.GenerateZKTransfer([]uint64{65, 35}, [][]byte{id, id})
+	Expect(err).NotTo(HaveOccurred())
+	for i, raw := range certifications {
+		c := &certification.Certification{}
+		Expect(c.Deserialize(raw)).To(Succeed())
+		prover, err := certification.NewProver(c, tokens[i], infos[i], pp)
+		Expect(err).NotTo(HaveOccurred())
+		proof, err := prover.Prove()
+		Expect(err).NotTo(HaveOccurred())
+		action.InputCertifications = append(action.InputCertifications, proof)
+	}
+	raw, err := action.Serialize()
+	Expect(err).NotTo(HaveOccurred())
+
+	tr := &api.TokenRequest{Transfers: [][]byte{raw}}
+	msg, err := tr.MessageToSign()
+	Expect(err).NotTo(HaveOccurred())
+	tr.Signatures, err = sender.SignTokenActions(msg, "1")
+	Expect(err).NotTo(HaveOccurred())
+	tr.AuditorSignature, err = auditor.Endorse(tr, "1")
+	Expect(err).NotTo(HaveOccurred())
+	return tr, state
+}
+
+// prepareSettlementRequest returns a transfer, signed by the first party only, that pays out a deposit owned
+// jointly by two parties, the ledger state holding the deposit, and the closed dispute fixing its payouts.
+// If not nil, tamper is applied to the transfer before it is signed.
+func prepareSettlementRequest(pp *crypto.PublicParams, auditor *audit.Auditor, tamper func(action *transfer.TransferAction)) (*api.TokenRequest, map[string][]byte, *api.Dispute) {
+	alice, _, signer := getIdemixInfo("./testdata/idemix")
+	bob, _, _ := getIdemixInfo("./testdata/idemix")
+	owner, err := (&multisig.Identity{Threshold: 2, Identities: []view.Identity{alice, bob}}).Serialize()
+	Expect(err).NotTo(HaveOccurred())
+
+	rand, err := bn256.GetRand()
+	Expect(err).NotTo(HaveOccurred())
+	bf := bn256.RandModOrder(rand)
+	deposit := &tokn.Token{Data: prepareToken(bn256.NewZrInt(100), bf, "ABC", pp.ZKATPedParams), Owner: owner}
+	info := &tokn.TokenInformation{Type: "ABC", Value: bn256.NewZrInt(100), BlindingFactor: bf}
+	id, err := keys.CreateTokenKey("channel", 0)
+	Expect(err).NotTo(HaveOccurred())
+	state := map[string][]byte{}
+	state[id], err = deposit.Serialize()
+	Expect(err).NotTo(HaveOccurred())
+
+	sender, err := transfer.NewSender([]view2.Signer{&multisig.Signer{Signers: []api.Signer{signer, nil}}}, []*tokn.Token{deposit}, []string{id}, []*tokn.TokenInformation{info}, pp)
+	Expect(err).NotTo(HaveOccurred())
+	action, _, err := sender.GenerateZKSettlement([]uint64{70, 30}, [][]byte{alice, bob})
+	Expect(err).NotTo(HaveOccurred())
+	if tamper != nil {
+		tamper(action)
+	}
+	raw, err := action.Serialize()
+	Expect(err).NotTo(HaveOccurred())
+
+	tr := &api.TokenRequest{Transfers: [][]byte{raw}}
+	msg, err := tr.MessageToSign()
+	Expect(err).NotTo(HaveOccurred())
+	tr.Signatures, err = sender.SignTokenActions(msg, "1")
+	Expect(err).NotTo(HaveOccurred())
+	tr.AuditorSignature, err = auditor.Endorse(tr, "1")
+	Expect(err).NotTo(HaveOccurred())
+
+	dispute := &api.Dispute{
+		Deposit: &token2.Id{TxId: "channel", Index: 0},
+		State: &api.State{
+			ChannelID: "channel",
+			SeqNumber: 3,
+			Parties:   []view.Identity{alice, bob},
+			Balances:  []map[string]uint64{{"ABC": 70}, {"ABC": 30}},
+		},
+		Closed: true,
+	}
+	return tr, state, dispute
+}
+
 func getState(key string) ([]byte, error) {
 	return fakeldger.GetState(key)
 }
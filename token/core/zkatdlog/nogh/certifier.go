@@ -6,18 +6,292 @@ SPDX-License-Identifier: Apache-2.0
 package nogh
 
 import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
 	api3 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/certification"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
 	token3 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
+const (
+	// DefaultCertificationValidity is how long a certification is valid, if not configured otherwise
+	DefaultCertificationValidity = 24 * time.Hour
+	// pendingCertificationTTL is how long the state of a certification request is kept waiting for the certifications
+	pendingCertificationTTL = 10 * time.Minute
+)
+
+// pendingCertification is the state of a certification request waiting for the certifications
+type pendingCertification struct {
+	requester *certification.Requester
+	createdAt time.Time
+}
+
+// NewCertificationRequest returns a request to have the passed tokens blindly certified.
+// The state needed to unblind the certifications is kept until VerifyCertifications is called for the same ids,
+// or for pendingCertificationTTL if VerifyCertifications is never called, e.g. because the request failed.
 func (s *service) NewCertificationRequest(ids []*token3.Id) ([]byte, error) {
-	return nil, nil
+	pp, err := s.certificationPublicParams()
+	if err != nil {
+		return nil, err
+	}
+
+	qe, err := s.channel.Vault().NewQueryExecutor()
+	if err != nil {
+		return nil, err
+	}
+	defer qe.Done()
+
+	var tokens []*token.Token
+	var infos []*token.TokenInformation
+	for _, id := range ids {
+		tok, ti, _, err := s.loadToken(qe, id)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		infos = append(infos, ti)
+	}
+
+	requester, err := certification.NewRequester(tokens, infos, pp)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed creating certification request for [%v]", ids)
+	}
+	request, err := requester.GenerateRequest()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed creating certification request for [%v]", ids)
+	}
+
+	now := time.Now()
+	s.requestersLock.Lock()
+	for k, p := range s.requesters {
+		if now.Sub(p.createdAt) > pendingCertificationTTL {
+			logger.Debugf("dropping certification request [%s], no certifications received in time", k)
+			delete(s.requesters, k)
+		}
+	}
+	s.requesters[certificationKey(ids)] = &pendingCertification{requester: requester, createdAt: now}
+	s.requestersLock.Unlock()
+
+	return request, nil
 }
 
+// Certify signs blindly the passed tokens, as fetched from the ledger, using the secret key of the passed wallet.
+// Tokens whose certification has been revoked are not certified again.
+// Each certification is wrapped in an envelope carrying its validity period, whose expiry is also certified.
 func (s *service) Certify(wallet api3.CertifierWallet, ids []*token3.Id, tokens [][]byte, request []byte) ([][]byte, error) {
-	return nil, nil
+	w, ok := wallet.(*certifierWallet)
+	if !ok {
+		return nil, errors.Errorf("expected *certifierWallet, got [%T]", wallet)
+	}
+	pp, err := s.certificationPublicParams()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(w.identity, pp.Certifier) {
+		return nil, errors.Errorf("certifier wallet [%s] does not match the certifier in the public parameters", w.ID())
+	}
+	if len(ids) != len(tokens) {
+		return nil, errors.Errorf("number of ids does not match number of tokens: expect [%d], got [%d]", len(ids), len(tokens))
+	}
+
+	if err := s.checkNotRevoked(ids); err != nil {
+		return nil, err
+	}
+
+	toks := make([]*token.Token, len(tokens))
+	for i, raw := range tokens {
+		toks[i] = &token.Token{}
+		if err := toks[i].Deserialize(raw); err != nil {
+			return nil, errors.Wrapf(err, "failed deserializing token [%v]", ids[i])
+		}
+	}
+	now := time.Now()
+	certifications, err := certification.NewCertifier(w.signer, pp).Certify(toks, request, now.Add(w.validity))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed certifying [%v]", ids)
+	}
+	for i, c := range certifications {
+		certifications[i], err = token3.NewCertificationEnvelope(c, now, w.validity).Serialize()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed serializing certification of [%v]", ids[i])
+		}
+	}
+	return certifications, nil
 }
 
-func (s *service) VerifyCertifications(ids []*token3.Id, certifications [][]byte) error {
+// VerifyCertifications unblinds and checks the responses of the certifier.
+// It returns the certifications to be stored, one for each id, in their envelopes.
+func (s *service) VerifyCertifications(ids []*token3.Id, certifications [][]byte) ([][]byte, error) {
+	k := certificationKey(ids)
+	s.requestersLock.Lock()
+	pending, ok := s.requesters[k]
+	delete(s.requesters, k)
+	s.requestersLock.Unlock()
+	if !ok {
+		return nil, errors.Errorf("no pending certification request for [%v]", ids)
+	}
+	requester := pending.requester
+	if len(certifications) != len(ids) {
+		return nil, errors.Errorf("number of certifications does not match number of ids: expect [%d], got [%d]", len(ids), len(certifications))
+	}
+
+	now := time.Now()
+	envelopes := make([]*token3.CertificationEnvelope, len(certifications))
+	responses := make([][]byte, len(certifications))
+	for i, raw := range certifications {
+		envelopes[i] = &token3.CertificationEnvelope{}
+		if err := envelopes[i].Deserialize(raw); err != nil {
+			return nil, errors.WithMessagef(err, "invalid certification for [%v]", ids[i])
+		}
+		if envelopes[i].Expired(now) {
+			return nil, errors.Errorf("certification for [%v] expired at [%s]", ids[i], time.Unix(envelopes[i].ExpiresAt, 0))
+		}
+		responses[i] = envelopes[i].Certification
+	}
+	if err := s.checkNotRevoked(ids); err != nil {
+		return nil, err
+	}
+
+	res, err := requester.VerifyResponses(responses)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed verifying certifications for [%v]", ids)
+	}
+	for i, raw := range res {
+		// the envelope is not signed, its expiry must be the certified one
+		c := &certification.Certification{}
+		if err := c.Deserialize(raw); err != nil {
+			return nil, errors.Wrapf(err, "failed deserializing certification of [%v]", ids[i])
+		}
+		if c.ExpiresAt != envelopes[i].ExpiresAt {
+			return nil, errors.Errorf("certification for [%v] expires at [%d], not at [%d] as declared", ids[i], c.ExpiresAt, envelopes[i].ExpiresAt)
+		}
+		envelopes[i].Certification = raw
+		res[i], err = envelopes[i].Serialize()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed serializing certification of [%v]", ids[i])
+		}
+	}
+	return res, nil
+}
+
+// certifyInputs proves that the passed inputs have been certified, using the certifications in storage.
+// Expired or revoked certifications would be rejected by the validators, therefore they are rejected here already.
+func (s *service) certifyInputs(ids []*token3.Id, tokens []*token.Token, infos []*token.TokenInformation, pp *crypto.PublicParams) ([][]byte, error) {
+	if err := s.checkNotRevoked(ids); err != nil {
+		return nil, err
+	}
+	var certifications [][]byte
+	if err := s.certificationStorage.Get(ids, func(id *token3.Id, raw []byte) error {
+		certifications = append(certifications, raw)
+		return nil
+	}); err != nil {
+		return nil, errors.WithMessagef(err, "failed loading certifications")
+	}
+
+	now := time.Now()
+	proofs := make([][]byte, len(tokens))
+	for i, raw := range certifications {
+		envelope := &token3.CertificationEnvelope{}
+		if err := envelope.Deserialize(raw); err != nil {
+			return nil, errors.WithMessagef(err, "invalid certification of [%v]", ids[i])
+		}
+		c := &certification.Certification{}
+		if err := c.Deserialize(envelope.Certification); err != nil {
+			return nil, errors.Wrapf(err, "failed deserializing certification of [%v]", ids[i])
+		}
+		if now.Unix() >= c.ExpiresAt {
+			return nil, errors.Errorf("certification of [%v] expired at [%s]", ids[i], time.Unix(c.ExpiresAt, 0))
+		}
+		prover, err := certification.NewProver(c, tokens[i], infos[i], pp)
+		if err != nil {
+			return nil, err
+		}
+		proofs[i], err = prover.Prove()
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed proving certification of [%v]", ids[i])
+		}
+	}
+	return proofs, nil
+}
+
+// checkNotRevoked returns an error if the certification of any of the passed tokens has been revoked
+func (s *service) checkNotRevoked(ids []*token3.Id) error {
+	revoked, err := s.revocationListFetcher.Revoked(ids)
+	if err != nil {
+		return errors.WithMessagef(err, "failed fetching revoked certifications for [%v]", ids)
+	}
+	for i, r := range revoked {
+		if r {
+			return errors.Errorf("certification of [%v] has been revoked", ids[i])
+		}
+	}
 	return nil
 }
+
+// certificationPublicParams returns the public parameters, fetching them again if no certifier is set yet.
+// Indeed, the certifier might have been registered after the public parameters were loaded.
+func (s *service) certificationPublicParams() (*crypto.PublicParams, error) {
+	pp := s.PublicParams()
+	if len(pp.Certifier) != 0 {
+		return pp, nil
+	}
+	if err := s.FetchPublicParams(); err != nil {
+		return nil, errors.WithMessagef(err, "failed fetching public params")
+	}
+	pp = s.PublicParams()
+	if len(pp.Certifier) == 0 {
+		return nil, errors.New("no certifier set in public parameters")
+	}
+	return pp, nil
+}
+
+// loadToken returns the token with the passed id, its opening and its key in the rwset
+func (s *service) loadToken(qe *fabric.QueryExecutor, id *token3.Id) (*token.Token, *token.TokenInformation, string, error) {
+	// Token Info
+	outputID, err := keys.CreateFabtokenKey(id.TxId, int(id.Index))
+	if err != nil {
+		return nil, nil, "", errors.Wrapf(err, "error creating output ID: %v", id)
+	}
+	meta, _, _, err := qe.GetStateMetadata(s.namespace, outputID)
+	if err != nil {
+		return nil, nil, "", errors.Wrapf(err, "failed getting metadata for id [%v]", id)
+	}
+	ti := &token.TokenInformation{}
+	err = ti.Deserialize(meta[info])
+	if err != nil {
+		return nil, nil, "", errors.Wrapf(err, "failed deserializeing token info for id [%v]", id)
+	}
+
+	// Token and InputID
+	outputID, err = keys.CreateTokenKey(id.TxId, int(id.Index))
+	if err != nil {
+		return nil, nil, "", errors.Wrapf(err, "error creating output ID: %v", id)
+	}
+	val, err := qe.GetState(s.namespace, outputID)
+	if err != nil {
+		return nil, nil, "", errors.Wrapf(err, "failed getting state [%s]", outputID)
+	}
+	tok := &token.Token{}
+	err = tok.Deserialize(val)
+	if err != nil {
+		return nil, nil, "", errors.Wrapf(err, "failed unmarshalling token for id [%v]", id)
+	}
+	return tok, ti, outputID, nil
+}
+
+func certificationKey(ids []*token3.Id) string {
+	var sb strings.Builder
+	for _, id := range ids {
+		sb.WriteString(fmt.Sprintf("%s:%d,", id.TxId, id.Index))
+	}
+	return sb.String()
+}
@@ -8,15 +8,18 @@ package driver
 import (
 	fabric2 "github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/pkg/errors"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/api"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/config"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/ppm"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/validator"
 	zkatdlog "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/nogh"
+	tcc "github.com/hyperledger-labs/fabric-token-sdk/token/services/tcc/fetcher"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault"
 )
 
@@ -33,21 +36,37 @@ func (d *Driver) PublicParametersFromBytes(params []byte) (api.PublicParameters,
 
 func (d *Driver) NewTokenService(sp view2.ServiceProvider, publicParamsFetcher api.PublicParamsFetcher, network string, channel api.Channel, namespace string) (api.TokenManagerService, error) {
 	nodeIdentity := view2.GetIdentityProvider(sp).DefaultIdentity()
+	wallets, err := fabric.LoadWallets(sp, channel.Name(), namespace)
+	if err != nil {
+		return nil, err
+	}
+	if wallets == nil {
+		wallets = &config.Wallets{}
+	}
+	issuerMapper, err := fabric.NewSignerMapper(sp, fabric.NewMapper(fabric.X509MSPIdentity, nodeIdentity, fabric2.GetFabricNetworkService(sp, network).LocalMembership()), wallets.Issuers)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed loading issuer wallets")
+	}
+	ip := identity.NewProvider(
+		sp,
+		map[api.IdentityUsage]identity.Mapper{
+			api.IssuerRole:  issuerMapper,
+			api.AuditorRole: fabric.NewMapper(fabric.X509MSPIdentity, nodeIdentity, fabric2.GetFabricNetworkService(sp, network).LocalMembership()),
+			api.OwnerRole:   fabric.NewMapper(fabric.IdemixMSPIdentity, nodeIdentity, fabric2.GetFabricNetworkService(sp, network).LocalMembership()),
+		},
+	)
+	if err := ip.LoadOwnerWallets(); err != nil {
+		return nil, errors.WithMessage(err, "failed loading owner wallets")
+	}
 	return zkatdlog.NewTokenService(
 		channel,
 		namespace,
 		sp,
 		publicParamsFetcher,
+		tcc.NewRevocationListFetcher(sp, network, channel.Name(), namespace),
 		&zkatdlog.VaultTokenCommitmentLoader{TokenVault: vault.NewVault(sp, channel, namespace).QueryEngine()},
 		vault.NewVault(sp, channel, namespace).QueryEngine(),
-		identity.NewProvider(
-			sp,
-			map[api.IdentityUsage]identity.Mapper{
-				api.IssuerRole:  fabric.NewMapper(fabric.X509MSPIdentity, nodeIdentity, fabric2.GetFabricNetworkService(sp, network).LocalMembership()),
-				api.AuditorRole: fabric.NewMapper(fabric.X509MSPIdentity, nodeIdentity, fabric2.GetFabricNetworkService(sp, network).LocalMembership()),
-				api.OwnerRole:   fabric.NewMapper(fabric.IdemixMSPIdentity, nodeIdentity, fabric2.GetFabricNetworkService(sp, network).LocalMembership()),
-			},
-		),
+		ip,
 	)
 }
 
@@ -0,0 +1,227 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package nogh
+
+import (
+	"github.com/pkg/errors"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/kvs"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/config"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
+)
+
+// DefaultGapLimit is the number of consecutive unused recipient identities after which
+// a wallet stops looking for its deterministically derived identities
+const DefaultGapLimit = 20
+
+type derivedIdentity struct {
+	index     uint64
+	id        view.Identity
+	auditInfo []byte
+}
+
+// loadOwnerDeriver returns the deriver of the recipient identities of the owner wallet with the passed id,
+// as configured for this TMS. It returns nil if the wallet does not derive its recipient identities deterministically.
+func (s *service) loadOwnerDeriver(id string) (*fabric.IdemixDeriver, error) {
+	var tmsConfigs []*config.TMS
+	if err := view2.GetConfigService(s.sp).UnmarshalKey("token.tms", &tmsConfigs); err != nil {
+		return nil, errors.WithMessagef(err, "cannot load token-sdk configuration")
+	}
+	for _, tms := range tmsConfigs {
+		if tms.Channel != s.channel.Name() || tms.Namespace != s.namespace || tms.Wallets == nil {
+			continue
+		}
+		for _, owner := range tms.Wallets.Owners {
+			// wallets with a signer are X.509 wallets
+			if owner.ID != id || owner.Signer != nil {
+				continue
+			}
+			return fabric.NewIdemixDeriver(s.sp, owner.Path, owner.MSPID)
+		}
+	}
+	return nil, nil
+}
+
+// nextRecipientIdentity returns the derived recipient identity at the next unused index
+func (w *wallet) nextRecipientIdentity() (view.Identity, error) {
+	w.hdLock.Lock()
+	defer w.hdLock.Unlock()
+
+	index, err := w.nextIndex()
+	if err != nil {
+		return nil, err
+	}
+	d, err := w.derive(index)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting recipient identity from wallet [%s]", w.ID())
+	}
+	if err := w.useDerived(d); err != nil {
+		return nil, errors.WithMessagef(err, "failed storing recipient identity in wallet [%s]", w.ID())
+	}
+	return d.id, nil
+}
+
+// matchDerived returns true if the passed identity is one of the next DefaultGapLimit derived identities.
+// This way, the tokens sent to identities handed out before a restore are recognized when they are committed.
+func (w *wallet) matchDerived(id view.Identity) bool {
+	w.hdLock.Lock()
+	defer w.hdLock.Unlock()
+
+	next, err := w.nextIndex()
+	if err != nil {
+		logger.Errorf("failed getting next index of wallet [%s]: [%s]", w.ID(), err)
+		return false
+	}
+	if err := w.fillLookahead(next, next+DefaultGapLimit); err != nil {
+		logger.Errorf("failed deriving recipient identities of wallet [%s]: [%s]", w.ID(), err)
+		return false
+	}
+	d, ok := w.lookahead[id.String()]
+	if !ok {
+		return false
+	}
+	logger.Debugf("identity [%s] matches index [%d] of wallet [%s]", id, d.index, w.ID())
+	if err := w.useDerived(d); err != nil {
+		logger.Errorf("failed storing recipient identity in wallet [%s]: [%s]", w.ID(), err)
+		return false
+	}
+	return true
+}
+
+// Restore recovers the derived recipient identities that own unspent tokens in the vault.
+// It derives identities, starting from index zero, until gapLimit consecutive identities are neither in use
+// nor own a token, and returns the number of identities recovered.
+func (w *wallet) Restore(gapLimit int) (int, error) {
+	if w.deriver == nil {
+		return 0, nil
+	}
+	if gapLimit <= 0 {
+		gapLimit = DefaultGapLimit
+	}
+
+	source, err := w.tokenService.qe.ListUnspentTokens()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed listing unspent tokens")
+	}
+	owners := map[string]bool{}
+	for _, t := range source.Tokens {
+		owners[view.Identity(t.Owner.Raw).String()] = true
+	}
+
+	w.hdLock.Lock()
+	defer w.hdLock.Unlock()
+
+	next, err := w.nextIndex()
+	if err != nil {
+		return 0, err
+	}
+	recovered := 0
+	for index, gap := uint64(0), 0; gap < gapLimit || index < next; index++ {
+		d, err := w.derive(index)
+		if err != nil {
+			return recovered, errors.WithMessagef(err, "failed deriving recipient identity [%d] of wallet [%s]", index, w.ID())
+		}
+		known := w.existsRecipientIdentity(d.id)
+		if !known && !owners[d.id.String()] {
+			gap++
+			continue
+		}
+		gap = 0
+		if known {
+			continue
+		}
+		if err := w.useDerived(d); err != nil {
+			return recovered, errors.WithMessagef(err, "failed storing recipient identity in wallet [%s]", w.ID())
+		}
+		recovered++
+	}
+	logger.Debugf("wallet [%s] restored, [%d] recipient identities recovered", w.ID(), recovered)
+	return recovered, nil
+}
+
+func (w *wallet) derive(index uint64) (*derivedIdentity, error) {
+	id, auditInfo, err := w.deriver.Identity(w.tokenService.channel.Name(), index)
+	if err != nil {
+		return nil, err
+	}
+	return &derivedIdentity{index: index, id: id, auditInfo: auditInfo}, nil
+}
+
+// fillLookahead derives the identities in the passed range of indices that are not in the lookahead yet
+func (w *wallet) fillLookahead(start, end uint64) error {
+	derived := map[uint64]bool{}
+	for _, d := range w.lookahead {
+		derived[d.index] = true
+	}
+	for index := start; index < end; index++ {
+		if derived[index] {
+			continue
+		}
+		d, err := w.derive(index)
+		if err != nil {
+			return err
+		}
+		w.lookahead[d.id.String()] = d
+	}
+	return nil
+}
+
+// useDerived registers the passed derived identity as a recipient identity of the wallet
+func (w *wallet) useDerived(d *derivedIdentity) error {
+	if err := w.tokenService.identityProvider.RegisterRecipientIdentity(d.id, d.auditInfo, nil); err != nil {
+		return err
+	}
+	if err := view2.GetEndpointService(w.tokenService.sp).Bind(view2.GetIdentityProvider(w.tokenService.sp).DefaultIdentity(), d.id); err != nil {
+		return errors.Wrapf(err, "failed binding to long term identity [%s]", d.id)
+	}
+	if err := w.putRecipientIdentity(d.id, []byte{}); err != nil {
+		return err
+	}
+	delete(w.lookahead, d.id.String())
+
+	next, err := w.nextIndex()
+	if err != nil {
+		return err
+	}
+	if d.index >= next {
+		return w.setNextIndex(d.index + 1)
+	}
+	return nil
+}
+
+func (w *wallet) nextIndex() (uint64, error) {
+	kvss := kvs.GetService(w.tokenService.sp)
+	k := w.nextIndexKey()
+	if !kvss.Exists(k) {
+		return 0, nil
+	}
+	var index uint64
+	if err := kvss.Get(k, &index); err != nil {
+		return 0, errors.Wrapf(err, "failed getting next index of wallet [%s]", w.ID())
+	}
+	return index, nil
+}
+
+func (w *wallet) setNextIndex(index uint64) error {
+	if err := kvs.GetService(w.tokenService.sp).Put(w.nextIndexKey(), index); err != nil {
+		return errors.Wrapf(err, "failed storing next index of wallet [%s]", w.ID())
+	}
+	return nil
+}
+
+func (w *wallet) nextIndexKey() string {
+	return kvs.CreateCompositeKeyOrPanic(
+		"zkatdlog.owner.wallet.recipient.index",
+		[]string{
+			w.tokenService.channel.Name(),
+			w.identityInfo.ID,
+			w.identityInfo.EnrollmentID,
+		},
+	)
+}
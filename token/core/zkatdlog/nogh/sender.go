@@ -11,7 +11,6 @@ import (
 	"github.com/pkg/errors"
 
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
-	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/hash"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 	api3 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
@@ -22,6 +21,16 @@ import (
 )
 
 func (s *service) Transfer(txID string, wallet api3.OwnerWallet, ids []*token3.Id, outputTokens ...*token3.Token) (api3.TransferAction, *api3.TransferMetadata, error) {
+	return s.transfer(txID, wallet, ids, false, outputTokens...)
+}
+
+// Settle is like Transfer but the outputs carry proofs of their values, the validator checks them against the payouts
+// of the closed dispute on the spent deposit
+func (s *service) Settle(txID string, wallet api3.OwnerWallet, ids []*token3.Id, outputTokens ...*token3.Token) (api3.TransferAction, *api3.TransferMetadata, error) {
+	return s.transfer(txID, wallet, ids, true, outputTokens...)
+}
+
+func (s *service) transfer(txID string, wallet api3.OwnerWallet, ids []*token3.Id, settlement bool, outputTokens ...*token3.Token) (api3.TransferAction, *api3.TransferMetadata, error) {
 	logger.Debugf("Prepare Transfer Action [%s,%v]", txID, ids)
 
 	var tokens []*token.Token
@@ -38,37 +47,11 @@ func (s *service) Transfer(txID string, wallet api3.OwnerWallet, ids []*token3.I
 
 	pp := s.PublicParams()
 	for _, id := range ids {
-		// Token Info
-		outputID, err := keys.CreateFabtokenKey(id.TxId, int(id.Index))
-		if err != nil {
-			return nil, nil, errors.Wrapf(err, "error creating output ID: %v", id)
-		}
-		meta, _, _, err := qe.GetStateMetadata(s.namespace, outputID)
-		if err != nil {
-			return nil, nil, errors.Wrapf(err, "failed getting metadata for id [%v]", id)
-		}
-		ti := &token.TokenInformation{}
-		err = ti.Deserialize(meta[info])
-		if err != nil {
-			return nil, nil, errors.Wrapf(err, "failed deserializeing token info for id [%v]", id)
-		}
-
-		// Token and InputID
-		outputID, err = keys.CreateTokenKey(id.TxId, int(id.Index))
-		if err != nil {
-			return nil, nil, errors.Wrapf(err, "error creating output ID: %v", id)
-		}
-		val, err := qe.GetState(s.namespace, outputID)
-		if err != nil {
-			return nil, nil, errors.Wrapf(err, "failed getting state [%s]", outputID)
-		}
-
-		logger.Debugf("loaded transfer input [%s]", hash.Hashable(val).String())
-		token := &token.Token{}
-		err = token.Deserialize(val)
+		token, ti, outputID, err := s.loadToken(qe, id)
 		if err != nil {
-			return nil, nil, errors.Wrapf(err, "failed unmarshalling token for id [%v]", id)
+			return nil, nil, err
 		}
+		logger.Debugf("loaded transfer input [%v]", id)
 		tok, err := token.GetTokenInTheClear(ti, pp)
 		if err != nil {
 			return nil, nil, errors.Wrapf(err, "invalid token, cannot get it in clear [%v]", id)
@@ -119,10 +102,20 @@ func (s *service) Transfer(txID string, wallet api3.OwnerWallet, ids []*token3.I
 			ownerIdentities = append(ownerIdentities, output.Owner.Raw)
 		}
 	}
-	transfer, infos, err := sender.GenerateZKTransfer(values, owners)
+	generate := sender.GenerateZKTransfer
+	if settlement {
+		generate = sender.GenerateZKSettlement
+	}
+	transfer, infos, err := generate(values, owners)
 	if err != nil {
 		return nil, nil, errors.Wrapf(err, "failed generating zkat proof for txid [%s]", txID)
 	}
+	if len(pp.Certifier) != 0 {
+		transfer.InputCertifications, err = s.certifyInputs(ids, tokens, inputInf, pp)
+		if err != nil {
+			return nil, nil, errors.WithMessagef(err, "failed proving inputs are certified for txid [%s]", txID)
+		}
+	}
 
 	// Prepare metadata
 	infoRaws := [][]byte{}
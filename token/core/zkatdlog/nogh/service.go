@@ -18,7 +18,9 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/ppm"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/validator"
+	certification2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/certification"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
 	token3 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
@@ -41,6 +43,7 @@ type QueryEngine interface {
 	ListUnspentTokens() (*token3.UnspentTokens, error)
 	ListAuditTokens(ids ...*token3.Id) ([]*token3.Token, error)
 	ListHistoryIssuedTokens() (*token3.IssuedTokens, error)
+	Balance(wallet api3.Wallet, tokenType string) (token3.Quantity, error)
 }
 
 type service struct {
@@ -49,8 +52,10 @@ type service struct {
 	sp                    view2.ServiceProvider
 	pp                    *crypto.PublicParams
 	publicParamsFetcher   api3.PublicParamsFetcher
+	revocationListFetcher api3.RevocationListFetcher
 	tokenCommitmentLoader TokenCommitmentLoader
 	qe                    QueryEngine
+	certificationStorage  *certification2.Storage
 
 	issuers []*struct {
 		label string
@@ -61,10 +66,14 @@ type service struct {
 	}
 
 	identityProvider api3.IdentityProvider
-	ownerWallets     []*wallet
 	issuerWallets    []*issuerWallet
 	auditorWallets   []*auditorWallet
+	certifierWallets []*certifierWallet
 	walletsLock      sync.Mutex
+
+	// requesters holds the state of the pending certification requests
+	requesters     map[string]*pendingCertification
+	requestersLock sync.Mutex
 }
 
 func NewTokenService(
@@ -72,6 +81,7 @@ func NewTokenService(
 	namespace string,
 	sp view2.ServiceProvider,
 	publicParamsFetcher api3.PublicParamsFetcher,
+	revocationListFetcher api3.RevocationListFetcher,
 	tokenCommitmentLoader TokenCommitmentLoader,
 	queryEngine QueryEngine,
 	identityProvider api3.IdentityProvider,
@@ -81,9 +91,12 @@ func NewTokenService(
 		namespace:             namespace,
 		sp:                    sp,
 		publicParamsFetcher:   publicParamsFetcher,
+		revocationListFetcher: revocationListFetcher,
 		tokenCommitmentLoader: tokenCommitmentLoader,
 		qe:                    queryEngine,
+		certificationStorage:  certification2.NewStorage(sp, channel, namespace),
 		identityProvider:      identityProvider,
+		requesters:            map[string]*pendingCertification{},
 	}
 	return s, nil
 }
@@ -108,6 +121,29 @@ func (s *service) DeserializeToken(tok []byte, infoRaw []byte) (*token3.Token, v
 	return to, ti.Issuer, nil
 }
 
+// SwapProof returns a zero-knowledge proof that the passed output carries the type and quantity of the token
+// information, the blinding factor is not revealed
+func (s *service) SwapProof(outputRaw []byte, tokenInfoRaw []byte) ([]byte, error) {
+	output := &token.Token{}
+	if err := output.Deserialize(outputRaw); err != nil {
+		return nil, err
+	}
+	ti := &token.TokenInformation{}
+	if err := ti.Deserialize(tokenInfoRaw); err != nil {
+		return nil, err
+	}
+	pp := s.PublicParams()
+	tok, err := output.GetTokenInTheClear(ti, pp)
+	if err != nil {
+		return nil, err
+	}
+	q, err := token3.ToQuantity(tok.Quantity, keys.Precision)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed parsing quantity")
+	}
+	return transfer.NewSwapProver(output.Data, tok.Type, q.ToBigInt().Uint64(), ti.BlindingFactor, pp.ZKATPedParams).Prove()
+}
+
 func (s *service) IdentityProvider() api3.IdentityProvider {
 	return s.identityProvider
 }
@@ -6,6 +6,12 @@ SPDX-License-Identifier: Apache-2.0
 package nogh
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
 	"github.com/pkg/errors"
 
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
@@ -13,8 +19,11 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
 	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/config"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/identity/fabric"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/math/gurvy/bn256"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue/anonym"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/pssign"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
@@ -143,28 +152,33 @@ func (s *service) OwnerWalletByIdentity(identity view.Identity) api2.OwnerWallet
 }
 
 func (s *service) ownerWallet(id interface{}) api2.OwnerWallet {
-	s.walletsLock.Lock()
-	defer s.walletsLock.Unlock()
-
-	// check if there is already a wallet
-	identity, walletID := s.identityProvider.LookupIdentifier(api2.OwnerRole, id)
-	for _, w := range s.ownerWallets {
-		if w.Contains(identity) || w.ID() == walletID {
-			logger.Debugf("found owner wallet [%s:%s]", identity, walletID)
-			return w
+	return s.identityProvider.OwnerWallet(id, func(idInfo *api2.IdentityInfo) (api2.OwnerWallet, error) {
+		deriver, err := s.loadOwnerDeriver(idInfo.ID)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed loading deterministic derivation for owner wallet [%s]", idInfo.ID)
 		}
-	}
+		return newOwnerWallet(s, idInfo.ID, idInfo, deriver), nil
+	})
+}
 
-	// Create the wallet
-	if idInfo := s.identityProvider.GetIdentityInfo(api2.OwnerRole, walletID); idInfo != nil {
-		w := newOwnerWallet(s, idInfo.ID, idInfo)
-		s.ownerWallets = append(s.ownerWallets, w)
-		logger.Debugf("created owner wallet [%s:%s]", identity, walletID)
-		return w
-	}
+func (s *service) RegisterOwnerWallet(id string, mspID string, path string) error {
+	return s.identityProvider.RegisterOwnerWallet(id, mspID, path)
+}
 
-	logger.Debugf("no owner wallet found for [%s:%s]", identity, walletID)
-	return nil
+func (s *service) RemoveOwnerWallet(id string) error {
+	return s.identityProvider.RemoveOwnerWallet(id)
+}
+
+func (s *service) OwnerWalletIDs() ([]string, error) {
+	return s.identityProvider.OwnerWalletIDs()
+}
+
+func (s *service) ExportOwnerWallet(id string, passphrase []byte) ([]byte, error) {
+	return s.identityProvider.ExportOwnerWallet(id, passphrase)
+}
+
+func (s *service) ImportOwnerWallet(raw []byte, passphrase []byte, path string) (string, error) {
+	return s.identityProvider.ImportOwnerWallet(raw, passphrase, path)
 }
 
 func (s *service) IssuerWallet(id string) api2.IssuerWallet {
@@ -192,7 +206,8 @@ func (s *service) issuerWallet(id interface{}) api2.IssuerWallet {
 	if idInfo := s.identityProvider.GetIdentityInfo(api2.IssuerRole, walletID); idInfo != nil {
 		id, err := idInfo.GetIdentity()
 		if err != nil {
-			panic(err)
+			logger.Errorf("failed getting identity of issuer wallet [%s]: [%s]", idInfo.ID, err)
+			return nil
 		}
 		w := newIssuerWallet(s, idInfo.ID, id)
 		s.issuerWallets = append(s.issuerWallets, w)
@@ -229,7 +244,8 @@ func (s *service) auditorWallet(id interface{}) api2.AuditorWallet {
 	if idInfo := s.identityProvider.GetIdentityInfo(api2.AuditorRole, walletID); idInfo != nil {
 		id, err := idInfo.GetIdentity()
 		if err != nil {
-			panic(err)
+			logger.Errorf("failed getting identity of auditor wallet [%s]: [%s]", idInfo.ID, err)
+			return nil
 		}
 		w := newAuditorWallet(s, idInfo.ID, id)
 		s.auditorWallets = append(s.auditorWallets, w)
@@ -242,24 +258,105 @@ func (s *service) auditorWallet(id interface{}) api2.AuditorWallet {
 }
 
 func (s *service) CertifierWallet(id string) api2.CertifierWallet {
-	return nil
+	s.walletsLock.Lock()
+	defer s.walletsLock.Unlock()
+
+	for _, w := range s.certifierWallets {
+		if w.ID() == id {
+			logger.Debugf("found certifier wallet [%s]", id)
+			return w
+		}
+	}
+
+	signer, validity, err := s.loadCertifierKey(id)
+	if err != nil {
+		logger.Errorf("failed loading certifier key for wallet [%s]: [%s]", id, err)
+		return nil
+	}
+	if signer == nil {
+		logger.Debugf("no certifier wallet found for [%s]", id)
+		return nil
+	}
+	identity, err := signer.SignVerifier.Serialize()
+	if err != nil {
+		logger.Errorf("failed serializing certifier public key for wallet [%s]: [%s]", id, err)
+		return nil
+	}
+	w := newCertifierWallet(id, identity, signer, validity)
+	s.certifierWallets = append(s.certifierWallets, w)
+	logger.Debugf("created certifier wallet [%s]", id)
+	return w
 }
 
 func (s *service) CertifierWalletByIdentity(id view.Identity) api2.CertifierWallet {
+	s.walletsLock.Lock()
+	defer s.walletsLock.Unlock()
+
+	for _, w := range s.certifierWallets {
+		if w.Contains(id) {
+			return w
+		}
+	}
 	return nil
 }
 
+// loadCertifierKey loads the secret key of the certifier wallet with the passed id, as configured for this TMS,
+// together with the validity of the certifications it issues.
+// It returns nil if no such wallet is configured.
+func (s *service) loadCertifierKey(id string) (*pssign.Signer, time.Duration, error) {
+	var tmsConfigs []*config.TMS
+	if err := view2.GetConfigService(s.sp).UnmarshalKey("token.tms", &tmsConfigs); err != nil {
+		return nil, 0, errors.WithMessagef(err, "cannot load token-sdk configuration")
+	}
+	for _, tms := range tmsConfigs {
+		if tms.Channel != s.channel.Name() || tms.Namespace != s.namespace || tms.Wallets == nil {
+			continue
+		}
+		for _, certifier := range tms.Wallets.Certifiers {
+			if certifier.ID != id {
+				continue
+			}
+			path := certifier.Path
+			if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+				path = filepath.Join(path, "certifier.sk")
+			}
+			raw, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, 0, errors.Wrapf(err, "failed reading certifier secret key from [%s]", path)
+			}
+			signer := &pssign.Signer{}
+			if err := signer.Deserialize(raw); err != nil {
+				return nil, 0, errors.Wrapf(err, "failed deserializing certifier secret key from [%s]", path)
+			}
+			validity := DefaultCertificationValidity
+			if tms.Certification != nil && tms.Certification.Interactive != nil && tms.Certification.Interactive.Validity > 0 {
+				validity = tms.Certification.Interactive.Validity
+			}
+			return signer, validity, nil
+		}
+	}
+	return nil, 0, nil
+}
+
 type wallet struct {
 	tokenService *service
 	id           string
 	identityInfo *api2.IdentityInfo
+
+	// deriver, if not nil, derives the recipient identities of the wallet deterministically
+	deriver *fabric.IdemixDeriver
+	hdLock  sync.Mutex
+	// lookahead contains the derived identities not yet in use, see matchDerived
+	lookahead map[string]*derivedIdentity
 }
 
-func newOwnerWallet(tokenService *service, id string, identityInfo *api2.IdentityInfo) *wallet {
+func newOwnerWallet(tokenService *service, id string, identityInfo *api2.IdentityInfo, deriver *fabric.IdemixDeriver) *wallet {
 	return &wallet{
 		tokenService: tokenService,
 		id:           id,
 		identityInfo: identityInfo,
+		deriver:      deriver,
+		lookahead:    map[string]*derivedIdentity{},
 	}
 }
 
@@ -268,10 +365,17 @@ func (w *wallet) ID() string {
 }
 
 func (w *wallet) Contains(identity view.Identity) bool {
-	return w.existsRecipientIdentity(identity)
+	if w.existsRecipientIdentity(identity) {
+		return true
+	}
+	return w.deriver != nil && w.matchDerived(identity)
 }
 
 func (w *wallet) GetRecipientIdentity() (view.Identity, error) {
+	if w.deriver != nil {
+		return w.nextRecipientIdentity()
+	}
+
 	// Get a new pseudonym
 	pseudonym, err := w.identityInfo.GetIdentity()
 	if err != nil {
@@ -331,6 +435,10 @@ func (w *wallet) ListTokens(opts *api2.ListTokensOptions) (*token2.UnspentTokens
 	return unspentTokens, nil
 }
 
+func (w *wallet) Balance(tokenType string) (token2.Quantity, error) {
+	return w.tokenService.qe.Balance(w, tokenType)
+}
+
 func (w *wallet) existsRecipientIdentity(id view.Identity) bool {
 	k := kvs.CreateCompositeKeyOrPanic(
 		"zkatdlog.owner.wallet.recipient.id",
@@ -405,8 +513,19 @@ func (w *issuerWallet) GetSigner(identity view.Identity) (api2.Signer, error) {
 	return si, nil
 }
 
+// HistoryTokens returns the tokens issued by this wallet and those redeemed back to it
 func (w *issuerWallet) HistoryTokens(opts *api2.ListTokensOptions) (*token2.IssuedTokens, error) {
-	logger.Debugf("issuer wallet [%s]: history tokens, type [%d]", w.ID(), opts.TokenType)
+	return w.history(opts, false)
+}
+
+// RedeemedTokens returns the tokens redeemed back to this wallet
+func (w *issuerWallet) RedeemedTokens(opts *api2.ListTokensOptions) (*token2.IssuedTokens, error) {
+	return w.history(opts, true)
+}
+
+// history returns the history of this wallet, the tokens redeemed back to it only if redeemedOnly is true
+func (w *issuerWallet) history(opts *api2.ListTokensOptions, redeemedOnly bool) (*token2.IssuedTokens, error) {
+	logger.Debugf("issuer wallet [%s]: history tokens, type [%s], redeemed only [%v]", w.ID(), opts.TokenType, redeemedOnly)
 	source, err := w.tokenService.qe.ListHistoryIssuedTokens()
 	if err != nil {
 		return nil, errors.Wrap(err, "token selection failed")
@@ -414,6 +533,10 @@ func (w *issuerWallet) HistoryTokens(opts *api2.ListTokensOptions) (*token2.Issu
 
 	unspentTokens := &token2.IssuedTokens{}
 	for _, t := range source.Tokens {
+		if redeemedOnly && !t.Redeemed {
+			continue
+		}
+
 		if len(opts.TokenType) != 0 && t.Type != opts.TokenType {
 			logger.Debugf("issuer wallet [%s]: discarding token of type [%s]!=[%s]", w.ID(), t.Type, opts.TokenType)
 			continue
@@ -469,3 +592,37 @@ func (w *auditorWallet) GetSigner(id view.Identity) (api2.Signer, error) {
 	}
 	return si, err
 }
+
+// certifierWallet holds the key-pair used to certify token commitments.
+// Its identity is the serialized public key of the certifier.
+type certifierWallet struct {
+	id       string
+	identity view.Identity
+	signer   *pssign.Signer
+	validity time.Duration
+}
+
+func newCertifierWallet(id string, identity view.Identity, signer *pssign.Signer, validity time.Duration) *certifierWallet {
+	return &certifierWallet{
+		id:       id,
+		identity: identity,
+		signer:   signer,
+		validity: validity,
+	}
+}
+
+func (w *certifierWallet) ID() string {
+	return w.id
+}
+
+func (w *certifierWallet) Contains(identity view.Identity) bool {
+	return w.identity.Equal(identity)
+}
+
+func (w *certifierWallet) GetCertifierIdentity() (view.Identity, error) {
+	return w.identity, nil
+}
+
+func (w *certifierWallet) GetSigner(id view.Identity) (api2.Signer, error) {
+	return nil, errors.Errorf("certifier wallet [%s] does not provide signers, certifications are blind signatures", w.ID())
+}
@@ -0,0 +1,236 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package encoding
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Marker is the first byte of any compact binary encoding.
+	// A JSON document never starts with this byte, therefore decoders can use it
+	// to tell the compact encoding apart from the legacy JSON one.
+	Marker byte = 0x00
+	// Version1 is the first version of the compact binary encoding
+	Version1 byte = 0x01
+)
+
+// IsBinary returns true if the passed bytes carry the compact binary encoding,
+// false if they are expected to be in the legacy JSON encoding.
+func IsBinary(raw []byte) bool {
+	return len(raw) >= 2 && raw[0] == Marker
+}
+
+// Writer builds a compact binary encoding made of a header, carrying the version,
+// followed by a sequence of length-prefixed fields.
+type Writer struct {
+	buf []byte
+}
+
+// NewWriter returns a new Writer whose output starts with the header for the passed version
+func NewWriter(version byte) *Writer {
+	return &Writer{buf: []byte{Marker, version}}
+}
+
+func (w *Writer) WriteUvarint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	w.buf = append(w.buf, tmp[:n]...)
+}
+
+func (w *Writer) WriteBool(b bool) {
+	if b {
+		w.buf = append(w.buf, 1)
+		return
+	}
+	w.buf = append(w.buf, 0)
+}
+
+func (w *Writer) WriteBytes(b []byte) {
+	w.WriteUvarint(uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *Writer) WriteString(s string) {
+	w.WriteBytes([]byte(s))
+}
+
+func (w *Writer) WriteBytesArray(array [][]byte) {
+	w.WriteUvarint(uint64(len(array)))
+	for _, b := range array {
+		w.WriteBytes(b)
+	}
+}
+
+func (w *Writer) WriteStringArray(array []string) {
+	w.WriteUvarint(uint64(len(array)))
+	for _, s := range array {
+		w.WriteString(s)
+	}
+}
+
+// Bytes returns the encoding built so far
+func (w *Writer) Bytes() []byte {
+	return w.buf
+}
+
+// Reader decodes what a Writer produced.
+// The first error encountered is sticky: once it happens, all subsequent reads return zero values
+// and the error is reported by Err.
+type Reader struct {
+	buf     []byte
+	version byte
+	err     error
+}
+
+// NewReader checks the header of the passed encoding and returns a Reader positioned on the first field
+func NewReader(raw []byte) (*Reader, error) {
+	if !IsBinary(raw) {
+		return nil, errors.New("invalid encoding, header not found")
+	}
+	if raw[1] != Version1 {
+		return nil, errors.Errorf("unsupported encoding version [%d]", raw[1])
+	}
+	return &Reader{buf: raw[2:], version: raw[1]}, nil
+}
+
+// Done returns true if all the bytes have been read, or an error occurred
+func (r *Reader) Done() bool {
+	return r.err != nil || len(r.buf) == 0
+}
+
+func (r *Reader) Version() byte {
+	return r.version
+}
+
+func (r *Reader) ReadUvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = errors.New("invalid encoding, failed reading varint")
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *Reader) ReadBool() bool {
+	if r.err != nil {
+		return false
+	}
+	if len(r.buf) == 0 {
+		r.err = errors.New("invalid encoding, failed reading bool")
+		return false
+	}
+	b := r.buf[0]
+	r.buf = r.buf[1:]
+	switch b {
+	case 0:
+		return false
+	case 1:
+		return true
+	default:
+		r.err = errors.Errorf("invalid encoding, invalid bool value [%d]", b)
+		return false
+	}
+}
+
+// ReadBytes returns a copy of the next length-prefixed field, or nil if the field is empty
+func (r *Reader) ReadBytes() []byte {
+	l := r.ReadUvarint()
+	if r.err != nil {
+		return nil
+	}
+	if l > uint64(len(r.buf)) {
+		r.err = errors.Errorf("invalid encoding, field length [%d] exceeds available bytes [%d]", l, len(r.buf))
+		return nil
+	}
+	if l == 0 {
+		return nil
+	}
+	res := make([]byte, l)
+	copy(res, r.buf[:l])
+	r.buf = r.buf[l:]
+	return res
+}
+
+func (r *Reader) ReadString() string {
+	return string(r.ReadBytes())
+}
+
+func (r *Reader) ReadBytesArray() [][]byte {
+	l := r.ReadLength()
+	if r.err != nil || l == 0 {
+		return nil
+	}
+	res := make([][]byte, l)
+	for i := range res {
+		res[i] = r.ReadBytes()
+	}
+	if r.err != nil {
+		return nil
+	}
+	return res
+}
+
+func (r *Reader) ReadStringArray() []string {
+	l := r.ReadLength()
+	if r.err != nil || l == 0 {
+		return nil
+	}
+	res := make([]string, l)
+	for i := range res {
+		res[i] = r.ReadString()
+	}
+	if r.err != nil {
+		return nil
+	}
+	return res
+}
+
+// Fail records the passed error, unless an error has already been recorded.
+// It allows decoders of nested structures to share the sticky error of the Reader.
+func (r *Reader) Fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Err returns the first error encountered while reading
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Close returns the first error encountered while reading, if any,
+// or an error if not all bytes have been consumed
+func (r *Reader) Close() error {
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.buf) != 0 {
+		return errors.Errorf("invalid encoding, [%d] trailing bytes", len(r.buf))
+	}
+	return nil
+}
+
+// ReadLength reads the number of elements of an array written with WriteUvarint,
+// making sure that the claimed length is plausible given the remaining bytes
+func (r *Reader) ReadLength() int {
+	l := r.ReadUvarint()
+	if r.err != nil {
+		return 0
+	}
+	// each element takes at least one byte
+	if l > uint64(len(r.buf)) {
+		r.err = errors.Errorf("invalid encoding, array length [%d] exceeds available bytes [%d]", l, len(r.buf))
+		return 0
+	}
+	return int(l)
+}
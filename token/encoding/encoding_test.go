@@ -0,0 +1,69 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package encoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundTrip(t *testing.T) {
+	w := NewWriter(Version1)
+	w.WriteUvarint(300)
+	w.WriteBool(true)
+	w.WriteBytes([]byte("hello"))
+	w.WriteString("world")
+	w.WriteBytesArray([][]byte{[]byte("a"), nil, []byte("bc")})
+	w.WriteStringArray([]string{"x", "y"})
+	raw := w.Bytes()
+	assert.True(t, IsBinary(raw))
+
+	r, err := NewReader(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(300), r.ReadUvarint())
+	assert.True(t, r.ReadBool())
+	assert.Equal(t, []byte("hello"), r.ReadBytes())
+	assert.Equal(t, "world", r.ReadString())
+	assert.Equal(t, [][]byte{[]byte("a"), nil, []byte("bc")}, r.ReadBytesArray())
+	assert.False(t, r.Done())
+	assert.Equal(t, []string{"x", "y"}, r.ReadStringArray())
+	assert.True(t, r.Done())
+	assert.NoError(t, r.Close())
+}
+
+func TestInvalid(t *testing.T) {
+	assert.False(t, IsBinary([]byte(`{"Issues":null}`)))
+	assert.False(t, IsBinary(nil))
+
+	_, err := NewReader([]byte(`{}`))
+	assert.Error(t, err)
+	_, err = NewReader([]byte{Marker, 0xFF})
+	assert.EqualError(t, err, "unsupported encoding version [255]")
+
+	// truncated field
+	w := NewWriter(Version1)
+	w.WriteBytes([]byte("hello"))
+	raw := w.Bytes()
+	r, err := NewReader(raw[:len(raw)-1])
+	assert.NoError(t, err)
+	assert.Nil(t, r.ReadBytes())
+	assert.Error(t, r.Close())
+
+	// trailing bytes
+	r, err = NewReader(append(raw, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), r.ReadBytes())
+	assert.EqualError(t, r.Close(), "invalid encoding, [1] trailing bytes")
+
+	// array length larger than the remaining bytes
+	w = NewWriter(Version1)
+	w.WriteUvarint(1000)
+	r, err = NewReader(w.Bytes())
+	assert.NoError(t, err)
+	assert.Nil(t, r.ReadBytesArray())
+	assert.Error(t, r.Err())
+}
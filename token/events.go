@@ -0,0 +1,140 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package token
+
+import (
+	"sync"
+
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+type EventType int
+
+const (
+	// TokensReceived is raised when a committed transaction gives new tokens to a wallet
+	TokensReceived EventType = iota
+	// TokensSpent is raised when a committed transaction spends tokens of a wallet
+	TokensSpent
+	// TxCommitted is raised when a transaction is committed as valid
+	TxCommitted
+	// TxInvalidated is raised when a transaction is found to be invalid
+	TxInvalidated
+)
+
+func (e EventType) String() string {
+	switch e {
+	case TokensReceived:
+		return "TokensReceived"
+	case TokensSpent:
+		return "TokensSpent"
+	case TxCommitted:
+		return "TxCommitted"
+	case TxInvalidated:
+		return "TxInvalidated"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event is a change that a transaction causes to the wallets of this node.
+// Token events refer to a single wallet and list the tokens received or spent,
+// transaction events list all the wallets of this node involved in the transaction.
+type Event struct {
+	Type      EventType
+	TxID      string
+	WalletIDs []string
+	Tokens    []*token2.Id
+}
+
+// EventListener is notified of the events it subscribed to.
+// Listeners are called once the final status of the transaction is known, on the finality path, therefore they must not block.
+type EventListener interface {
+	OnEvent(event *Event)
+}
+
+// Subscription is the registration of a listener
+type Subscription struct {
+	unsubscribe func()
+	once        sync.Once
+}
+
+// Unsubscribe stops the notification of events to the listener
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(s.unsubscribe)
+}
+
+type subscriber struct {
+	listener EventListener
+}
+
+// EventService dispatches the events of a token management service to the listeners.
+// Transaction subscriptions end once the transaction is either committed or invalidated.
+type EventService struct {
+	lock    sync.RWMutex
+	txs     map[string][]*subscriber
+	wallets map[string][]*subscriber
+}
+
+func newEventService() *EventService {
+	return &EventService{
+		txs:     map[string][]*subscriber{},
+		wallets: map[string][]*subscriber{},
+	}
+}
+
+// SubscribeTx registers a listener for the events of the transaction with the passed id
+func (e *EventService) SubscribeTx(txID string, listener EventListener) *Subscription {
+	return e.subscribe(e.txs, txID, listener)
+}
+
+// SubscribeWallet registers a listener for the events involving the wallet with the passed id
+func (e *EventService) SubscribeWallet(walletID string, listener EventListener) *Subscription {
+	return e.subscribe(e.wallets, walletID, listener)
+}
+
+// Notify dispatches the passed event to the listeners of its transaction and wallets
+func (e *EventService) Notify(event *Event) {
+	e.lock.Lock()
+	var subscribers []*subscriber
+	subscribers = append(subscribers, e.txs[event.TxID]...)
+	if event.Type == TxCommitted || event.Type == TxInvalidated {
+		delete(e.txs, event.TxID)
+	}
+	for _, walletID := range event.WalletIDs {
+		subscribers = append(subscribers, e.wallets[walletID]...)
+	}
+	e.lock.Unlock()
+
+	logger.Debugf("notify event [%s] of [%s] to [%d] listeners", event.Type, event.TxID, len(subscribers))
+	for _, s := range subscribers {
+		s.listener.OnEvent(event)
+	}
+}
+
+func (e *EventService) subscribe(subscribers map[string][]*subscriber, key string, listener EventListener) *Subscription {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	s := &subscriber{listener: listener}
+	subscribers[key] = append(subscribers[key], s)
+	return &Subscription{unsubscribe: func() {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+
+		list := subscribers[key]
+		for i, entry := range list {
+			if entry == s {
+				list = append(list[:i], list[i+1:]...)
+				break
+			}
+		}
+		if len(list) == 0 {
+			delete(subscribers, key)
+		} else {
+			subscribers[key] = list
+		}
+	}}
+}
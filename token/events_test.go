@@ -0,0 +1,105 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package token
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingListener struct {
+	lock   sync.Mutex
+	events []*Event
+}
+
+func (r *recordingListener) OnEvent(event *Event) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recordingListener) types() []EventType {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var types []EventType
+	for _, event := range r.events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestEventServiceTx(t *testing.T) {
+	es := newEventService()
+	tx1, tx2 := &recordingListener{}, &recordingListener{}
+	es.SubscribeTx("tx1", tx1)
+	es.SubscribeTx("tx2", tx2)
+
+	// a transaction subscription gets the events of that transaction only, until it is committed
+	es.Notify(&Event{Type: TokensReceived, TxID: "tx1", WalletIDs: []string{"alice"}})
+	es.Notify(&Event{Type: TxCommitted, TxID: "tx1", WalletIDs: []string{"alice"}})
+	es.Notify(&Event{Type: TxCommitted, TxID: "tx1", WalletIDs: []string{"alice"}})
+	assert.Equal(t, []EventType{TokensReceived, TxCommitted}, tx1.types())
+	assert.Empty(t, tx2.types())
+
+	// or invalidated
+	es.Notify(&Event{Type: TxInvalidated, TxID: "tx2"})
+	es.Notify(&Event{Type: TxInvalidated, TxID: "tx2"})
+	assert.Equal(t, []EventType{TxInvalidated}, tx2.types())
+	assert.Empty(t, es.txs)
+}
+
+func TestEventServiceWallet(t *testing.T) {
+	es := newEventService()
+	alice, bob := &recordingListener{}, &recordingListener{}
+	es.SubscribeWallet("alice", alice)
+	es.SubscribeWallet("bob", bob)
+
+	// a wallet subscription gets the events involving that wallet, and outlives the transactions
+	es.Notify(&Event{Type: TokensSpent, TxID: "tx1", WalletIDs: []string{"alice"}})
+	es.Notify(&Event{Type: TokensReceived, TxID: "tx1", WalletIDs: []string{"bob"}})
+	es.Notify(&Event{Type: TxCommitted, TxID: "tx1", WalletIDs: []string{"alice", "bob"}})
+	es.Notify(&Event{Type: TxInvalidated, TxID: "tx2", WalletIDs: []string{"alice"}})
+	assert.Equal(t, []EventType{TokensSpent, TxCommitted, TxInvalidated}, alice.types())
+	assert.Equal(t, []EventType{TokensReceived, TxCommitted}, bob.types())
+	assert.Equal(t, "tx2", alice.events[2].TxID)
+}
+
+func TestEventServiceUnsubscribe(t *testing.T) {
+	es := newEventService()
+	first, second, tx := &recordingListener{}, &recordingListener{}, &recordingListener{}
+	s1 := es.SubscribeWallet("alice", first)
+	es.SubscribeWallet("alice", second)
+	s3 := es.SubscribeTx("tx1", tx)
+
+	// only the unsubscribed listener stops getting events
+	s1.Unsubscribe()
+	s3.Unsubscribe()
+	es.Notify(&Event{Type: TxCommitted, TxID: "tx1", WalletIDs: []string{"alice"}})
+	assert.Empty(t, first.types())
+	assert.Empty(t, tx.types())
+	assert.Equal(t, []EventType{TxCommitted}, second.types())
+
+	// unsubscribing twice is harmless
+	s1.Unsubscribe()
+	assert.Len(t, es.wallets["alice"], 1)
+
+	// the same listener can subscribe again
+	s1 = es.SubscribeWallet("alice", first)
+	es.Notify(&Event{Type: TxCommitted, TxID: "tx2", WalletIDs: []string{"alice"}})
+	assert.Equal(t, []EventType{TxCommitted}, first.types())
+	assert.Equal(t, []EventType{TxCommitted, TxCommitted}, second.types())
+
+	// unsubscribing from a transaction already committed is harmless too
+	s3 = es.SubscribeTx("tx3", tx)
+	es.Notify(&Event{Type: TxCommitted, TxID: "tx3"})
+	s3.Unsubscribe()
+	assert.Equal(t, []EventType{TxCommitted}, tx.types())
+	s1.Unsubscribe()
+	assert.Len(t, es.wallets["alice"], 1)
+	assert.Empty(t, es.txs)
+}
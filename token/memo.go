@@ -0,0 +1,234 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package token
+
+import (
+	"crypto/rand"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
+)
+
+// RedeemIssuerMemo is the key of the memo binding a redeemed output to the issuer it goes back to
+const RedeemIssuerMemo = "token.redeem.issuer"
+
+// TransferMemo is application data to attach to the outputs of a transfer
+type TransferMemo struct {
+	Key   string
+	Value []byte
+	// Private memos are disclosed only to the owner of the output, and to the auditor
+	Private bool
+}
+
+// WithMemo attaches the passed memo to each output of the transfer that goes to a recipient
+func WithMemo(key string, value []byte) TransferOption {
+	return func(o *TransferOptions) error {
+		o.Memos = append(o.Memos, &TransferMemo{Key: key, Value: value})
+		return nil
+	}
+}
+
+// WithPrivateMemo attaches the passed memo to each output of the transfer that goes to a recipient.
+// The memo is disclosed only to the recipient of the output.
+func WithPrivateMemo(key string, value []byte) TransferOption {
+	return func(o *TransferOptions) error {
+		o.Memos = append(o.Memos, &TransferMemo{Key: key, Value: value, Private: true})
+		return nil
+	}
+}
+
+// SetMemo attaches the passed memo to the request as a whole. The memo is disclosed to all parties.
+func (t *Request) SetMemo(key string, value []byte) error {
+	return t.addMemo(api2.RequestMemo, 0, 0, key, value, nil)
+}
+
+// SetIssueMemo attaches the passed memo to the passed output of the passed issue action.
+// If private is true, the memo is disclosed only to the owner of the output.
+func (t *Request) SetIssueMemo(issue, output int, key string, value []byte, private bool) error {
+	if issue < 0 || issue >= len(t.Metadata.Issues) {
+		return errors.Errorf("issue action [%d] out of range", issue)
+	}
+	receivers := t.Metadata.Issues[issue].Receivers
+	if output < 0 || output >= len(receivers) {
+		return errors.Errorf("output [%d] of issue action [%d] out of range", output, issue)
+	}
+	var recipient view.Identity
+	if private {
+		recipient = receivers[output]
+	}
+	return t.addMemo(api2.IssueMemo, issue, output, key, value, recipient)
+}
+
+// SetTransferMemo attaches the passed memo to the passed output of the passed transfer action.
+// If private is true, the memo is disclosed only to the owner of the output.
+func (t *Request) SetTransferMemo(transfer, output int, key string, value []byte, private bool) error {
+	if transfer < 0 || transfer >= len(t.Metadata.Transfers) {
+		return errors.Errorf("transfer action [%d] out of range", transfer)
+	}
+	receivers := t.Metadata.Transfers[transfer].Receivers
+	if output < 0 || output >= len(receivers) {
+		return errors.Errorf("output [%d] of transfer action [%d] out of range", output, transfer)
+	}
+	var recipient view.Identity
+	if private {
+		if receivers[output].IsNone() {
+			return errors.Errorf("output [%d] of transfer action [%d] is a redeem, it has no recipient", output, transfer)
+		}
+		recipient = receivers[output]
+	}
+	return t.addMemo(api2.TransferMemo, transfer, output, key, value, recipient)
+}
+
+// Memos returns the memos attached to the request as a whole
+func (t *Request) Memos() (map[string][]byte, error) {
+	return t.memosOf(api2.RequestMemo, 0, 0)
+}
+
+// FilterMemos returns a copy of this request without the private memos whose recipient is not accepted by the passed function.
+// Only the content of the memos is removed, the request keeps their hashes.
+func (t *Request) FilterMemos(keep func(recipient view.Identity) bool) *Request {
+	var memos []api2.MemoMetadata
+	for _, memo := range t.Metadata.Memos {
+		if !memo.Recipient.IsNone() && !keep(memo.Recipient) {
+			continue
+		}
+		memos = append(memos, memo)
+	}
+	metadata := *t.Metadata
+	metadata.Memos = memos
+	return &Request{
+		TxID:         t.TxID,
+		Actions:      t.Actions,
+		Metadata:     &metadata,
+		TokenService: t.TokenService,
+	}
+}
+
+func (t *Request) addMemo(target api2.MemoTarget, action, output int, key string, value []byte, recipient view.Identity) error {
+	if len(key) == 0 {
+		return errors.New("memo key not set")
+	}
+	existing, err := t.memosOf(target, action, output)
+	if err != nil {
+		return err
+	}
+	if _, ok := existing[key]; ok {
+		return errors.Errorf("memo [%s] already set", key)
+	}
+
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return errors.Wrap(err, "failed generating memo salt")
+	}
+	metadata := api2.MemoMetadata{Key: key, Value: value, Salt: salt, Recipient: recipient}
+	raw, err := (&api2.Memo{Target: target, Action: action, Output: output, Hash: metadata.Hash()}).Serialize()
+	if err != nil {
+		return errors.Wrap(err, "failed serializing memo")
+	}
+	t.Actions.Memos = append(t.Actions.Memos, raw)
+	t.Metadata.Memos = append(t.Metadata.Memos, metadata)
+	return nil
+}
+
+// memosOf returns the content of the memos attached to the passed target that this party can see
+func (t *Request) memosOf(target api2.MemoTarget, action, output int) (map[string][]byte, error) {
+	memos, err := t.memos()
+	if err != nil {
+		return nil, err
+	}
+	res := map[string][]byte{}
+	for i, memo := range memos {
+		if memo.Target != target || memo.Action != action || memo.Output != output {
+			continue
+		}
+		if metadata := t.memoMetadata(memo); metadata != nil {
+			res[metadata.Key] = metadata.Value
+		} else {
+			logger.Debugf("content of memo [%d] not available", i)
+		}
+	}
+	return res, nil
+}
+
+func (t *Request) memos() ([]*api2.Memo, error) {
+	var memos []*api2.Memo
+	for i, raw := range t.Actions.Memos {
+		memo := &api2.Memo{}
+		if err := memo.Deserialize(raw); err != nil {
+			return nil, errors.Wrapf(err, "failed deserializing memo [%d]", i)
+		}
+		memos = append(memos, memo)
+	}
+	return memos, nil
+}
+
+func (t *Request) memoMetadata(memo *api2.Memo) *api2.MemoMetadata {
+	for i := range t.Metadata.Memos {
+		if t.Metadata.Memos[i].Matches(memo) {
+			return &t.Metadata.Memos[i]
+		}
+	}
+	return nil
+}
+
+// verifyMemos checks that the memos refer to existing outputs and that each content matches a memo
+func (t *Request) verifyMemos() error {
+	memos, err := t.memos()
+	if err != nil {
+		return err
+	}
+	for i, memo := range memos {
+		switch memo.Target {
+		case api2.IssueMemo:
+			if memo.Action >= len(t.Metadata.Issues) || memo.Output >= len(t.Metadata.Issues[memo.Action].Receivers) {
+				return errors.Errorf("memo [%d] refers to a non existing issue output [%d,%d]", i, memo.Action, memo.Output)
+			}
+		case api2.TransferMemo:
+			if memo.Action >= len(t.Metadata.Transfers) || memo.Output >= len(t.Metadata.Transfers[memo.Action].Receivers) {
+				return errors.Errorf("memo [%d] refers to a non existing transfer output [%d,%d]", i, memo.Action, memo.Output)
+			}
+		}
+	}
+	for i := range t.Metadata.Memos {
+		found := false
+		for _, memo := range memos {
+			if t.Metadata.Memos[i].Matches(memo) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("content of memo [%s] does not match any memo of the request", t.Metadata.Memos[i].Key)
+		}
+	}
+	return nil
+}
+
+// importMemos appends the memos of the passed request shifting their action indices past the actions of this request.
+// It must be called before the actions of the passed request are appended.
+func (t *Request) importMemos(request *Request) error {
+	memos, err := request.memos()
+	if err != nil {
+		return err
+	}
+	for _, memo := range memos {
+		switch memo.Target {
+		case api2.IssueMemo:
+			memo.Action += len(t.Actions.Issues)
+		case api2.TransferMemo:
+			memo.Action += len(t.Actions.Transfers)
+		}
+		raw, err := memo.Serialize()
+		if err != nil {
+			return errors.Wrap(err, "failed serializing memo")
+		}
+		t.Actions.Memos = append(t.Actions.Memos, raw)
+	}
+	t.Metadata.Memos = append(t.Metadata.Memos, request.Metadata.Memos...)
+	return nil
+}
@@ -6,6 +6,8 @@ SPDX-License-Identifier: Apache-2.0
 package token
 
 import (
+	"sync"
+
 	tokenapi "github.com/hyperledger-labs/fabric-token-sdk/token/api"
 )
 
@@ -38,6 +40,9 @@ type ManagementServiceProvider struct {
 	selectorManagerProvider     SelectorManagerProvider
 	vaultProvider               VaultProvider
 	sigService                  tokenapi.SigService
+
+	eventsLock sync.Mutex
+	events     map[string]*EventService
 }
 
 func NewManagementServiceProvider(
@@ -57,6 +62,7 @@ func NewManagementServiceProvider(
 		certificationClientProvider: certificationClientProvider,
 		selectorManagerProvider:     selectorManagerProvider,
 		sigService:                  sigService,
+		events:                      map[string]*EventService{},
 	}
 }
 
@@ -90,7 +96,22 @@ func (p *ManagementServiceProvider) GetManagementService(opts ...ServiceOption)
 		certificationClientProvider: p.certificationClientProvider,
 		selectorManagerProvider:     p.selectorManagerProvider,
 		signatureService:            &SignatureService{p.sigService},
+		eventService:                p.eventService(opt.Network, opt.Channel, opt.Namespace),
+	}
+}
+
+// eventService returns the event service shared by all the instances of the same token management service
+func (p *ManagementServiceProvider) eventService(network, channel, namespace string) *EventService {
+	p.eventsLock.Lock()
+	defer p.eventsLock.Unlock()
+
+	k := network + ":" + channel + ":" + namespace
+	e, ok := p.events[k]
+	if !ok {
+		e = newEventService()
+		p.events[k] = e
 	}
+	return e
 }
 
 func GetManagementServiceProvider(sp ServiceProvider) *ManagementServiceProvider {
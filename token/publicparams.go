@@ -19,8 +19,12 @@ func (c *PublicParametersManager) SetAuditor(auditor []byte) ([]byte, error) {
 	return c.ppm.SetAuditor(auditor)
 }
 
-func (c *PublicParametersManager) SetCertifier(certifier []byte) ([]byte, error) {
-	return c.ppm.SetCertifier(certifier)
+func (c *PublicParametersManager) SetCertifier(certifier []byte, identity []byte) ([]byte, error) {
+	return c.ppm.SetCertifier(certifier, identity)
+}
+
+func (c *PublicParametersManager) CertifierIdentity() []byte {
+	return c.ppm.CertifierIdentity()
 }
 
 func (c *PublicParametersManager) AddIssuer(bytes []byte) ([]byte, error) {
@@ -0,0 +1,160 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package token
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// clearOutput is a token in the clear, as the outputs of a fabtoken transfer
+type clearOutput struct {
+	tok *token2.Token
+}
+
+func (o *clearOutput) Serialize() ([]byte, error) {
+	return json.Marshal(o.tok)
+}
+
+func (o *clearOutput) IsRedeem() bool {
+	return len(o.tok.Owner.Raw) == 0
+}
+
+type clearTransfer struct {
+	api2.TransferAction
+	Inputs  []*token2.Id
+	Outputs []*token2.Token
+}
+
+func (t *clearTransfer) Serialize() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+func (t *clearTransfer) GetOutputs() []api2.Output {
+	var res []api2.Output
+	for _, tok := range t.Outputs {
+		res = append(res, &clearOutput{tok: tok})
+	}
+	return res
+}
+
+// clearTMS transfers tokens in the clear, it implements the part of the token manager service used by the redeems
+type clearTMS struct {
+	api2.TokenManagerService
+}
+
+func (c *clearTMS) Transfer(txID string, wallet api2.OwnerWallet, ids []*token2.Id, outputs ...*token2.Token) (api2.TransferAction, *api2.TransferMetadata, error) {
+	metadata := &api2.TransferMetadata{TokenIDs: ids}
+	for _, output := range outputs {
+		metadata.Receivers = append(metadata.Receivers, output.Owner.Raw)
+		metadata.TokenInfo = append(metadata.TokenInfo, nil)
+	}
+	return &clearTransfer{Inputs: ids, Outputs: outputs}, metadata, nil
+}
+
+func (c *clearTMS) VerifyTransfer(tr api2.TransferAction, tokenInfos [][]byte) error {
+	return nil
+}
+
+func (c *clearTMS) DeserializeTransferAction(raw []byte) (api2.TransferAction, error) {
+	transfer := &clearTransfer{}
+	if err := json.Unmarshal(raw, transfer); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+func (c *clearTMS) DeserializeToken(outputRaw []byte, tokenInfoRaw []byte) (*token2.Token, view.Identity, error) {
+	tok := &token2.Token{}
+	if err := json.Unmarshal(outputRaw, tok); err != nil {
+		return nil, nil, err
+	}
+	return tok, nil, nil
+}
+
+type clearOwnerWallet struct {
+	api2.OwnerWallet
+}
+
+func (w *clearOwnerWallet) ID() string {
+	return "alice"
+}
+
+func (w *clearOwnerWallet) GetRecipientIdentity() (view.Identity, error) {
+	return view.Identity("alice"), nil
+}
+
+// fixedSelector selects a single token of the given quantity, whatever is asked
+type fixedSelector struct {
+	quantity uint64
+}
+
+func (s *fixedSelector) Select(ownerFilter OwnerFilter, q, tokenType string) ([]*token2.Id, token2.Quantity, error) {
+	return []*token2.Id{{TxId: "tx0", Index: 0}}, token2.NewQuantityFromUInt64(s.quantity), nil
+}
+
+func redeemed(t *testing.T, request *Request) map[int]string {
+	rts, err := request.RedeemedTokens()
+	assert.NoError(t, err)
+	res := map[int]string{}
+	for _, rt := range rts {
+		assert.Equal(t, view.Identity("issuer"), rt.Issuer)
+		assert.Equal(t, "USD", rt.Token.Type)
+		res[rt.Index] = rt.Token.Quantity
+	}
+	return res
+}
+
+func TestRedeemValues(t *testing.T) {
+	tms := &ManagementService{tms: &clearTMS{}}
+	wallet := &OwnerWallet{w: &clearOwnerWallet{}}
+
+	// multiple values are redeemed in a single action, the rest goes back to the wallet
+	request := NewRequest(tms, "tx1")
+	assert.NoError(t, request.RedeemValues(wallet, "USD", []uint64{10, 20}, WithTokenSelector(&fixedSelector{quantity: 50}), WithRedeemIssuer(view.Identity("issuer"))))
+	assert.Len(t, request.Actions.Transfers, 1)
+	assert.Equal(t, view.Identity("issuer"), request.Metadata.Transfers[0].RedeemIssuer)
+	transfer, err := tms.tms.DeserializeTransferAction(request.Actions.Transfers[0])
+	assert.NoError(t, err)
+	outputs := transfer.(*clearTransfer).Outputs
+	assert.Len(t, outputs, 3)
+	assert.Equal(t, token2.NewQuantityFromUInt64(20).Decimal(), outputs[2].Quantity)
+	assert.Equal(t, []byte("alice"), outputs[2].Owner.Raw)
+	// the redeemed outputs, not the change, go back to the issuer
+	assert.Equal(t, map[int]string{
+		0: token2.NewQuantityFromUInt64(10).Decimal(),
+		1: token2.NewQuantityFromUInt64(20).Decimal(),
+	}, redeemed(t, request))
+
+	// no change if the inputs match the redeemed values
+	request = NewRequest(tms, "tx2")
+	assert.NoError(t, request.Redeem(wallet, "USD", 30, WithTokenSelector(&fixedSelector{quantity: 30}), WithRedeemIssuer(view.Identity("issuer"))))
+	transfer, err = tms.tms.DeserializeTransferAction(request.Actions.Transfers[0])
+	assert.NoError(t, err)
+	assert.Len(t, transfer.(*clearTransfer).Outputs, 1)
+	assert.Equal(t, map[int]string{0: token2.NewQuantityFromUInt64(30).Decimal()}, redeemed(t, request))
+
+	// without an issuer, the tokens are just destroyed
+	request = NewRequest(tms, "tx3")
+	assert.NoError(t, request.RedeemValues(wallet, "USD", []uint64{10, 20}, WithTokenSelector(&fixedSelector{quantity: 50})))
+	assert.Empty(t, redeemed(t, request))
+
+	// the indexes of the redeemed outputs count the outputs of the actions before
+	request = NewRequest(tms, "tx4")
+	request.Metadata.Issues = append(request.Metadata.Issues, api2.IssueMetadata{Outputs: [][]byte{nil, nil}})
+	assert.NoError(t, request.RedeemValues(wallet, "USD", []uint64{5}, WithTokenSelector(&fixedSelector{quantity: 10})))
+	assert.NoError(t, request.RedeemValues(wallet, "USD", []uint64{10}, WithTokenSelector(&fixedSelector{quantity: 10}), WithRedeemIssuer(view.Identity("issuer"))))
+	assert.Equal(t, map[int]string{4: token2.NewQuantityFromUInt64(10).Decimal()}, redeemed(t, request))
+
+	assert.EqualError(t, NewRequest(tms, "tx5").RedeemValues(wallet, "USD", nil), "no value to redeem")
+}
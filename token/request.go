@@ -6,8 +6,6 @@ SPDX-License-Identifier: Apache-2.0
 package token
 
 import (
-	"encoding/json"
-
 	"github.com/pkg/errors"
 
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
@@ -20,6 +18,12 @@ import (
 type TransferOptions struct {
 	Selector Selector
 	TokenIDs []*token2.Id
+	// Memos are attached to the outputs that go to the recipients
+	Memos []*TransferMemo
+	// RedeemIssuer is the issuer the redeemed tokens go back to
+	RedeemIssuer view.Identity
+	// Settlement is true if the transfer pays out the deposit of a closed dispute
+	Settlement bool
 }
 
 func compileTransferOptions(opts ...TransferOption) (*TransferOptions, error) {
@@ -48,6 +52,24 @@ func WithTokenIDs(ids ...*token2.Id) TransferOption {
 	}
 }
 
+// WithRedeemIssuer binds, with a RedeemIssuerMemo memo on each redeemed output, the redeemed tokens to the passed issuer
+// they go back to. The issuer receives the transaction and sees the redeemed tokens in the history of its issuer wallet.
+func WithRedeemIssuer(issuer view.Identity) TransferOption {
+	return func(o *TransferOptions) error {
+		o.RedeemIssuer = issuer
+		return nil
+	}
+}
+
+// WithSettlement marks the transfer as the payout of the deposit of a channel whose dispute is closed.
+// The validator checks the outputs against the balances fixed by the dispute.
+func WithSettlement() TransferOption {
+	return func(o *TransferOptions) error {
+		o.Settlement = true
+		return nil
+	}
+}
+
 type AuditRecord struct {
 	TxID   string
 	Inputs *InputStream
@@ -62,6 +84,8 @@ type Issue struct {
 type Transfer struct {
 	Senders   []view.Identity
 	Receivers []view.Identity
+	// RedeemIssuer is the issuer the redeemed outputs go back to, if any
+	RedeemIssuer view.Identity
 }
 
 type Request struct {
@@ -157,10 +181,19 @@ func (t *Request) Transfer(wallet *OwnerWallet, typ string, values []uint64, own
 
 	logger.Debugf("Prepare Transfer Action [id:%s,ins:%d,outs:%d]", t.TxID, len(tokenIDs), len(outputTokens))
 
+	transferOpts, err := compileTransferOptions(opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed compiling transfer options")
+	}
+
 	ts := t.TokenService.tms
 
 	// Compute transfer
-	transfer, transferMetadata, err := ts.Transfer(t.TxID, wallet.w, tokenIDs, outputTokens...)
+	compute := ts.Transfer
+	if ss, ok := ts.(api2.SettlementService); ok && transferOpts.Settlement {
+		compute = ss.Settle
+	}
+	transfer, transferMetadata, err := compute(t.TxID, wallet.w, tokenIDs, outputTokens...)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed creating transfer action")
 	}
@@ -177,11 +210,33 @@ func (t *Request) Transfer(wallet *OwnerWallet, typ string, values []uint64, own
 	t.Actions.Transfers = append(t.Actions.Transfers, raw)
 	t.Metadata.Transfers = append(t.Metadata.Transfers, *transferMetadata)
 
+	for _, memo := range transferOpts.Memos {
+		for j := range owners {
+			if err := t.SetTransferMemo(len(t.Actions.Transfers)-1, j, memo.Key, memo.Value, memo.Private); err != nil {
+				return nil, errors.WithMessagef(err, "failed attaching memo [%s]", memo.Key)
+			}
+		}
+	}
+
 	return &TransferAction{a: transfer}, nil
 }
 
 func (t *Request) Redeem(wallet *OwnerWallet, typ string, value uint64, opts ...TransferOption) error {
-	tokenIDs, outputTokens, err := t.prepareTransfer(true, wallet, typ, []uint64{value}, []view.Identity{nil}, opts...)
+	return t.RedeemValues(wallet, typ, []uint64{value}, opts...)
+}
+
+// RedeemValues redeems the passed values of the passed type from the passed wallet in a single transfer action.
+// The rest of the selected tokens goes back to the wallet. Each wallet involved in a redeem adds its own action.
+func (t *Request) RedeemValues(wallet *OwnerWallet, typ string, values []uint64, opts ...TransferOption) error {
+	if len(values) == 0 {
+		return errors.New("no value to redeem")
+	}
+	transferOpts, err := compileTransferOptions(opts...)
+	if err != nil {
+		return errors.Wrap(err, "failed compiling transfer options")
+	}
+
+	tokenIDs, outputTokens, err := t.prepareTransfer(true, wallet, typ, values, make([]view.Identity, len(values)), opts...)
 	if err != nil {
 		return errors.Wrap(err, "failed preparing transfer")
 	}
@@ -200,6 +255,7 @@ func (t *Request) Redeem(wallet *OwnerWallet, typ string, value uint64, opts ...
 	if err := ts.VerifyTransfer(transfer, transferMetadata.TokenInfo); err != nil {
 		return errors.Wrap(err, "failed checking generated proof")
 	}
+	transferMetadata.RedeemIssuer = transferOpts.RedeemIssuer
 
 	// Append
 	raw, err := transfer.Serialize()
@@ -209,9 +265,76 @@ func (t *Request) Redeem(wallet *OwnerWallet, typ string, value uint64, opts ...
 	t.Actions.Transfers = append(t.Actions.Transfers, raw)
 	t.Metadata.Transfers = append(t.Metadata.Transfers, *transferMetadata)
 
+	// the issuer is bound to the redeemed outputs by signed memos, the metadata only tells whom to distribute to
+	if !transferOpts.RedeemIssuer.IsNone() {
+		for j, receiver := range transferMetadata.Receivers {
+			if !receiver.IsNone() {
+				continue
+			}
+			if err := t.SetTransferMemo(len(t.Actions.Transfers)-1, j, RedeemIssuerMemo, transferOpts.RedeemIssuer, false); err != nil {
+				return errors.WithMessage(err, "failed binding redeem issuer")
+			}
+		}
+	}
+
 	return nil
 }
 
+// RedeemedToken is an output of a token request redeemed back to an issuer
+type RedeemedToken struct {
+	// Index is the position of the output among all the outputs of the token request
+	Index     int
+	Issuer    view.Identity
+	Token     *token2.Token
+	TokenInfo []byte
+}
+
+// RedeemedTokens returns the outputs of the transfers that redeem tokens back to an issuer,
+// as bound by the RedeemIssuerMemo memos of the request
+func (t *Request) RedeemedTokens() ([]*RedeemedToken, error) {
+	base := 0
+	for _, issue := range t.Metadata.Issues {
+		base += len(issue.Outputs)
+	}
+	var res []*RedeemedToken
+	for i, transfer := range t.Actions.Transfers {
+		action, err := t.TokenService.tms.DeserializeTransferAction(transfer)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed deserializing transfer action [%d]", i)
+		}
+		outputs := action.GetOutputs()
+		for j, output := range outputs {
+			memos, err := t.memosOf(api2.TransferMemo, i, j)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed getting memos [%d,%d]", i, j)
+			}
+			issuer := memos[RedeemIssuerMemo]
+			if len(issuer) == 0 {
+				continue
+			}
+			raw, err := output.Serialize()
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed deserializing transfer action output [%d,%d]", i, j)
+			}
+			tok, _, err := t.TokenService.tms.DeserializeToken(raw, t.Metadata.Transfers[i].TokenInfo[j])
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed getting transfer action output in the clear [%d,%d]", i, j)
+			}
+			if len(tok.Owner.Raw) != 0 {
+				continue
+			}
+			res = append(res, &RedeemedToken{
+				Index:     base + j,
+				Issuer:    issuer,
+				Token:     tok,
+				TokenInfo: t.Metadata.Transfers[i].TokenInfo[j],
+			})
+		}
+		base += len(outputs)
+	}
+	return res, nil
+}
+
 func (t *Request) Outputs() (*OutputStream, error) {
 	var outputs []*Output
 	for i, issue := range t.Actions.Issues {
@@ -233,12 +356,19 @@ func (t *Request) Outputs() (*OutputStream, error) {
 				return nil, errors.Wrapf(err, "failed getting enrollment id [%d,%d]", i, j)
 			}
 
+			memos, err := t.memosOf(api2.IssueMemo, i, j)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed getting memos [%d,%d]", i, j)
+			}
+
 			outputs = append(outputs, &Output{
-				ActionIndex:  i,
-				Owner:        tok.Owner.Raw,
-				EnrollmentID: eID,
-				Type:         tok.Type,
-				Quantity:     tok.Quantity,
+				ActionIndex:    i,
+				Owner:          tok.Owner.Raw,
+				OwnerAuditInfo: t.Metadata.Issues[i].AuditInfos[j],
+				EnrollmentID:   eID,
+				Type:           tok.Type,
+				Quantity:       tok.Quantity,
+				Memos:          memos,
 			})
 		}
 	}
@@ -264,12 +394,19 @@ func (t *Request) Outputs() (*OutputStream, error) {
 				}
 			}
 
+			memos, err := t.memosOf(api2.TransferMemo, i, j)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed getting memos [%d,%d]", i, j)
+			}
+
 			outputs = append(outputs, &Output{
-				ActionIndex:  i,
-				Owner:        tok.Owner.Raw,
-				EnrollmentID: eID,
-				Type:         tok.Type,
-				Quantity:     tok.Quantity,
+				ActionIndex:    i,
+				Owner:          tok.Owner.Raw,
+				OwnerAuditInfo: t.Metadata.Transfers[i].ReceiverAuditInfos[j],
+				EnrollmentID:   eID,
+				Type:           tok.Type,
+				Quantity:       tok.Quantity,
+				Memos:          memos,
 			})
 		}
 	}
@@ -319,6 +456,10 @@ func (t *Request) Verify() error {
 		}
 	}
 
+	if err := t.verifyMemos(); err != nil {
+		return errors.WithMessagef(err, "failed verifying memos")
+	}
+
 	if _, err := t.Inputs(); err != nil {
 		return errors.WithMessagef(err, "failed verifying inputs")
 	}
@@ -344,7 +485,7 @@ func (t *Request) IsValid() error {
 }
 
 func (t *Request) MarshallToAudit() ([]byte, error) {
-	bytes, err := json.Marshal(&api2.TokenRequest{Issues: t.Actions.Issues, Transfers: t.Actions.Transfers})
+	bytes, err := t.Actions.MessageToSign()
 	if err != nil {
 		return nil, errors.Wrapf(err, "audit of tx [%s] failed: error marshal token request for signature", t.TxID)
 	}
@@ -352,11 +493,7 @@ func (t *Request) MarshallToAudit() ([]byte, error) {
 }
 
 func (t *Request) MarshallToSign() ([]byte, error) {
-	req := &api2.TokenRequest{
-		Issues:    t.Actions.Issues,
-		Transfers: t.Actions.Transfers,
-	}
-	return json.Marshal(req)
+	return t.Actions.MessageToSign()
 }
 
 func (t *Request) RequestToBytes() ([]byte, error) {
@@ -432,14 +569,18 @@ func (t *Request) Transfers() []*Transfer {
 	var transfers []*Transfer
 	for _, transfer := range t.Metadata.Transfers {
 		transfers = append(transfers, &Transfer{
-			Senders:   transfer.Senders,
-			Receivers: transfer.Receivers,
+			Senders:      transfer.Senders,
+			Receivers:    transfer.Receivers,
+			RedeemIssuer: transfer.RedeemIssuer,
 		})
 	}
 	return transfers
 }
 
 func (t *Request) Import(request *Request) error {
+	if err := t.importMemos(request); err != nil {
+		return errors.WithMessage(err, "failed importing memos")
+	}
 	for _, issue := range request.Actions.Issues {
 		t.Actions.Issues = append(t.Actions.Issues, issue)
 	}
@@ -452,9 +593,88 @@ func (t *Request) Import(request *Request) error {
 	for _, transfer := range request.Metadata.Transfers {
 		t.Metadata.Transfers = append(t.Metadata.Transfers, transfer)
 	}
+	for _, swap := range request.Actions.Swaps {
+		t.Actions.Swaps = append(t.Actions.Swaps, swap)
+	}
+	return nil
+}
+
+// DeclareSwap declares that the output giveOutput of the transfer action giveTransfer is exchanged
+// with the output wantOutput of the transfer action wantTransfer.
+// The terms of the swap are derived from the outputs and are covered by the signatures on the request.
+func (t *Request) DeclareSwap(giveTransfer, giveOutput, wantTransfer, wantOutput int) error {
+	give, err := t.SwapLeg(giveTransfer, giveOutput)
+	if err != nil {
+		return errors.WithMessagef(err, "failed preparing give leg")
+	}
+	want, err := t.SwapLeg(wantTransfer, wantOutput)
+	if err != nil {
+		return errors.WithMessagef(err, "failed preparing want leg")
+	}
+	swap := &api2.Swap{Give: give, Want: want}
+	if err := swap.Validate(len(t.Actions.Transfers)); err != nil {
+		return err
+	}
+	raw, err := swap.Serialize()
+	if err != nil {
+		return errors.Wrap(err, "failed serializing swap")
+	}
+	t.Actions.Swaps = append(t.Actions.Swaps, raw)
 	return nil
 }
 
+// Swaps returns the swaps declared in this request
+func (t *Request) Swaps() ([]*api2.Swap, error) {
+	var swaps []*api2.Swap
+	for i, raw := range t.Actions.Swaps {
+		swap := &api2.Swap{}
+		if err := swap.Deserialize(raw); err != nil {
+			return nil, errors.Wrapf(err, "failed deserializing swap [%d]", i)
+		}
+		swaps = append(swaps, swap)
+	}
+	return swaps, nil
+}
+
+// SwapLeg returns the terms of the passed output of the passed transfer action as a swap leg
+func (t *Request) SwapLeg(transferIndex, outputIndex int) (*api2.SwapLeg, error) {
+	if transferIndex < 0 || transferIndex >= len(t.Actions.Transfers) {
+		return nil, errors.Errorf("transfer action [%d] out of range", transferIndex)
+	}
+	action, err := t.TokenService.tms.DeserializeTransferAction(t.Actions.Transfers[transferIndex])
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed deserializing transfer action [%d]", transferIndex)
+	}
+	outputs := action.GetOutputs()
+	if outputIndex < 0 || outputIndex >= len(outputs) {
+		return nil, errors.Errorf("output [%d] of transfer action [%d] out of range", outputIndex, transferIndex)
+	}
+	raw, err := outputs[outputIndex].Serialize()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed serializing output [%d,%d]", transferIndex, outputIndex)
+	}
+	tokenInfo := t.Metadata.Transfers[transferIndex].TokenInfo[outputIndex]
+	tok, _, err := t.TokenService.tms.DeserializeToken(raw, tokenInfo)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting output in the clear [%d,%d]", transferIndex, outputIndex)
+	}
+	q, err := token2.ToQuantity(tok.Quantity, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed parsing quantity of output [%d,%d]", transferIndex, outputIndex)
+	}
+	proof, err := t.TokenService.tms.SwapProof(raw, tokenInfo)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed proving the terms of output [%d,%d]", transferIndex, outputIndex)
+	}
+	return &api2.SwapLeg{
+		Transfer: transferIndex,
+		Output:   outputIndex,
+		Type:     tok.Type,
+		Quantity: q.ToBigInt().Uint64(),
+		Proof:    proof,
+	}, nil
+}
+
 func (t *Request) AuditCheck() error {
 	if err := t.Verify(); err != nil {
 		return err
@@ -27,6 +27,10 @@ import (
 	_ "github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor/auditdb/db/memory"
 	_ "github.com/hyperledger-labs/fabric-token-sdk/token/services/certifier/dummy"
 	_ "github.com/hyperledger-labs/fabric-token-sdk/token/services/certifier/interactive"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/offchaintx/service"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/ownerdb"
+	_ "github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/ownerdb/db/badger"
+	_ "github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/ownerdb/db/memory"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/query"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/selector"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/processor"
@@ -98,6 +102,16 @@ func (p *SDK) Install() error {
 	}
 	assert.NoError(p.registry.RegisterService(auditdb.NewManager(p.registry, driverName)))
 
+	// OwnerDB
+	driverName = view2.GetConfigService(p.registry).GetString("token.owner.ownerdb.persistence.type")
+	if len(driverName) == 0 {
+		driverName = "memory"
+	}
+	assert.NoError(p.registry.RegisterService(ownerdb.NewManager(p.registry, driverName)))
+
+	// Off-chain channels
+	assert.NoError(p.registry.RegisterService(service.NewTrackerService(p.registry)))
+
 	logger.Infof("Install View Handlers")
 	query.InstallQueryViewFactories(p.registry)
 
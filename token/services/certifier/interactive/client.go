@@ -7,8 +7,14 @@ package interactive
 
 import (
 	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
@@ -23,11 +29,12 @@ type QueryEngine interface {
 
 type CertificationStorage interface {
 	Exists(id *token2.Id) bool
+	Get(ids []*token2.Id, callback func(*token2.Id, []byte) error) error
 	Store(certifications map[*token2.Id][]byte) error
 }
 
 type Vault interface {
-	GetLastTxID() (string, error)
+	Status(txid string) (fabric.ValidationCode, []string, error)
 }
 
 type ViewManager interface {
@@ -42,8 +49,43 @@ type Resolver interface {
 	ResolveIdentities(endpoints ...string) []view.Identity
 }
 
+const (
+	minBackoff   = 1 * time.Second
+	maxBackoff   = 1 * time.Minute
+	pollInterval = 10 * time.Second
+	// renewalInterval is how often all tokens are checked for certifications close to expiry
+	renewalInterval = 1 * time.Minute
+	// DefaultMaxBatchSize is the maximum number of tokens sent in a single certification request, if not configured
+	DefaultMaxBatchSize = 100
+)
+
+// Metrics reports the state of the certifications handled by a CertificationClient
+type Metrics struct {
+	// Outstanding is the number of tokens waiting to be certified
+	Outstanding int64
+	// Requests is the number of certification requests sent to the certifiers
+	Requests int64
+	// Failures is the number of certification requests that failed
+	Failures int64
+	// Certified is the number of tokens certified
+	Certified int64
+}
+
+// certifierHealth tracks the failures of a certifier to back off from it
+type certifierHealth struct {
+	failures    int
+	nextAttempt time.Time
+}
+
 // CertificationClient scans the vault for tokens not yet certified and asks the certification.
+// Requests rotate across the configured certifiers, skipping those that recently failed.
+// The certifiers share the certification key in the public parameters, they are replicas for availability:
+// a token is certified once any of them has certified it, with a certification not expired.
+// Certifications close to expiry, see renewBefore, are renewed proactively.
 type CertificationClient struct {
+	// metrics is first to be 64-bit aligned for atomic operations
+	metrics Metrics
+
 	ctx                  context.Context
 	channel, namespace   string
 	vault                Vault
@@ -51,6 +93,16 @@ type CertificationClient struct {
 	certificationStorage CertificationStorage
 	viewManager          ViewManager
 	certifiers           []view2.Identity
+	maxBatchSize         int
+	renewBefore          time.Duration
+
+	healthLock sync.Mutex
+	health     map[string]*certifierHealth
+	next       int
+
+	events      chan struct{}
+	pendingLock sync.Mutex
+	pending     map[string][]*token2.Id
 }
 
 func NewCertificationClient(
@@ -62,7 +114,12 @@ func NewCertificationClient(
 	cm CertificationStorage,
 	fm ViewManager,
 	certifiers []view2.Identity,
+	maxBatchSize int,
+	renewBefore time.Duration,
 ) *CertificationClient {
+	if maxBatchSize < 1 {
+		maxBatchSize = DefaultMaxBatchSize
+	}
 	return &CertificationClient{
 		ctx:                  ctx,
 		channel:              channel,
@@ -72,17 +129,64 @@ func NewCertificationClient(
 		certificationStorage: cm,
 		viewManager:          fm,
 		certifiers:           certifiers,
+		maxBatchSize:         maxBatchSize,
+		renewBefore:          renewBefore,
+		health:               map[string]*certifierHealth{},
+		events:               make(chan struct{}, 1),
+		pending:              map[string][]*token2.Id{},
 	}
 }
 
 func (d *CertificationClient) IsCertified(id *token2.Id) bool {
-	return d.certificationStorage.Exists(id)
+	return d.hasCertifications(id, isValid)
 }
 
+// needsCertification returns true if the passed token is not certified, or its certifications are due for renewal
+func (d *CertificationClient) needsCertification(id *token2.Id) bool {
+	return !d.hasCertifications(id, d.isFresh)
+}
+
+// hasCertifications returns true if the passed token has a certification accepted by the passed check
+func (d *CertificationClient) hasCertifications(id *token2.Id, check func(certification []byte) bool) bool {
+	if !d.certificationStorage.Exists(id) {
+		return false
+	}
+	ok := false
+	if err := d.certificationStorage.Get([]*token2.Id{id}, func(_ *token2.Id, raw []byte) error {
+		ok = check(raw)
+		return nil
+	}); err != nil {
+		logger.Errorf("failed getting certification of [%s]: [%s]", id, err)
+		return false
+	}
+	return ok
+}
+
+// isFresh returns true if the passed certification is not due for renewal
+func (d *CertificationClient) isFresh(certification []byte) bool {
+	e := &token2.CertificationEnvelope{}
+	if err := e.Deserialize(certification); err != nil {
+		return false
+	}
+	return time.Now().Before(e.RenewAt(d.renewBefore))
+}
+
+// isValid returns true if the passed certification has not expired
+func isValid(certification []byte) bool {
+	e := &token2.CertificationEnvelope{}
+	if err := e.Deserialize(certification); err != nil {
+		return false
+	}
+	return !e.Expired(time.Now())
+}
+
+// RequestCertification asks the certifiers to certify the passed tokens, unless already certified.
+// Certifications due for renewal are requested again.
+// Certifiers that fail are put in backoff and the next one is tried.
 func (d *CertificationClient) RequestCertification(ids ...*token2.Id) error {
 	var toBeCertified []*token2.Id
 	for _, id := range ids {
-		if !d.IsCertified(id) {
+		if d.needsCertification(id) {
 			toBeCertified = append(toBeCertified, id)
 		}
 	}
@@ -91,18 +195,92 @@ func (d *CertificationClient) RequestCertification(ids ...*token2.Id) error {
 		return nil
 	}
 
-	resultBoxed, err := d.viewManager.InitiateView(NewCertificationRequestView(d.channel, d.namespace, d.certifiers[0], toBeCertified...))
-	if err != nil {
-		return err
+	var lastErr error
+	for _, certifier := range d.rotation() {
+		key := certifier.UniqueID()
+		atomic.AddInt64(&d.metrics.Requests, 1)
+		resultBoxed, err := d.viewManager.InitiateView(NewCertificationRequestView(d.channel, d.namespace, certifier, toBeCertified...))
+		if err != nil {
+			atomic.AddInt64(&d.metrics.Failures, 1)
+			logger.Warnf("failed getting certifications from [%s], try next certifier: [%s]", certifier, err)
+			d.markFailure(key)
+			lastErr = err
+			continue
+		}
+		d.markSuccess(key)
+		certifications, ok := resultBoxed.(map[*token2.Id][]byte)
+		if !ok {
+			panic("invalid type, expected map[token.Id][]byte")
+		}
+		return d.certificationStorage.Store(certifications)
+	}
+
+	if lastErr != nil {
+		return errors.WithMessagef(lastErr, "failed getting certifications for [%v]", toBeCertified)
+	}
+	return errors.Errorf("failed getting certifications for [%v], all certifiers in backoff", toBeCertified)
+}
+
+// rotation returns the certifiers to be tried, in order.
+// The starting certifier rotates at each call and certifiers in backoff are skipped.
+func (d *CertificationClient) rotation() []view2.Identity {
+	d.healthLock.Lock()
+	defer d.healthLock.Unlock()
+
+	now := time.Now()
+	var res []view2.Identity
+	for i := 0; i < len(d.certifiers); i++ {
+		certifier := d.certifiers[(d.next+i)%len(d.certifiers)]
+		if h, ok := d.health[certifier.UniqueID()]; ok && now.Before(h.nextAttempt) {
+			logger.Debugf("certifier [%s] in backoff until [%s], skipping", certifier, h.nextAttempt)
+			continue
+		}
+		res = append(res, certifier)
 	}
-	certifications, ok := resultBoxed.(map[*token2.Id][]byte)
+	d.next = (d.next + 1) % len(d.certifiers)
+	return res
+}
+
+func (d *CertificationClient) markFailure(certifier string) {
+	d.healthLock.Lock()
+	defer d.healthLock.Unlock()
+
+	h, ok := d.health[certifier]
 	if !ok {
-		panic("invalid type, expected map[token.Id][]byte")
+		h = &certifierHealth{}
+		d.health[certifier] = h
 	}
-	if err := d.certificationStorage.Store(certifications); err != nil {
-		return err
+	h.failures++
+	h.nextAttempt = time.Now().Add(backoff(h.failures))
+}
+
+func (d *CertificationClient) markSuccess(certifier string) {
+	d.healthLock.Lock()
+	defer d.healthLock.Unlock()
+
+	delete(d.health, certifier)
+}
+
+// Metrics returns a snapshot of the certification metrics of this client
+func (d *CertificationClient) Metrics() Metrics {
+	return Metrics{
+		Outstanding: atomic.LoadInt64(&d.metrics.Outstanding),
+		Requests:    atomic.LoadInt64(&d.metrics.Requests),
+		Failures:    atomic.LoadInt64(&d.metrics.Failures),
+		Certified:   atomic.LoadInt64(&d.metrics.Certified),
+	}
+}
+
+// OnNewTokens records the passed tokens to be certified once the transaction that created them is committed
+func (d *CertificationClient) OnNewTokens(txID string, ids []*token2.Id) {
+	d.pendingLock.Lock()
+	d.pending[txID] = append(d.pending[txID], ids...)
+	d.pendingLock.Unlock()
+
+	select {
+	case d.events <- struct{}{}:
+	default:
 	}
-	return nil
 }
 
 func (d *CertificationClient) Start() error {
@@ -110,53 +288,163 @@ func (d *CertificationClient) Start() error {
 	return nil
 }
 
+// Scan requests the certification of the tokens not certified yet.
+// It starts with a full scan of the vault, then it requests the certification of the notified tokens
+// once their transaction is committed. The vault is scanned again periodically, to renew certifications
+// close to expiry, and after a failure.
+// The tokens are sent in batches of at most maxBatchSize, and after a failure the client backs off
+// before trying again.
 func (d *CertificationClient) Scan() {
-	var lastTXID string
+	var retryAt, lastScan time.Time
+	failures := 0
+	// start with a full scan to catch up with the tokens committed while not running
+	rescan := true
+	outstanding := map[string]*token2.Id{}
+	fail := func(err error) {
+		failures++
+		wait := backoff(failures)
+		retryAt = time.Now().Add(wait)
+		logger.Errorf("failed retrieving certifications [%s], try again in [%s]", err, wait)
+	}
 	for {
-		// Check the unspent tokens
-		tokens, err := d.queryEngine.ListUnspentTokens()
-		if err != nil {
-			break
-		}
-		var toBeCertified []*token2.Id
-		for _, token := range tokens.Tokens {
-			// does token have a certification?
-			if !d.certificationStorage.Exists(token.Id) {
-				// if no, batch it
-				toBeCertified = append(toBeCertified, token.Id)
+		if rescan && !time.Now().Before(retryAt) {
+			ids, err := d.uncertified()
+			if err != nil {
+				fail(errors.WithMessage(err, "failed listing unspent tokens"))
+			} else {
+				for k, id := range ids {
+					outstanding[k] = id
+				}
+				rescan = false
+				lastScan = time.Now()
 			}
 		}
+		for _, id := range d.committed() {
+			outstanding[id.String()] = id
+		}
 
-		if len(toBeCertified) != 0 {
-			// Request certification
-			logger.Debugf("request certification of [%v]", toBeCertified)
-			if err := d.RequestCertification(toBeCertified...); err != nil {
-				logger.Errorf("failed retrieving certification [%s], try later", err)
-				time.Sleep(2 * time.Second)
-				continue
+		if len(outstanding) != 0 && !time.Now().Before(retryAt) {
+			if err := d.certifyInBatches(outstanding); err != nil {
+				// the failure might be due to tokens spent in the meantime, list them again before retrying
+				outstanding = map[string]*token2.Id{}
+				rescan = true
+				fail(err)
+			} else {
+				failures = 0
 			}
-			logger.Debugf("request certification of [%v] satisfied with no error", toBeCertified)
-		}
-
-		done := false
-		for {
-			select {
-			case <-d.ctx.Done():
-				return
-			case <-time.After(2 * time.Second):
-				txid, err := d.vault.GetLastTxID()
-				if err != nil {
-					continue
-				}
-				if txid != lastTXID {
-					lastTXID = txid
-					done = true
-					break
-				}
+		}
+		atomic.StoreInt64(&d.metrics.Outstanding, int64(len(outstanding)))
+		logger.Debugf("certification metrics [%+v]", d.Metrics())
+
+		wait := pollInterval
+		if d.hasPending() && minBackoff < wait {
+			// notified transactions not committed yet, check again soon
+			wait = minBackoff
+		}
+		if len(outstanding) != 0 || rescan {
+			if w := time.Until(retryAt); w > 0 && w < wait {
+				wait = w
+			}
+		}
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-d.events:
+		case <-time.After(wait):
+			if time.Since(lastScan) > renewalInterval {
+				// look for certifications close to expiry
+				rescan = true
 			}
-			if done {
-				break
+		}
+	}
+}
+
+// certifyInBatches requests the certification of the passed tokens in batches of at most maxBatchSize.
+// Certified tokens are removed from the passed map. It stops at the first batch that fails.
+func (d *CertificationClient) certifyInBatches(outstanding map[string]*token2.Id) error {
+	var batch []*token2.Id
+	flush := func() error {
+		logger.Debugf("request certification of [%v]", batch)
+		if err := d.RequestCertification(batch...); err != nil {
+			return err
+		}
+		logger.Debugf("request certification of [%v] satisfied with no error", batch)
+		for _, id := range batch {
+			delete(outstanding, id.String())
+		}
+		atomic.AddInt64(&d.metrics.Certified, int64(len(batch)))
+		batch = nil
+		return nil
+	}
+	for _, id := range outstanding {
+		batch = append(batch, id)
+		if len(batch) == d.maxBatchSize {
+			if err := flush(); err != nil {
+				return err
 			}
 		}
 	}
+	if len(batch) != 0 {
+		return flush()
+	}
+	return nil
+}
+
+// uncertified returns the unspent tokens that are not certified yet, or whose certifications are due for renewal
+func (d *CertificationClient) uncertified() (map[string]*token2.Id, error) {
+	tokens, err := d.queryEngine.ListUnspentTokens()
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]*token2.Id{}
+	for _, token := range tokens.Tokens {
+		if d.needsCertification(token.Id) {
+			res[token.Id.String()] = token.Id
+		}
+	}
+	return res, nil
+}
+
+// committed returns the notified tokens whose transaction is now committed, and forgets those of invalid transactions
+func (d *CertificationClient) committed() []*token2.Id {
+	d.pendingLock.Lock()
+	defer d.pendingLock.Unlock()
+
+	var res []*token2.Id
+	for txID, ids := range d.pending {
+		code, _, err := d.vault.Status(txID)
+		if err != nil {
+			logger.Warnf("failed getting status of [%s]: [%s]", txID, err)
+			continue
+		}
+		switch code {
+		case fabric.Valid:
+			res = append(res, ids...)
+			delete(d.pending, txID)
+		case fabric.Invalid:
+			delete(d.pending, txID)
+		}
+	}
+	return res
+}
+
+// hasPending returns true if there are notified tokens whose transaction is not committed yet
+func (d *CertificationClient) hasPending() bool {
+	d.pendingLock.Lock()
+	defer d.pendingLock.Unlock()
+
+	return len(d.pending) != 0
+}
+
+// backoff returns the exponential backoff, with jitter, after the passed number of consecutive failures
+func backoff(failures int) time.Duration {
+	b := minBackoff
+	for i := 1; i < failures && b < maxBackoff; i++ {
+		b *= 2
+	}
+	if b > maxBackoff {
+		b = maxBackoff
+	}
+	// pick uniformly in [b/2, b) so that clients failing together do not retry together
+	return b/2 + time.Duration(rand.Int63n(int64(b/2)))
 }
@@ -0,0 +1,292 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package interactive
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+type memStorage struct {
+	lock           sync.Mutex
+	certifications map[string][]byte
+}
+
+func newMemStorage() *memStorage {
+	return &memStorage{certifications: map[string][]byte{}}
+}
+
+func (m *memStorage) Exists(id *token2.Id) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	_, ok := m.certifications[id.String()]
+	return ok
+}
+
+func (m *memStorage) Get(ids []*token2.Id, callback func(*token2.Id, []byte) error) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, id := range ids {
+		if err := callback(id, m.certifications[id.String()]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memStorage) Store(certifications map[*token2.Id][]byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for id, certification := range certifications {
+		m.certifications[id.String()] = certification
+	}
+	return nil
+}
+
+// fakeCertifiers certify all the requested tokens, unless down. If failAt is positive, the failAt-th request fails.
+type fakeCertifiers struct {
+	lock     sync.Mutex
+	down     map[string]bool
+	failAt   int
+	calls    []string
+	requests [][]*token2.Id
+}
+
+func (f *fakeCertifiers) InitiateView(v view.View) (interface{}, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	request := v.(*CertificationRequestView)
+	f.calls = append(f.calls, string(request.certifier))
+	f.requests = append(f.requests, request.ids)
+	if f.down[string(request.certifier)] || len(f.calls) == f.failAt {
+		return nil, errors.Errorf("certifier [%s] unavailable", request.certifier)
+	}
+	res := map[*token2.Id][]byte{}
+	for _, id := range request.ids {
+		res[id] = envelope(time.Now(), time.Hour)
+	}
+	return res, nil
+}
+
+func (f *fakeCertifiers) setDown(down map[string]bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.down = down
+}
+
+func (f *fakeCertifiers) callCount() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return len(f.calls)
+}
+
+func (f *fakeCertifiers) reset() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.calls = nil
+	f.requests = nil
+}
+
+func envelope(issuedAt time.Time, validity time.Duration) []byte {
+	raw, _ := (&token2.CertificationEnvelope{
+		IssuedAt:      issuedAt.Unix(),
+		ExpiresAt:     issuedAt.Add(validity).Unix(),
+		Certification: []byte("certification"),
+	}).Serialize()
+	return raw
+}
+
+func newTestClient(certifiers *fakeCertifiers, storage CertificationStorage, maxBatchSize int, ids ...string) *CertificationClient {
+	var identities []view2.Identity
+	for _, id := range ids {
+		identities = append(identities, view2.Identity(id))
+	}
+	return NewCertificationClient(context.Background(), "ch", "ns", nil, nil, storage, certifiers, identities, maxBatchSize, 0)
+}
+
+func tokenID(txID string, index uint32) *token2.Id {
+	return &token2.Id{TxId: txID, Index: index}
+}
+
+func TestRequestCertificationRotation(t *testing.T) {
+	certifiers := &fakeCertifiers{}
+	storage := newMemStorage()
+	client := newTestClient(certifiers, storage, 0, "c1", "c2", "c3")
+
+	// each request starts from the next certifier
+	for i, txID := range []string{"tx1", "tx2", "tx3", "tx4"} {
+		assert.NoError(t, client.RequestCertification(tokenID(txID, 0)))
+		assert.True(t, client.IsCertified(tokenID(txID, 0)))
+		assert.Equal(t, []string{"c1", "c2", "c3"}[i%3], certifiers.calls[i])
+	}
+	assert.Len(t, certifiers.calls, 4)
+
+	// certified tokens are not requested again
+	certifiers.reset()
+	assert.NoError(t, client.RequestCertification(tokenID("tx1", 0), tokenID("tx5", 0)))
+	assert.Equal(t, [][]*token2.Id{{tokenID("tx5", 0)}}, certifiers.requests)
+
+	// certifications due for renewal are requested again, expired ones do not count as certified
+	certifiers.reset()
+	assert.NoError(t, storage.Store(map[*token2.Id][]byte{
+		tokenID("tx6", 0): envelope(time.Now().Add(-50*time.Minute), time.Hour),
+		tokenID("tx7", 0): envelope(time.Now().Add(-2*time.Hour), time.Hour),
+	}))
+	assert.True(t, client.IsCertified(tokenID("tx6", 0)))
+	assert.False(t, client.IsCertified(tokenID("tx7", 0)))
+	assert.NoError(t, client.RequestCertification(tokenID("tx6", 0), tokenID("tx7", 0)))
+	assert.Len(t, certifiers.requests, 1)
+	assert.ElementsMatch(t, []*token2.Id{tokenID("tx6", 0), tokenID("tx7", 0)}, certifiers.requests[0])
+	assert.True(t, client.IsCertified(tokenID("tx7", 0)))
+
+	assert.Equal(t, Metrics{Requests: 6}, client.Metrics())
+}
+
+func TestRequestCertificationFailover(t *testing.T) {
+	certifiers := &fakeCertifiers{down: map[string]bool{"c1": true}}
+	client := newTestClient(certifiers, newMemStorage(), 0, "c1", "c2", "c3")
+
+	// a failing certifier is skipped
+	assert.NoError(t, client.RequestCertification(tokenID("tx1", 0)))
+	assert.Equal(t, []string{"c1", "c2"}, certifiers.calls)
+	assert.True(t, client.IsCertified(tokenID("tx1", 0)))
+	assert.Equal(t, Metrics{Requests: 2, Failures: 1}, client.Metrics())
+
+	// and it is not contacted again while in backoff
+	certifiers.reset()
+	for _, txID := range []string{"tx2", "tx3", "tx4"} {
+		assert.NoError(t, client.RequestCertification(tokenID(txID, 0)))
+	}
+	assert.NotContains(t, certifiers.calls, "c1")
+	assert.Len(t, certifiers.calls, 3)
+
+	// once the backoff is over, it is tried again, and a success clears its failures
+	certifiers.reset()
+	certifiers.down = nil
+	client.health[view2.Identity("c1").UniqueID()].nextAttempt = time.Now()
+	assert.Equal(t, 1, client.health[view2.Identity("c1").UniqueID()].failures)
+	for _, txID := range []string{"tx5", "tx6", "tx7"} {
+		assert.NoError(t, client.RequestCertification(tokenID(txID, 0)))
+	}
+	assert.Contains(t, certifiers.calls, "c1")
+	assert.Empty(t, client.health)
+
+	// all certifiers failing, the last error is returned and all of them are in backoff
+	certifiers.reset()
+	certifiers.down = map[string]bool{"c1": true, "c2": true, "c3": true}
+	err := client.RequestCertification(tokenID("tx8", 0))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unavailable")
+	assert.Len(t, certifiers.calls, 3)
+	assert.False(t, client.IsCertified(tokenID("tx8", 0)))
+
+	certifiers.reset()
+	err = client.RequestCertification(tokenID("tx8", 0))
+	assert.EqualError(t, err, "failed getting certifications for [[[tx8:0]]], all certifiers in backoff")
+	assert.Empty(t, certifiers.calls)
+}
+
+func TestBackoff(t *testing.T) {
+	for _, c := range []struct {
+		failures int
+		max      time.Duration
+	}{
+		{1, minBackoff},
+		{2, 2 * minBackoff},
+		{3, 4 * minBackoff},
+		{7, maxBackoff},
+		{100, maxBackoff},
+	} {
+		for i := 0; i < 10; i++ {
+			b := backoff(c.failures)
+			assert.True(t, b >= c.max/2 && b < c.max, "backoff [%s] after [%d] failures not in [%s,%s)", b, c.failures, c.max/2, c.max)
+		}
+	}
+}
+
+func TestCertifyInBatches(t *testing.T) {
+	outstanding := map[string]*token2.Id{}
+	for i := uint32(0); i < 5; i++ {
+		outstanding[tokenID("tx1", i).String()] = tokenID("tx1", i)
+	}
+
+	// the tokens are split in batches of at most maxBatchSize
+	certifiers := &fakeCertifiers{}
+	client := newTestClient(certifiers, newMemStorage(), 2, "c1")
+	assert.NoError(t, client.certifyInBatches(outstanding))
+	var sizes []int
+	for _, request := range certifiers.requests {
+		sizes = append(sizes, len(request))
+	}
+	assert.ElementsMatch(t, []int{2, 2, 1}, sizes)
+	assert.Empty(t, outstanding)
+	for i := uint32(0); i < 5; i++ {
+		assert.True(t, client.IsCertified(tokenID("tx1", i)))
+	}
+	assert.Equal(t, Metrics{Requests: 3, Certified: 5}, client.Metrics())
+
+	// it stops at the first batch that fails, the tokens of the batches certified so far are removed
+	for i := uint32(0); i < 5; i++ {
+		outstanding[tokenID("tx2", i).String()] = tokenID("tx2", i)
+	}
+	certifiers = &fakeCertifiers{failAt: 2}
+	client = newTestClient(certifiers, newMemStorage(), 2, "c1")
+	assert.Error(t, client.certifyInBatches(outstanding))
+	assert.Len(t, certifiers.requests, 2)
+	assert.Len(t, outstanding, 3)
+	for _, id := range certifiers.requests[0] {
+		assert.NotContains(t, outstanding, id.String())
+	}
+	assert.Equal(t, Metrics{Requests: 2, Failures: 1, Certified: 2}, client.Metrics())
+}
+
+type fakeQueryEngine struct {
+	tokens []*token2.UnspentToken
+}
+
+func (f *fakeQueryEngine) ListUnspentTokens() (*token2.UnspentTokens, error) {
+	return &token2.UnspentTokens{Tokens: f.tokens}, nil
+}
+
+func TestScanBackoff(t *testing.T) {
+	qe := &fakeQueryEngine{}
+	for i := uint32(0); i < 3; i++ {
+		qe.tokens = append(qe.tokens, &token2.UnspentToken{Id: tokenID("tx1", i)})
+	}
+	certifiers := &fakeCertifiers{down: map[string]bool{"c1": true}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := NewCertificationClient(ctx, "ch", "ns", nil, qe, newMemStorage(), certifiers, []view2.Identity{view2.Identity("c1")}, 2, 0)
+	go client.Scan()
+
+	// the first batch fails, the next ones are not sent
+	assert.Eventually(t, func() bool { return certifiers.callCount() == 1 }, time.Second, 10*time.Millisecond)
+	// no retry before the backoff, at least minBackoff/2
+	time.Sleep(minBackoff / 4)
+	assert.Equal(t, 1, certifiers.callCount())
+	assert.Equal(t, int64(1), client.Metrics().Failures)
+
+	// after the backoff, the tokens are listed again and certified
+	certifiers.setDown(nil)
+	assert.Eventually(t, func() bool { return client.Metrics().Certified == 3 }, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return client.Metrics() == Metrics{Requests: 3, Failures: 1, Certified: 3}
+	}, time.Second, 10*time.Millisecond)
+	for i := uint32(0); i < 3; i++ {
+		assert.True(t, client.IsCertified(tokenID("tx1", i)))
+	}
+}
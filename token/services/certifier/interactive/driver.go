@@ -8,6 +8,7 @@ package interactive
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -16,8 +17,14 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/certifier"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/certifier/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/processor"
+)
+
+const (
+	Interactive = "interactive"
 )
 
 type Driver struct {
@@ -50,6 +57,8 @@ func (d *Driver) NewCertificationClient(sp view2.ServiceProvider, network, chann
 			return nil, errors.WithMessagef(err, "cannot load token-sdk configuration")
 		}
 		var certifiers []view.Identity
+		maxBatchSize := DefaultMaxBatchSize
+		var renewBefore time.Duration
 		for _, tms := range tmsConfigs {
 			if tms.Channel == channel && tms.Namespace == namespace {
 				var err error
@@ -57,6 +66,10 @@ func (d *Driver) NewCertificationClient(sp view2.ServiceProvider, network, chann
 				if err != nil {
 					return nil, errors.WithMessagef(err, "cannot resolve certifier identities")
 				}
+				if tms.Certification.Interactive.MaxBatchSize > 0 {
+					maxBatchSize = tms.Certification.Interactive.MaxBatchSize
+				}
+				renewBefore = tms.Certification.Interactive.RenewBefore
 				break
 			}
 		}
@@ -73,7 +86,10 @@ func (d *Driver) NewCertificationClient(sp view2.ServiceProvider, network, chann
 			tokenVault.CertificationStorage(),
 			view2.GetManager(sp),
 			certifiers,
+			maxBatchSize,
+			renewBefore,
 		)
+		processor.AddTokenListener(channel, namespace, inst)
 		inst.Start()
 
 		d.cms[k] = inst
@@ -93,3 +109,7 @@ func (d *Driver) NewCertificationService(sp view2.ServiceProvider, network, chan
 
 	return d.certifier, nil
 }
+
+func init() {
+	certifier.Register(Interactive, NewDriver())
+}
@@ -170,11 +170,15 @@ func (i *CertificationRequestView) Call(context view.Context) (interface{}, erro
 
 	// 4. Validate response
 	logger.Debugf("validate certification request response for [%v]", i.ids)
-	if err := cm.VerifyCertifications(i.ids, certifications); err != nil {
+	certifications, err = cm.VerifyCertifications(i.ids, certifications)
+	if err != nil {
 		logger.Errorf("failed verifying certifications of [%v] from [%s] with err [%s]", i.ids, i.certifier, err)
 		return nil, errors.WithMessagef(err, "failed verifying certifications of [%v] from [%s]", i.ids, i.certifier)
 	}
 
+	if len(certifications) != len(i.ids) {
+		return nil, errors.Errorf("expected [%d] certifications from [%s], got [%d]", len(i.ids), i.certifier, len(certifications))
+	}
 	logger.Debugf("certifications of [%v] from [%s] are valid", i.ids, i.certifier)
 
 	// 5. return token certifications in the form of a map
@@ -13,6 +13,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/certifier"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/tcc"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 var logger = flogging.MustGetLogger("token-sdk.certifier")
@@ -29,15 +30,15 @@ func NewRegisterView(network string, channel string, namespace string, wallet st
 }
 
 func (r *RegisterView) Call(context view.Context) (interface{}, error) {
-	// If the tms does not support graph hiding, skip
+	// If the tms does not hide either the transaction graph or the token data, skip
 	tms := token.GetManagementService(
 		context,
 		token.WithNetwork(r.Network),
 		token.WithChannel(r.Channel),
 		token.WithNamespace(r.Namespace),
 	)
-	if !tms.PublicParametersManager().GraphHiding() {
-		logger.Warnf("the token management system for [%s:%s] does not support graph hiding, skipping certifier registration", r.Channel, r.Namespace)
+	if !tms.PublicParametersManager().GraphHiding() && !tms.PublicParametersManager().TokenDataHiding() {
+		logger.Warnf("the token management system for [%s:%s] does not support graph or token data hiding, skipping certifier registration", r.Channel, r.Namespace)
 		return nil, nil
 	}
 
@@ -76,3 +77,26 @@ func (r *RegisterView) Call(context view.Context) (interface{}, error) {
 
 	return nil, nil
 }
+
+// RevokeView publishes, in the namespace, the revocation of the certifications of the passed tokens.
+// Owners reject revoked certifications, and the certifier refuses to certify these tokens again.
+type RevokeView struct {
+	Network   string
+	Channel   string
+	Namespace string
+	IDs       []*token2.Id
+}
+
+func NewRevokeView(network string, channel string, namespace string, ids ...*token2.Id) *RevokeView {
+	return &RevokeView{Network: network, Channel: channel, Namespace: namespace, IDs: ids}
+}
+
+func (r *RevokeView) Call(context view.Context) (interface{}, error) {
+	if len(r.IDs) == 0 {
+		return nil, errors.Errorf("no tokens to revoke")
+	}
+	if _, err := context.RunView(tcc.NewRevokeCertificationsView(r.Network, r.Channel, r.Namespace, r.IDs...)); err != nil {
+		return nil, errors.WithMessagef(err, "failed revoking certifications [%s:%s][%v]", r.Channel, r.Namespace, r.IDs)
+	}
+	return nil, nil
+}
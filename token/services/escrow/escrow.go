@@ -0,0 +1,87 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package escrow
+
+import (
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/multisig"
+)
+
+var logger = flogging.MustGetLogger("token-sdk.escrow")
+
+// Escrow lists the parties of an escrow.
+// Escrowed tokens are owned by a multisig identity that requires either the signatures
+// of both the buyer and the seller, or the signature of the arbiter alone.
+type Escrow struct {
+	Buyer   view.Identity
+	Seller  view.Identity
+	Arbiter view.Identity
+}
+
+// IsEscrow returns true if the passed owner is the identity of an escrow
+func IsEscrow(owner view.Identity) bool {
+	_, err := FromIdentity(owner)
+	return err == nil
+}
+
+// FromIdentity returns the escrow whose identity is the passed one
+func FromIdentity(owner view.Identity) (*Escrow, error) {
+	if !multisig.IsMultisig(owner) {
+		return nil, errors.New("not a multisig identity")
+	}
+	outer := &multisig.Identity{}
+	if err := outer.Deserialize(owner); err != nil {
+		return nil, err
+	}
+	if outer.Threshold != 1 || len(outer.Identities) != 2 || !multisig.IsMultisig(outer.Identities[0]) {
+		return nil, errors.New("not an escrow identity")
+	}
+	inner := &multisig.Identity{}
+	if err := inner.Deserialize(outer.Identities[0]); err != nil {
+		return nil, err
+	}
+	if inner.Threshold != 2 || len(inner.Identities) != 2 {
+		return nil, errors.New("not an escrow identity")
+	}
+	return &Escrow{
+		Buyer:   inner.Identities[0],
+		Seller:  inner.Identities[1],
+		Arbiter: outer.Identities[1],
+	}, nil
+}
+
+// Identity returns the multisig identity owning the tokens of this escrow
+func (e *Escrow) Identity() (view.Identity, error) {
+	parties, err := e.coOwners()
+	if err != nil {
+		return nil, err
+	}
+	id := &multisig.Identity{Threshold: 1, Identities: parties}
+	if err := id.Validate(); err != nil {
+		return nil, errors.WithMessage(err, "invalid escrow")
+	}
+	return id.Serialize()
+}
+
+// IsParty returns true if the passed identity is the buyer, the seller or the arbiter of this escrow
+func (e *Escrow) IsParty(id view.Identity) bool {
+	return id.Equal(e.Buyer) || id.Equal(e.Seller) || id.Equal(e.Arbiter)
+}
+
+// coOwners returns the co-owners of the escrow identity: the buyer and the seller jointly, and the arbiter
+func (e *Escrow) coOwners() ([]view.Identity, error) {
+	if e.Buyer.IsNone() || e.Seller.IsNone() || e.Arbiter.IsNone() {
+		return nil, errors.New("escrow parties not set")
+	}
+	inner, err := (&multisig.Identity{Threshold: 2, Identities: []view.Identity{e.Buyer, e.Seller}}).Serialize()
+	if err != nil {
+		return nil, err
+	}
+	return []view.Identity{inner, e.Arbiter}, nil
+}
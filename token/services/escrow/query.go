@@ -0,0 +1,90 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package escrow
+
+import (
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// State is an open escrow, that is an unspent token owned by an escrow identity
+type State struct {
+	TokenID  *token2.Id
+	Type     string
+	Quantity string
+	Escrow   *Escrow
+}
+
+// QueryEngine lists the open escrows recorded in the vault in which this node is a party
+type QueryEngine struct {
+	tms *token.ManagementService
+}
+
+// NewQueryEngine returns a query engine on the vault of the passed token management service
+func NewQueryEngine(tms *token.ManagementService) *QueryEngine {
+	return &QueryEngine{tms: tms}
+}
+
+// GetQueryEngine returns a query engine on the vault of the default token management service
+func GetQueryEngine(sp view2.ServiceProvider) *QueryEngine {
+	return NewQueryEngine(token.GetManagementService(sp))
+}
+
+// ListEscrows returns the open escrows, optionally filtered by token type
+func (q *QueryEngine) ListEscrows(opts ...token.ListTokensOption) ([]*State, error) {
+	options := &token.ListTokensOptions{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	unspentTokens, err := q.tms.Vault().NewQueryEngine().ListUnspentTokens()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed listing unspent tokens")
+	}
+	var res []*State
+	for _, t := range unspentTokens.Tokens {
+		if len(options.TokenType) != 0 && t.Type != options.TokenType {
+			continue
+		}
+		e, err := FromIdentity(t.Owner.Raw)
+		if err != nil {
+			continue
+		}
+		if !q.isParty(e) {
+			continue
+		}
+		res = append(res, &State{TokenID: t.Id, Type: t.Type, Quantity: t.Quantity, Escrow: e})
+	}
+	return res, nil
+}
+
+// GetEscrow returns the open escrow holding the token with the passed id
+func (q *QueryEngine) GetEscrow(id *token2.Id) (*State, error) {
+	escrows, err := q.ListEscrows()
+	if err != nil {
+		return nil, err
+	}
+	for _, state := range escrows {
+		if state.TokenID.TxId == id.TxId && state.TokenID.Index == id.Index {
+			return state, nil
+		}
+	}
+	return nil, errors.Errorf("no open escrow holds token [%s]", id)
+}
+
+func (q *QueryEngine) isParty(e *Escrow) bool {
+	for _, party := range []view.Identity{e.Buyer, e.Seller, e.Arbiter} {
+		if q.tms.WalletManager().OwnerWalletByIdentity(party) != nil {
+			return true
+		}
+	}
+	return false
+}
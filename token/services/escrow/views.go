@@ -0,0 +1,335 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package escrow
+
+import (
+	"time"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/multisig"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+type openEscrowView struct {
+	wallet  string
+	typ     string
+	amount  uint64
+	seller  view.Identity
+	arbiter view.Identity
+	opts    []ttxcc.TxOption
+}
+
+// NewOpenEscrowView returns a view that escrows amount tokens of type typ, taken from the passed wallet,
+// for the passed seller under the passed arbiter. The wallet owner is the buyer.
+// The seller and the arbiter are asked for a recipient identity and must run NewAcceptEscrowView.
+// The view returns the id of the transaction.
+func NewOpenEscrowView(wallet string, typ string, amount uint64, seller, arbiter view.Identity, opts ...ttxcc.TxOption) *openEscrowView {
+	return &openEscrowView{wallet: wallet, typ: typ, amount: amount, seller: seller, arbiter: arbiter, opts: opts}
+}
+
+func (o *openEscrowView) Call(context view.Context) (interface{}, error) {
+	wallet := ttxcc.GetWallet(context, o.wallet)
+	buyer, err := wallet.GetRecipientIdentity()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting buyer identity")
+	}
+	seller, err := ttxcc.RequestRecipientIdentity(context, o.seller)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting seller identity")
+	}
+	arbiter, err := ttxcc.RequestRecipientIdentity(context, o.arbiter)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting arbiter identity")
+	}
+	e := &Escrow{Buyer: buyer, Seller: seller, Arbiter: arbiter}
+	coOwners, err := e.coOwners()
+	if err != nil {
+		return nil, err
+	}
+
+	// the audit info of the joint identity of buyer and seller is needed to build that of the escrow
+	sigService := view2.GetSigService(context)
+	info := &multisig.Info{}
+	for _, party := range []view.Identity{buyer, seller} {
+		auditInfo, err := sigService.GetAuditInfo(party)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting audit info for [%s]", party)
+		}
+		info.AuditInfos = append(info.AuditInfos, auditInfo)
+	}
+	raw, err := info.Serialize()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed serializing multisig info")
+	}
+	if err := sigService.RegisterAuditInfo(coOwners[0], raw); err != nil {
+		return nil, errors.WithMessage(err, "failed registering audit info of buyer and seller")
+	}
+
+	tx, err := ttxcc.NewAnonymousTransaction(context, o.opts...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed creating transaction")
+	}
+	if _, err := tx.TransferToMultisig(wallet, o.typ, o.amount, 1, coOwners); err != nil {
+		return nil, errors.WithMessage(err, "failed adding transfer to escrow")
+	}
+	if _, err := context.RunView(ttxcc.NewCollectEndorsementsView(tx)); err != nil {
+		return nil, errors.WithMessage(err, "failed collecting endorsements")
+	}
+	if _, err := context.RunView(ttxcc.NewOrderingView(tx)); err != nil {
+		return nil, errors.WithMessage(err, "failed ordering transaction")
+	}
+	return tx.ID(), nil
+}
+
+type acceptEscrowView struct{}
+
+// NewAcceptEscrowView returns the view run by the seller and the arbiter in response to NewOpenEscrowView.
+// It checks that the transaction escrows tokens with this node as a party, and waits for its finality.
+// The view returns the transaction.
+func NewAcceptEscrowView() *acceptEscrowView {
+	return &acceptEscrowView{}
+}
+
+func (a *acceptEscrowView) Call(context view.Context) (interface{}, error) {
+	me, err := ttxcc.RespondRequestRecipientIdentity(context)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed responding to identity request")
+	}
+	tx, err := ttxcc.ReceiveTransaction(context)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed receiving transaction")
+	}
+
+	outputs, err := tx.Outputs()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting outputs")
+	}
+	found := false
+	for i := 0; i < outputs.Count(); i++ {
+		e, err := FromIdentity(outputs.At(i).Owner)
+		if err != nil {
+			continue
+		}
+		found = found || e.IsParty(me)
+	}
+	if !found {
+		return nil, errors.Errorf("transaction [%s] escrows no tokens for [%s]", tx.ID(), me)
+	}
+
+	if _, err := context.RunView(ttxcc.NewAcceptView(tx)); err != nil {
+		return nil, errors.WithMessage(err, "failed accepting transaction")
+	}
+	if _, err := context.RunView(ttxcc.NewFinalityView(tx)); err != nil {
+		return nil, errors.WithMessagef(err, "transaction [%s] not committed", tx.ID())
+	}
+	return tx, nil
+}
+
+type settleView struct {
+	wallet  string
+	id      *token2.Id
+	release bool
+	opts    []ttxcc.TxOption
+}
+
+// NewReleaseView returns a view that pays the escrowed token with the passed id to the seller.
+// The wallet must hold a party of the escrow. The other parties must run NewSettleResponderView.
+// The view returns the id of the transaction.
+func NewReleaseView(wallet string, id *token2.Id, opts ...ttxcc.TxOption) *settleView {
+	return &settleView{wallet: wallet, id: id, release: true, opts: opts}
+}
+
+// NewRefundView returns a view that pays the escrowed token with the passed id back to the buyer.
+// The wallet must hold a party of the escrow. The other parties must run NewSettleResponderView.
+// The view returns the id of the transaction.
+func NewRefundView(wallet string, id *token2.Id, opts ...ttxcc.TxOption) *settleView {
+	return &settleView{wallet: wallet, id: id, release: false, opts: opts}
+}
+
+func (s *settleView) Call(context view.Context) (interface{}, error) {
+	tms := token.GetManagementService(context)
+	state, err := NewQueryEngine(tms).GetEscrow(s.id)
+	if err != nil {
+		return nil, err
+	}
+	if err := registerParties(context, tms, state); err != nil {
+		return nil, err
+	}
+	recipient := state.Escrow.Buyer
+	if s.release {
+		recipient = state.Escrow.Seller
+	}
+	q, err := token2.ToQuantity(state.Quantity, 64)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed parsing quantity of token [%s]", s.id)
+	}
+
+	tx, err := ttxcc.NewAnonymousTransaction(context, s.opts...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed creating transaction")
+	}
+	err = tx.TransferFromMultisig(ttxcc.GetWallet(context, s.wallet), s.id, []uint64{q.ToBigInt().Uint64()}, []view.Identity{recipient})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed adding transfer from escrow")
+	}
+	// the other parties are asked to sign while collecting endorsements
+	if _, err := context.RunView(ttxcc.NewCollectEndorsementsView(tx)); err != nil {
+		return nil, errors.WithMessage(err, "failed collecting endorsements")
+	}
+	if _, err := context.RunView(ttxcc.NewOrderingView(tx)); err != nil {
+		return nil, errors.WithMessage(err, "failed ordering transaction")
+	}
+	return tx.ID(), nil
+}
+
+// registerParties registers the identities of the parties of the passed escrow using the audit info
+// of the escrow identity, so that any party can pay the buyer or the seller
+func registerParties(sp view2.ServiceProvider, tms *token.ManagementService, state *State) error {
+	id, e := state.TokenID, state.Escrow
+	owner, err := e.Identity()
+	if err != nil {
+		return err
+	}
+	raw, err := view2.GetSigService(sp).GetAuditInfo(owner)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting audit info of escrow [%s]", id)
+	}
+	outer := &multisig.Info{}
+	if err := outer.Deserialize(raw); err != nil || len(outer.AuditInfos) != 2 {
+		return errors.Errorf("invalid audit info for escrow [%s]", id)
+	}
+	inner := &multisig.Info{}
+	if err := inner.Deserialize(outer.AuditInfos[0]); err != nil || len(inner.AuditInfos) != 2 {
+		return errors.Errorf("invalid audit info for escrow [%s]", id)
+	}
+	wm := tms.WalletManager()
+	parties := []view.Identity{e.Buyer, e.Seller, e.Arbiter}
+	auditInfos := [][]byte{inner.AuditInfos[0], inner.AuditInfos[1], outer.AuditInfos[1]}
+	for i, party := range parties {
+		if wm.OwnerWalletByIdentity(party) != nil {
+			continue
+		}
+		if err := wm.RegisterRecipientIdentity(party, auditInfos[i], nil); err != nil {
+			return errors.WithMessagef(err, "failed registering escrow party [%s]", party)
+		}
+	}
+	return nil
+}
+
+// Settlement describes a transaction spending an escrowed token
+type Settlement struct {
+	State *State
+	// Release is true if the token goes to the seller, false if it goes back to the buyer
+	Release bool
+}
+
+// Approver decides whether this node agrees with the passed settlement
+type Approver func(tx *ttxcc.Transaction, settlement *Settlement) error
+
+type settleResponderView struct {
+	approve Approver
+}
+
+// NewSettleResponderView returns the view run by the parties of an escrow in response to
+// NewReleaseView and NewRefundView. If the passed approver agrees with the settlement, the
+// transaction is endorsed, otherwise the signature request is rejected and the transaction
+// goes through only if the other parties meet the escrow policy without this node.
+// The view returns the transaction.
+func NewSettleResponderView(approve Approver) *settleResponderView {
+	return &settleResponderView{approve: approve}
+}
+
+func (s *settleResponderView) Call(context view.Context) (interface{}, error) {
+	tx, err := ttxcc.ReceiveTransaction(context)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed receiving transaction")
+	}
+	settlement, err := s.inspect(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.approve(tx, settlement); err != nil {
+		logger.Infof("rejecting settlement of escrow [%s]: [%s]", settlement.State.TokenID, err)
+		// reject the signature request, the transaction is distributed anyway if it gets enough signatures
+		session := context.Session()
+		if _, err := s.receive(session); err != nil {
+			return nil, err
+		}
+		if err := session.SendError([]byte(err.Error())); err != nil {
+			return nil, errors.Wrap(err, "failed rejecting signature request")
+		}
+		tx, err = ttxcc.ReceiveTransaction(context)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed receiving transaction")
+		}
+		if _, err := context.RunView(ttxcc.NewAcceptView(tx)); err != nil {
+			return nil, errors.WithMessage(err, "failed accepting transaction")
+		}
+	} else {
+		if _, err := context.RunView(ttxcc.NewEndorseView(tx)); err != nil {
+			return nil, errors.WithMessage(err, "failed endorsing transaction")
+		}
+	}
+
+	if _, err := context.RunView(ttxcc.NewFinalityView(tx)); err != nil {
+		return nil, errors.WithMessagef(err, "transaction [%s] not committed", tx.ID())
+	}
+	return tx, nil
+}
+
+// inspect checks that the passed transaction pays an escrowed token in full to either the buyer or the seller
+func (s *settleResponderView) inspect(tx *ttxcc.Transaction) (*Settlement, error) {
+	inputs, err := tx.Inputs()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting inputs")
+	}
+	if inputs.Count() != 1 {
+		return nil, errors.Errorf("expected one escrowed input, got [%d]", inputs.Count())
+	}
+	state, err := NewQueryEngine(tx.TokenService()).GetEscrow(inputs.At(0).Id)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs, err := tx.Outputs()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting outputs")
+	}
+	if outputs.Count() == 0 {
+		return nil, errors.New("expected outputs")
+	}
+	recipient := outputs.At(0).Owner
+	if !recipient.Equal(state.Escrow.Seller) && !recipient.Equal(state.Escrow.Buyer) {
+		return nil, errors.Errorf("escrow [%s] can only be paid to the buyer or the seller", state.TokenID)
+	}
+	q, err := token2.ToQuantity(state.Quantity, 64)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed parsing quantity of token [%s]", state.TokenID)
+	}
+	if outputs.ByRecipient(recipient).Count() != outputs.Count() || outputs.Sum().Cmp(q) != 0 {
+		return nil, errors.Errorf("escrow [%s] must be paid in full to a single party", state.TokenID)
+	}
+	return &Settlement{State: state, Release: recipient.Equal(state.Escrow.Seller)}, nil
+}
+
+func (s *settleResponderView) receive(session view.Session) (*view.Message, error) {
+	var msg *view.Message
+	select {
+	case msg = <-session.Receive():
+	case <-time.After(60 * time.Second):
+		return nil, errors.Errorf("timeout from party [%s]", session.Info().Caller)
+	}
+	if msg.Status == view.ERROR {
+		return nil, errors.New(string(msg.Payload))
+	}
+	return msg, nil
+}
@@ -6,7 +6,12 @@ SPDX-License-Identifier: Apache-2.0
 package api
 
 import (
+	"time"
+
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 // this what is used to compute the hash; party and counterparty will end up with the same hash
@@ -17,14 +22,75 @@ type Transfer struct {
 	Value    uint64
 }
 
+// ChannelInfo describes a channel as opened on the ledger
+type ChannelInfo struct {
+	ID string
+	// Counterparty is the long-term identity of the node of the other party
+	Counterparty view.Identity
+	// Parties are the identities, opener first, that co-own the deposit and sign the states of the channel
+	Parties []view.Identity
+	// Me is the index of this node's identity in Parties
+	Me int
+	// Deposit is the token, owned jointly by the parties, backing the channel
+	Deposit *token2.Id
+	// Deposits are the amounts per token type that each party, in the order of Parties, put in the channel
+	Deposits []map[string]uint64
+}
+
+// The states of a channel and their disputes are validated on the ledger, their types are defined in token/api
+type (
+	State   = api2.State
+	Lock    = api2.Lock
+	Payout  = api2.Payout
+	Dispute = api2.Dispute
+)
+
+const (
+	// LockOp locks tokens of the initiator for the other party
+	LockOp = "lock"
+	// UnlockOp executes a lock by revealing the preimage of its hash lock, it is initiated by the receiver of the lock
+	UnlockOp = "unlock"
+	// CancelOp removes a lock without executing it, it is initiated by the receiver of the lock
+	CancelOp = "cancel"
+)
+
+// Conditional is a hashlocked update of a channel
+type Conditional struct {
+	Op       string
+	HashLock []byte
+	// Type, Value and Timeout are set for LockOp only
+	Type    string
+	Value   uint64
+	Timeout time.Time
+	// Preimage is set for UnlockOp only
+	Preimage []byte
+}
+
 type Channel interface {
-	Receive(id, ttype string, value uint64, sig []byte) error
-	Send(id, ttype string, value uint64) error
+	Info() *ChannelInfo
+	// State returns the current state of the channel
+	State() (*State, error)
+	// Next returns the state the channel reaches by sending, or receiving if send is false, the passed value, without applying it
+	Next(send bool, ttype string, value uint64) (*State, error)
+	// Receive applies the reception of the passed value, sig is the signature of the counterparty on the resulting state
+	Receive(ttype string, value uint64, sig []byte) error
+	// Send applies the sending of the passed value, sig is the signature of the counterparty on the resulting state
+	Send(ttype string, value uint64, sig []byte) error
+	// NextConditional returns the state the channel reaches when the passed conditional update is initiated by this node's
+	// party, or by the counterparty if mine is false, without applying it
+	NextConditional(mine bool, c *Conditional) (*State, error)
+	// ApplyConditional applies the passed conditional update, sig is the signature of the counterparty on the resulting state
+	ApplyConditional(mine bool, c *Conditional, sig []byte) error
+	// Signature returns the signature of the counterparty on the state with the passed sequence number
+	Signature(seqNumber int) []byte
 	Net() ([]*Transfer, error)
+	// Close marks the channel as settled, no more updates are accepted
+	Close() error
+	IsClosed() bool
 }
 
 type Tracker interface {
-	OpenChannelTo(id string, recipient view.Identity) (Channel, error)
+	OpenChannel(info *ChannelInfo) (Channel, error)
 
 	Channel(id string) (Channel, error)
 }
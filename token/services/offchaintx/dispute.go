@@ -0,0 +1,201 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package offchaintx
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric/services/chaincode"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/multisig"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/offchaintx/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/tcc"
+)
+
+type disputeView struct {
+	id string
+}
+
+// NewDisputeView returns a view that submits the current state of the channel with the passed id to the token chaincode.
+// If the channel is not disputed yet, a dispute is opened, otherwise the disputed state is challenged.
+// The known preimages of the pending locks this node receives are then revealed.
+// The view returns the *api.Dispute recorded on the ledger.
+func NewDisputeView(id string) *disputeView {
+	return &disputeView{id: id}
+}
+
+func (d *disputeView) Call(context view.Context) (interface{}, error) {
+	ch, err := GetChannel(context, context.Me(), d.id)
+	if err != nil {
+		return nil, err
+	}
+	if ch.IsClosed() {
+		return nil, errors.Errorf("channel [%s] already closed", d.id)
+	}
+	info := ch.Info()
+	state, err := ch.State()
+	if err != nil {
+		return nil, err
+	}
+	if state.SeqNumber == 0 {
+		return nil, errors.Errorf("channel [%s] has no state signed by the counterparty", d.id)
+	}
+
+	tms := token.GetManagementService(context)
+	sigma, err := signState(tms, info.Parties[info.Me], state)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed signing state")
+	}
+	signatures := make([][]byte, 2)
+	signatures[info.Me] = sigma
+	signatures[1-info.Me] = ch.ch.Signature(state.SeqNumber)
+	signature, err := (&multisig.Signature{Signatures: signatures}).Serialize()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed serializing signature")
+	}
+	raw, err := state.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling state")
+	}
+	request, err := json.Marshal(&tcc.DisputeRequest{Deposit: info.Deposit, State: raw, Signature: signature})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling dispute request")
+	}
+
+	function := tcc.ChallengeDisputeFunction
+	if _, err := queryDispute(context, tms, d.id); err != nil {
+		if !strings.Contains(err.Error(), "is not disputed") {
+			return nil, err
+		}
+		function = tcc.OpenDisputeFunction
+	}
+	logger.Debugf("submit state [%d] of channel [%s] with [%s]", state.SeqNumber, d.id, function)
+	dispute, err := invokeDispute(context, tms, function, request)
+	if err != nil {
+		return nil, err
+	}
+	return revealPreimages(context, tms, info, dispute)
+}
+
+type revealView struct {
+	id string
+}
+
+// NewRevealView returns a view that reveals, in the open dispute on the channel with the passed id, the known preimages
+// of the pending locks of the disputed state that this node receives, so that they are executed when the dispute is closed.
+// The view returns the *api.Dispute recorded on the ledger.
+func NewRevealView(id string) *revealView {
+	return &revealView{id: id}
+}
+
+func (r *revealView) Call(context view.Context) (interface{}, error) {
+	ch, err := GetChannel(context, context.Me(), r.id)
+	if err != nil {
+		return nil, err
+	}
+	tms := token.GetManagementService(context)
+	dispute, err := queryDispute(context, tms, r.id)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Closed {
+		return nil, errors.Errorf("dispute on channel [%s] is closed", r.id)
+	}
+	return revealPreimages(context, tms, ch.Info(), dispute)
+}
+
+// revealPreimages reveals the known preimages of the pending locks of the disputed state that this node receives,
+// if not timed out nor revealed yet
+func revealPreimages(context view.Context, tms *token.ManagementService, info *api.ChannelInfo, dispute *api.Dispute) (*api.Dispute, error) {
+	for _, lock := range dispute.State.Locks {
+		if lock.Sender == info.Me || dispute.Revealed(lock.HashLock) || !time.Now().Before(lock.Timeout) {
+			continue
+		}
+		preimage, err := getPreimage(context, lock.HashLock)
+		if err != nil {
+			logger.Debugf("cannot reveal lock [%s] of channel [%s]: [%s]", hex.EncodeToString(lock.HashLock), info.ID, err)
+			continue
+		}
+		request, err := json.Marshal(&tcc.RevealRequest{ChannelID: info.ID, Preimage: preimage})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed marshalling reveal request")
+		}
+		logger.Debugf("reveal preimage of lock [%s] of channel [%s]", hex.EncodeToString(lock.HashLock), info.ID)
+		dispute, err = invokeDispute(context, tms, tcc.RevealPreimageFunction, request)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dispute, nil
+}
+
+type closeDisputeView struct {
+	id string
+}
+
+// NewCloseDisputeView returns a view that closes the dispute on the channel with the passed id once its challenge period is over.
+// The view returns the *api.Dispute recorded on the ledger, whose balances the channel must be settled with.
+func NewCloseDisputeView(id string) *closeDisputeView {
+	return &closeDisputeView{id: id}
+}
+
+func (c *closeDisputeView) Call(context view.Context) (interface{}, error) {
+	return invokeDispute(context, token.GetManagementService(context), tcc.CloseDisputeFunction, []byte(c.id))
+}
+
+// closedDispute returns the dispute on the passed channel if it has been closed, nil otherwise
+func closedDispute(context view.Context, tms *token.ManagementService, id string) *api.Dispute {
+	dispute, err := queryDispute(context, tms, id)
+	if err != nil {
+		logger.Debugf("no dispute on channel [%s]: [%s]", id, err)
+		return nil
+	}
+	if !dispute.Closed {
+		return nil
+	}
+	return dispute
+}
+
+func queryDispute(context view.Context, tms *token.ManagementService, id string) (*api.Dispute, error) {
+	payload, err := context.RunView(chaincode.NewQueryView(
+		tms.Namespace(), tcc.QueryDisputeFunction, []byte(id),
+	).WithNetwork(tms.Network()).WithChannel(tms.Channel()))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed querying dispute on [%s]", id)
+	}
+	return unmarshalDispute(payload)
+}
+
+func invokeDispute(context view.Context, tms *token.ManagementService, function string, arg []byte) (*api.Dispute, error) {
+	payload, err := context.RunView(chaincode.NewInvokeView(
+		tms.Namespace(), function, arg,
+	).WithNetwork(tms.Network()).WithChannel(tms.Channel()).WithInvokerIdentity(
+		fabric.GetFabricNetworkService(context, tms.Network()).IdentityProvider().DefaultIdentity(),
+	))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed invoking [%s]", function)
+	}
+	return unmarshalDispute(payload)
+}
+
+func unmarshalDispute(payload interface{}) (*api.Dispute, error) {
+	raw, ok := payload.([]byte)
+	if !ok {
+		return nil, errors.Errorf("expected []byte from TCC, got [%T]", payload)
+	}
+	dispute := &api.Dispute{}
+	if err := json.Unmarshal(raw, dispute); err != nil {
+		return nil, errors.Wrap(err, "failed unmarshalling dispute")
+	}
+	return dispute, nil
+}
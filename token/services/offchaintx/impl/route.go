@@ -0,0 +1,54 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package impl
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Edge is a channel that From can use to send tokens to To.
+// Capacity is the amount, per token type, From can send over the channel.
+type Edge struct {
+	ChannelID string
+	From      string
+	To        string
+	Capacity  map[string]uint64
+}
+
+// FindRoute returns the shortest sequence of edges leading from 'from' to 'to' that can all carry
+// value tokens of type ttype. Edges are explored in the passed order.
+func FindRoute(edges []*Edge, from, to, ttype string, value uint64) ([]*Edge, error) {
+	if from == to {
+		return nil, errors.New("sender and receiver must be different")
+	}
+	// breadth-first search, previous maps a node to the edge used to reach it
+	previous := map[string]*Edge{}
+	visited := map[string]bool{from: true}
+	frontier := []string{from}
+	for len(frontier) != 0 && !visited[to] {
+		var next []string
+		for _, node := range frontier {
+			for _, edge := range edges {
+				if edge.From != node || visited[edge.To] || edge.Capacity[ttype] < value {
+					continue
+				}
+				visited[edge.To] = true
+				previous[edge.To] = edge
+				next = append(next, edge.To)
+			}
+		}
+		frontier = next
+	}
+	if !visited[to] {
+		return nil, errors.Errorf("no route from [%s] to [%s] for [%d] of type [%s]", from, to, value, ttype)
+	}
+
+	var route []*Edge
+	for node := to; node != from; node = previous[node].From {
+		route = append([]*Edge{previous[node]}, route...)
+	}
+	return route, nil
+}
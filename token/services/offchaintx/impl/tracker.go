@@ -7,8 +7,10 @@ package impl
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -26,6 +28,23 @@ type Transfer struct {
 	Value    uint64
 }
 
+// Lock is a conditional transfer of Value tokens of type Type from Sender to Receiver,
+// executed once the preimage of HashLock is revealed, before Timeout
+type Lock struct {
+	HashLock []byte
+	Sender   string
+	Receiver string
+	Type     string
+	Value    uint64
+	Timeout  time.Time
+}
+
+// LockUpdate is what is used to compute the hash when a lock is added, executed or cancelled
+type LockUpdate struct {
+	Op   string
+	Lock *Lock
+}
+
 type Net struct {
 	Sender        string
 	Receiver      string
@@ -78,6 +97,79 @@ func (t *Tracker) Send(id, ttype string, value uint64) error {
 	return nil
 }
 
+// Acknowledge records the counterparty signature on the current state of the channel, it is called after receiving an ack
+func (t *Tracker) Acknowledge(id string, sig []byte) error {
+	if t.Channels[id] == nil {
+		return errors.Errorf("there is no open channel with ID '%s'", id)
+	}
+	if t.Channels[id].ProofOfReceipt == nil {
+		return errors.Errorf("channel with ID '%s' is not initialized properly", id)
+	}
+	t.Channels[id].ProofOfReceipt[strconv.Itoa(t.Channels[id].SeqNumber)] = sig
+	return nil
+}
+
+// AddLock adds a pending lock to the channel, it is called after receiving an ack if the party is the sender of the lock,
+// after sending an ack otherwise
+func (t *Tracker) AddLock(id string, lock *Lock) error {
+	c := t.Channels[id]
+	if c == nil {
+		return errors.Errorf("there is no open channel with ID '%s'", id)
+	}
+	if !(lock.Sender == t.Party && lock.Receiver == c.Counterparty) && !(lock.Sender == c.Counterparty && lock.Receiver == t.Party) {
+		return errors.Errorf("lock must be between the parties of channel with ID '%s'", id)
+	}
+	if len(lock.HashLock) != sha256.Size {
+		return errors.Errorf("hash lock must be [%d] bytes long", sha256.Size)
+	}
+	key := hex.EncodeToString(lock.HashLock)
+	if c.Locks[key] != nil {
+		return errors.Errorf("hash lock [%s] already used in channel with ID '%s'", key, id)
+	}
+	if c.Locks == nil {
+		c.Locks = make(map[string]*Lock)
+	}
+	c.Locks[key] = lock
+	return c.chain(&LockUpdate{Op: "lock", Lock: lock})
+}
+
+// Unlock executes the pending lock whose hash lock is the hash of the passed preimage
+func (t *Tracker) Unlock(id string, preimage []byte) error {
+	c := t.Channels[id]
+	if c == nil {
+		return errors.Errorf("there is no open channel with ID '%s'", id)
+	}
+	hash := sha256.Sum256(preimage)
+	key := hex.EncodeToString(hash[:])
+	lock := c.Locks[key]
+	if lock == nil {
+		return errors.Errorf("there is no pending lock [%s] in channel with ID '%s'", key, id)
+	}
+	delete(c.Locks, key)
+	value := int64(lock.Value)
+	if lock.Sender == t.Party {
+		value = -value
+	}
+	c.Net[lock.Type] += value
+	c.Info = append(c.Info, &ExchangeInfo{Type: lock.Type, Value: value})
+	return c.chain(&LockUpdate{Op: "unlock", Lock: lock})
+}
+
+// CancelLock removes the pending lock with the passed hash lock without executing it
+func (t *Tracker) CancelLock(id string, hashLock []byte) error {
+	c := t.Channels[id]
+	if c == nil {
+		return errors.Errorf("there is no open channel with ID '%s'", id)
+	}
+	key := hex.EncodeToString(hashLock)
+	lock := c.Locks[key]
+	if lock == nil {
+		return errors.Errorf("there is no pending lock [%s] in channel with ID '%s'", key, id)
+	}
+	delete(c.Locks, key)
+	return c.chain(&LockUpdate{Op: "cancel", Lock: lock})
+}
+
 func (t *Tracker) Net(id string) ([]*Transfer, error) {
 	var net []*Transfer
 	if t.Channels[id] == nil {
@@ -150,7 +242,44 @@ type Channel struct {
 	Info           []*ExchangeInfo   // ordered list of all the information exchanged (helps with rollback) and dispute
 	Hash           [32]byte          // Merkle tree or hash chain of all exchanges
 	SeqNumber      int               // counter increased everytime the exchange is updated
-	ProofOfReceipt map[string][]byte // counterparty signatures on their transfers (key is the corresponding sequence number)
+	ProofOfReceipt map[string][]byte // counterparty signatures on their transfers and acks (key is the corresponding sequence number)
+	Locks          map[string]*Lock  // pending conditional transfers (key is the hex encoding of the hash lock)
+}
+
+// chain adds the passed exchange to the hash chain and moves to the next sequence number
+func (c *Channel) chain(exchange interface{}) error {
+	raw, err := json.Marshal(exchange)
+	if err != nil {
+		return err
+	}
+	c.Hash = sha256.Sum256(append(c.Hash[:], raw...))
+	c.SeqNumber++
+	return nil
+}
+
+// Clone returns a deep copy of the channel
+func (c *Channel) Clone() *Channel {
+	clone := *c
+	clone.Net = make(map[string]int64, len(c.Net))
+	for k, v := range c.Net {
+		clone.Net[k] = v
+	}
+	clone.Info = make([]*ExchangeInfo, len(c.Info))
+	for i, info := range c.Info {
+		e := *info
+		clone.Info[i] = &e
+	}
+	clone.ProofOfReceipt = make(map[string][]byte, len(c.ProofOfReceipt))
+	for k, v := range c.ProofOfReceipt {
+		clone.ProofOfReceipt[k] = v
+	}
+	if c.Locks != nil {
+		clone.Locks = make(map[string]*Lock, len(c.Locks))
+		for k, v := range c.Locks {
+			clone.Locks[k] = v
+		}
+	}
+	return &clone
 }
 
 type ExchangeInfo struct {
@@ -6,6 +6,8 @@ SPDX-License-Identifier: Apache-2.0
 package impl_test
 
 import (
+	"crypto/sha256"
+
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/offchaintx/impl"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -164,6 +166,129 @@ var _ = Describe("Transaction Tracker", func() {
 			})
 		})
 	})
+	Describe("Acknowledge", func() {
+		BeforeEach(func() {
+			tracker = &impl.Tracker{Party: "alice", Channels: make(map[string]*impl.Channel)}
+			err := tracker.Open("ChannelID", "bob")
+			Expect(err).NotTo(HaveOccurred())
+			err = tracker.Send("ChannelID", "USD", 100)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("Records the signature for the current sequence number", func() {
+			err := tracker.Acknowledge("ChannelID", []byte("ack"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tracker.Channels["ChannelID"].ProofOfReceipt["1"]).To(Equal([]byte("ack")))
+		})
+		Context("When the channel is not open", func() {
+			It("fails", func() {
+				err := tracker.Acknowledge("AnotherID", []byte("ack"))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("there is no open channel with ID 'AnotherID'"))
+			})
+		})
+	})
+	Describe("Clone", func() {
+		BeforeEach(func() {
+			tracker = &impl.Tracker{Party: "alice", Channels: make(map[string]*impl.Channel)}
+			err := tracker.Open("ChannelID", "bob")
+			Expect(err).NotTo(HaveOccurred())
+			err = tracker.Receive("ChannelID", "USD", 50, []byte("signature"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("Does not share state with the original", func() {
+			clone := &impl.Tracker{Party: "alice", Channels: map[string]*impl.Channel{"ChannelID": tracker.Channels["ChannelID"].Clone()}}
+			err := clone.Send("ChannelID", "USD", 20)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(clone.Channels["ChannelID"].SeqNumber).To(Equal(2))
+			Expect(tracker.Channels["ChannelID"].SeqNumber).To(Equal(1))
+			Expect(tracker.Channels["ChannelID"].Net["USD"]).To(Equal(int64(50)))
+			Expect(tracker.Channels["ChannelID"].Info).To(HaveLen(1))
+			Expect(tracker.Channels["ChannelID"].Hash).NotTo(Equal(clone.Channels["ChannelID"].Hash))
+		})
+	})
+	Describe("Locks", func() {
+		var (
+			bob      *impl.Tracker
+			preimage []byte
+			hashLock []byte
+		)
+		BeforeEach(func() {
+			tracker = &impl.Tracker{Party: "alice", Channels: make(map[string]*impl.Channel)}
+			bob = &impl.Tracker{Party: "bob", Channels: make(map[string]*impl.Channel)}
+			Expect(tracker.Open("ChannelID", "bob")).To(Succeed())
+			Expect(bob.Open("ChannelID", "alice")).To(Succeed())
+			preimage = []byte("preimage")
+			hash := sha256.Sum256(preimage)
+			hashLock = hash[:]
+			lock := &impl.Lock{HashLock: hashLock, Sender: "alice", Receiver: "bob", Type: "USD", Value: 30}
+			Expect(tracker.AddLock("ChannelID", lock)).To(Succeed())
+			Expect(bob.AddLock("ChannelID", lock)).To(Succeed())
+		})
+		It("Adds a pending lock without changing the net", func() {
+			Expect(tracker.Channels["ChannelID"].SeqNumber).To(Equal(1))
+			Expect(tracker.Channels["ChannelID"].Locks).To(HaveLen(1))
+			Expect(tracker.Channels["ChannelID"].Net["USD"]).To(Equal(int64(0)))
+			Expect(tracker.Channels["ChannelID"].Hash).To(Equal(bob.Channels["ChannelID"].Hash))
+		})
+		It("Executes the lock given the preimage", func() {
+			Expect(tracker.Unlock("ChannelID", preimage)).To(Succeed())
+			Expect(bob.Unlock("ChannelID", preimage)).To(Succeed())
+			Expect(tracker.Channels["ChannelID"].SeqNumber).To(Equal(2))
+			Expect(tracker.Channels["ChannelID"].Locks).To(BeEmpty())
+			Expect(tracker.Channels["ChannelID"].Net["USD"]).To(Equal(int64(-30)))
+			Expect(bob.Channels["ChannelID"].Net["USD"]).To(Equal(int64(30)))
+			Expect(tracker.Channels["ChannelID"].Hash).To(Equal(bob.Channels["ChannelID"].Hash))
+		})
+		It("Cancels the lock", func() {
+			Expect(tracker.CancelLock("ChannelID", hashLock)).To(Succeed())
+			Expect(tracker.Channels["ChannelID"].SeqNumber).To(Equal(2))
+			Expect(tracker.Channels["ChannelID"].Locks).To(BeEmpty())
+			Expect(tracker.Channels["ChannelID"].Net["USD"]).To(Equal(int64(0)))
+		})
+		It("fails with a wrong preimage", func() {
+			err := tracker.Unlock("ChannelID", []byte("wrong"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("there is no pending lock"))
+		})
+		It("fails to reuse a hash lock", func() {
+			err := tracker.AddLock("ChannelID", &impl.Lock{HashLock: hashLock, Sender: "bob", Receiver: "alice", Type: "USD", Value: 10})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("already used"))
+		})
+		It("fails with a lock outside the channel", func() {
+			hash := sha256.Sum256([]byte("another preimage"))
+			err := tracker.AddLock("ChannelID", &impl.Lock{HashLock: hash[:], Sender: "alice", Receiver: "charlie", Type: "USD", Value: 10})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("lock must be between the parties of channel"))
+		})
+	})
+	Describe("FindRoute", func() {
+		var edges []*impl.Edge
+		BeforeEach(func() {
+			edges = []*impl.Edge{
+				{ChannelID: "ab", From: "alice", To: "bob", Capacity: map[string]uint64{"USD": 100}},
+				{ChannelID: "bc", From: "bob", To: "charlie", Capacity: map[string]uint64{"USD": 10}},
+				{ChannelID: "ad", From: "alice", To: "dave", Capacity: map[string]uint64{"USD": 100}},
+				{ChannelID: "de", From: "dave", To: "eve", Capacity: map[string]uint64{"USD": 100}},
+				{ChannelID: "ec", From: "eve", To: "charlie", Capacity: map[string]uint64{"USD": 100}},
+			}
+		})
+		It("Returns the shortest route", func() {
+			route, err := impl.FindRoute(edges, "alice", "charlie", "USD", 10)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(route).To(Equal([]*impl.Edge{edges[0], edges[1]}))
+		})
+		It("Avoids channels without enough capacity", func() {
+			route, err := impl.FindRoute(edges, "alice", "charlie", "USD", 50)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(route).To(Equal([]*impl.Edge{edges[2], edges[3], edges[4]}))
+		})
+		It("fails when there is no route", func() {
+			_, err := impl.FindRoute(edges, "charlie", "alice", "USD", 1)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("no route from [charlie] to [alice]"))
+		})
+	})
 	Describe("Delete", func() {
 		BeforeEach(func() {
 			tracker = &impl.Tracker{Party: "alice", Channels: make(map[string]*impl.Channel)}
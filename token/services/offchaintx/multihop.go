@@ -0,0 +1,255 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package offchaintx
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/kvs"
+	session2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/session"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/offchaintx/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/offchaintx/impl"
+)
+
+const (
+	preimagePrefix = "token-sdk.offchaintx.preimage"
+	// lockDelta is the time each hop of a route has to resolve its lock: a lock times out lockDelta before
+	// the lock it is forwarded from, so that a node can still execute the incoming lock once the outgoing one is
+	lockDelta = 5 * time.Minute
+)
+
+// Hop is a step of a route: the channel to use and the node at its other end
+type Hop struct {
+	ChannelID string
+	Node      view.Identity
+}
+
+// NewHashLock generates a secret preimage and returns its hash, to be given to the payer.
+// The preimage is stored, so that the locks on the hash reaching this node can be executed.
+func NewHashLock(sp view2.ServiceProvider) ([]byte, error) {
+	preimage := make([]byte, 32)
+	if _, err := rand.Read(preimage); err != nil {
+		return nil, errors.Wrap(err, "failed generating preimage")
+	}
+	hash := sha256.Sum256(preimage)
+	if err := putPreimage(sp, preimage); err != nil {
+		return nil, err
+	}
+	return hash[:], nil
+}
+
+// putPreimage stores the passed preimage, so that it can be revealed in a dispute
+func putPreimage(sp view2.ServiceProvider, preimage []byte) error {
+	hash := sha256.Sum256(preimage)
+	if err := kvs.GetService(sp).Put(preimageKey(hash[:]), preimage); err != nil {
+		return errors.Wrap(err, "failed storing preimage")
+	}
+	return nil
+}
+
+func getPreimage(sp view2.ServiceProvider, hashLock []byte) ([]byte, error) {
+	key := preimageKey(hashLock)
+	kvss := kvs.GetService(sp)
+	if !kvss.Exists(key) {
+		return nil, errors.Errorf("no preimage for hash lock [%s]", hex.EncodeToString(hashLock))
+	}
+	var preimage []byte
+	if err := kvss.Get(key, &preimage); err != nil {
+		return nil, errors.Wrapf(err, "failed loading preimage for hash lock [%s]", hex.EncodeToString(hashLock))
+	}
+	return preimage, nil
+}
+
+func preimageKey(hashLock []byte) string {
+	return kvs.CreateCompositeKeyOrPanic(preimagePrefix, []string{hex.EncodeToString(hashLock)})
+}
+
+// FindRoute returns a route from 'from' to 'to' over the passed edges, shared by the nodes that route payments,
+// along which value tokens of type typ can be sent
+func FindRoute(edges []*impl.Edge, from, to view.Identity, typ string, value uint64) ([]*Hop, error) {
+	route, err := impl.FindRoute(edges, string(from), string(to), typ, value)
+	if err != nil {
+		return nil, err
+	}
+	hops := make([]*Hop, len(route))
+	for i, edge := range route {
+		hops[i] = &Hop{ChannelID: edge.ChannelID, Node: view.Identity(edge.To)}
+	}
+	return hops, nil
+}
+
+// Edges returns the edges, one per direction, of the channel, where this node is identified by the passed identity.
+// The capacity of an edge is the balance of its sender minus its pending locks.
+func (c *channel) Edges(me view.Identity) ([]*impl.Edge, error) {
+	info := c.Info()
+	state, err := c.State()
+	if err != nil {
+		return nil, err
+	}
+	capacity := func(party int) map[string]uint64 {
+		res := map[string]uint64{}
+		for typ, v := range state.Balances[party] {
+			res[typ] = v
+		}
+		for _, lock := range state.Locks {
+			if lock.Sender == party {
+				res[lock.Type] -= lock.Value
+			}
+		}
+		return res
+	}
+	return []*impl.Edge{
+		{ChannelID: info.ID, From: string(me), To: string(info.Counterparty), Capacity: capacity(info.Me)},
+		{ChannelID: info.ID, From: string(info.Counterparty), To: string(me), Capacity: capacity(1 - info.Me)},
+	}, nil
+}
+
+type payRouteView struct {
+	route    []*Hop
+	hashLock []byte
+	typ      string
+	value    uint64
+	timeout  time.Time
+}
+
+// NewPayRouteView returns a view that pays off-chain the passed value of tokens of type typ to the last node of the route,
+// which must hold the preimage of the passed hash lock (see NewHashLock).
+// The value is locked on the channel with the first hop, which forwards the lock along the rest of the route.
+// Once the payee executes its lock by revealing the preimage, each node executes the lock of the previous hop,
+// if any node fails the locks are cancelled backwards.
+// The lock with the first hop times out after lockDelta per hop, each forwarded lock lockDelta earlier than the previous one.
+// The nodes of the route must run NewReceiveView in response to this view. The view returns the preimage.
+func NewPayRouteView(route []*Hop, hashLock []byte, typ string, value uint64) *payRouteView {
+	return &payRouteView{route: route, hashLock: hashLock, typ: typ, value: value}
+}
+
+// newForwardView returns a view that forwards the passed lock, just received, along the passed route
+func newForwardView(route []*Hop, lock *api.Conditional) (*payRouteView, error) {
+	timeout := lock.Timeout.Add(-lockDelta)
+	if !timeout.After(time.Now()) {
+		return nil, errors.Errorf("lock [%s] times out at [%s], too early to be forwarded", hex.EncodeToString(lock.HashLock), lock.Timeout)
+	}
+	return &payRouteView{route: route, hashLock: lock.HashLock, typ: lock.Type, value: lock.Value, timeout: timeout}, nil
+}
+
+func (p *payRouteView) Call(context view.Context) (interface{}, error) {
+	if len(p.route) == 0 {
+		return nil, errors.New("empty route")
+	}
+	hop := p.route[0]
+	ch, err := GetChannel(context, context.Me(), hop.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	if !ch.Info().Counterparty.Equal(hop.Node) {
+		return nil, errors.Errorf("channel [%s] does not lead to [%s]", hop.ChannelID, hop.Node)
+	}
+	session, err := context.GetSession(p, hop.Node)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting session")
+	}
+
+	timeout := p.timeout
+	if timeout.IsZero() {
+		timeout = time.Now().Add(time.Duration(len(p.route)) * lockDelta)
+	}
+	cond := &api.Conditional{Op: api.LockOp, HashLock: p.hashLock, Type: p.typ, Value: p.value, Timeout: timeout.UTC()}
+	if _, err := sendConditional(context, session, ch, cond, p.route[1:]); err != nil {
+		return nil, errors.WithMessagef(err, "failed locking [%d] of type [%s] on channel [%s]", p.value, p.typ, hop.ChannelID)
+	}
+
+	// wait for the lock to be executed or cancelled, after its timeout the lock can only be cancelled
+	payload, err := session2.ReadMessageWithTimeout(session, time.Until(timeout))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "lock on channel [%s] not resolved", hop.ChannelID)
+	}
+	update, _, _, err := receive(context, session, hop.Node, payload)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed resolving lock on channel [%s]", hop.ChannelID)
+	}
+	if update.Conditional == nil {
+		return nil, errors.Errorf("expected the resolution of the lock on channel [%s]", hop.ChannelID)
+	}
+	switch update.Conditional.Op {
+	case api.UnlockOp:
+		hash := sha256.Sum256(update.Conditional.Preimage)
+		if bytes.Equal(hash[:], p.hashLock) {
+			// keep the preimage, the lock of the previous hop might have to be executed in a dispute
+			if err := putPreimage(context, update.Conditional.Preimage); err != nil {
+				return nil, err
+			}
+			return update.Conditional.Preimage, nil
+		}
+	case api.CancelOp:
+		if bytes.Equal(update.Conditional.HashLock, p.hashLock) {
+			return nil, errors.Errorf("payment cancelled on channel [%s]", hop.ChannelID)
+		}
+	}
+	return nil, errors.Errorf("expected the resolution of the lock on channel [%s]", hop.ChannelID)
+}
+
+// resolve executes the passed lock, just received, if this node is the payee or the lock can be forwarded
+// along its route, and cancels it otherwise
+func resolve(context view.Context, session view.Session, update *Update) (*api.State, error) {
+	lock := update.Conditional
+	var preimage []byte
+	var err error
+	if len(update.Route) == 0 {
+		preimage, err = getPreimage(context, lock.HashLock)
+	} else {
+		var forward *payRouteView
+		forward, err = newForwardView(update.Route, lock)
+		if err == nil {
+			var res interface{}
+			res, err = context.RunView(forward)
+			if err == nil {
+				preimage = res.([]byte)
+			}
+		}
+	}
+
+	cond := &api.Conditional{Op: api.UnlockOp, Preimage: preimage}
+	if err != nil {
+		logger.Warnf("cancel lock [%s] on channel [%s]: [%s]", hex.EncodeToString(lock.HashLock), update.ChannelID, err)
+		cond = &api.Conditional{Op: api.CancelOp, HashLock: lock.HashLock}
+	}
+	ch, chErr := GetChannel(context, context.Me(), update.ChannelID)
+	if chErr != nil {
+		return nil, chErr
+	}
+	state, resolveErr := sendConditional(context, session, ch, cond, nil)
+	if resolveErr != nil {
+		return nil, errors.WithMessagef(resolveErr, "failed resolving lock on channel [%s]", update.ChannelID)
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(err, "payment cancelled")
+	}
+	return state, nil
+}
+
+// sendConditional proposes the passed conditional update over the passed session and applies it once acknowledged
+func sendConditional(context view.Context, session view.Session, ch *channel, cond *api.Conditional, route []*Hop) (*api.State, error) {
+	next, err := ch.ch.NextConditional(true, cond)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := propose(context, session, ch, &Update{ChannelID: ch.Info().ID, Conditional: cond, Route: route}, next)
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.ch.ApplyConditional(true, cond, sig); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
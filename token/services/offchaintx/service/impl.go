@@ -6,45 +6,426 @@ SPDX-License-Identifier: Apache-2.0
 package service
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/kvs"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/offchaintx/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/offchaintx/impl"
 )
 
-type trackerService struct{}
+const channelPrefix = "token-sdk.offchaintx.channel"
+
+type trackerService struct {
+	sp       view2.ServiceProvider
+	lock     sync.Mutex
+	trackers map[string]*tracker
+}
+
+// NewTrackerService returns a tracker service that stores the channels in the key-value store of the passed service provider
+func NewTrackerService(sp view2.ServiceProvider) *trackerService {
+	return &trackerService{sp: sp, trackers: map[string]*tracker{}}
+}
 
 func (t *trackerService) Tracker(id view.Identity) (api.Tracker, error) {
-	panic("implement me")
+	if id.IsNone() {
+		return nil, errors.New("tracker identity not set")
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	tr, ok := t.trackers[id.UniqueID()]
+	if !ok {
+		tr = &tracker{
+			sp:       t.sp,
+			me:       id,
+			t:        &impl.Tracker{Party: id.UniqueID(), Channels: map[string]*impl.Channel{}},
+			channels: map[string]*channel{},
+		}
+		t.trackers[id.UniqueID()] = tr
+	}
+	return tr, nil
 }
 
 type tracker struct {
-	channels []*channel
+	sp       view2.ServiceProvider
+	me       view.Identity
+	lock     sync.Mutex
+	t        *impl.Tracker
+	channels map[string]*channel
 }
 
-func (t *tracker) OpenChannelTo(id string, recipient view.Identity) (api.Channel, error) {
-	panic("implement me")
+func (t *tracker) OpenChannel(info *api.ChannelInfo) (api.Channel, error) {
+	if len(info.ID) == 0 || info.Counterparty.IsNone() {
+		return nil, errors.New("channel id and counterparty must be set")
+	}
+	if len(info.Parties) != 2 || len(info.Deposits) != 2 || info.Me < 0 || info.Me > 1 {
+		return nil, errors.New("a channel must have two parties")
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, err := t.load(info.ID); err == nil {
+		return nil, errors.Errorf("channel with ID `%s` is already open", info.ID)
+	}
+	if err := t.t.Open(info.ID, info.Counterparty.UniqueID()); err != nil {
+		return nil, err
+	}
+	c := &channel{tracker: t, ChannelInfo: info, Channel: t.t.Channels[info.ID]}
+	if err := c.store(); err != nil {
+		return nil, err
+	}
+	t.channels[info.ID] = c
+	return c, nil
 }
 
 func (t *tracker) Channel(id string) (api.Channel, error) {
-	panic("implement me")
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.load(id)
+}
+
+// load returns the channel with the passed id, reading it from the key-value store if needed
+func (t *tracker) load(id string) (*channel, error) {
+	if c, ok := t.channels[id]; ok {
+		return c, nil
+	}
+	key := t.channelKey(id)
+	kvss := kvs.GetService(t.sp)
+	if !kvss.Exists(key) {
+		return nil, errors.Errorf("there is no open channel with ID '%s'", id)
+	}
+	c := &channel{}
+	if err := kvss.Get(key, c); err != nil {
+		return nil, errors.Wrapf(err, "failed loading channel [%s]", id)
+	}
+	c.tracker = t
+	t.t.Channels[id] = c.Channel
+	t.channels[id] = c
+	return c, nil
 }
 
+func (t *tracker) channelKey(id string) string {
+	return kvs.CreateCompositeKeyOrPanic(channelPrefix, []string{t.me.UniqueID(), id})
+}
+
+// channel is the persistent record of a channel
 type channel struct {
-	ID           string
-	CounterParty view.Identity
+	tracker *tracker
+	lock    sync.Mutex
+
+	*api.ChannelInfo
+	Channel *impl.Channel
+	Closed  bool
+}
+
+func (c *channel) Info() *api.ChannelInfo {
+	return c.ChannelInfo
+}
+
+func (c *channel) State() (*api.State, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.state(c.Channel)
 }
 
-func (c *channel) Receive(id, ttype string, value uint64, sig []byte) error {
-	panic("implement me")
+func (c *channel) Next(send bool, ttype string, value uint64) (*api.State, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	next, err := c.apply(send, ttype, value, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.state(next)
+}
+
+func (c *channel) Receive(ttype string, value uint64, sig []byte) error {
+	return c.update(false, ttype, value, sig)
 }
 
-func (c *channel) Send(id, ttype string, value uint64) error {
-	panic("implement me")
+func (c *channel) Send(ttype string, value uint64, sig []byte) error {
+	return c.update(true, ttype, value, sig)
+}
+
+func (c *channel) NextConditional(mine bool, cond *api.Conditional) (*api.State, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	next, err := c.applyConditional(mine, cond, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.state(next)
+}
+
+func (c *channel) ApplyConditional(mine bool, cond *api.Conditional, sig []byte) error {
+	if len(sig) == 0 {
+		return errors.New("the signature of the counterparty is required")
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	next, err := c.applyConditional(mine, cond, sig)
+	if err != nil {
+		return err
+	}
+	return c.commit(next)
+}
+
+func (c *channel) Signature(seqNumber int) []byte {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.Channel.ProofOfReceipt[strconv.Itoa(seqNumber)]
 }
 
 func (c *channel) Net() ([]*api.Transfer, error) {
-	panic("implement me")
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t := &impl.Tracker{Party: c.tracker.t.Party, Channels: map[string]*impl.Channel{c.ID: c.Channel}}
+	net, err := t.Net(c.ID)
+	if err != nil {
+		return nil, err
+	}
+	var res []*api.Transfer
+	for _, tr := range net {
+		res = append(res, &api.Transfer{Sender: tr.Sender, Receiver: tr.Receiver, Type: tr.Type, Value: tr.Value})
+	}
+	return res, nil
+}
+
+func (c *channel) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.Closed = true
+	return c.store()
+}
+
+func (c *channel) IsClosed() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.Closed
+}
+
+// update applies the passed exchange and stores the channel
+func (c *channel) update(send bool, ttype string, value uint64, sig []byte) error {
+	if len(sig) == 0 {
+		return errors.New("the signature of the counterparty is required")
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	next, err := c.apply(send, ttype, value, sig)
+	if err != nil {
+		return err
+	}
+	return c.commit(next)
+}
+
+// commit replaces the channel with the passed one and stores it
+func (c *channel) commit(next *impl.Channel) error {
+	previous := c.Channel
+	c.Channel = next
+	if err := c.store(); err != nil {
+		c.Channel = previous
+		return err
+	}
+	c.tracker.lock.Lock()
+	c.tracker.t.Channels[c.ID] = next
+	c.tracker.lock.Unlock()
+	return nil
+}
+
+// apply returns a copy of the channel with the passed exchange applied.
+// The sender must have enough balance in the channel.
+func (c *channel) apply(send bool, ttype string, value uint64, sig []byte) (*impl.Channel, error) {
+	if c.Closed {
+		return nil, errors.Errorf("channel with ID '%s' is closed", c.ID)
+	}
+	if value == 0 {
+		return nil, errors.New("value must be positive")
+	}
+	sender := c.Me
+	if !send {
+		sender = 1 - c.Me
+	}
+	if err := c.checkAvailable(sender, ttype, value); err != nil {
+		return nil, err
+	}
+
+	t := &impl.Tracker{Party: c.tracker.t.Party, Channels: map[string]*impl.Channel{c.ID: c.Channel.Clone()}}
+	if send {
+		if err := t.Send(c.ID, ttype, value); err != nil {
+			return nil, err
+		}
+		if sig != nil {
+			if err := t.Acknowledge(c.ID, sig); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		if err := t.Receive(c.ID, ttype, value, sig); err != nil {
+			return nil, err
+		}
+	}
+	return t.Channels[c.ID], nil
+}
+
+// applyConditional returns a copy of the channel with the passed conditional update applied.
+// Locks are added by their sender, that must have enough balance, and executed, before they time out, or cancelled by their receiver.
+func (c *channel) applyConditional(mine bool, cond *api.Conditional, sig []byte) (*impl.Channel, error) {
+	if c.Closed {
+		return nil, errors.Errorf("channel with ID '%s' is closed", c.ID)
+	}
+	initiator, other := c.tracker.t.Party, c.Channel.Counterparty
+	if !mine {
+		initiator, other = other, initiator
+	}
+
+	t := &impl.Tracker{Party: c.tracker.t.Party, Channels: map[string]*impl.Channel{c.ID: c.Channel.Clone()}}
+	switch cond.Op {
+	case api.LockOp:
+		if cond.Value == 0 {
+			return nil, errors.New("value must be positive")
+		}
+		if !cond.Timeout.After(time.Now()) {
+			return nil, errors.Errorf("lock [%s] already timed out at [%s]", hex.EncodeToString(cond.HashLock), cond.Timeout)
+		}
+		sender := c.Me
+		if !mine {
+			sender = 1 - c.Me
+		}
+		if err := c.checkAvailable(sender, cond.Type, cond.Value); err != nil {
+			return nil, err
+		}
+		lock := &impl.Lock{HashLock: cond.HashLock, Sender: initiator, Receiver: other, Type: cond.Type, Value: cond.Value, Timeout: cond.Timeout.UTC()}
+		if err := t.AddLock(c.ID, lock); err != nil {
+			return nil, err
+		}
+	case api.UnlockOp:
+		hash := sha256.Sum256(cond.Preimage)
+		if err := c.checkLockReceiver(hash[:], initiator); err != nil {
+			return nil, err
+		}
+		if lock := c.Channel.Locks[hex.EncodeToString(hash[:])]; !time.Now().Before(lock.Timeout) {
+			return nil, errors.Errorf("lock [%s] in channel [%s] timed out at [%s]", hex.EncodeToString(hash[:]), c.ID, lock.Timeout)
+		}
+		if err := t.Unlock(c.ID, cond.Preimage); err != nil {
+			return nil, err
+		}
+	case api.CancelOp:
+		if err := c.checkLockReceiver(cond.HashLock, initiator); err != nil {
+			return nil, err
+		}
+		if err := t.CancelLock(c.ID, cond.HashLock); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("unknown conditional update [%s]", cond.Op)
+	}
+	if sig != nil {
+		if err := t.Acknowledge(c.ID, sig); err != nil {
+			return nil, err
+		}
+	}
+	return t.Channels[c.ID], nil
+}
+
+// checkLockReceiver checks that the passed party is the receiver of the pending lock with the passed hash lock
+func (c *channel) checkLockReceiver(hashLock []byte, party string) error {
+	lock, ok := c.Channel.Locks[hex.EncodeToString(hashLock)]
+	if !ok {
+		return errors.Errorf("there is no pending lock [%s] in channel [%s]", hex.EncodeToString(hashLock), c.ID)
+	}
+	if lock.Receiver != party {
+		return errors.Errorf("lock [%s] in channel [%s] can only be resolved by its receiver", hex.EncodeToString(hashLock), c.ID)
+	}
+	return nil
+}
+
+// checkAvailable checks that the party with the passed index can send value tokens of the passed type,
+// its pending locks included
+func (c *channel) checkAvailable(party int, ttype string, value uint64) error {
+	available := c.balances(c.Channel)[party][ttype]
+	for _, lock := range c.locks(c.Channel) {
+		if lock.Sender == party && lock.Type == ttype {
+			available -= lock.Value
+		}
+	}
+	if available < value {
+		return errors.Errorf("insufficient balance of type [%s] in channel [%s], [%d] < [%d]", ttype, c.ID, available, value)
+	}
+	return nil
+}
+
+func (c *channel) state(ch *impl.Channel) (*api.State, error) {
+	return &api.State{
+		ChannelID: c.ID,
+		SeqNumber: ch.SeqNumber,
+		Hash:      append([]byte(nil), ch.Hash[:]...),
+		Parties:   c.Parties,
+		Balances:  c.balances(ch),
+		Locks:     c.locks(ch),
+	}, nil
+}
+
+// locks returns the pending locks of the passed channel, sorted by hash lock
+func (c *channel) locks(ch *impl.Channel) []*api.Lock {
+	var keys []string
+	for key := range ch.Locks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var res []*api.Lock
+	for _, key := range keys {
+		lock := ch.Locks[key]
+		sender := c.Me
+		if lock.Sender != c.tracker.t.Party {
+			sender = 1 - c.Me
+		}
+		res = append(res, &api.Lock{HashLock: lock.HashLock, Sender: sender, Type: lock.Type, Value: lock.Value, Timeout: lock.Timeout})
+	}
+	return res
+}
+
+// balances returns the deposits of the parties adjusted by the net of the passed channel
+func (c *channel) balances(ch *impl.Channel) []map[string]uint64 {
+	res := make([]map[string]uint64, len(c.Deposits))
+	for i, deposits := range c.Deposits {
+		res[i] = map[string]uint64{}
+		for typ, v := range deposits {
+			res[i][typ] = v
+		}
+	}
+	for typ, net := range ch.Net {
+		if net >= 0 {
+			res[c.Me][typ] += uint64(net)
+			res[1-c.Me][typ] -= uint64(net)
+		} else {
+			res[c.Me][typ] -= uint64(-net)
+			res[1-c.Me][typ] += uint64(-net)
+		}
+	}
+	return res
 }
 
 func (c *channel) store() error {
-	panic("implement me")
+	if err := kvs.GetService(c.tracker.sp).Put(c.tracker.channelKey(c.ID), c); err != nil {
+		return errors.Wrapf(err, "failed storing channel [%s]", c.ID)
+	}
+	return nil
 }
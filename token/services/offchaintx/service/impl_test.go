@@ -0,0 +1,242 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package service
+
+import (
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/kvs"
+	registry2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/registry"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/offchaintx/api"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+type fakeProv struct {
+	typ string
+}
+
+func (f *fakeProv) GetString(key string) string {
+	return f.typ
+}
+
+func (f *fakeProv) GetDuration(key string) time.Duration {
+	return time.Duration(0)
+}
+
+func (f *fakeProv) GetBool(key string) bool {
+	return false
+}
+
+func (f *fakeProv) GetStringSlice(key string) []string {
+	return nil
+}
+
+func (f *fakeProv) IsSet(key string) bool {
+	return false
+}
+
+func (f *fakeProv) UnmarshalKey(key string, rawVal interface{}) error {
+	*(rawVal.(*kvs.Opts)) = kvs.Opts{}
+	return nil
+}
+
+func (f *fakeProv) ConfigFileUsed() string {
+	return ""
+}
+
+func (f *fakeProv) GetPath(key string) string {
+	return ""
+}
+
+func (f *fakeProv) TranslatePath(path string) string {
+	return ""
+}
+
+func newRegistry(t *testing.T) view2.ServiceProvider {
+	registry := registry2.New()
+	assert.NoError(t, registry.RegisterService(&fakeProv{typ: "memory"}))
+	kvss, err := kvs.New("memory", "", registry)
+	assert.NoError(t, err)
+	assert.NoError(t, registry.RegisterService(kvss))
+	return registry
+}
+
+func newInfo() *api.ChannelInfo {
+	return &api.ChannelInfo{
+		ID:           "ChannelID",
+		Counterparty: view.Identity("bob"),
+		Parties:      []view.Identity{view.Identity("alice.party"), view.Identity("bob.party")},
+		Me:           0,
+		Deposit:      &token2.Id{TxId: "ChannelID", Index: 0},
+		Deposits:     []map[string]uint64{{"USD": 100}, {}},
+	}
+}
+
+func TestChannel(t *testing.T) {
+	registry := newRegistry(t)
+	tracker, err := NewTrackerService(registry).Tracker(view.Identity("alice"))
+	assert.NoError(t, err)
+
+	ch, err := tracker.OpenChannel(newInfo())
+	assert.NoError(t, err)
+	_, err = tracker.OpenChannel(newInfo())
+	assert.Error(t, err)
+
+	// Next does not apply the update
+	next, err := ch.Next(true, "USD", 30)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, next.SeqNumber)
+	assert.Equal(t, []map[string]uint64{{"USD": 70}, {"USD": 30}}, next.Balances)
+	state, err := ch.State()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, state.SeqNumber)
+
+	assert.Error(t, ch.Send("USD", 30, nil))
+	assert.NoError(t, ch.Send("USD", 30, []byte("ack1")))
+	state, err = ch.State()
+	assert.NoError(t, err)
+	assert.Equal(t, next, state)
+	assert.Equal(t, []byte("ack1"), ch.Signature(1))
+
+	// the counterparty cannot send more than it holds
+	_, err = ch.Next(false, "USD", 31)
+	assert.Error(t, err)
+	assert.NoError(t, ch.Receive("USD", 10, []byte("sig2")))
+	state, err = ch.State()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, state.SeqNumber)
+	assert.Equal(t, []map[string]uint64{{"USD": 80}, {"USD": 20}}, state.Balances)
+
+	net, err := ch.Net()
+	assert.NoError(t, err)
+	assert.Equal(t, []*api.Transfer{{Sender: view.Identity("alice").UniqueID(), Receiver: view.Identity("bob").UniqueID(), Type: "USD", Value: 20}}, net)
+
+	// the channel survives a restart
+	tracker, err = NewTrackerService(registry).Tracker(view.Identity("alice"))
+	assert.NoError(t, err)
+	reloaded, err := tracker.Channel("ChannelID")
+	assert.NoError(t, err)
+	reloadedState, err := reloaded.State()
+	assert.NoError(t, err)
+	assert.Equal(t, state, reloadedState)
+	assert.Equal(t, []byte("sig2"), reloaded.Signature(2))
+
+	assert.NoError(t, reloaded.Close())
+	assert.True(t, reloaded.IsClosed())
+	_, err = reloaded.Next(true, "USD", 1)
+	assert.Error(t, err)
+
+	_, err = tracker.Channel("AnotherID")
+	assert.Error(t, err)
+}
+
+// openPair opens, on the trackers of the two passed nodes, the two ends of a channel funded by the first node
+func openPair(t *testing.T, id string, from, to view.Identity, fromTracker, toTracker api.Tracker, deposit uint64) (api.Channel, api.Channel) {
+	parties := []view.Identity{view.Identity(string(from) + ".party"), view.Identity(string(to) + ".party")}
+	deposits := []map[string]uint64{{"USD": deposit}, {}}
+	fromCh, err := fromTracker.OpenChannel(&api.ChannelInfo{ID: id, Counterparty: to, Parties: parties, Me: 0, Deposit: &token2.Id{TxId: id}, Deposits: deposits})
+	assert.NoError(t, err)
+	toCh, err := toTracker.OpenChannel(&api.ChannelInfo{ID: id, Counterparty: from, Parties: parties, Me: 1, Deposit: &token2.Id{TxId: id}, Deposits: deposits})
+	assert.NoError(t, err)
+	return fromCh, toCh
+}
+
+// exchange applies the passed conditional update, initiated by the node owning the first channel, on both ends
+func exchange(t *testing.T, initiator, responder api.Channel, cond *api.Conditional) *api.State {
+	next, err := initiator.NextConditional(true, cond)
+	assert.NoError(t, err)
+	other, err := responder.NextConditional(false, cond)
+	assert.NoError(t, err)
+	assert.Equal(t, next, other)
+	assert.NoError(t, initiator.ApplyConditional(true, cond, []byte("ack")))
+	assert.NoError(t, responder.ApplyConditional(false, cond, []byte("update")))
+	return next
+}
+
+func TestMultiHop(t *testing.T) {
+	alice, bob, charlie := view.Identity("alice"), view.Identity("bob"), view.Identity("charlie")
+	trackers := map[string]api.Tracker{}
+	for _, node := range []view.Identity{alice, bob, charlie} {
+		tracker, err := NewTrackerService(newRegistry(t)).Tracker(node)
+		assert.NoError(t, err)
+		trackers[string(node)] = tracker
+	}
+	aliceAB, bobAB := openPair(t, "ab", alice, bob, trackers["alice"], trackers["bob"], 100)
+	bobBC, charlieBC := openPair(t, "bc", bob, charlie, trackers["bob"], trackers["charlie"], 100)
+
+	preimage := []byte("preimage")
+	hash := sha256.Sum256(preimage)
+	timeout := time.Now().Add(time.Hour).UTC()
+	lock := &api.Conditional{Op: api.LockOp, HashLock: hash[:], Type: "USD", Value: 30, Timeout: timeout}
+
+	// locks are forwarded from the payer to the payee, each hop with an earlier timeout
+	state := exchange(t, aliceAB, bobAB, lock)
+	assert.Equal(t, []*api.Lock{{HashLock: hash[:], Sender: 0, Type: "USD", Value: 30, Timeout: timeout}}, state.Locks)
+	assert.Equal(t, []map[string]uint64{{"USD": 100}, {}}, state.Balances)
+	forwarded := *lock
+	forwarded.Timeout = timeout.Add(-time.Minute)
+	exchange(t, bobBC, charlieBC, &forwarded)
+
+	// locked tokens cannot be spent
+	_, err := aliceAB.Next(true, "USD", 71)
+	assert.Error(t, err)
+	anotherHash := sha256.Sum256([]byte("another preimage"))
+	_, err = aliceAB.NextConditional(true, &api.Conditional{Op: api.LockOp, HashLock: anotherHash[:], Type: "USD", Value: 71, Timeout: timeout})
+	assert.Error(t, err)
+
+	// only the receiver of a lock can execute or cancel it
+	unlock := &api.Conditional{Op: api.UnlockOp, Preimage: preimage}
+	_, err = aliceAB.NextConditional(true, unlock)
+	assert.Error(t, err)
+	_, err = bobBC.NextConditional(true, &api.Conditional{Op: api.CancelOp, HashLock: hash[:]})
+	assert.Error(t, err)
+	_, err = charlieBC.NextConditional(true, &api.Conditional{Op: api.UnlockOp, Preimage: []byte("wrong")})
+	assert.Error(t, err)
+
+	// the payee reveals the preimage, the locks are executed backwards
+	state = exchange(t, charlieBC, bobBC, unlock)
+	assert.Empty(t, state.Locks)
+	assert.Equal(t, []map[string]uint64{{"USD": 70}, {"USD": 30}}, state.Balances)
+	state = exchange(t, bobAB, aliceAB, unlock)
+	assert.Empty(t, state.Locks)
+	assert.Equal(t, []map[string]uint64{{"USD": 70}, {"USD": 30}}, state.Balances)
+
+	// a failed payment is cancelled backwards and releases the locked tokens
+	cancelHash := sha256.Sum256([]byte("unknown preimage"))
+	exchange(t, aliceAB, bobAB, &api.Conditional{Op: api.LockOp, HashLock: cancelHash[:], Type: "USD", Value: 70, Timeout: timeout})
+	_, err = aliceAB.Next(true, "USD", 1)
+	assert.Error(t, err)
+	state = exchange(t, bobAB, aliceAB, &api.Conditional{Op: api.CancelOp, HashLock: cancelHash[:]})
+	assert.Empty(t, state.Locks)
+	assert.Equal(t, []map[string]uint64{{"USD": 70}, {"USD": 30}}, state.Balances)
+	_, err = aliceAB.Next(true, "USD", 70)
+	assert.NoError(t, err)
+
+	// a lock cannot be added once timed out, nor executed after its timeout, it can only be cancelled
+	expiredHash := sha256.Sum256([]byte("expired preimage"))
+	_, err = aliceAB.NextConditional(true, &api.Conditional{Op: api.LockOp, HashLock: expiredHash[:], Type: "USD", Value: 10, Timeout: time.Now()})
+	assert.Error(t, err)
+	exchange(t, aliceAB, bobAB, &api.Conditional{Op: api.LockOp, HashLock: expiredHash[:], Type: "USD", Value: 10, Timeout: time.Now().Add(50 * time.Millisecond)})
+	time.Sleep(100 * time.Millisecond)
+	_, err = bobAB.NextConditional(true, &api.Conditional{Op: api.UnlockOp, Preimage: []byte("expired preimage")})
+	assert.Error(t, err)
+	state = exchange(t, bobAB, aliceAB, &api.Conditional{Op: api.CancelOp, HashLock: expiredHash[:]})
+	assert.Empty(t, state.Locks)
+	assert.Equal(t, []map[string]uint64{{"USD": 70}, {"USD": 30}}, state.Balances)
+
+	// both ends agree on the hash chain
+	aliceState, err := aliceAB.State()
+	assert.NoError(t, err)
+	bobState, err := bobAB.State()
+	assert.NoError(t, err)
+	assert.Equal(t, aliceState, bobState)
+}
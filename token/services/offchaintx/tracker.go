@@ -19,26 +19,30 @@ type channel struct {
 	ch api.Channel
 }
 
-func (c *channel) Receive(id, ttype string, value uint64, sig []byte) error {
-	return c.ch.Receive(id, ttype, value, sig)
+func (c *channel) Info() *api.ChannelInfo {
+	return c.ch.Info()
 }
 
-func (c *channel) Send(id, ttype string, value uint64) error {
-	return c.ch.Send(id, ttype, value)
+func (c *channel) State() (*api.State, error) {
+	return c.ch.State()
 }
 
 func (c *channel) Net() ([]*api.Transfer, error) {
 	return c.ch.Net()
 }
 
-func OpenChannelTo(sp view2.ServiceProvider, me view.Identity, id string, recipient view.Identity) (*channel, error) {
+func (c *channel) IsClosed() bool {
+	return c.ch.IsClosed()
+}
+
+func openChannel(sp view2.ServiceProvider, me view.Identity, info *api.ChannelInfo) (*channel, error) {
 	tracker, err := getTrackerService(sp).Tracker(me)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed getting tracker")
 	}
-	ch, err := tracker.OpenChannelTo(id, recipient)
+	ch, err := tracker.OpenChannel(info)
 	if err != nil {
-		return nil, errors.WithMessage(err, "failed opening channel to")
+		return nil, errors.WithMessage(err, "failed opening channel")
 	}
 	return &channel{ch: ch}, nil
 }
@@ -50,7 +54,7 @@ func GetChannel(sp view2.ServiceProvider, me view.Identity, id string) (*channel
 	}
 	ch, err := tracker.Channel(id)
 	if err != nil {
-		return nil, errors.WithMessage(err, "failed opening channel to")
+		return nil, errors.WithMessage(err, "failed getting channel")
 	}
 	return &channel{ch: ch}, nil
 }
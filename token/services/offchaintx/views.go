@@ -0,0 +1,599 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package offchaintx
+
+import (
+	"encoding/json"
+	"time"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
+	session2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/session"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/multisig"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/offchaintx/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxcc"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+var logger = flogging.MustGetLogger("token-sdk.offchaintx")
+
+// SettlementMemo is the key of the request memo identifying the channel state a settlement transaction implements
+const SettlementMemo = "offchaintx.settlement"
+
+// Update is an off-chain transfer, signed by the sender, that moves the channel to the state with sequence number SeqNumber
+type Update struct {
+	ChannelID string
+	SeqNumber int
+	Type      string
+	Value     uint64
+	Signature []byte
+	// Conditional is set, instead of Type and Value, for hashlocked updates
+	Conditional *api.Conditional `json:",omitempty"`
+	// Route is the rest of the route a lock has to be forwarded along, empty if this node is the payee
+	Route []*Hop `json:",omitempty"`
+}
+
+// Ack is the signature of the receiver of an Update on the state the update moves the channel to
+type Ack struct {
+	SeqNumber int
+	Signature []byte
+}
+
+// Settlement identifies the state of the channel that a settlement transaction pays out
+type Settlement struct {
+	ChannelID string
+	SeqNumber int
+}
+
+type openChannelView struct {
+	wallet       string
+	counterparty view.Identity
+	typ          string
+	deposit      uint64
+	opts         []ttxcc.TxOption
+}
+
+// NewOpenChannelView returns a view that opens a channel with the passed counterparty, depositing on the ledger
+// the passed amount of tokens of type typ, taken from the passed wallet, to an identity owned jointly by the two parties.
+// The counterparty must run NewAcceptChannelView. The view returns the id of the channel.
+func NewOpenChannelView(wallet string, counterparty view.Identity, typ string, deposit uint64, opts ...ttxcc.TxOption) *openChannelView {
+	return &openChannelView{wallet: wallet, counterparty: counterparty, typ: typ, deposit: deposit, opts: opts}
+}
+
+func (o *openChannelView) Call(context view.Context) (interface{}, error) {
+	wallet := ttxcc.GetWallet(context, o.wallet)
+	me, err := wallet.GetRecipientIdentity()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting my identity")
+	}
+	other, err := ttxcc.RequestRecipientIdentity(context, o.counterparty)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting counterparty identity")
+	}
+
+	tx, err := ttxcc.NewAnonymousTransaction(context, o.opts...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed creating transaction")
+	}
+	owner, err := tx.TransferToMultisig(wallet, o.typ, o.deposit, 2, []view.Identity{me, other})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed adding deposit")
+	}
+	deposit, err := depositID(tx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := context.RunView(ttxcc.NewCollectEndorsementsView(tx)); err != nil {
+		return nil, errors.WithMessage(err, "failed collecting endorsements")
+	}
+	if _, err := context.RunView(ttxcc.NewOrderingView(tx)); err != nil {
+		return nil, errors.WithMessage(err, "failed ordering transaction")
+	}
+
+	info := &api.ChannelInfo{
+		ID:           tx.ID(),
+		Counterparty: o.counterparty,
+		Parties:      []view.Identity{me, other},
+		Me:           0,
+		Deposit:      deposit,
+		Deposits:     []map[string]uint64{{o.typ: o.deposit}, {}},
+	}
+	if _, err := openChannel(context, context.Me(), info); err != nil {
+		return nil, err
+	}
+	return info.ID, nil
+}
+
+type acceptChannelView struct{}
+
+// NewAcceptChannelView returns the view run by the counterparty in response to NewOpenChannelView.
+// It checks that the transaction deposits tokens for a channel with this node, and waits for its finality.
+// The view returns the id of the channel.
+func NewAcceptChannelView() *acceptChannelView {
+	return &acceptChannelView{}
+}
+
+func (a *acceptChannelView) Call(context view.Context) (interface{}, error) {
+	me, err := ttxcc.RespondRequestRecipientIdentity(context)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed responding to identity request")
+	}
+	tx, err := ttxcc.ReceiveTransaction(context)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed receiving transaction")
+	}
+
+	outputs, err := tx.Outputs()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting outputs")
+	}
+	var info *api.ChannelInfo
+	for i := 0; i < outputs.Count(); i++ {
+		output := outputs.At(i)
+		parties, ok := channelParties(output.Owner)
+		if !ok || !parties[1].Equal(me) {
+			continue
+		}
+		q, err := token2.ToQuantity(output.Quantity, 64)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "invalid deposit quantity [%s]", output.Quantity)
+		}
+		info = &api.ChannelInfo{
+			ID:           tx.ID(),
+			Counterparty: context.Session().Info().Caller,
+			Parties:      parties,
+			Me:           1,
+			Deposit:      &token2.Id{TxId: tx.ID(), Index: uint32(i)},
+			Deposits:     []map[string]uint64{{output.Type: q.ToBigInt().Uint64()}, {}},
+		}
+		break
+	}
+	if info == nil {
+		return nil, errors.Errorf("transaction [%s] deposits no tokens for a channel with [%s]", tx.ID(), me)
+	}
+
+	if _, err := context.RunView(ttxcc.NewAcceptView(tx)); err != nil {
+		return nil, errors.WithMessage(err, "failed accepting transaction")
+	}
+	if _, err := context.RunView(ttxcc.NewFinalityView(tx)); err != nil {
+		return nil, errors.WithMessagef(err, "transaction [%s] not committed", tx.ID())
+	}
+	if err := registerCounterparty(context, tx.TokenService(), info); err != nil {
+		return nil, err
+	}
+	if _, err := openChannel(context, context.Me(), info); err != nil {
+		return nil, err
+	}
+	return info.ID, nil
+}
+
+type sendView struct {
+	id    string
+	typ   string
+	value uint64
+}
+
+// NewSendView returns a view that sends off-chain the passed value of tokens of type typ over the channel with the passed id.
+// The update is applied once the counterparty, running NewReceiveView, acknowledges it.
+// The view returns the new state of the channel.
+func NewSendView(id string, typ string, value uint64) *sendView {
+	return &sendView{id: id, typ: typ, value: value}
+}
+
+func (s *sendView) Call(context view.Context) (interface{}, error) {
+	ch, err := GetChannel(context, context.Me(), s.id)
+	if err != nil {
+		return nil, err
+	}
+	next, err := ch.ch.Next(true, s.typ, s.value)
+	if err != nil {
+		return nil, err
+	}
+	session, err := context.GetSession(context.Initiator(), ch.Info().Counterparty)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting session")
+	}
+	sig, err := propose(context, session, ch, &Update{ChannelID: s.id, Type: s.typ, Value: s.value}, next)
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.ch.Send(s.typ, s.value, sig); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+type receiveView struct{}
+
+// NewReceiveView returns the view run by the counterparty in response to NewSendView and NewPayRouteView.
+// Updates must come in sequence: an update repeating the current sequence number is acknowledged again,
+// any other gap is rejected. Locks are forwarded along their route, or executed if this node is the payee,
+// and cancelled if this fails. The view returns the new state of the channel.
+func NewReceiveView() *receiveView {
+	return &receiveView{}
+}
+
+func (r *receiveView) Call(context view.Context) (interface{}, error) {
+	session, payload, err := session2.ReadFirstMessage(context)
+	if err != nil {
+		return nil, err
+	}
+	update, state, applied, err := receive(context, session, session.Info().Caller, payload)
+	if err != nil {
+		if sendErr := session.SendError([]byte(err.Error())); sendErr != nil {
+			logger.Warnf("failed rejecting update: [%s]", sendErr)
+		}
+		return nil, err
+	}
+	if applied && update.Conditional != nil && update.Conditional.Op == api.LockOp {
+		return resolve(context, session, update)
+	}
+	return state, nil
+}
+
+// propose sends the passed update, moving the channel to next, and returns the signature of the counterparty on next
+func propose(context view.Context, session view.Session, ch *channel, update *Update, next *api.State) ([]byte, error) {
+	info := ch.Info()
+	tms := token.GetManagementService(context)
+	sigma, err := signState(tms, info.Parties[info.Me], next)
+	if err != nil {
+		return nil, err
+	}
+	update.SeqNumber = next.SeqNumber
+	update.Signature = sigma
+	raw, err := json.Marshal(update)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling update")
+	}
+	if err := session.Send(raw); err != nil {
+		return nil, errors.Wrap(err, "failed sending update")
+	}
+	payload, err := session2.ReadMessageWithTimeout(session, 60*time.Second)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed receiving ack")
+	}
+	ack := &Ack{}
+	if err := json.Unmarshal(payload, ack); err != nil {
+		return nil, errors.Wrap(err, "failed unmarshalling ack")
+	}
+	if ack.SeqNumber != next.SeqNumber {
+		return nil, errors.Errorf("ack for sequence number [%d], expected [%d]", ack.SeqNumber, next.SeqNumber)
+	}
+	if err := verifyState(tms, info.Parties[1-info.Me], next, ack.Signature); err != nil {
+		return nil, errors.WithMessage(err, "invalid ack")
+	}
+	return ack.Signature, nil
+}
+
+// receive checks and acknowledges the passed update, sent by the passed party, and applies it if new.
+// It returns the update, the state of the channel, and whether the update has been applied.
+func receive(context view.Context, session view.Session, from view.Identity, payload []byte) (*Update, *api.State, bool, error) {
+	update := &Update{}
+	if err := json.Unmarshal(payload, update); err != nil {
+		return nil, nil, false, errors.Wrap(err, "failed unmarshalling update")
+	}
+	ch, err := GetChannel(context, context.Me(), update.ChannelID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	info := ch.Info()
+	if !from.Equal(info.Counterparty) {
+		return nil, nil, false, errors.Errorf("update for channel [%s] not sent by the counterparty", update.ChannelID)
+	}
+	tms := token.GetManagementService(context)
+	current, err := ch.State()
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	var next *api.State
+	switch update.SeqNumber {
+	case current.SeqNumber:
+		// the ack got lost, acknowledge again if this is the update already received
+		if err := verifyState(tms, info.Parties[1-info.Me], current, update.Signature); err != nil {
+			return nil, nil, false, errors.Errorf("update with stale sequence number [%d]", update.SeqNumber)
+		}
+		next = current
+	case current.SeqNumber + 1:
+		if update.Conditional != nil {
+			next, err = ch.ch.NextConditional(false, update.Conditional)
+		} else {
+			next, err = ch.ch.Next(false, update.Type, update.Value)
+		}
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if err := verifyState(tms, info.Parties[1-info.Me], next, update.Signature); err != nil {
+			return nil, nil, false, errors.WithMessage(err, "invalid update")
+		}
+	default:
+		return nil, nil, false, errors.Errorf("update with sequence number [%d], expected [%d]", update.SeqNumber, current.SeqNumber+1)
+	}
+
+	sigma, err := signState(tms, info.Parties[info.Me], next)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	raw, err := json.Marshal(&Ack{SeqNumber: next.SeqNumber, Signature: sigma})
+	if err != nil {
+		return nil, nil, false, errors.Wrap(err, "failed marshalling ack")
+	}
+	if err := session.Send(raw); err != nil {
+		return nil, nil, false, errors.Wrap(err, "failed sending ack")
+	}
+	if next == current {
+		return update, next, false, nil
+	}
+	if update.Conditional != nil {
+		err = ch.ch.ApplyConditional(false, update.Conditional, update.Signature)
+	} else {
+		err = ch.ch.Receive(update.Type, update.Value, update.Signature)
+	}
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return update, next, true, nil
+}
+
+type settleChannelView struct {
+	wallet string
+	id     string
+	opts   []ttxcc.TxOption
+}
+
+// NewSettleChannelView returns a view that closes the channel with the passed id by paying out the deposit
+// according to the balances of the current state, or of the dispute if a dispute on the channel has been closed.
+// The wallet must hold this node's party of the channel.
+// The counterparty must run NewSettleChannelResponderView. Its signature is not needed if the dispute is closed,
+// in which case the counterparty only receives the transaction, if reachable. The view returns the id of the transaction.
+func NewSettleChannelView(wallet string, id string, opts ...ttxcc.TxOption) *settleChannelView {
+	return &settleChannelView{wallet: wallet, id: id, opts: opts}
+}
+
+func (s *settleChannelView) Call(context view.Context) (interface{}, error) {
+	ch, err := GetChannel(context, context.Me(), s.id)
+	if err != nil {
+		return nil, err
+	}
+	if ch.IsClosed() {
+		return nil, errors.Errorf("channel [%s] already closed", s.id)
+	}
+	info := ch.Info()
+	state, err := ch.State()
+	if err != nil {
+		return nil, err
+	}
+	payouts := state.Payouts()
+	dispute := closedDispute(context, token.GetManagementService(context), s.id)
+	if dispute != nil {
+		state, payouts = dispute.State, dispute.Payouts()
+	} else if len(state.Locks) != 0 {
+		return nil, errors.Errorf("channel [%s] has pending locks", s.id)
+	}
+	var values []uint64
+	var owners []view.Identity
+	for _, payout := range payouts {
+		values = append(values, payout.Value)
+		owners = append(owners, payout.Owner)
+	}
+
+	tx, err := ttxcc.NewAnonymousTransaction(context, s.opts...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed creating transaction")
+	}
+	memo, err := json.Marshal(&Settlement{ChannelID: s.id, SeqNumber: state.SeqNumber})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling settlement")
+	}
+	if err := tx.SetMemo(SettlementMemo, memo); err != nil {
+		return nil, errors.WithMessage(err, "failed binding settlement")
+	}
+	if dispute != nil {
+		// the balances are fixed by the dispute, the counterparty does not need to sign
+		err = tx.SettleFromMultisig(ttxcc.GetWallet(context, s.wallet), info.Deposit, values, owners)
+	} else {
+		err = tx.TransferFromMultisig(ttxcc.GetWallet(context, s.wallet), info.Deposit, values, owners)
+	}
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed adding settlement transfer")
+	}
+	// unless the dispute is closed, the counterparty is asked to sign while collecting endorsements
+	if _, err := context.RunView(ttxcc.NewCollectEndorsementsView(tx)); err != nil {
+		return nil, errors.WithMessage(err, "failed collecting endorsements")
+	}
+	if _, err := context.RunView(ttxcc.NewOrderingView(tx)); err != nil {
+		return nil, errors.WithMessage(err, "failed ordering transaction")
+	}
+	if err := ch.ch.Close(); err != nil {
+		return nil, err
+	}
+	return tx.ID(), nil
+}
+
+type settleChannelResponderView struct{}
+
+// NewSettleChannelResponderView returns the view run by the counterparty in response to NewSettleChannelView.
+// It endorses the transaction only if it pays out the deposit according to the current state of the channel,
+// or to the balances of the dispute if a dispute on the channel has been closed.
+// The view returns the transaction.
+func NewSettleChannelResponderView() *settleChannelResponderView {
+	return &settleChannelResponderView{}
+}
+
+func (s *settleChannelResponderView) Call(context view.Context) (interface{}, error) {
+	tx, err := ttxcc.ReceiveTransaction(context)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed receiving transaction")
+	}
+	ch, disputed, err := s.inspect(context, tx)
+	if err != nil {
+		return nil, err
+	}
+	if disputed {
+		// the payout of a closed dispute is signed by the initiator alone and distributed once complete
+		_, err = context.RunView(ttxcc.NewAcceptView(tx))
+	} else {
+		_, err = context.RunView(ttxcc.NewEndorseView(tx))
+	}
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed endorsing transaction")
+	}
+	if _, err := context.RunView(ttxcc.NewFinalityView(tx)); err != nil {
+		return nil, errors.WithMessagef(err, "transaction [%s] not committed", tx.ID())
+	}
+	if err := ch.ch.Close(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// inspect checks that the passed transaction spends the deposit of a channel to pay out the balances of its current state.
+// It returns true if the balances are those of a closed dispute.
+func (s *settleChannelResponderView) inspect(context view.Context, tx *ttxcc.Transaction) (*channel, bool, error) {
+	memos, err := tx.TokenRequest.Memos()
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "failed getting memos")
+	}
+	raw, ok := memos[SettlementMemo]
+	if !ok {
+		return nil, false, errors.Errorf("transaction [%s] does not settle a channel", tx.ID())
+	}
+	settlement := &Settlement{}
+	if err := json.Unmarshal(raw, settlement); err != nil {
+		return nil, false, errors.Wrap(err, "failed unmarshalling settlement")
+	}
+	ch, err := GetChannel(context, context.Me(), settlement.ChannelID)
+	if err != nil {
+		return nil, false, err
+	}
+	if ch.IsClosed() {
+		return nil, false, errors.Errorf("channel [%s] already closed", settlement.ChannelID)
+	}
+	info := ch.Info()
+	state, err := ch.State()
+	if err != nil {
+		return nil, false, err
+	}
+	balances := state.Balances
+	dispute := closedDispute(context, token.GetManagementService(context), settlement.ChannelID)
+	if dispute != nil {
+		state, balances = dispute.State, dispute.Balances()
+	} else if len(state.Locks) != 0 {
+		return nil, false, errors.Errorf("channel [%s] has pending locks", settlement.ChannelID)
+	}
+	if settlement.SeqNumber != state.SeqNumber {
+		return nil, false, errors.Errorf("settlement of state [%d], current state is [%d]", settlement.SeqNumber, state.SeqNumber)
+	}
+
+	inputs, err := tx.Inputs()
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "failed getting inputs")
+	}
+	if inputs.Count() != 1 || inputs.At(0).Id.TxId != info.Deposit.TxId || inputs.At(0).Id.Index != info.Deposit.Index {
+		return nil, false, errors.Errorf("settlement of channel [%s] must spend its deposit only", settlement.ChannelID)
+	}
+	outputs, err := tx.Outputs()
+	if err != nil {
+		return nil, false, errors.WithMessage(err, "failed getting outputs")
+	}
+	count := 0
+	for i, party := range state.Parties {
+		paid := outputs.ByRecipient(party)
+		count += paid.Count()
+		for typ, v := range balances[i] {
+			if paid.ByType(typ).Sum().Cmp(token2.NewQuantityFromUInt64(v)) != 0 {
+				return nil, false, errors.Errorf("settlement of channel [%s] does not pay [%d] of type [%s] to party [%d]", settlement.ChannelID, v, typ, i)
+			}
+		}
+	}
+	if count != outputs.Count() {
+		return nil, false, errors.Errorf("settlement of channel [%s] pays parties outside the channel", settlement.ChannelID)
+	}
+	return ch, dispute != nil, nil
+}
+
+// channelParties returns the co-owners of the passed owner if it is the joint identity of a channel
+func channelParties(owner view.Identity) ([]view.Identity, bool) {
+	if !multisig.IsMultisig(owner) {
+		return nil, false
+	}
+	id := &multisig.Identity{}
+	if err := id.Deserialize(owner); err != nil {
+		return nil, false
+	}
+	if id.Threshold != 2 || len(id.Identities) != 2 || multisig.IsMultisig(id.Identities[0]) || multisig.IsMultisig(id.Identities[1]) {
+		return nil, false
+	}
+	return id.Identities, true
+}
+
+// depositID returns the id of the output of the passed transaction owned by the passed identity
+func depositID(tx *ttxcc.Transaction, owner view.Identity) (*token2.Id, error) {
+	outputs, err := tx.Outputs()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting outputs")
+	}
+	for i := 0; i < outputs.Count(); i++ {
+		if outputs.At(i).Owner.Equal(owner) {
+			return &token2.Id{TxId: tx.ID(), Index: uint32(i)}, nil
+		}
+	}
+	return nil, errors.New("deposit output not found")
+}
+
+// registerCounterparty registers the identity of the opener of the channel using the audit info
+// of the joint identity, so that the settlement can pay it
+func registerCounterparty(sp view2.ServiceProvider, tms *token.ManagementService, info *api.ChannelInfo) error {
+	owner, err := (&multisig.Identity{Threshold: 2, Identities: info.Parties}).Serialize()
+	if err != nil {
+		return err
+	}
+	raw, err := view2.GetSigService(sp).GetAuditInfo(owner)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting audit info of channel [%s]", info.ID)
+	}
+	mi := &multisig.Info{}
+	if err := mi.Deserialize(raw); err != nil || len(mi.AuditInfos) != 2 {
+		return errors.Errorf("invalid audit info for channel [%s]", info.ID)
+	}
+	other := 1 - info.Me
+	if err := tms.WalletManager().RegisterRecipientIdentity(info.Parties[other], mi.AuditInfos[other], nil); err != nil {
+		return errors.WithMessagef(err, "failed registering counterparty of channel [%s]", info.ID)
+	}
+	return nil
+}
+
+func signState(tms *token.ManagementService, party view.Identity, state *api.State) ([]byte, error) {
+	w := tms.WalletManager().OwnerWalletByIdentity(party)
+	if w == nil {
+		return nil, errors.Errorf("no wallet holds party [%s]", party)
+	}
+	signer, err := w.GetSigner(party)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting signer for [%s]", party)
+	}
+	raw, err := state.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling state")
+	}
+	return signer.Sign(raw)
+}
+
+func verifyState(tms *token.ManagementService, party view.Identity, state *api.State, sigma []byte) error {
+	verifier, err := tms.SigService().GetVerifier(party)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting verifier for [%s]", party)
+	}
+	raw, err := state.Bytes()
+	if err != nil {
+		return errors.Wrap(err, "failed marshalling state")
+	}
+	return verifier.Verify(raw, sigma)
+}
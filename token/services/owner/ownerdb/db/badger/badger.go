@@ -0,0 +1,223 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package badger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/ownerdb/driver"
+	"github.com/pkg/errors"
+)
+
+const (
+	namespace          = "default"
+	namespaceSeparator = "\u0000"
+)
+
+type Record struct {
+	Id     uint64
+	Record *driver.TransactionRecord
+}
+
+type Persistence struct {
+	db *badger.DB
+
+	seq     *badger.Sequence
+	txn     *badger.Txn
+	txnLock sync.Mutex
+}
+
+func OpenDB(path string) (*Persistence, error) {
+	db, err := badger.Open(badger.DefaultOptions(path))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open DB at '%s'", path)
+	}
+	seq, err := db.GetSequence([]byte("idseq"), 1)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting sequence for DB at '%s'", path)
+	}
+
+	return &Persistence{db: db, seq: seq}, nil
+}
+
+func (db *Persistence) Close() error {
+	if err := db.seq.Release(); err != nil {
+		logger.Errorf("failed closing seq [%s]", err)
+	}
+
+	err := db.db.Close()
+	if err != nil {
+		return errors.Wrap(err, "could not close DB")
+	}
+
+	return nil
+}
+
+func (db *Persistence) BeginUpdate() error {
+	db.txnLock.Lock()
+	defer db.txnLock.Unlock()
+
+	if db.txn != nil {
+		return errors.New("previous commit in progress")
+	}
+
+	db.txn = db.db.NewTransaction(true)
+
+	return nil
+}
+
+func (db *Persistence) Commit() error {
+	db.txnLock.Lock()
+	defer db.txnLock.Unlock()
+
+	if db.txn == nil {
+		return errors.New("no commit in progress")
+	}
+
+	err := db.txn.Commit()
+	if err != nil {
+		return errors.Wrap(err, "could not commit transaction")
+	}
+
+	db.txn = nil
+
+	return nil
+}
+
+func (db *Persistence) Discard() error {
+	db.txnLock.Lock()
+	defer db.txnLock.Unlock()
+
+	if db.txn == nil {
+		return errors.New("no commit in progress")
+	}
+
+	db.txn.Discard()
+
+	db.txn = nil
+
+	return nil
+}
+
+func (db *Persistence) AddRecord(record *driver.TransactionRecord) error {
+	if db.txn == nil {
+		return errors.New("no commit in progress")
+	}
+
+	next, err := db.seq.Next()
+	if err != nil {
+		return errors.Wrapf(err, "failed getting next index")
+	}
+	return db.put(&Record{Id: next, Record: record})
+}
+
+func (db *Persistence) SetStatus(txID string, status driver.Status, at time.Time) error {
+	if db.txn == nil {
+		return errors.New("no commit in progress")
+	}
+
+	records, err := db.records(db.txn)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if record.Record.TxID != txID {
+			continue
+		}
+		driver.SetStatus(record.Record, status, at)
+		if err := db.put(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *Persistence) Query(params *driver.QueryParams, direction driver.Direction, value driver.Value, numRecords int) ([]*driver.TransactionRecord, error) {
+	txn := db.db.NewTransaction(false)
+	defer txn.Discard()
+
+	all, err := db.records(txn)
+	if err != nil {
+		return nil, err
+	}
+
+	var records RecordSlice
+	for _, record := range all {
+		if !params.Match(record.Record) || !driver.MatchValue(record.Record, value) {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	// Sort
+	switch direction {
+	case driver.FromBeginning:
+		sort.Sort(records)
+	case driver.FromLast:
+		sort.Sort(sort.Reverse(records))
+	}
+
+	if numRecords > 0 && len(records) > numRecords {
+		records = records[:numRecords]
+	}
+
+	var res []*driver.TransactionRecord
+	for _, record := range records {
+		res = append(res, record.Record)
+	}
+
+	return res, nil
+}
+
+func (db *Persistence) put(record *Record) error {
+	dbKey := dbKey(namespace, fmt.Sprintf("%d", record.Id))
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrapf(err, "could not marshal record for key %s", dbKey)
+	}
+	if err := db.txn.Set([]byte(dbKey), bytes); err != nil {
+		return errors.Wrapf(err, "could not set value for key %s", dbKey)
+	}
+	return nil
+}
+
+func (db *Persistence) records(txn *badger.Txn) ([]*Record, error) {
+	it := txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+
+	prefix := []byte(namespace + namespaceSeparator)
+	var records []*Record
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		item := it.Item()
+		record := &Record{}
+		err := item.Value(func(val []byte) error {
+			if err := json.Unmarshal(val, record); err != nil {
+				return errors.Wrapf(err, "could not unmarshal key %s", string(item.Key()))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get value for key %s", string(item.Key()))
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func dbKey(namespace, key string) string {
+	return namespace + namespaceSeparator + key
+}
+
+type RecordSlice []*Record
+
+func (p RecordSlice) Len() int           { return len(p) }
+func (p RecordSlice) Less(i, j int) bool { return p[i].Id < p[j].Id }
+func (p RecordSlice) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
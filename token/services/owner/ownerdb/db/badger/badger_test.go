@@ -0,0 +1,76 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package badger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/ownerdb/driver"
+)
+
+func TestDB(t *testing.T) {
+	dbpath := filepath.Join(tempDir, "DB-TestQueries")
+	db, err := OpenDB(dbpath)
+	assert.NoError(t, err)
+	assert.NotNil(t, db)
+	defer db.Close()
+
+	assert.NoError(t, db.BeginUpdate())
+	for i, amount := range []int64{10, -20, 30} {
+		err = db.AddRecord(&driver.TransactionRecord{
+			TxID:      fmt.Sprintf("%d", i),
+			Type:      "magic",
+			Amount:    big.NewInt(amount),
+			Status:    driver.Pending,
+			Timestamp: time.Now(),
+		})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, db.Commit())
+
+	records, err := db.Query(&driver.QueryParams{}, driver.FromLast, driver.Received, 1)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "2", records[0].TxID)
+
+	assert.NoError(t, db.BeginUpdate())
+	confirmedAt := time.Now().Add(time.Minute).UTC()
+	assert.NoError(t, db.SetStatus("1", driver.Confirmed, confirmedAt))
+	assert.NoError(t, db.Commit())
+
+	records, err = db.Query(&driver.QueryParams{Status: []driver.Status{driver.Confirmed}}, driver.FromBeginning, driver.All, 0)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "1", records[0].TxID)
+	assert.Equal(t, int64(-20), records[0].Amount.Int64())
+	assert.True(t, confirmedAt.Equal(records[0].ConfirmedAt))
+
+	records, err = db.Query(&driver.QueryParams{ConfirmedTo: confirmedAt.Add(-time.Second)}, driver.FromBeginning, driver.All, 0)
+	assert.NoError(t, err)
+	assert.Len(t, records, 0)
+}
+
+var tempDir string
+
+func TestMain(m *testing.M) {
+	var err error
+	tempDir, err = ioutil.TempDir("", "badger-fsc-test")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create temporary directory: %v", err)
+		os.Exit(-1)
+	}
+	defer os.RemoveAll(tempDir)
+
+	m.Run()
+}
@@ -0,0 +1,50 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package badger
+
+import (
+	"os"
+	"path/filepath"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/ownerdb"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/ownerdb/driver"
+	"github.com/pkg/errors"
+)
+
+var logger = flogging.MustGetLogger("token-sdk.owner.ownerdb.badger")
+
+type Opts struct {
+	Path string
+}
+
+type Driver struct {
+}
+
+func (d Driver) Open(sp view2.ServiceProvider, name string) (driver.OwnerDB, error) {
+	opts := &Opts{}
+	err := view2.GetConfigService(sp).UnmarshalKey("token.owner.ownerdb.persistence.opts", opts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting opts for owner db")
+	}
+	opts.Path = filepath.Join(opts.Path, name)
+	logger.Debugf("init owner db with badger at [%s]", opts.Path)
+
+	err = os.MkdirAll(opts.Path, 0755)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed creating folders for owner db [%s]", opts.Path)
+	}
+	persistence, err := OpenDB(opts.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed opening owner db [%s]", opts.Path)
+	}
+	return persistence, nil
+}
+
+func init() {
+	ownerdb.Register("badger", &Driver{})
+}
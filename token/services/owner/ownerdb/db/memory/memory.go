@@ -0,0 +1,89 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package memory
+
+import (
+	"sync"
+	"time"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/ownerdb"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/ownerdb/driver"
+)
+
+type Persistence struct {
+	lock    sync.RWMutex
+	records []*driver.TransactionRecord
+}
+
+func (p *Persistence) Query(params *driver.QueryParams, direction driver.Direction, value driver.Value, numRecords int) ([]*driver.TransactionRecord, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	var res []*driver.TransactionRecord
+	for i := range p.records {
+		record := p.records[i]
+		if direction == driver.FromLast {
+			record = p.records[len(p.records)-1-i]
+		}
+		if !params.Match(record) || !driver.MatchValue(record, value) {
+			continue
+		}
+		res = append(res, record)
+		if numRecords != 0 && len(res) == numRecords {
+			break
+		}
+	}
+	return res, nil
+}
+
+func (p *Persistence) AddRecord(record *driver.TransactionRecord) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.records = append(p.records, record)
+	return nil
+}
+
+func (p *Persistence) SetStatus(txID string, status driver.Status, at time.Time) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, record := range p.records {
+		if record.TxID == txID {
+			driver.SetStatus(record, status, at)
+		}
+	}
+	return nil
+}
+
+func (p *Persistence) Close() error {
+	return nil
+}
+
+func (p *Persistence) BeginUpdate() error {
+	return nil
+}
+
+func (p *Persistence) Commit() error {
+	return nil
+}
+
+func (p *Persistence) Discard() error {
+	return nil
+}
+
+type Driver struct {
+}
+
+func (d Driver) Open(sp view2.ServiceProvider, name string) (driver.OwnerDB, error) {
+	return &Persistence{}, nil
+}
+
+func init() {
+	ownerdb.Register("memory", &Driver{})
+}
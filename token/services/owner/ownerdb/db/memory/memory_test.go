@@ -0,0 +1,68 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package memory
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/ownerdb/driver"
+)
+
+func Test(t *testing.T) {
+	db := &Persistence{}
+	now := time.Now()
+	for i, amount := range []int64{10, 20, -5} {
+		err := db.AddRecord(&driver.TransactionRecord{
+			TxID:      string(rune('a' + i)),
+			Type:      "EUR",
+			Amount:    big.NewInt(amount),
+			Status:    driver.Pending,
+			Timestamp: now.Add(time.Duration(i) * time.Minute),
+		})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, db.SetStatus("a", driver.Confirmed, now.Add(5*time.Minute)))
+	assert.NoError(t, db.SetStatus("b", driver.Deleted, now.Add(5*time.Minute)))
+
+	records, err := db.Query(&driver.QueryParams{}, driver.FromBeginning, driver.All, 0)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "a", records[0].TxID)
+	records, err = db.Query(&driver.QueryParams{}, driver.FromLast, driver.All, 1)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "c", records[0].TxID)
+	records, err = db.Query(&driver.QueryParams{}, driver.FromBeginning, driver.Sent, 0)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	records, err = db.Query(&driver.QueryParams{Status: []driver.Status{driver.Deleted}}, driver.FromBeginning, driver.All, 0)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	records, err = db.Query(&driver.QueryParams{Types: []string{"USD"}}, driver.FromBeginning, driver.All, 0)
+	assert.NoError(t, err)
+	assert.Len(t, records, 0)
+	records, err = db.Query(&driver.QueryParams{To: now.Add(30 * time.Second)}, driver.FromBeginning, driver.All, 0)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	records, err = db.Query(&driver.QueryParams{TxIDs: []string{"c"}}, driver.FromBeginning, driver.All, 0)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	// records are selected by confirmation time, not by the time they were recorded
+	records, err = db.Query(&driver.QueryParams{ConfirmedTo: now.Add(4 * time.Minute)}, driver.FromBeginning, driver.All, 0)
+	assert.NoError(t, err)
+	assert.Len(t, records, 0)
+	records, err = db.Query(&driver.QueryParams{ConfirmedTo: now.Add(5 * time.Minute)}, driver.FromBeginning, driver.All, 0)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "a", records[0].TxID)
+	assert.NoError(t, db.SetStatus("a", driver.Confirmed, now.Add(10*time.Minute)))
+	assert.Equal(t, now.Add(5*time.Minute), records[0].ConfirmedAt)
+}
@@ -0,0 +1,147 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package driver
+
+import (
+	"math/big"
+	"time"
+
+	view "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+)
+
+type Direction int
+
+const (
+	FromLast Direction = iota
+	FromBeginning
+)
+
+type Value int
+
+const (
+	Sent Value = iota
+	Received
+	All
+)
+
+type Status string
+
+const (
+	Pending   Status = "Pending"
+	Confirmed Status = "Confirmed"
+	Deleted   Status = "Deleted"
+)
+
+// TransactionRecord is the movement of tokens of a given type caused by a transaction to an owner wallet
+type TransactionRecord struct {
+	TxID string
+	Type string
+	// Positive is money received. Negative is money sent
+	Amount *big.Int
+	// Counterparties are the owners of the inputs and outputs of the transaction not belonging to the wallet
+	Counterparties []view2.Identity
+	// Memos are the application memos of the transaction and of the outputs received by the wallet
+	Memos  map[string][]byte `json:",omitempty"`
+	Status Status
+	// Timestamp is when the transaction was recorded
+	Timestamp time.Time
+	// ConfirmedAt is when the transaction was confirmed, zero if it is not
+	ConfirmedAt time.Time `json:",omitempty"`
+}
+
+// QueryParams selects the records returned by a query. Empty fields select all the records.
+type QueryParams struct {
+	TxIDs  []string
+	Types  []string
+	Status []Status
+	// From and To bound the timestamp of the records, both included
+	From time.Time
+	To   time.Time
+	// ConfirmedTo selects the records confirmed at or before it
+	ConfirmedTo time.Time
+}
+
+// Match returns true if the passed record is selected by these parameters.
+// Deleted records are selected only if explicitly requested.
+func (p *QueryParams) Match(record *TransactionRecord) bool {
+	if len(p.TxIDs) != 0 && !contains(p.TxIDs, record.TxID) {
+		return false
+	}
+	if len(p.Types) != 0 && !contains(p.Types, record.Type) {
+		return false
+	}
+	if len(p.Status) != 0 {
+		found := false
+		for _, st := range p.Status {
+			if record.Status == st {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	} else if record.Status == Deleted {
+		return false
+	}
+	if !p.From.IsZero() && record.Timestamp.Before(p.From) {
+		return false
+	}
+	if !p.To.IsZero() && record.Timestamp.After(p.To) {
+		return false
+	}
+	if !p.ConfirmedTo.IsZero() && (record.ConfirmedAt.IsZero() || record.ConfirmedAt.After(p.ConfirmedTo)) {
+		return false
+	}
+	return true
+}
+
+// MatchValue returns true if the passed record moves tokens in the direction selected by value
+func MatchValue(record *TransactionRecord, value Value) bool {
+	switch value {
+	case Sent:
+		return record.Amount.Sign() < 0
+	case Received:
+		return record.Amount.Sign() > 0
+	default:
+		return true
+	}
+}
+
+// SetStatus sets the passed status on the passed record, stamping the confirmation time
+func SetStatus(record *TransactionRecord, status Status, at time.Time) {
+	if status == Confirmed && record.Status != Confirmed {
+		record.ConfirmedAt = at
+	} else if status != Confirmed {
+		record.ConfirmedAt = time.Time{}
+	}
+	record.Status = status
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+type OwnerDB interface {
+	Close() error
+	BeginUpdate() error
+	Commit() error
+	Discard() error
+	AddRecord(record *TransactionRecord) error
+	// SetStatus sets the status of the records of the passed transaction, changed at the passed time
+	SetStatus(txID string, status Status, at time.Time) error
+	Query(params *QueryParams, direction Direction, value Value, numRecords int) ([]*TransactionRecord, error)
+}
+
+type Driver interface {
+	Open(sp view.ServiceProvider, name string) (OwnerDB, error)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ownerdb
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/ownerdb/driver"
+)
+
+// TransactionsFilter selects the records of an owner db
+type TransactionsFilter struct {
+	db *OwnerDB
+
+	params         driver.QueryParams
+	value          driver.Value
+	LastNumRecords int
+
+	records []*TransactionRecord
+}
+
+func (f *TransactionsFilter) ByTxID(txID string) *TransactionsFilter {
+	f.params.TxIDs = append(f.params.TxIDs, txID)
+	return f
+}
+
+func (f *TransactionsFilter) ByType(tokenType string) *TransactionsFilter {
+	f.params.Types = append(f.params.Types, tokenType)
+	return f
+}
+
+func (f *TransactionsFilter) ByStatus(status Status) *TransactionsFilter {
+	f.params.Status = append(f.params.Status, status)
+	return f
+}
+
+// Sent selects the records of tokens sent
+func (f *TransactionsFilter) Sent() *TransactionsFilter {
+	f.value = driver.Sent
+	return f
+}
+
+// Received selects the records of tokens received
+func (f *TransactionsFilter) Received() *TransactionsFilter {
+	f.value = driver.Received
+	return f
+}
+
+// After selects the records stored at or after the passed time
+func (f *TransactionsFilter) After(t time.Time) *TransactionsFilter {
+	f.params.From = t
+	return f
+}
+
+// Before selects the records stored at or before the passed time
+func (f *TransactionsFilter) Before(t time.Time) *TransactionsFilter {
+	f.params.To = t
+	return f
+}
+
+// ConfirmedBefore selects the records confirmed at or before the passed time
+func (f *TransactionsFilter) ConfirmedBefore(t time.Time) *TransactionsFilter {
+	f.params.ConfirmedTo = t
+	return f
+}
+
+// Last selects the most recent num records
+func (f *TransactionsFilter) Last(num int) *TransactionsFilter {
+	f.LastNumRecords = num
+	return f
+}
+
+func (f *TransactionsFilter) Execute() (*TransactionsFilter, error) {
+	records, err := f.db.db.Query(&f.params, driver.FromLast, f.value, f.LastNumRecords)
+	if err != nil {
+		return nil, err
+	}
+	f.records = records
+	return f, nil
+}
+
+// Records returns the selected records, the most recent first
+func (f *TransactionsFilter) Records() []*TransactionRecord {
+	return f.records
+}
+
+// Sum returns the sum of the amounts of the selected records
+func (f *TransactionsFilter) Sum() *big.Int {
+	sum := big.NewInt(0)
+	for _, record := range f.records {
+		sum = sum.Add(sum, record.Amount)
+	}
+	return sum
+}
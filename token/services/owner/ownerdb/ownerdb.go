@@ -0,0 +1,237 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ownerdb
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/ownerdb/driver"
+	"github.com/pkg/errors"
+	"go.uber.org/atomic"
+)
+
+var logger = flogging.MustGetLogger("token-sdk.owner.ownerdb")
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]driver.Driver)
+)
+
+// Register makes a OwnerDB driver available by the provided name.
+// If Register is called twice with the same name or if driver is nil,
+// it panics.
+func Register(name string, driver driver.Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	if driver == nil {
+		panic("owner: Register driver is nil")
+	}
+	if _, dup := drivers[name]; dup {
+		panic("owner: Register called twice for driver " + name)
+	}
+	drivers[name] = driver
+}
+
+// Drivers returns a sorted list of the names of the registered drivers.
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	list := make([]string, 0, len(drivers))
+	for name := range drivers {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// TransactionRecord is the movement of tokens of a given type caused by a transaction to an owner wallet
+type TransactionRecord = driver.TransactionRecord
+
+type Status = driver.Status
+
+const (
+	Pending   = driver.Pending
+	Confirmed = driver.Confirmed
+	Deleted   = driver.Deleted
+)
+
+type QueryExecutor struct {
+	db     *OwnerDB
+	closed bool
+}
+
+func (qe *QueryExecutor) NewTransactionsFilter() *TransactionsFilter {
+	return &TransactionsFilter{
+		db:    qe.db,
+		value: driver.All,
+	}
+}
+
+// BalanceAt returns the balance of the passed token type as it was at the passed time,
+// reconstructed from the transactions confirmed until then
+func (qe *QueryExecutor) BalanceAt(tokenType string, at time.Time) (*big.Int, error) {
+	filter, err := qe.NewTransactionsFilter().ByType(tokenType).ByStatus(Confirmed).ConfirmedBefore(at).Execute()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed querying transactions of type [%s]", tokenType)
+	}
+	return filter.Sum(), nil
+}
+
+func (qe *QueryExecutor) Done() {
+	if qe.closed {
+		return
+	}
+	qe.db.counter.Dec()
+	qe.db.storeLock.RUnlock()
+	qe.closed = true
+}
+
+// OwnerDB records the transactions an owner wallet took part in
+type OwnerDB struct {
+	counter atomic.Int32
+
+	db        driver.OwnerDB
+	storeLock sync.RWMutex
+}
+
+func newOwnerDB(p driver.OwnerDB) *OwnerDB {
+	return &OwnerDB{db: p}
+}
+
+// Append stores the passed records of the transaction with the passed id as pending.
+// If records of the transaction are already stored, nothing is done.
+func (db *OwnerDB) Append(txID string, records []*TransactionRecord) error {
+	logger.Debugf("Appending new records for [%s]... [%d]", txID, db.counter)
+	db.storeLock.Lock()
+	defer db.storeLock.Unlock()
+	logger.Debug("lock acquired")
+
+	existing, err := db.db.Query(&driver.QueryParams{
+		TxIDs:  []string{txID},
+		Status: []driver.Status{Pending, Confirmed, Deleted},
+	}, driver.FromBeginning, driver.All, 1)
+	if err != nil {
+		return errors.WithMessagef(err, "failed looking up records for txid '%s'", txID)
+	}
+	if len(existing) != 0 {
+		logger.Debugf("records for [%s] already stored", txID)
+		return nil
+	}
+
+	if err := db.db.BeginUpdate(); err != nil {
+		return errors.WithMessagef(err, "begin update for txid '%s' failed", txID)
+	}
+
+	now := time.Now()
+	for _, record := range records {
+		record.TxID = txID
+		record.Status = Pending
+		record.Timestamp = now
+		if err := db.db.AddRecord(record); err != nil {
+			if err1 := db.db.Discard(); err1 != nil {
+				logger.Errorf("got error %s; discarding caused %s", err.Error(), err1.Error())
+			}
+			return err
+		}
+	}
+
+	if err := db.db.Commit(); err != nil {
+		return errors.WithMessagef(err, "committing tx for txid '%s' failed", txID)
+	}
+
+	logger.Debugf("Appending new records completed without errors")
+	return nil
+}
+
+func (db *OwnerDB) NewQueryExecutor() *QueryExecutor {
+	db.counter.Inc()
+	db.storeLock.RLock()
+
+	return &QueryExecutor{db: db}
+}
+
+// SetStatus sets the status of the transaction with the passed id, a confirmed transaction is stamped with the current time
+func (db *OwnerDB) SetStatus(txID string, status Status) error {
+	logger.Debugf("Set status [%s][%s]...[%d]", txID, status, db.counter)
+	db.storeLock.Lock()
+	defer db.storeLock.Unlock()
+	logger.Debug("lock acquired")
+
+	if err := db.db.BeginUpdate(); err != nil {
+		return errors.WithMessagef(err, "begin update for txid '%s' failed", txID)
+	}
+
+	if err := db.db.SetStatus(txID, status, time.Now()); err != nil {
+		if err1 := db.db.Discard(); err1 != nil {
+			logger.Errorf("got error %s; discarding caused %s", err.Error(), err1.Error())
+		}
+		return errors.Wrapf(err, "failed setting status [%s][%s]", txID, status)
+	}
+
+	if err := db.db.Commit(); err != nil {
+		return errors.WithMessagef(err, "committing tx for txid '%s' failed", txID)
+	}
+
+	logger.Debugf("Set status [%s][%s]...[%d] done without errors", txID, status, db.counter)
+	return nil
+}
+
+type Manager struct {
+	sp      view2.ServiceProvider
+	driver  string
+	mutex   sync.Mutex
+	ownerDB map[string]*OwnerDB
+}
+
+func NewManager(sp view2.ServiceProvider, driver string) *Manager {
+	return &Manager{
+		sp:      sp,
+		driver:  driver,
+		ownerDB: map[string]*OwnerDB{},
+	}
+}
+
+// OwnerDB returns the owner db of the passed wallet, opening it if needed
+func (cm *Manager) OwnerDB(w *token.OwnerWallet) (*OwnerDB, error) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	id := w.ID()
+	c, ok := cm.ownerDB[id]
+	if !ok {
+		driversMu.RLock()
+		d, ok := drivers[cm.driver]
+		driversMu.RUnlock()
+		if !ok {
+			return nil, errors.Errorf("owner db driver [%s] not found", cm.driver)
+		}
+		db, err := d.Open(cm.sp, id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed instantiating owner db driver")
+		}
+		c = newOwnerDB(db)
+		cm.ownerDB[id] = c
+	}
+	return c, nil
+}
+
+func GetOwnerDB(sp view2.ServiceProvider, w *token.OwnerWallet) *OwnerDB {
+	s, err := sp.GetService(&Manager{})
+	if err != nil {
+		panic(err)
+	}
+	c, err := s.(*Manager).OwnerDB(w)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
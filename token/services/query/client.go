@@ -59,3 +59,54 @@ func (c *viewClient) AllMyBalances() ([]Balance, error) {
 	}
 	return bal.Balances, err
 }
+
+// TMSBalances returns the balances of the passed wallet in each configured token management service
+func (c *viewClient) TMSBalances(wallet string) ([]*TMSBalance, error) {
+	res := &TMSBalances{}
+	if err := c.call("zkat.tms.balance.query", &TMSBalancesQuery{Wallet: wallet}, res); err != nil {
+		return nil, err
+	}
+	return res.TMSs, nil
+}
+
+// Tokens returns the page of unspent tokens selected by the passed query
+func (c *viewClient) Tokens(query *TokensQuery) (*TokensPage, error) {
+	res := &TokensPage{}
+	if err := c.call("zkat.tokens.query", query, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// IssuedTokens returns the page of issued, or redeemed, tokens selected by the passed query
+func (c *viewClient) IssuedTokens(query *IssuedTokensQuery) (*IssuedTokensPage, error) {
+	res := &IssuedTokensPage{}
+	if err := c.call("zkat.issued.query", query, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Transactions returns the page of transactions selected by the passed query
+func (c *viewClient) Transactions(query *TransactionsQuery) (*TransactionsPage, error) {
+	res := &TransactionsPage{}
+	if err := c.call("zkat.transactions.query", query, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *viewClient) call(fid string, query interface{}, res interface{}) error {
+	raw, err := c.vClient.CallView(fid, common.JSONMarshall(query))
+	if err != nil {
+		return err
+	}
+	payload, ok := raw.([]byte)
+	if !ok {
+		return errors.Errorf("expected []byte from [%s], got [%T]", fid, raw)
+	}
+	if err := json.Unmarshal(payload, res); err != nil {
+		return errors.Wrapf(err, "failed unmarshalling result of [%s]", fid)
+	}
+	return nil
+}
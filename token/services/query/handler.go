@@ -7,9 +7,16 @@ package query
 
 import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/flogging"
 )
 
+var logger = flogging.MustGetLogger("token-sdk.query")
+
 func InstallQueryViewFactories(sp view.ServiceProvider) {
 	view.GetRegistry(sp).RegisterFactory("zkat.balance.query", &BalanceViewFactory{})
 	view.GetRegistry(sp).RegisterFactory("zkat.all.balance.query", &AllMyBalanceViewFactory{})
+	view.GetRegistry(sp).RegisterFactory("zkat.tms.balance.query", &TMSBalancesViewFactory{})
+	view.GetRegistry(sp).RegisterFactory("zkat.tokens.query", &TokensViewFactory{})
+	view.GetRegistry(sp).RegisterFactory("zkat.issued.query", &IssuedTokensViewFactory{})
+	view.GetRegistry(sp).RegisterFactory("zkat.transactions.query", &TransactionsViewFactory{})
 }
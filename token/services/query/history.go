@@ -0,0 +1,161 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/ownerdb"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// IssuedTokensQuery lists the tokens issued by, and redeemed back to, an issuer wallet, sorted by id
+type IssuedTokensQuery struct {
+	TMSQuery
+	Page
+	Wallet string
+	// Type, if not empty, selects the tokens of the given type only
+	Type string
+	// Redeemed selects the tokens redeemed back to the issuer only
+	Redeemed bool
+}
+
+// IssuedTokensPage is a page of the result of an IssuedTokensQuery. Total is the number of tokens matching the query.
+type IssuedTokensPage struct {
+	Tokens []*token2.IssuedToken
+	Total  int
+}
+
+type IssuedTokensView struct {
+	*IssuedTokensQuery
+}
+
+func (i *IssuedTokensView) Call(context view.Context) (interface{}, error) {
+	wallet := i.tms(context).WalletManager().IssuerWallet(i.Wallet)
+	if wallet == nil {
+		return nil, fmt.Errorf("issuer wallet %s not found", i.Wallet)
+	}
+	var opts []token.ListTokensOption
+	if len(i.Type) != 0 {
+		opts = append(opts, token.WithType(i.Type))
+	}
+	var history *token2.IssuedTokens
+	var err error
+	if i.Redeemed {
+		history, err = wallet.RedeemedTokens(opts...)
+	} else {
+		history, err = wallet.HistoryTokens(opts...)
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed loading history of issuer wallet [%s]", i.Wallet)
+	}
+	tokens := history.Tokens
+	sort.Slice(tokens, func(a, b int) bool {
+		return lessID(tokens[a].Id, tokens[b].Id)
+	})
+	start, end, err := i.bounds(len(tokens))
+	if err != nil {
+		return nil, err
+	}
+	return &IssuedTokensPage{Tokens: tokens[start:end], Total: len(tokens)}, nil
+}
+
+type IssuedTokensViewFactory struct{}
+
+func (g *IssuedTokensViewFactory) NewView(in []byte) (view.View, error) {
+	f := &IssuedTokensView{IssuedTokensQuery: &IssuedTokensQuery{}}
+	if err := json.Unmarshal(in, f.IssuedTokensQuery); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// TransactionsQuery lists the transactions an owner wallet took part in, the most recent first.
+// Empty fields select all the transactions.
+type TransactionsQuery struct {
+	TMSQuery
+	Page
+	Wallet string
+	Type   string
+	Status []ownerdb.Status
+	// Sent and Received select the transactions that moved tokens out of or into the wallet, at most one can be set
+	Sent     bool
+	Received bool
+	// After and Before bound the time the transactions were recorded at, both included
+	After  time.Time
+	Before time.Time
+}
+
+// TransactionsPage is a page of the result of a TransactionsQuery. Total is the number of transactions matching the query.
+type TransactionsPage struct {
+	Records []*ownerdb.TransactionRecord
+	Total   int
+}
+
+type TransactionsView struct {
+	*TransactionsQuery
+}
+
+func (t *TransactionsView) Call(context view.Context) (interface{}, error) {
+	if t.Sent && t.Received {
+		return nil, errors.New("sent and received are mutually exclusive")
+	}
+	wallet := t.tms(context).WalletManager().OwnerWallet(t.Wallet)
+	if wallet == nil {
+		return nil, fmt.Errorf("wallet %s not found", t.Wallet)
+	}
+	s, err := context.GetService(&ownerdb.Manager{})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting owner db manager")
+	}
+	db, err := s.(*ownerdb.Manager).OwnerDB(wallet)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting owner db of wallet [%s]", t.Wallet)
+	}
+
+	qe := db.NewQueryExecutor()
+	defer qe.Done()
+	filter := qe.NewTransactionsFilter().After(t.After).Before(t.Before)
+	if len(t.Type) != 0 {
+		filter = filter.ByType(t.Type)
+	}
+	for _, status := range t.Status {
+		filter = filter.ByStatus(status)
+	}
+	switch {
+	case t.Sent:
+		filter = filter.Sent()
+	case t.Received:
+		filter = filter.Received()
+	}
+	filter, err = filter.Execute()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed querying transactions of wallet [%s]", t.Wallet)
+	}
+	records := filter.Records()
+	start, end, err := t.bounds(len(records))
+	if err != nil {
+		return nil, err
+	}
+	return &TransactionsPage{Records: records[start:end], Total: len(records)}, nil
+}
+
+type TransactionsViewFactory struct{}
+
+func (g *TransactionsViewFactory) NewView(in []byte) (view.View, error) {
+	f := &TransactionsView{TransactionsQuery: &TransactionsQuery{}}
+	if err := json.Unmarshal(in, f.TransactionsQuery); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,169 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/config"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// TMSQuery selects the token management service a query runs against.
+// Empty fields select the default values.
+type TMSQuery struct {
+	Network   string
+	Channel   string
+	Namespace string
+}
+
+func (q *TMSQuery) tms(sp view2.ServiceProvider) *token.ManagementService {
+	return token.GetManagementService(sp, token.WithNetwork(q.Network), token.WithChannel(q.Channel), token.WithNamespace(q.Namespace))
+}
+
+// Page selects a page of the results of a query. A zero Limit selects all the results from Offset on.
+type Page struct {
+	Offset int
+	Limit  int
+}
+
+// bounds returns the indexes, in a result set of the passed size, delimiting the page
+func (p *Page) bounds(total int) (int, int, error) {
+	if p.Offset < 0 || p.Limit < 0 {
+		return 0, 0, errors.Errorf("invalid page [%d,%d]", p.Offset, p.Limit)
+	}
+	start := p.Offset
+	if start > total {
+		start = total
+	}
+	end := total
+	if p.Limit != 0 && start+p.Limit < total {
+		end = start + p.Limit
+	}
+	return start, end, nil
+}
+
+// TokensQuery lists the unspent tokens of a wallet, in vault order
+type TokensQuery struct {
+	TMSQuery
+	Page
+	Wallet string
+	// Type, if not empty, selects the tokens of the given type only
+	Type string
+}
+
+// TokensPage is a page of the result of a TokensQuery. More tells if there are tokens after the page, to be
+// requested with the next Offset.
+type TokensPage struct {
+	Tokens []*token2.UnspentToken
+	More   bool
+}
+
+type TokensView struct {
+	*TokensQuery
+}
+
+func (t *TokensView) Call(context view.Context) (interface{}, error) {
+	wallet := t.tms(context).WalletManager().OwnerWallet(t.Wallet)
+	if wallet == nil {
+		return nil, fmt.Errorf("wallet %s not found", t.Wallet)
+	}
+	// the vault is scanned only up to the end of the page
+	tokens, more, err := t.tms(context).Vault().NewQueryEngine().UnspentTokensPage(func(tok *token2.UnspentToken) bool {
+		return (len(t.Type) == 0 || tok.Type == t.Type) && wallet.Contains(tok.Owner.Raw)
+	}, t.Offset, t.Limit)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed listing tokens of wallet [%s]", t.Wallet)
+	}
+	return &TokensPage{Tokens: tokens.Tokens, More: more}, nil
+}
+
+type TokensViewFactory struct{}
+
+func (g *TokensViewFactory) NewView(in []byte) (view.View, error) {
+	f := &TokensView{TokensQuery: &TokensQuery{}}
+	if err := json.Unmarshal(in, f.TokensQuery); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// TMSBalancesQuery returns the balances of a wallet in each configured token management service
+type TMSBalancesQuery struct {
+	Wallet string
+}
+
+// TMSBalance holds the balances of a wallet in a token management service
+type TMSBalance struct {
+	Network   string
+	Channel   string
+	Namespace string
+	Balances  []Balance
+}
+
+type TMSBalances struct {
+	TMSs []*TMSBalance
+}
+
+type TMSBalancesView struct {
+	*TMSBalancesQuery
+}
+
+func (t *TMSBalancesView) Call(context view.Context) (interface{}, error) {
+	var tmsConfigs []*config.TMS
+	if err := view2.GetConfigService(context).UnmarshalKey("token.tms", &tmsConfigs); err != nil {
+		return nil, errors.WithMessagef(err, "cannot load token-sdk configuration")
+	}
+	if len(tmsConfigs) == 0 {
+		tmsConfigs = []*config.TMS{{}}
+	}
+	res := &TMSBalances{}
+	for _, tmsConfig := range tmsConfigs {
+		tms := (&TMSQuery{Network: tmsConfig.Network, Channel: tmsConfig.Channel, Namespace: tmsConfig.Namespace}).tms(context)
+		wallet := tms.WalletManager().OwnerWallet(t.Wallet)
+		if wallet == nil {
+			logger.Debugf("wallet [%s] not found in [%s:%s:%s], skipping", t.Wallet, tms.Network(), tms.Channel(), tms.Namespace())
+			continue
+		}
+		balances, err := walletBalances(wallet)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed computing balances in [%s:%s:%s]", tms.Network(), tms.Channel(), tms.Namespace())
+		}
+		res.TMSs = append(res.TMSs, &TMSBalance{
+			Network:   tms.Network(),
+			Channel:   tms.Channel(),
+			Namespace: tms.Namespace(),
+			Balances:  balances,
+		})
+	}
+	return res, nil
+}
+
+type TMSBalancesViewFactory struct{}
+
+func (g *TMSBalancesViewFactory) NewView(in []byte) (view.View, error) {
+	f := &TMSBalancesView{TMSBalancesQuery: &TMSBalancesQuery{}}
+	if err := json.Unmarshal(in, f.TMSBalancesQuery); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func lessID(a, b *token2.Id) bool {
+	if a == nil || b == nil {
+		return a == nil && b != nil
+	}
+	if a.TxId != b.TxId {
+		return a.TxId < b.TxId
+	}
+	return a.Index < b.Index
+}
@@ -0,0 +1,43 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageBounds(t *testing.T) {
+	for _, c := range []struct {
+		page       Page
+		start, end int
+	}{
+		{Page{}, 0, 10},
+		{Page{Offset: 0, Limit: 3}, 0, 3},
+		{Page{Offset: 3, Limit: 3}, 3, 6},
+		{Page{Offset: 8, Limit: 3}, 8, 10},
+		{Page{Offset: 4}, 4, 10},
+		// past the end
+		{Page{Offset: 10, Limit: 3}, 10, 10},
+		{Page{Offset: 20, Limit: 3}, 10, 10},
+	} {
+		start, end, err := c.page.bounds(10)
+		assert.NoError(t, err)
+		assert.Equal(t, c.start, start, "page %v", c.page)
+		assert.Equal(t, c.end, end, "page %v", c.page)
+	}
+
+	start, end, err := (&Page{Offset: 2, Limit: 3}).bounds(0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, start)
+	assert.Equal(t, 0, end)
+
+	_, _, err = (&Page{Offset: -1}).bounds(10)
+	assert.EqualError(t, err, "invalid page [-1,0]")
+	_, _, err = (&Page{Limit: -1}).bounds(10)
+	assert.EqualError(t, err, "invalid page [0,-1]")
+}
@@ -8,6 +8,7 @@ package query
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
 
@@ -37,18 +38,10 @@ func (b *BalanceView) Call(context view.Context) (interface{}, error) {
 	if wallet == nil {
 		return nil, fmt.Errorf("wallet %s not found", b.Wallet)
 	}
-	unspentTokens, err := wallet.ListTokens(token.WithType(b.Type))
+	sum, err := wallet.Balance(b.Type)
 	if err != nil {
 		return nil, err
 	}
-	sum := token2.NewZeroQuantity(keys.Precision)
-	for _, tok := range unspentTokens.Tokens {
-		q, err := token2.ToQuantity(tok.Quantity, keys.Precision)
-		if err != nil {
-			return nil, err
-		}
-		sum = sum.Add(q)
-	}
 	return Balance{Quantity: sum.Decimal(), Type: b.Type}, nil
 }
 
@@ -76,17 +69,25 @@ type AllMyBalanceView struct {
 }
 
 func (b *AllMyBalanceView) Call(context view.Context) (interface{}, error) {
-	balances := make(map[string]token2.Quantity)
 	wallet := token.GetManagementService(context, token.WithChannel(b.Channel)).WalletManager().OwnerWallet(b.Wallet)
 	if wallet == nil {
 		return nil, fmt.Errorf("wallet %s not found", b.Wallet)
 	}
+	balances, err := walletBalances(wallet)
+	if err != nil {
+		return nil, err
+	}
+	return AllMyBalances{balances}, nil
+}
+
+// walletBalances returns the balances of the passed wallet, one per token type, sorted by type
+func walletBalances(wallet *token.OwnerWallet) ([]Balance, error) {
 	unspentTokens, err := wallet.ListTokens()
 	if err != nil {
 		return nil, err
 	}
+	balances := make(map[string]token2.Quantity)
 	for _, tok := range unspentTokens.Tokens {
-		fmt.Printf("\n am I here? \n")
 		_, exists := balances[tok.Type]
 		if !exists {
 			balances[tok.Type] = token2.NewZeroQuantity(keys.Precision)
@@ -101,7 +102,10 @@ func (b *AllMyBalanceView) Call(context view.Context) (interface{}, error) {
 	for k := range balances {
 		mybalance = append(mybalance, Balance{Type: k, Quantity: balances[k].Decimal()})
 	}
-	return AllMyBalances{mybalance}, nil
+	sort.Slice(mybalance, func(i, j int) bool {
+		return mybalance[i].Type < mybalance[j].Type
+	})
+	return mybalance, nil
 }
 
 type AllMyBalanceViewFactory struct{}
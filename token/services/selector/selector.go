@@ -93,6 +93,7 @@ func (s *selector) Select(ownerFilter token.OwnerFilter, q, tokenType string) ([
 		potentialSumWithNonCertified = token2.NewZeroQuantity(s.precision)
 		toBeSpent = nil
 		var toBeCertified []*token2.Id
+		var toBeCertifiedQuantities []token2.Quantity
 		var locked []*token2.Id
 
 		for _, t := range unspentTokens.Tokens {
@@ -128,6 +129,7 @@ func (s *selector) Select(ownerFilter token.OwnerFilter, q, tokenType string) ([
 			// check certification, if needed
 			if s.certClient != nil && !s.certClient.IsCertified(t.Id) {
 				toBeCertified = append(toBeCertified, t.Id)
+				toBeCertifiedQuantities = append(toBeCertifiedQuantities, q)
 				potentialSumWithNonCertified = potentialSumWithNonCertified.Add(q)
 
 				logger.Debugf("token [%s,%s,%v] is not certified, skipping", q, tokenType, ownerFilter.Contains(t.Owner.Raw))
@@ -162,18 +164,23 @@ func (s *selector) Select(ownerFilter token.OwnerFilter, q, tokenType string) ([
 		if !concurrencyIssue && target.Cmp(potentialSumWithNonCertified) <= 0 && s.requestCertification {
 			logger.Warnf("token selection failed: missing certifications, request them for [%s]", toBeCertified)
 			// request certification
-			err := s.certClient.RequestCertification(toBeCertified...)
-			if err == nil {
-				// TODO: refine this
-				ids := append(toBeSpent, toBeCertified...)
+			if err := s.certClient.RequestCertification(toBeCertified...); err != nil {
+				logger.Warnf("token selection: failed requesting token certification for [%v]: [%s]", toBeCertified, err)
+			}
+			// the request might have been satisfied only partially,
+			// only the tokens that are now certified can be spent
+			certified, notCertified, certifiedSum := s.certified(toBeCertified, toBeCertifiedQuantities)
+			if total := sum.Add(certifiedSum); target.Cmp(total) <= 0 {
+				ids := append(toBeSpent, certified...)
 				err := s.concurrencyCheck(ids)
 				if err == nil {
-					return ids, potentialSumWithNonCertified, nil
+					s.locker.UnlockIDs(notCertified...)
+					return ids, total, nil
 				}
 				concurrencyIssue = true
 				logger.Errorf("concurrency issue, some of the tokens might not exist anymore [%s]", err)
 			} else {
-				logger.Warnf("token selection failed: failed requesting token certification for [%v]: [%s]", toBeCertified, err)
+				logger.Warnf("token selection failed: not enough certified tokens after requesting certification for [%v]", toBeCertified)
 			}
 		}
 
@@ -232,6 +239,21 @@ func (s *selector) Select(ownerFilter token.OwnerFilter, q, tokenType string) ([
 	}
 }
 
+// certified splits the passed tokens in certified and not certified, and returns the total quantity of the certified ones
+func (s *selector) certified(ids []*token2.Id, quantities []token2.Quantity) ([]*token2.Id, []*token2.Id, token2.Quantity) {
+	var certified, notCertified []*token2.Id
+	sum := token2.NewZeroQuantity(s.precision)
+	for i, id := range ids {
+		if !s.certClient.IsCertified(id) {
+			notCertified = append(notCertified, id)
+			continue
+		}
+		certified = append(certified, id)
+		sum = sum.Add(quantities[i])
+	}
+	return certified, notCertified, sum
+}
+
 func (s *selector) concurrencyCheck(ids []*token2.Id) error {
 	_, err := s.queryService.GetTokens(ids...)
 	return err
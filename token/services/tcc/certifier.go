@@ -37,8 +37,8 @@ func (r *RegisterCertifierView) Call(context view.Context) (interface{}, error)
 		token.WithChannel(r.Channel),
 		token.WithNamespace(r.Namespace),
 	)
-	if !tms.PublicParametersManager().GraphHiding() {
-		logger.Warnf("the token management system for [%s:%s] does not support graph hiding, skipping certifier registration at the token chaincode", r.Channel, r.Namespace)
+	if !tms.PublicParametersManager().GraphHiding() && !tms.PublicParametersManager().TokenDataHiding() {
+		logger.Warnf("the token management system for [%s:%s] does not support graph or token data hiding, skipping certifier registration at the token chaincode", r.Channel, r.Namespace)
 		return nil, nil
 	}
 
@@ -114,3 +114,41 @@ func (r *GetTokenView) Call(context view.Context) (interface{}, error) {
 	}
 	return tokens, nil
 }
+
+type RevokeCertificationsView struct {
+	Network   string
+	Channel   string
+	Namespace string
+	IDs       []*token2.Id
+}
+
+func NewRevokeCertificationsView(network string, channel string, namespace string, ids ...*token2.Id) *RevokeCertificationsView {
+	if len(ids) == 0 {
+		panic("no ids specified")
+	}
+	return &RevokeCertificationsView{Network: network, Channel: channel, Namespace: namespace, IDs: ids}
+}
+
+func (r *RevokeCertificationsView) Call(context view.Context) (interface{}, error) {
+	idsRaw, err := json.Marshal(r.IDs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed marshalling ids")
+	}
+
+	tms := token.GetManagementService(
+		context,
+		token.WithNetwork(r.Network),
+		token.WithChannel(r.Channel),
+		token.WithNamespace(r.Namespace),
+	)
+	logger.Debugf("revoke certifications of [%v]", r.IDs)
+	_, err = context.RunView(chaincode.NewInvokeView(
+		tms.Namespace(), RevokeCertificationsFunction, idsRaw,
+	).WithNetwork(tms.Network()).WithChannel(tms.Channel()).WithInvokerIdentity(
+		fabric.GetFabricNetworkService(context, tms.Network()).IdentityProvider().DefaultIdentity(),
+	))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed revoking certifications of [%v]", r.IDs)
+	}
+	return nil, nil
+}
@@ -0,0 +1,334 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package tcc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/multisig"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// DefaultChallengePeriod is the time the counterparty has to answer a dispute, if the chaincode does not set one
+const DefaultChallengePeriod = 24 * time.Hour
+
+//go:generate counterfeiter -o mock/owner_verifier.go -fake-name OwnerVerifier . OwnerVerifier
+
+// OwnerVerifier checks the ownership of ledger tokens, it is used to verify the states submitted in a dispute
+type OwnerVerifier interface {
+	Owner(raw []byte) (view2.Identity, error)
+	VerifySignature(owner view2.Identity, message, sigma []byte) error
+}
+
+// DisputeRequest submits the state of an off-chain channel to the chaincode
+type DisputeRequest struct {
+	// Deposit is the token backing the channel, owned jointly by the parties
+	Deposit *token2.Id
+	// State is the serialized api.State
+	State []byte
+	// Signature is the multisig signature of the parties on State
+	Signature []byte
+}
+
+// RevealRequest reveals, during a dispute, the preimage of the hash lock of a pending lock of the disputed state
+type RevealRequest struct {
+	ChannelID string
+	Preimage  []byte
+}
+
+func (cc *TokenChaincode) challengePeriod() time.Duration {
+	if cc.ChallengePeriod == 0 {
+		return DefaultChallengePeriod
+	}
+	return cc.ChallengePeriod
+}
+
+func (cc *TokenChaincode) ownerVerifier(stub shim.ChaincodeStubInterface) (OwnerVerifier, error) {
+	if err := cc.init(stub); err != nil {
+		return nil, err
+	}
+	if cc.OwnerVerifier == nil {
+		return nil, errors.New("the token driver does not support disputes")
+	}
+	return cc.OwnerVerifier, nil
+}
+
+func (cc *TokenChaincode) openDispute(raw []byte, stub shim.ChaincodeStubInterface) pb.Response {
+	request := &DisputeRequest{}
+	if err := json.Unmarshal(raw, request); err != nil {
+		return shim.Error(fmt.Sprintf("failed unmarshalling dispute request: [%s]", err))
+	}
+	if request.Deposit == nil {
+		return shim.Error("deposit not set")
+	}
+	state, err := cc.verifyState(stub, request.Deposit, request)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("failed verifying state: [%s]", err))
+	}
+	dispute, err := getDispute(stub, state.ChannelID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if dispute != nil {
+		return shim.Error(fmt.Sprintf("channel [%s] is already disputed", state.ChannelID))
+	}
+	now, err := cc.txTime(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	logger.Debugf("open dispute on channel [%s] at state [%d]", state.ChannelID, state.SeqNumber)
+	return putDispute(stub, &api.Dispute{
+		Deposit:  request.Deposit,
+		State:    state,
+		Deadline: now.Add(cc.challengePeriod()),
+	})
+}
+
+func (cc *TokenChaincode) challengeDispute(raw []byte, stub shim.ChaincodeStubInterface) pb.Response {
+	request := &DisputeRequest{}
+	if err := json.Unmarshal(raw, request); err != nil {
+		return shim.Error(fmt.Sprintf("failed unmarshalling dispute request: [%s]", err))
+	}
+	state := &api.State{}
+	if err := state.FromBytes(request.State); err != nil {
+		return shim.Error(fmt.Sprintf("failed unmarshalling state: [%s]", err))
+	}
+	dispute, err := getDispute(stub, state.ChannelID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if dispute == nil {
+		return shim.Error(fmt.Sprintf("channel [%s] is not disputed", state.ChannelID))
+	}
+	if dispute.Closed {
+		return shim.Error(fmt.Sprintf("dispute on channel [%s] is closed", state.ChannelID))
+	}
+	now, err := cc.txTime(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !now.Before(dispute.Deadline) {
+		return shim.Error(fmt.Sprintf("challenge period of channel [%s] is over", state.ChannelID))
+	}
+	if state.SeqNumber <= dispute.State.SeqNumber {
+		return shim.Error(fmt.Sprintf("state [%d] is not newer than the disputed state [%d]", state.SeqNumber, dispute.State.SeqNumber))
+	}
+	state, err = cc.verifyState(stub, dispute.Deposit, request)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("failed verifying state: [%s]", err))
+	}
+
+	logger.Debugf("challenge dispute on channel [%s] with state [%d]", state.ChannelID, state.SeqNumber)
+	dispute.State = state
+	dispute.Deadline = now.Add(cc.challengePeriod())
+	return putDispute(stub, dispute)
+}
+
+func (cc *TokenChaincode) closeDispute(channelID []byte, stub shim.ChaincodeStubInterface) pb.Response {
+	dispute, err := getDispute(stub, string(channelID))
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if dispute == nil {
+		return shim.Error(fmt.Sprintf("channel [%s] is not disputed", channelID))
+	}
+	if dispute.Closed {
+		return shim.Error(fmt.Sprintf("dispute on channel [%s] is already closed", channelID))
+	}
+	now, err := cc.txTime(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if now.Before(dispute.Deadline) {
+		return shim.Error(fmt.Sprintf("challenge period of channel [%s] ends at [%s]", channelID, dispute.Deadline))
+	}
+
+	logger.Debugf("close dispute on channel [%s] at state [%d]", channelID, dispute.State.SeqNumber)
+	dispute.Closed = true
+	return putDispute(stub, dispute)
+}
+
+// revealPreimage records the preimage of a pending lock of the disputed state, so that the lock is executed
+// when the dispute is closed. The preimage must be revealed before both the end of the challenge period and the
+// timeout of the lock.
+func (cc *TokenChaincode) revealPreimage(raw []byte, stub shim.ChaincodeStubInterface) pb.Response {
+	request := &RevealRequest{}
+	if err := json.Unmarshal(raw, request); err != nil {
+		return shim.Error(fmt.Sprintf("failed unmarshalling reveal request: [%s]", err))
+	}
+	dispute, err := getDispute(stub, request.ChannelID)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if dispute == nil {
+		return shim.Error(fmt.Sprintf("channel [%s] is not disputed", request.ChannelID))
+	}
+	if dispute.Closed {
+		return shim.Error(fmt.Sprintf("dispute on channel [%s] is closed", request.ChannelID))
+	}
+	now, err := cc.txTime(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !now.Before(dispute.Deadline) {
+		return shim.Error(fmt.Sprintf("challenge period of channel [%s] is over", request.ChannelID))
+	}
+	hash := sha256.Sum256(request.Preimage)
+	var lock *api.Lock
+	for _, l := range dispute.State.Locks {
+		if bytes.Equal(l.HashLock, hash[:]) {
+			lock = l
+			break
+		}
+	}
+	if lock == nil {
+		return shim.Error(fmt.Sprintf("no pending lock [%s] in the disputed state of channel [%s]", hex.EncodeToString(hash[:]), request.ChannelID))
+	}
+	if !now.Before(lock.Timeout) {
+		return shim.Error(fmt.Sprintf("lock [%s] of channel [%s] timed out at [%s]", hex.EncodeToString(hash[:]), request.ChannelID, lock.Timeout))
+	}
+	if dispute.Revealed(hash[:]) {
+		return shim.Error(fmt.Sprintf("preimage of lock [%s] of channel [%s] already revealed", hex.EncodeToString(hash[:]), request.ChannelID))
+	}
+
+	logger.Debugf("reveal preimage of lock [%s] on channel [%s]", hex.EncodeToString(hash[:]), request.ChannelID)
+	dispute.Preimages = append(dispute.Preimages, request.Preimage)
+	return putDispute(stub, dispute)
+}
+
+func (cc *TokenChaincode) queryDispute(channelID []byte, stub shim.ChaincodeStubInterface) pb.Response {
+	key, err := keys.CreateDisputeKey(string(channelID))
+	if err != nil {
+		return shim.Error(fmt.Sprintf("failed creating dispute key for [%s]: [%s]", channelID, err))
+	}
+	raw, err := stub.GetState(key)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("failed getting dispute on [%s]: [%s]", channelID, err))
+	}
+	if len(raw) == 0 {
+		return shim.Error(fmt.Sprintf("channel [%s] is not disputed", channelID))
+	}
+	return shim.Success(raw)
+}
+
+// verifyState checks that the state in the request is signed by the owner of the passed deposit,
+// and that the owner is the joint identity of the parties of the state
+func (cc *TokenChaincode) verifyState(stub shim.ChaincodeStubInterface, deposit *token2.Id, request *DisputeRequest) (*api.State, error) {
+	ov, err := cc.ownerVerifier(stub)
+	if err != nil {
+		return nil, err
+	}
+	state := &api.State{}
+	if err := state.FromBytes(request.State); err != nil {
+		return nil, errors.Wrap(err, "failed unmarshalling state")
+	}
+	if len(state.Parties) != 2 || len(state.Balances) != 2 {
+		return nil, errors.New("a channel must have two parties")
+	}
+	if state.ChannelID != deposit.TxId {
+		return nil, errors.Errorf("channel [%s] is not opened by the transaction of deposit [%s]", state.ChannelID, deposit)
+	}
+
+	key, err := keys.CreateTokenKey(deposit.TxId, int(deposit.Index))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed creating key for deposit [%s]", deposit)
+	}
+	raw, err := stub.GetState(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting deposit [%s]", deposit)
+	}
+	if len(raw) == 0 {
+		return nil, errors.Errorf("deposit [%s] does not exist", deposit)
+	}
+	owner, err := ov.Owner(raw)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting owner of deposit [%s]", deposit)
+	}
+	parties, err := (&multisig.Identity{Threshold: 2, Identities: state.Parties}).Serialize()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed serializing parties")
+	}
+	if !owner.Equal(parties) {
+		return nil, errors.Errorf("deposit [%s] is not owned by the parties of channel [%s]", deposit, state.ChannelID)
+	}
+	if err := ov.VerifySignature(owner, request.State, request.Signature); err != nil {
+		return nil, errors.WithMessagef(err, "invalid signature on state [%d] of channel [%s]", state.SeqNumber, state.ChannelID)
+	}
+	return state, nil
+}
+
+func getDispute(stub shim.ChaincodeStubInterface, channelID string) (*api.Dispute, error) {
+	key, err := keys.CreateDisputeKey(channelID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed creating dispute key for [%s]", channelID)
+	}
+	raw, err := stub.GetState(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting dispute on [%s]", channelID)
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	dispute := &api.Dispute{}
+	if err := json.Unmarshal(raw, dispute); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling dispute on [%s]", channelID)
+	}
+	return dispute, nil
+}
+
+func putDispute(stub shim.ChaincodeStubInterface, dispute *api.Dispute) pb.Response {
+	key, err := keys.CreateDisputeKey(dispute.State.ChannelID)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("failed creating dispute key for [%s]: [%s]", dispute.State.ChannelID, err))
+	}
+	raw, err := json.Marshal(dispute)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("failed marshalling dispute: [%s]", err))
+	}
+	if err := stub.PutState(key, raw); err != nil {
+		return shim.Error(fmt.Sprintf("failed storing dispute on [%s]: [%s]", dispute.State.ChannelID, err))
+	}
+	return shim.Success(raw)
+}
+
+func (cc *TokenChaincode) now() time.Time {
+	if cc.Clock == nil {
+		return time.Now()
+	}
+	return cc.Clock()
+}
+
+// txTime returns the timestamp of the transaction, once checked against the local clock.
+// The timestamp is chosen by the client, unbounded it could open, challenge or close a dispute at any time.
+func (cc *TokenChaincode) txTime(stub shim.ChaincodeStubInterface) (time.Time, error) {
+	ts, err := stub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed getting transaction timestamp")
+	}
+	t, err := ptypes.Timestamp(ts)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid transaction timestamp")
+	}
+	if err := token.CheckTxTime(t, cc.now()); err != nil {
+		return time.Time{}, err
+	}
+	return t, nil
+}
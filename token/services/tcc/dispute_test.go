@@ -0,0 +1,270 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package tcc_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/multisig"
+	chaincode2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/tcc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/tcc/mock"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+var _ = Describe("dispute", func() {
+	var (
+		fakestub          *mock.ChaincodeStubInterface
+		chaincode         *chaincode2.TokenChaincode
+		fakeOwnerVerifier *mock.OwnerVerifier
+		ledger            map[string][]byte
+		now               time.Time
+		parties           []view.Identity
+		deposit           *token2.Id
+	)
+
+	state := func(seq int, balances ...uint64) []byte {
+		s := &api.State{
+			ChannelID: "channel",
+			SeqNumber: seq,
+			Parties:   parties,
+			Balances:  []map[string]uint64{{"USD": balances[0]}, {"USD": balances[1]}},
+		}
+		raw, err := s.Bytes()
+		Expect(err).NotTo(HaveOccurred())
+		return raw
+	}
+	request := func(state []byte) []byte {
+		raw, err := json.Marshal(&chaincode2.DisputeRequest{Deposit: deposit, State: state, Signature: []byte("signature")})
+		Expect(err).NotTo(HaveOccurred())
+		return raw
+	}
+	invoke := func(function string, arg []byte) (*api.Dispute, error) {
+		fakestub.GetArgsReturns([][]byte{[]byte(function), arg})
+		response := chaincode.Invoke(fakestub)
+		if response.Status != 200 {
+			return nil, errors.New(response.Message)
+		}
+		dispute := &api.Dispute{}
+		Expect(json.Unmarshal(response.Payload, dispute)).To(Succeed())
+		return dispute, nil
+	}
+
+	BeforeEach(func() {
+		fakeOwnerVerifier = &mock.OwnerVerifier{}
+		chaincode = &chaincode2.TokenChaincode{
+			TokenServicesFactory: func(i []byte) (chaincode2.PublicParametersManager, chaincode2.Validator, error) {
+				return &mock.PublicParametersManager{}, &mock.Validator{}, nil
+			},
+			OwnerVerifier:   fakeOwnerVerifier,
+			ChallengePeriod: time.Hour,
+			Clock: func() time.Time {
+				return now
+			},
+		}
+
+		ledger = map[string][]byte{}
+		now = time.Unix(1000, 0)
+		fakestub = &mock.ChaincodeStubInterface{}
+		fakestub.GetStateStub = func(key string) ([]byte, error) {
+			return ledger[key], nil
+		}
+		fakestub.PutStateStub = func(key string, value []byte) error {
+			ledger[key] = value
+			return nil
+		}
+		fakestub.GetTxTimestampStub = func() (*timestamp.Timestamp, error) {
+			return ptypes.TimestampProto(now)
+		}
+		fakestub.GetArgsReturns([][]byte{[]byte("init"), []byte(base64.StdEncoding.EncodeToString([]byte("public parameters")))})
+		Expect(chaincode.Init(fakestub).Status).To(Equal(int32(200)))
+
+		parties = []view.Identity{view.Identity("alice"), view.Identity("bob")}
+		owner, err := (&multisig.Identity{Threshold: 2, Identities: parties}).Serialize()
+		Expect(err).NotTo(HaveOccurred())
+		fakeOwnerVerifier.OwnerReturns(owner, nil)
+
+		deposit = &token2.Id{TxId: "channel", Index: 0}
+		key, err := keys.CreateTokenKey(deposit.TxId, int(deposit.Index))
+		Expect(err).NotTo(HaveOccurred())
+		ledger[key] = []byte("deposit")
+	})
+
+	Describe("openDispute", func() {
+		It("records the submitted state", func() {
+			dispute, err := invoke("openDispute", request(state(3, 70, 30)))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dispute.State.SeqNumber).To(Equal(3))
+			Expect(dispute.Deadline).To(BeTemporally("==", now.Add(time.Hour)))
+			Expect(dispute.Closed).To(BeFalse())
+
+			owner, message, sigma := fakeOwnerVerifier.VerifySignatureArgsForCall(0)
+			Expect(multisig.IsMultisig(owner)).To(BeTrue())
+			Expect(message).To(Equal(state(3, 70, 30)))
+			Expect(sigma).To(Equal([]byte("signature")))
+
+			fakestub.GetArgsReturns([][]byte{[]byte("queryDispute"), []byte("channel")})
+			response := chaincode.Invoke(fakestub)
+			Expect(response.Status).To(Equal(int32(200)))
+			stored := &api.Dispute{}
+			Expect(json.Unmarshal(response.Payload, stored)).To(Succeed())
+			Expect(stored.State.SeqNumber).To(Equal(3))
+
+			_, err = invoke("openDispute", request(state(4, 60, 40)))
+			Expect(err).To(MatchError(ContainSubstring("channel [channel] is already disputed")))
+		})
+		It("fails when the transaction timestamp is far from the local clock", func() {
+			fakestub.GetTxTimestampStub = func() (*timestamp.Timestamp, error) {
+				return ptypes.TimestampProto(now.Add(-token.MaxTxTimeSkew - time.Minute))
+			}
+			_, err := invoke("openDispute", request(state(3, 70, 30)))
+			Expect(err).To(MatchError(ContainSubstring("away from the local time")))
+		})
+		It("fails when the signature is not valid", func() {
+			fakeOwnerVerifier.VerifySignatureReturns(errors.New("invalid signature"))
+			_, err := invoke("openDispute", request(state(3, 70, 30)))
+			Expect(err).To(MatchError(ContainSubstring("invalid signature")))
+		})
+		It("fails when the deposit is not owned by the parties", func() {
+			fakeOwnerVerifier.OwnerReturns(view.Identity("charlie"), nil)
+			_, err := invoke("openDispute", request(state(3, 70, 30)))
+			Expect(err).To(MatchError(ContainSubstring("is not owned by the parties of channel [channel]")))
+		})
+		It("fails when the deposit does not exist", func() {
+			deposit = &token2.Id{TxId: "channel", Index: 1}
+			_, err := invoke("openDispute", request(state(3, 70, 30)))
+			Expect(err).To(MatchError(ContainSubstring("does not exist")))
+		})
+		It("fails when the deposit does not belong to the channel", func() {
+			deposit = &token2.Id{TxId: "another channel", Index: 0}
+			_, err := invoke("openDispute", request(state(3, 70, 30)))
+			Expect(err).To(MatchError(ContainSubstring("is not opened by the transaction of deposit")))
+		})
+		It("fails when the driver does not support disputes", func() {
+			chaincode.OwnerVerifier = nil
+			_, err := invoke("openDispute", request(state(3, 70, 30)))
+			Expect(err).To(MatchError(ContainSubstring("the token driver does not support disputes")))
+		})
+	})
+
+	Describe("challengeDispute and closeDispute", func() {
+		BeforeEach(func() {
+			_, err := invoke("openDispute", request(state(3, 70, 30)))
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("releases the funds per the latest state", func() {
+			now = now.Add(30 * time.Minute)
+			_, err := invoke("challengeDispute", request(state(3, 60, 40)))
+			Expect(err).To(MatchError(ContainSubstring("state [3] is not newer than the disputed state [3]")))
+			dispute, err := invoke("challengeDispute", request(state(5, 50, 50)))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dispute.State.SeqNumber).To(Equal(5))
+			Expect(dispute.Deadline).To(BeTemporally("==", now.Add(time.Hour)))
+
+			now = now.Add(59 * time.Minute)
+			_, err = invoke("closeDispute", []byte("channel"))
+			Expect(err).To(MatchError(ContainSubstring("challenge period of channel [channel] ends at")))
+
+			now = now.Add(time.Minute)
+			_, err = invoke("challengeDispute", request(state(6, 40, 60)))
+			Expect(err).To(MatchError(ContainSubstring("challenge period of channel [channel] is over")))
+			dispute, err = invoke("closeDispute", []byte("channel"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dispute.Closed).To(BeTrue())
+			Expect(dispute.State.SeqNumber).To(Equal(5))
+			Expect(dispute.State.Balances).To(Equal([]map[string]uint64{{"USD": 50}, {"USD": 50}}))
+
+			_, err = invoke("closeDispute", []byte("channel"))
+			Expect(err).To(MatchError(ContainSubstring("dispute on channel [channel] is already closed")))
+		})
+		It("fails to challenge with an invalid signature", func() {
+			fakeOwnerVerifier.VerifySignatureReturns(errors.New("invalid signature"))
+			_, err := invoke("challengeDispute", request(state(5, 50, 50)))
+			Expect(err).To(MatchError(ContainSubstring("invalid signature")))
+		})
+		It("fails on channels not disputed", func() {
+			_, err := invoke("closeDispute", []byte("another channel"))
+			Expect(err).To(MatchError(ContainSubstring("channel [another channel] is not disputed")))
+		})
+	})
+
+	Describe("revealPreimage", func() {
+		var (
+			preimage []byte
+			reveal   func(preimage []byte) []byte
+		)
+		BeforeEach(func() {
+			preimage = []byte("preimage")
+			hash := sha256.Sum256(preimage)
+			s := &api.State{
+				ChannelID: "channel",
+				SeqNumber: 3,
+				Parties:   parties,
+				Balances:  []map[string]uint64{{"USD": 70}, {"USD": 30}},
+				Locks:     []*api.Lock{{HashLock: hash[:], Sender: 0, Type: "USD", Value: 20, Timeout: now.Add(30 * time.Minute)}},
+			}
+			raw, err := s.Bytes()
+			Expect(err).NotTo(HaveOccurred())
+			_, err = invoke("openDispute", request(raw))
+			Expect(err).NotTo(HaveOccurred())
+			reveal = func(preimage []byte) []byte {
+				raw, err := json.Marshal(&chaincode2.RevealRequest{ChannelID: "channel", Preimage: preimage})
+				Expect(err).NotTo(HaveOccurred())
+				return raw
+			}
+		})
+		It("executes the lock when the dispute is closed", func() {
+			now = now.Add(10 * time.Minute)
+			_, err := invoke("revealPreimage", reveal([]byte("another preimage")))
+			Expect(err).To(MatchError(ContainSubstring("no pending lock")))
+			dispute, err := invoke("revealPreimage", reveal(preimage))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dispute.Preimages).To(Equal([][]byte{preimage}))
+			_, err = invoke("revealPreimage", reveal(preimage))
+			Expect(err).To(MatchError(ContainSubstring("already revealed")))
+
+			now = now.Add(time.Hour)
+			dispute, err = invoke("closeDispute", []byte("channel"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dispute.Balances()).To(Equal([]map[string]uint64{{"USD": 50}, {"USD": 50}}))
+			Expect(dispute.Payouts()).To(Equal([]*api.Payout{
+				{Owner: parties[0], Type: "USD", Value: 50},
+				{Owner: parties[1], Type: "USD", Value: 50},
+			}))
+		})
+		It("gives the lock back to its sender once timed out", func() {
+			now = now.Add(30 * time.Minute)
+			_, err := invoke("revealPreimage", reveal(preimage))
+			Expect(err).To(MatchError(ContainSubstring("timed out")))
+
+			now = now.Add(time.Hour)
+			dispute, err := invoke("closeDispute", []byte("channel"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dispute.Balances()).To(Equal([]map[string]uint64{{"USD": 70}, {"USD": 30}}))
+		})
+		It("fails once the dispute is closed", func() {
+			now = now.Add(time.Hour)
+			_, err := invoke("revealPreimage", reveal(preimage))
+			Expect(err).To(MatchError(ContainSubstring("challenge period of channel [channel] is over")))
+			_, err = invoke("closeDispute", []byte("channel"))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = invoke("revealPreimage", reveal(preimage))
+			Expect(err).To(MatchError(ContainSubstring("dispute on channel [channel] is closed")))
+		})
+	})
+})
@@ -0,0 +1,65 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package tcc
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric/services/chaincode"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+const QueryRevokedCertificationsFunction = "queryRevokedCertifications"
+
+type revocationListFetcher struct {
+	sp        view.ServiceProvider
+	network   string
+	channel   string
+	namespace string
+}
+
+func NewRevocationListFetcher(sp view.ServiceProvider, network string, channel string, namespace string) *revocationListFetcher {
+	return &revocationListFetcher{
+		sp:        sp,
+		network:   network,
+		channel:   channel,
+		namespace: namespace,
+	}
+}
+
+func (c *revocationListFetcher) Revoked(ids []*token.Id) ([]bool, error) {
+	logger.Debugf("retrieve revoked certifications for [%s:%s][%v]", c.channel, c.namespace, ids)
+
+	idsRaw, err := json.Marshal(ids)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed marshalling ids")
+	}
+	resBoxed, err := view.GetManager(c.sp).InitiateView(
+		chaincode.NewQueryView(
+			c.namespace,
+			QueryRevokedCertificationsFunction,
+			idsRaw,
+		).WithNetwork(c.network).WithChannel(c.channel),
+	)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed querying revoked certifications")
+	}
+	raw, ok := resBoxed.([]byte)
+	if !ok {
+		return nil, errors.Errorf("expected []byte from TCC, got [%T]", resBoxed)
+	}
+	var revoked []bool
+	if err := json.Unmarshal(raw, &revoked); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling revoked certifications")
+	}
+	if len(revoked) != len(ids) {
+		return nil, errors.Errorf("expected [%d] revocations, got [%d]", len(ids), len(revoked))
+	}
+	return revoked, nil
+}
@@ -21,6 +21,16 @@ type PublicParametersManager struct {
 		result1 []byte
 		result2 error
 	}
+	CertifierIdentityStub        func() []byte
+	certifierIdentityMutex       sync.RWMutex
+	certifierIdentityArgsForCall []struct {
+	}
+	certifierIdentityReturns struct {
+		result1 []byte
+	}
+	certifierIdentityReturnsOnCall map[int]struct {
+		result1 []byte
+	}
 	SetAuditorStub        func([]byte) ([]byte, error)
 	setAuditorMutex       sync.RWMutex
 	setAuditorArgsForCall []struct {
@@ -34,10 +44,11 @@ type PublicParametersManager struct {
 		result1 []byte
 		result2 error
 	}
-	SetCertifierStub        func([]byte) ([]byte, error)
+	SetCertifierStub        func([]byte, []byte) ([]byte, error)
 	setCertifierMutex       sync.RWMutex
 	setCertifierArgsForCall []struct {
 		arg1 []byte
+		arg2 []byte
 	}
 	setCertifierReturns struct {
 		result1 []byte
@@ -119,6 +130,58 @@ func (fake *PublicParametersManager) AddIssuerReturnsOnCall(i int, result1 []byt
 	}{result1, result2}
 }
 
+func (fake *PublicParametersManager) CertifierIdentity() []byte {
+	fake.certifierIdentityMutex.Lock()
+	ret, specificReturn := fake.certifierIdentityReturnsOnCall[len(fake.certifierIdentityArgsForCall)]
+	fake.certifierIdentityArgsForCall = append(fake.certifierIdentityArgsForCall, struct {
+	}{})
+	fake.recordInvocation("CertifierIdentity", []interface{}{})
+	fake.certifierIdentityMutex.Unlock()
+	if fake.CertifierIdentityStub != nil {
+		return fake.CertifierIdentityStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.certifierIdentityReturns
+	return fakeReturns.result1
+}
+
+func (fake *PublicParametersManager) CertifierIdentityCallCount() int {
+	fake.certifierIdentityMutex.RLock()
+	defer fake.certifierIdentityMutex.RUnlock()
+	return len(fake.certifierIdentityArgsForCall)
+}
+
+func (fake *PublicParametersManager) CertifierIdentityCalls(stub func() []byte) {
+	fake.certifierIdentityMutex.Lock()
+	defer fake.certifierIdentityMutex.Unlock()
+	fake.CertifierIdentityStub = stub
+}
+
+func (fake *PublicParametersManager) CertifierIdentityReturns(result1 []byte) {
+	fake.certifierIdentityMutex.Lock()
+	defer fake.certifierIdentityMutex.Unlock()
+	fake.CertifierIdentityStub = nil
+	fake.certifierIdentityReturns = struct {
+		result1 []byte
+	}{result1}
+}
+
+func (fake *PublicParametersManager) CertifierIdentityReturnsOnCall(i int, result1 []byte) {
+	fake.certifierIdentityMutex.Lock()
+	defer fake.certifierIdentityMutex.Unlock()
+	fake.CertifierIdentityStub = nil
+	if fake.certifierIdentityReturnsOnCall == nil {
+		fake.certifierIdentityReturnsOnCall = make(map[int]struct {
+			result1 []byte
+		})
+	}
+	fake.certifierIdentityReturnsOnCall[i] = struct {
+		result1 []byte
+	}{result1}
+}
+
 func (fake *PublicParametersManager) SetAuditor(arg1 []byte) ([]byte, error) {
 	var arg1Copy []byte
 	if arg1 != nil {
@@ -187,21 +250,27 @@ func (fake *PublicParametersManager) SetAuditorReturnsOnCall(i int, result1 []by
 	}{result1, result2}
 }
 
-func (fake *PublicParametersManager) SetCertifier(arg1 []byte) ([]byte, error) {
+func (fake *PublicParametersManager) SetCertifier(arg1 []byte, arg2 []byte) ([]byte, error) {
 	var arg1Copy []byte
 	if arg1 != nil {
 		arg1Copy = make([]byte, len(arg1))
 		copy(arg1Copy, arg1)
 	}
+	var arg2Copy []byte
+	if arg2 != nil {
+		arg2Copy = make([]byte, len(arg2))
+		copy(arg2Copy, arg2)
+	}
 	fake.setCertifierMutex.Lock()
 	ret, specificReturn := fake.setCertifierReturnsOnCall[len(fake.setCertifierArgsForCall)]
 	fake.setCertifierArgsForCall = append(fake.setCertifierArgsForCall, struct {
 		arg1 []byte
-	}{arg1Copy})
-	fake.recordInvocation("SetCertifier", []interface{}{arg1Copy})
+		arg2 []byte
+	}{arg1Copy, arg2Copy})
+	fake.recordInvocation("SetCertifier", []interface{}{arg1Copy, arg2Copy})
 	fake.setCertifierMutex.Unlock()
 	if fake.SetCertifierStub != nil {
-		return fake.SetCertifierStub(arg1)
+		return fake.SetCertifierStub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -216,17 +285,17 @@ func (fake *PublicParametersManager) SetCertifierCallCount() int {
 	return len(fake.setCertifierArgsForCall)
 }
 
-func (fake *PublicParametersManager) SetCertifierCalls(stub func([]byte) ([]byte, error)) {
+func (fake *PublicParametersManager) SetCertifierCalls(stub func([]byte, []byte) ([]byte, error)) {
 	fake.setCertifierMutex.Lock()
 	defer fake.setCertifierMutex.Unlock()
 	fake.SetCertifierStub = stub
 }
 
-func (fake *PublicParametersManager) SetCertifierArgsForCall(i int) []byte {
+func (fake *PublicParametersManager) SetCertifierArgsForCall(i int) ([]byte, []byte) {
 	fake.setCertifierMutex.RLock()
 	defer fake.setCertifierMutex.RUnlock()
 	argsForCall := fake.setCertifierArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *PublicParametersManager) SetCertifierReturns(result1 []byte, result2 error) {
@@ -260,6 +329,8 @@ func (fake *PublicParametersManager) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.addIssuerMutex.RLock()
 	defer fake.addIssuerMutex.RUnlock()
+	fake.certifierIdentityMutex.RLock()
+	defer fake.certifierIdentityMutex.RUnlock()
 	fake.setAuditorMutex.RLock()
 	defer fake.setAuditorMutex.RUnlock()
 	fake.setCertifierMutex.RLock()
@@ -14,6 +14,7 @@ import (
 	"io/ioutil"
 	"os"
 	"runtime/debug"
+	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
@@ -24,6 +25,7 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/hash"
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/translator"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
@@ -38,6 +40,15 @@ const (
 	AddCertifierFunction      = "addCertifier"
 	QueryTokensFunctions      = "queryTokens"
 
+	RevokeCertificationsFunction       = "revokeCertifications"
+	QueryRevokedCertificationsFunction = "queryRevokedCertifications"
+
+	OpenDisputeFunction      = "openDispute"
+	ChallengeDisputeFunction = "challengeDispute"
+	CloseDisputeFunction     = "closeDispute"
+	QueryDisputeFunction     = "queryDispute"
+	RevealPreimageFunction   = "revealPreimage"
+
 	PublicParamsPathVarEnv = "PUBLIC_PARAMS_FILE_PATH"
 )
 
@@ -66,7 +77,8 @@ type Validator interface {
 type PublicParametersManager interface {
 	AddIssuer(issuer []byte) ([]byte, error)
 	SetAuditor(auditor []byte) ([]byte, error)
-	SetCertifier(certifier []byte) ([]byte, error)
+	SetCertifier(certifier []byte, identity []byte) ([]byte, error)
+	CertifierIdentity() []byte
 }
 
 type TokenChaincode struct {
@@ -76,6 +88,13 @@ type TokenChaincode struct {
 
 	PPDigest             []byte
 	TokenServicesFactory func([]byte) (PublicParametersManager, Validator, error)
+
+	// OwnerVerifier is set from the validator, if this supports it
+	OwnerVerifier OwnerVerifier
+	// ChallengePeriod is the time a party has to answer a dispute, DefaultChallengePeriod if not set
+	ChallengePeriod time.Duration
+	// Clock returns the local time the transaction timestamps are checked against, time.Now if not set
+	Clock func() time.Time
 }
 
 func (cc *TokenChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
@@ -163,6 +182,41 @@ func (cc *TokenChaincode) Invoke(stub shim.ChaincodeStubInterface) (res pb.Respo
 				return shim.Error("request to retrieve tokens is empty")
 			}
 			return cc.queryTokens(args[1], stub)
+		case RevokeCertificationsFunction:
+			if len(args) != 2 {
+				return shim.Error("request to revoke certifications is empty")
+			}
+			return cc.revokeCertifications(args[1], stub)
+		case QueryRevokedCertificationsFunction:
+			if len(args) != 2 {
+				return shim.Error("request to query revoked certifications is empty")
+			}
+			return cc.queryRevokedCertifications(args[1], stub)
+		case OpenDisputeFunction:
+			if len(args) != 2 {
+				return shim.Error("request to open dispute is empty")
+			}
+			return cc.openDispute(args[1], stub)
+		case ChallengeDisputeFunction:
+			if len(args) != 2 {
+				return shim.Error("request to challenge dispute is empty")
+			}
+			return cc.challengeDispute(args[1], stub)
+		case CloseDisputeFunction:
+			if len(args) != 2 {
+				return shim.Error("request to close dispute is empty")
+			}
+			return cc.closeDispute(args[1], stub)
+		case QueryDisputeFunction:
+			if len(args) != 2 {
+				return shim.Error("request to query dispute is empty")
+			}
+			return cc.queryDispute(args[1], stub)
+		case RevealPreimageFunction:
+			if len(args) != 2 {
+				return shim.Error("request to reveal preimage is empty")
+			}
+			return cc.revealPreimage(args[1], stub)
 		default:
 			return shim.Error(fmt.Sprintf("function not [%s] recognized", f))
 		}
@@ -238,6 +292,9 @@ func (cc *TokenChaincode) init(stub shim.ChaincodeStubInterface) error {
 	}
 	cc.PublicParametersManager = ppm
 	cc.Validator = validator
+	if ov, ok := validator.(OwnerVerifier); ok {
+		cc.OwnerVerifier = ov
+	}
 	cc.PPDigest = digest
 
 	return nil
@@ -340,14 +397,21 @@ func (cc *TokenChaincode) addAuditor(auditor []byte, stub shim.ChaincodeStubInte
 }
 
 func (cc *TokenChaincode) addCertifier(certifier []byte, stub shim.ChaincodeStubInterface) pb.Response {
-	// todo authenticate creator of addCertifier request
 	// todo only admins are allowed to add certifier
 
 	ppm, err := cc.publicParametersManager(stub)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
-	raw, err := ppm.SetCertifier(certifier)
+	// the creator becomes the certifier identity, once set only the certifier itself can replace it
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return shim.Error(fmt.Sprintf("failed getting creator: [%s]", err))
+	}
+	if current := ppm.CertifierIdentity(); len(current) != 0 && !bytes.Equal(current, creator) {
+		return shim.Error("only the certifier can replace the certifier")
+	}
+	raw, err := ppm.SetCertifier(certifier, creator)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
@@ -384,3 +448,67 @@ func (cc *TokenChaincode) queryTokens(idsRaw []byte, stub shim.ChaincodeStubInte
 	}
 	return shim.Success(raw)
 }
+
+func (cc *TokenChaincode) revokeCertifications(idsRaw []byte, stub shim.ChaincodeStubInterface) pb.Response {
+	// only the certifier is allowed to revoke certifications
+	ppm, err := cc.publicParametersManager(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	certifier := ppm.CertifierIdentity()
+	if len(certifier) == 0 {
+		return shim.Error("no certifier set")
+	}
+	creator, err := stub.GetCreator()
+	if err != nil {
+		return shim.Error(fmt.Sprintf("failed getting creator: [%s]", err))
+	}
+	if !bytes.Equal(certifier, creator) {
+		return shim.Error("only the certifier can revoke certifications")
+	}
+
+	var ids []*token2.Id
+	if err := json.Unmarshal(idsRaw, &ids); err != nil {
+		logger.Errorf("failed unmarshalling tokens ids: [%s]", err)
+		return shim.Error(err.Error())
+	}
+
+	logger.Debugf("revoke certifications of [%v]...", ids)
+	for _, id := range ids {
+		key, err := keys.CreateRevokedCertificationKey(id.TxId, int(id.Index))
+		if err != nil {
+			return shim.Error(fmt.Sprintf("failed creating revocation key for [%v]: [%s]", id, err))
+		}
+		if err := stub.PutState(key, []byte{1}); err != nil {
+			return shim.Error(fmt.Sprintf("failed revoking certification of [%v]: [%s]", id, err))
+		}
+	}
+	return shim.Success(nil)
+}
+
+func (cc *TokenChaincode) queryRevokedCertifications(idsRaw []byte, stub shim.ChaincodeStubInterface) pb.Response {
+	var ids []*token2.Id
+	if err := json.Unmarshal(idsRaw, &ids); err != nil {
+		logger.Errorf("failed unmarshalling tokens ids: [%s]", err)
+		return shim.Error(err.Error())
+	}
+
+	logger.Debugf("query revoked certifications of [%v]...", ids)
+	revoked := make([]bool, len(ids))
+	for i, id := range ids {
+		key, err := keys.CreateRevokedCertificationKey(id.TxId, int(id.Index))
+		if err != nil {
+			return shim.Error(fmt.Sprintf("failed creating revocation key for [%v]: [%s]", id, err))
+		}
+		raw, err := stub.GetState(key)
+		if err != nil {
+			return shim.Error(fmt.Sprintf("failed getting revocation of [%v]: [%s]", id, err))
+		}
+		revoked[i] = len(raw) != 0
+	}
+	raw, err := json.Marshal(revoked)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("failed marshalling revocations: [%s]", err))
+	}
+	return shim.Success(raw)
+}
@@ -125,6 +125,7 @@ var _ = Describe("ccvalidator", func() {
 				Expect(err).NotTo(HaveOccurred())
 				fakestub.GetArgsReturns(args)
 				fakePPM.SetCertifierReturns([]byte("certifier was added"), nil)
+				fakestub.GetCreatorReturns([]byte("certifier"), nil)
 
 			})
 			When("addAuditor is called correctly", func() {
@@ -133,7 +134,19 @@ var _ = Describe("ccvalidator", func() {
 					Expect(response).NotTo(BeNil())
 					Expect(response.Status).To(Equal(int32(200)))
 					Expect(response.Payload).To(Equal([]byte("certifier was added")))
-
+					_, identity := fakePPM.SetCertifierArgsForCall(0)
+					Expect(identity).To(Equal([]byte("certifier")))
+				})
+			})
+			When("another certifier is already set", func() {
+				BeforeEach(func() {
+					fakePPM.CertifierIdentityReturns([]byte("another certifier"))
+				})
+				It("fails", func() {
+					response := chaincode.Invoke(fakestub)
+					Expect(response.Status).To(Equal(int32(500)))
+					Expect(response.Message).To(ContainSubstring("only the certifier can replace the certifier"))
+					Expect(fakePPM.SetCertifierCallCount()).To(Equal(0))
 				})
 			})
 			When("addCertifier fails", func() {
@@ -154,6 +167,72 @@ var _ = Describe("ccvalidator", func() {
 			})
 		})
 
+		Describe("Revoke Certifications", func() {
+			BeforeEach(func() {
+				args := make([][]byte, 2)
+				args[0] = []byte("revokeCertifications")
+				args[1] = []byte(`[{"tx_id":"a","index":1}]`)
+				fakestub.GetArgsReturns(args)
+				fakePPM.CertifierIdentityReturns([]byte("certifier"))
+				fakestub.GetCreatorReturns([]byte("certifier"), nil)
+			})
+			It("records the revocation", func() {
+				response := chaincode.Invoke(fakestub)
+				Expect(response.Status).To(Equal(int32(200)))
+				Expect(fakestub.PutStateCallCount()).To(Equal(1))
+				key, value := fakestub.PutStateArgsForCall(0)
+				Expect(key).To(ContainSubstring("revoked"))
+				Expect(value).To(Equal([]byte{1}))
+			})
+			When("the ids are not valid", func() {
+				BeforeEach(func() {
+					fakestub.GetArgsReturns([][]byte{[]byte("revokeCertifications"), []byte("ids")})
+				})
+				It("fails", func() {
+					response := chaincode.Invoke(fakestub)
+					Expect(response.Status).To(Equal(int32(500)))
+					Expect(fakestub.PutStateCallCount()).To(Equal(0))
+				})
+			})
+			When("the caller is not the certifier", func() {
+				BeforeEach(func() {
+					fakestub.GetCreatorReturns([]byte("alice"), nil)
+				})
+				It("fails", func() {
+					response := chaincode.Invoke(fakestub)
+					Expect(response.Status).To(Equal(int32(500)))
+					Expect(response.Message).To(ContainSubstring("only the certifier can revoke certifications"))
+					Expect(fakestub.PutStateCallCount()).To(Equal(0))
+				})
+			})
+			When("no certifier is set", func() {
+				BeforeEach(func() {
+					fakePPM.CertifierIdentityReturns(nil)
+				})
+				It("fails", func() {
+					response := chaincode.Invoke(fakestub)
+					Expect(response.Status).To(Equal(int32(500)))
+					Expect(fakestub.PutStateCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Describe("Query Revoked Certifications", func() {
+			BeforeEach(func() {
+				args := make([][]byte, 2)
+				args[0] = []byte("queryRevokedCertifications")
+				args[1] = []byte(`[{"tx_id":"a","index":1},{"tx_id":"b","index":0}]`)
+				fakestub.GetArgsReturns(args)
+				fakestub.GetStateReturnsOnCall(0, []byte{1}, nil)
+				fakestub.GetStateReturnsOnCall(1, nil, nil)
+			})
+			It("returns which certifications are revoked", func() {
+				response := chaincode.Invoke(fakestub)
+				Expect(response.Status).To(Equal(int32(200)))
+				Expect(response.Payload).To(Equal([]byte("[true,false]")))
+			})
+		})
+
 		Context("Invoke is called correctly with a token request", func() {
 			BeforeEach(func() {
 				var err error
@@ -8,7 +8,6 @@ package ttxcc
 import (
 	"github.com/pkg/errors"
 
-	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 )
 
@@ -18,10 +17,11 @@ type acceptView struct {
 }
 
 func (s *acceptView) Call(context view.Context) (interface{}, error) {
-	// Processes
-	env := s.tx.Payload.FabricEnvelope
-	if env == nil {
-		return nil, errors.Errorf("expected fabric envelope")
+	if err := s.tx.registerScripts(); err != nil {
+		return nil, err
+	}
+	if err := s.tx.registerMultisigs(); err != nil {
+		return nil, err
 	}
 	err := s.tx.storeTransient()
 	if err != nil {
@@ -29,21 +29,10 @@ func (s *acceptView) Call(context view.Context) (interface{}, error) {
 	}
 
 	logger.Debugf("parse rws for id [%s]", s.tx.ID())
-	ch := fabric.GetChannel(context, s.tx.Network(), s.tx.Channel())
-	rws, err := ch.Vault().GetRWSet(s.tx.ID(), env.Results())
-	if err != nil {
-		return nil, errors.WithMessagef(err, "failed getting rwset for tx [%s]", s.tx.ID())
-	}
-	rws.Done()
-
-	rawEnv, err := env.Bytes()
-	if err != nil {
-		return nil, errors.WithMessagef(err, "failed marshalling tx env [%s]", s.tx.ID())
-	}
-
-	if err := ch.Vault().StoreEnvelope(env.TxID(), rawEnv); err != nil {
-		return nil, errors.WithMessagef(err, "failed storing tx env [%s]", s.tx.ID())
+	if err := s.tx.store(context); err != nil {
+		return nil, err
 	}
+	s.tx.appendToHistory()
 
 	logger.Debugf("send back ack")
 	// Ack for distribution
@@ -0,0 +1,66 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric/services/endorser"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/approver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+)
+
+type approveView struct {
+	issuingValidator IssuingValidator
+}
+
+// NewApproveView returns the view an approver of the token namespace runs, in response to the initiator of a transaction
+// using the NamespaceBackend, to check the token request written in the transaction and endorse it.
+// The issues of the token request are checked with the passed IssuingValidator.
+func NewApproveView(issuingValidator IssuingValidator) *approveView {
+	return &approveView{issuingValidator: issuingValidator}
+}
+
+func (a *approveView) Call(context view.Context) (interface{}, error) {
+	etx, err := endorser.ReceiveTransaction(context)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed receiving transaction")
+	}
+	tms := token.GetManagementService(context, token.WithNetwork(etx.Network()), token.WithChannel(etx.Channel()))
+	ns := tms.Namespace()
+	logger.Debugf("approve token request in namespace [%s] for tx [%s]", ns, etx.ID())
+
+	rws, err := etx.RWSet()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting rws")
+	}
+	key, err := keys.CreateTokenRequestKey(etx.ID())
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed computing token request key")
+	}
+	requestRaw, err := rws.GetState(ns, key, fabric.FromIntermediate)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting token request for tx [%s]", etx.ID())
+	}
+	if len(requestRaw) == 0 {
+		return nil, errors.Errorf("no token request found for tx [%s]", etx.ID())
+	}
+
+	issuingValidator := a.issuingValidator
+	if issuingValidator == nil {
+		issuingValidator = &noIssuers{}
+	}
+	app := approver.NewTokenRWSetApprover(tms.Validator(), issuingValidator, fabric.GetVault(context, etx.Network(), etx.Channel()), etx.ID(), rws, ns)
+	if err := app.ValidateRequest(tms.Validator(), requestRaw); err != nil {
+		return nil, errors.WithMessagef(err, "failed approving tx [%s]", etx.ID())
+	}
+
+	return context.RunView(endorser.NewEndorseView(etx))
+}
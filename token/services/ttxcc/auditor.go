@@ -10,7 +10,6 @@ import (
 
 	"github.com/pkg/errors"
 
-	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/hash"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
@@ -63,10 +62,14 @@ func (r *RegisterAuditorView) Call(context view.Context) (interface{}, error) {
 
 type AuditingViewInitiator struct {
 	tx *Transaction
+	// timeout bounds the wait for the auditor signature
+	timeout time.Duration
+	// session is the session opened with the auditor
+	session view.Session
 }
 
 func newAuditingViewInitiator(tx *Transaction) *AuditingViewInitiator {
-	return &AuditingViewInitiator{tx: tx}
+	return &AuditingViewInitiator{tx: tx, timeout: 60 * time.Second}
 }
 
 func (a *AuditingViewInitiator) Call(context view.Context) (interface{}, error) {
@@ -74,6 +77,7 @@ func (a *AuditingViewInitiator) Call(context view.Context) (interface{}, error)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed getting session")
 	}
+	a.session = session
 
 	// Send transaction
 	txRaw, err := a.tx.Bytes()
@@ -91,7 +95,7 @@ func (a *AuditingViewInitiator) Call(context view.Context) (interface{}, error)
 	select {
 	case msg = <-ch:
 		logger.Debug("reply received from %s", a.tx.opts.auditor)
-	case <-time.After(60 * time.Second):
+	case <-time.After(a.timeout):
 		return nil, errors.Errorf("Timeout from party %s", a.tx.opts.auditor)
 	}
 	if msg.Status == view.ERROR {
@@ -177,43 +181,26 @@ func (a *AuditApproveView) signAndSendBack(context view.Context) error {
 		return errors.WithMessagef(err, "failed sending back auditor signature")
 	}
 
-	if err := a.waitFabricEnvelope(context); err != nil {
+	if err := a.waitEndorsedTransaction(context); err != nil {
 		return errors.WithMessagef(err, "failed obtaining auditor signature")
 	}
 	return nil
 }
 
-func (a *AuditApproveView) waitFabricEnvelope(context view.Context) error {
+func (a *AuditApproveView) waitEndorsedTransaction(context view.Context) error {
 	tx, err := ReceiveTransaction(context)
 	if err != nil {
 		return errors.Wrapf(err, "failed receiving transaction")
 	}
 
 	// Processes
-	logger.Debugf("Processes Fabric Envelope...")
-	env := tx.Payload.FabricEnvelope
-	if env == nil {
-		return errors.Errorf("expected fabric envelope")
-	}
-
+	logger.Debugf("Processes endorsed transaction...")
 	err = tx.storeTransient()
 	if err != nil {
 		return errors.Wrapf(err, "failed storing transient")
 	}
-
-	ch := fabric.GetChannel(context, tx.Network(), tx.Channel())
-	rws, err := ch.Vault().GetRWSet(tx.ID(), env.Results())
-	if err != nil {
-		return errors.WithMessagef(err, "failed getting rwset for tx [%s]", tx.ID())
-	}
-	rws.Done()
-
-	rawEnv, err := env.Bytes()
-	if err != nil {
-		return errors.WithMessagef(err, "failed marshalling tx env [%s]", tx.ID())
-	}
-	if err := ch.Vault().StoreEnvelope(env.TxID(), rawEnv); err != nil {
-		return errors.WithMessagef(err, "failed storing tx env [%s]", tx.ID())
+	if err := tx.store(context); err != nil {
+		return err
 	}
 
 	// Send the proposal response back
@@ -0,0 +1,291 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"encoding/base64"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric/services/endorser"
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/translator"
+)
+
+const (
+	// ChaincodeBackend has the token request endorsed by the token chaincode. It is the default backend.
+	ChaincodeBackend = "chaincode"
+	// NamespaceBackend has the token request written directly in the rws of the transaction
+	// and approved by the approvers of the token namespace, see WithNamespaceApprovers
+	NamespaceBackend = "namespace"
+)
+
+// Backend tells how the token request of a transaction reaches the ledger
+type Backend interface {
+	// Init binds the transaction to the ledger transaction that will carry its token request, fixing its id
+	Init(sp view2.ServiceProvider, tx *Transaction) error
+	// Endorse has the token request endorsed. Afterwards, the transaction can be distributed and ordered.
+	Endorse(context view.Context, tx *Transaction) error
+	// Store stores the endorsed transaction in the local vault
+	Store(context view.Context, tx *Transaction) error
+	// Broadcast submits the endorsed transaction for ordering
+	Broadcast(context view.Context, tx *Transaction) error
+}
+
+var (
+	backendsLock sync.RWMutex
+	backends     = map[string]Backend{
+		ChaincodeBackend: &chaincodeBackend{},
+		NamespaceBackend: &namespaceBackend{},
+	}
+)
+
+// RegisterBackend makes the passed backend available under the passed name, see WithBackend
+func RegisterBackend(name string, backend Backend) {
+	backendsLock.Lock()
+	defer backendsLock.Unlock()
+	backends[name] = backend
+}
+
+func getBackend(name string) (Backend, error) {
+	if len(name) == 0 {
+		name = ChaincodeBackend
+	}
+	backendsLock.RLock()
+	defer backendsLock.RUnlock()
+	b, ok := backends[name]
+	if !ok {
+		return nil, errors.Errorf("backend [%s] not found", name)
+	}
+	return b, nil
+}
+
+type chaincodeBackend struct{}
+
+func (c *chaincodeBackend) Init(sp view2.ServiceProvider, tx *Transaction) error {
+	fabric.GetFabricNetworkService(sp, tx.Network()).TransactionManager().ComputeTxID(&tx.Payload.Id)
+	return nil
+}
+
+func (c *chaincodeBackend) Endorse(context view.Context, tx *Transaction) error {
+	requestRaw, err := tx.TokenRequest.RequestToBytes()
+	if err != nil {
+		return errors.Wrapf(err, "failed marshalling request")
+	}
+
+	logger.Debugf("call chaincode for endorsement [nonce=%s]", base64.StdEncoding.EncodeToString(tx.Id.Nonce))
+
+	env, err := fabric.GetChannel(context, tx.Network(), tx.Channel()).Chaincode(tx.Namespace()).Endorse(
+		"invoke", requestRaw,
+	).WithInvokerIdentity(tx.Signer).WithTxID(tx.Payload.Id).Call()
+	if err != nil {
+		return err
+	}
+	return tx.setEnvelope(env)
+}
+
+func (c *chaincodeBackend) Store(context view.Context, tx *Transaction) error {
+	env := tx.Payload.FabricEnvelope
+	if env == nil {
+		return errors.Errorf("expected fabric envelope")
+	}
+
+	ch := fabric.GetChannel(context, tx.Network(), tx.Channel())
+	rws, err := ch.Vault().GetRWSet(tx.ID(), env.Results())
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting rwset for tx [%s]", tx.ID())
+	}
+	rws.Done()
+
+	rawEnv, err := env.Bytes()
+	if err != nil {
+		return errors.WithMessagef(err, "failed marshalling tx env [%s]", tx.ID())
+	}
+	if err := ch.Vault().StoreEnvelope(env.TxID(), rawEnv); err != nil {
+		return errors.WithMessagef(err, "failed storing tx env [%s]", tx.ID())
+	}
+	return nil
+}
+
+func (c *chaincodeBackend) Broadcast(context view.Context, tx *Transaction) error {
+	return fabric.GetFabricNetworkService(context, tx.Network()).Ordering().Broadcast(tx.Payload.FabricEnvelope)
+}
+
+type namespaceBackend struct{}
+
+func (n *namespaceBackend) Init(sp view2.ServiceProvider, tx *Transaction) error {
+	_, etx, err := endorser.NewTransactionWith(sp, tx.Network(), tx.Channel(), tx.Signer)
+	if err != nil {
+		return errors.WithMessage(err, "failed creating endorser transaction")
+	}
+	tx.Payload.Id.Nonce = etx.Transaction.Nonce()
+	tx.Payload.Id.Creator = etx.Transaction.Creator()
+	etx.SetProposal(tx.Namespace(), "Version-0.0", "")
+	return n.setTransaction(tx, etx)
+}
+
+func (n *namespaceBackend) Endorse(context view.Context, tx *Transaction) error {
+	if tx.opts == nil || len(tx.opts.approvers) == 0 {
+		return errors.Errorf("no approvers set for transaction [%s]", tx.ID())
+	}
+	etx, err := n.transaction(context, tx)
+	if err != nil {
+		return err
+	}
+
+	// write the token request in the rws, as the token chaincode would
+	requestRaw, err := tx.TokenRequest.RequestToBytes()
+	if err != nil {
+		return errors.Wrapf(err, "failed marshalling request")
+	}
+	qe, err := fabric.GetVault(context, tx.Network(), tx.Channel()).NewQueryExecutor()
+	if err != nil {
+		return errors.WithMessage(err, "failed getting query executor")
+	}
+	actions, err := tx.TokenService().Validator().UnmarshallAndVerify(&vaultLedger{qe: qe, namespace: tx.Namespace()}, tx.ID(), requestRaw)
+	qe.Done()
+	if err != nil {
+		return errors.WithMessagef(err, "failed verifying token request [%s]", tx.ID())
+	}
+	rws, err := etx.RWSet()
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting rwset")
+	}
+	if err := writeRequest(rws, tx.issuingValidator(), tx.ID(), tx.Namespace(), actions, requestRaw); err != nil {
+		return err
+	}
+	metaRaw, err := tx.TokenRequest.MetadataToBytes()
+	if err != nil {
+		return errors.Wrapf(err, "failed marshalling metadata")
+	}
+	if err := etx.SetTransient("zkat", metaRaw); err != nil {
+		return errors.Wrapf(err, "failed storing metadata in transaction [%s]", tx.ID())
+	}
+
+	if _, err := context.RunView(endorser.NewCollectApprovesView(etx, tx.opts.approvers...)); err != nil {
+		return errors.WithMessagef(err, "failed collecting approvals for transaction [%s]", tx.ID())
+	}
+	return n.setTransaction(tx, etx)
+}
+
+func (n *namespaceBackend) Store(context view.Context, tx *Transaction) error {
+	etx, err := n.transaction(context, tx)
+	if err != nil {
+		return err
+	}
+	results, err := etx.Results()
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting results of tx [%s]", tx.ID())
+	}
+
+	ch := fabric.GetChannel(context, tx.Network(), tx.Channel())
+	rws, err := ch.Vault().GetRWSet(tx.ID(), results)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting rwset for tx [%s]", tx.ID())
+	}
+	rws.Done()
+
+	raw, err := etx.Bytes()
+	if err != nil {
+		return errors.WithMessagef(err, "failed marshalling tx [%s]", tx.ID())
+	}
+	if err := ch.Vault().StoreTransaction(tx.ID(), raw); err != nil {
+		return errors.WithMessagef(err, "failed storing tx [%s]", tx.ID())
+	}
+	return nil
+}
+
+func (n *namespaceBackend) Broadcast(context view.Context, tx *Transaction) error {
+	etx, err := n.transaction(context, tx)
+	if err != nil {
+		return err
+	}
+	return fabric.GetFabricNetworkService(context, tx.Network()).Ordering().Broadcast(etx.Transaction)
+}
+
+func (n *namespaceBackend) transaction(context view.Context, tx *Transaction) (*endorser.Transaction, error) {
+	if len(tx.Payload.EndorserTransaction) == 0 {
+		return nil, errors.Errorf("expected endorser transaction")
+	}
+	_, etx, err := endorser.NewTransactionFromBytes(context, tx.Payload.EndorserTransaction)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed unmarshalling endorser transaction [%s]", tx.ID())
+	}
+	if etx.ID() != tx.ID() {
+		return nil, errors.Errorf("invalid endorser transaction, transaction ids do not match [%s][%s]", etx.ID(), tx.ID())
+	}
+	return etx, nil
+}
+
+func (n *namespaceBackend) setTransaction(tx *Transaction, etx *endorser.Transaction) error {
+	raw, err := etx.Bytes()
+	if err != nil {
+		return errors.WithMessage(err, "failed marshalling endorser transaction")
+	}
+	tx.Payload.EndorserTransaction = raw
+	return nil
+}
+
+// loadRequest returns the token request written in the passed endorser transaction, a new one if there is none
+func loadRequest(tms *token.ManagementService, etx *endorser.Transaction) (*token.Request, error) {
+	rws, err := etx.RWSet()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting rwset")
+	}
+	key, err := keys.CreateTokenRequestKey(etx.ID())
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed computing token request key")
+	}
+	requestRaw, err := rws.GetState(tms.Namespace(), key, fabric.FromIntermediate)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting token request for tx [%s]", etx.ID())
+	}
+	if len(requestRaw) == 0 {
+		request, err := tms.NewRequest(etx.ID())
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed creating token request for tx [%s]", etx.ID())
+		}
+		return request, nil
+	}
+	if !etx.ExistsTransientState("zkat") {
+		return nil, errors.Errorf("no token request metadata found in tx [%s]", etx.ID())
+	}
+	request, err := tms.NewRequestFromBytes(etx.ID(), requestRaw, etx.GetTransient("zkat"))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed unmarshalling token request of tx [%s]", etx.ID())
+	}
+	return request, nil
+}
+
+type vaultLedger struct {
+	qe        *fabric.QueryExecutor
+	namespace string
+}
+
+func (l *vaultLedger) GetState(key string) ([]byte, error) {
+	return l.qe.GetState(l.namespace, key)
+}
+
+// writeRequest writes in the passed rws the outputs of the passed actions and the token request, as the token
+// chaincode would. The issues are checked with the passed IssuingValidator.
+func writeRequest(rws translator.RWSet, issuingValidator IssuingValidator, txID, namespace string, actions []interface{}, requestRaw []byte) error {
+	w := translator.New(issuingValidator, txID, rws, namespace)
+	for _, action := range actions {
+		if err := w.Write(action); err != nil {
+			return errors.Wrap(err, "failed to write token action")
+		}
+	}
+	if err := w.CommitTokenRequest(requestRaw); err != nil {
+		return errors.Wrap(err, "failed to write token request")
+	}
+	return nil
+}
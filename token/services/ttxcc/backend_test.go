@@ -0,0 +1,117 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/translator/mock"
+)
+
+func mspIdentity(t *testing.T, mspID string) view.Identity {
+	raw, err := proto.Marshal(&msp.SerializedIdentity{Mspid: mspID, IdBytes: []byte("cert")})
+	assert.NoError(t, err)
+	return raw
+}
+
+func TestWithNamespaceApprovers(t *testing.T) {
+	opts, err := compile(WithNamespaceApprovers(view.Identity("alice"), view.Identity("bob")))
+	assert.NoError(t, err)
+	assert.Equal(t, NamespaceBackend, opts.backend)
+	assert.Equal(t, []view.Identity{view.Identity("alice"), view.Identity("bob")}, opts.approvers)
+
+	_, err = compile(WithNamespaceApprovers())
+	assert.EqualError(t, err, "no approvers passed")
+}
+
+type fakeBackend struct{ namespaceBackend }
+
+func TestGetBackend(t *testing.T) {
+	b, err := getBackend("")
+	assert.NoError(t, err)
+	assert.IsType(t, &chaincodeBackend{}, b)
+
+	b, err = getBackend(NamespaceBackend)
+	assert.NoError(t, err)
+	assert.IsType(t, &namespaceBackend{}, b)
+
+	_, err = getBackend("fake")
+	assert.EqualError(t, err, "backend [fake] not found")
+
+	RegisterBackend("fake", &fakeBackend{})
+	b, err = getBackend("fake")
+	assert.NoError(t, err)
+	assert.IsType(t, &fakeBackend{}, b)
+}
+
+func TestIssuingValidator(t *testing.T) {
+	issuers := MSPIssuers{"TokenOrg", "HouseOrg"}
+	assert.NoError(t, issuers.Validate(mspIdentity(t, "TokenOrg"), "USD"))
+	assert.NoError(t, issuers.Validate(mspIdentity(t, "HouseOrg"), "USD"))
+	assert.EqualError(t, issuers.Validate(mspIdentity(t, "Org1"), "USD"), "issuer of msp [Org1] cannot issue tokens")
+	assert.Error(t, issuers.Validate(view.Identity("not an msp identity"), "USD"))
+
+	tx := &Transaction{}
+	assert.IsType(t, &noIssuers{}, tx.issuingValidator())
+	assert.Error(t, tx.issuingValidator().Validate(mspIdentity(t, "TokenOrg"), "USD"))
+
+	tx.opts, _ = compile(WithIssuingValidator(issuers))
+	assert.Equal(t, issuers, tx.issuingValidator())
+}
+
+func TestWriteRequest(t *testing.T) {
+	issue := &mock.IssueAction{}
+	issue.GetIssuerReturns(mspIdentity(t, "TokenOrg"))
+	issue.GetSerializedOutputsReturns([][]byte{[]byte("output")}, nil)
+	issue.NumOutputsReturns(1)
+
+	// the issuer is accepted, the output and the token request are written
+	rws := &mock.RWSet{}
+	assert.NoError(t, writeRequest(rws, MSPIssuers{"TokenOrg"}, "tx1", "zkat", []interface{}{issue}, []byte("request")))
+	tokenKey, err := keys.CreateTokenKey("tx1", 0)
+	assert.NoError(t, err)
+	requestKey, err := keys.CreateTokenRequestKey("tx1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, rws.SetStateCallCount())
+	ns, key, value := rws.SetStateArgsForCall(0)
+	assert.Equal(t, "zkat", ns)
+	assert.Equal(t, tokenKey, key)
+	assert.Equal(t, []byte("output"), value)
+	ns, key, value = rws.SetStateArgsForCall(1)
+	assert.Equal(t, "zkat", ns)
+	assert.Equal(t, requestKey, key)
+	assert.Equal(t, []byte("request"), value)
+
+	// the issuer is rejected, nothing is written
+	rws = &mock.RWSet{}
+	err = writeRequest(rws, MSPIssuers{"HouseOrg"}, "tx1", "zkat", []interface{}{issue}, []byte("request"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "issuer of msp [TokenOrg] cannot issue tokens")
+	assert.Equal(t, 0, rws.SetStateCallCount())
+
+	// without an issuing validator, issues are rejected
+	rws = &mock.RWSet{}
+	assert.Error(t, writeRequest(rws, &noIssuers{}, "tx1", "zkat", []interface{}{issue}, []byte("request")))
+	assert.Equal(t, 0, rws.SetStateCallCount())
+
+	// a request already in the rws is not overwritten
+	rws = &mock.RWSet{}
+	rws.GetStateStub = func(ns, key string, opts ...fabric.GetStateOpt) ([]byte, error) {
+		if key == requestKey {
+			return []byte("other"), nil
+		}
+		return nil, nil
+	}
+	assert.Error(t, writeRequest(rws, MSPIssuers{"TokenOrg"}, "tx1", "zkat", []interface{}{issue}, []byte("request")))
+}
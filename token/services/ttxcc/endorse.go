@@ -6,8 +6,10 @@ SPDX-License-Identifier: Apache-2.0
 package ttxcc
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/pkg/errors"
@@ -18,27 +20,55 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/multisig"
 )
 
 type signatureRequest struct {
 	Request []byte
 	TxID    []byte
 	Signer  view.Identity
+	// Deadline is the time by which the flow must complete, zero if there is none
+	Deadline time.Time
 }
 
 func (sr *signatureRequest) MessageToSign() []byte {
 	return append(sr.Request, sr.TxID...)
 }
 
+const (
+	defaultSignatureTimeout    = 60 * time.Second
+	defaultDistributionTimeout = 240 * time.Second
+)
+
 type collectEndorsementsView struct {
 	tx *Transaction
+	// txSent tracks the sessions on which the transaction has already been sent
+	txSent map[string]bool
+	// sessions tracks the sessions opened with the counterparties, to notify them if the flow aborts
+	sessions map[string]view.Session
 }
 
 func NewCollectEndorsementsView(tx *Transaction) *collectEndorsementsView {
 	return &collectEndorsementsView{tx: tx}
 }
 
+// Call collects the endorsements of the transaction and distributes it.
+// If any step fails or the deadline set with WithTimeout is exceeded, the tokens locked by the transaction
+// are released and the counterparties contacted so far are notified that the transaction has been aborted.
 func (c *collectEndorsementsView) Call(context view.Context) (interface{}, error) {
+	if c.tx.opts != nil && c.tx.opts.timeout > 0 {
+		c.tx.Deadline = time.Now().Add(c.tx.opts.timeout)
+	}
+	res, err := c.collect(context)
+	if err != nil {
+		c.abort(err)
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *collectEndorsementsView) collect(context view.Context) (interface{}, error) {
 	// Store transient
 	err := c.tx.storeTransient()
 	if err != nil {
@@ -62,21 +92,29 @@ func (c *collectEndorsementsView) Call(context view.Context) (interface{}, error
 
 	// 2. Audit
 	if !c.tx.opts.auditor.IsNone() {
-		_, err := context.RunView(newAuditingViewInitiator(c.tx))
+		timeout, err := c.timeout(defaultSignatureTimeout)
+		if err != nil {
+			return nil, err
+		}
+		auditing := newAuditingViewInitiator(c.tx)
+		auditing.timeout = timeout
+		_, err = context.RunView(auditing)
+		if auditing.session != nil {
+			c.track(auditing.session)
+		}
 		if err != nil {
 			return nil, errors.WithMessagef(err, "failed requesting auditing from [%s]", c.tx.opts.auditor.String())
 		}
 		distributionList = append(distributionList, c.tx.opts.auditor)
 	}
 
-	// 3. Endorse and return the Fabric transaction envelope
-	env, err := c.callChaincode(context)
-	if err != nil {
+	// 3. Have the token request endorsed by the backend
+	if err := c.endorseWithTimeout(context); err != nil {
 		return nil, err
 	}
 
-	// Distribute Env to all parties
-	if err := c.distributeEnv(context, env, distributionList); err != nil {
+	// Distribute the endorsed transaction to all parties
+	if err := c.distribute(context, distributionList); err != nil {
 		return nil, err
 	}
 
@@ -115,7 +153,7 @@ func (c *collectEndorsementsView) requestSignaturesOnIssues(context view.Context
 			continue
 		}
 
-		session, err := context.GetSession(context.Initiator(), party)
+		session, err := c.session(context, party)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed getting session")
 		}
@@ -123,9 +161,10 @@ func (c *collectEndorsementsView) requestSignaturesOnIssues(context view.Context
 		ch := session.Receive()
 
 		signatureRequest := &signatureRequest{
-			Request: requestRaw,
-			TxID:    []byte(c.tx.ID()),
-			Signer:  party,
+			Request:  requestRaw,
+			TxID:     []byte(c.tx.ID()),
+			Signer:   party,
+			Deadline: c.tx.Deadline,
 		}
 		signatureRequestRaw, err := json.Marshal(signatureRequest)
 		if err != nil {
@@ -136,13 +175,11 @@ func (c *collectEndorsementsView) requestSignaturesOnIssues(context view.Context
 			return nil, errors.Wrap(err, "failed sending transaction content")
 		}
 
-		var msg *view.Message
-		select {
-		case msg = <-ch:
-			logger.Debugf("collect signatures on issue: reply received from [%s]", party)
-		case <-time.After(60 * time.Second):
-			return nil, errors.Errorf("Timeout from party %s", party)
+		msg, err := c.receive(ch, party, defaultSignatureTimeout)
+		if err != nil {
+			return nil, err
 		}
+		logger.Debugf("collect signatures on issue: reply received from [%s]", party)
 		if msg.Status == view.ERROR {
 			return nil, errors.New(string(msg.Payload))
 		}
@@ -177,86 +214,34 @@ func (c *collectEndorsementsView) requestSignaturesOnTransfers(context view.Cont
 	for i, transfer := range transfers {
 		distributionList = append(distributionList, transfer.Senders...)
 		distributionList = append(distributionList, transfer.Receivers...)
+		if !transfer.RedeemIssuer.IsNone() {
+			// the issuer the tokens are redeemed to must know about the transaction
+			distributionList = append(distributionList, transfer.RedeemIssuer)
+		}
 
 		logger.Debugf("collecting signature on [%d]-th request transfer, signers [%d]", i, len(transfer.Senders))
 
 		// contact transfer and ask for the signature unless it is me
 		for _, party := range transfer.Senders {
 			signatureRequest := &signatureRequest{
-				Request: requestRaw,
-				TxID:    []byte(c.tx.ID()),
-				Signer:  party,
+				Request:  requestRaw,
+				TxID:     []byte(c.tx.ID()),
+				Signer:   party,
+				Deadline: c.tx.Deadline,
 			}
 
-			logger.Debugf("collecting signature on request (transfer) from [%s]", party.UniqueID())
-
-			if w := token.GetManagementService(context, token.WithChannel(c.tx.Channel())).WalletManager().OwnerWalletByIdentity(party); w != nil {
-				logger.Debugf("collecting signature on request (transfer) from [%s], it is me!", party.UniqueID())
-				// Sign
-				si, err := w.GetSigner(party)
-				if err != nil {
-					return nil, err
-				}
-				logger.Debugf("signing tx-id [%s,nonce=%s]", c.tx.ID(), base64.StdEncoding.EncodeToString(c.tx.Id.Nonce))
-				sigma, err := si.Sign(signatureRequest.MessageToSign())
-				if err != nil {
-					return nil, err
-				}
-				logger.Debugf("signature verified (me) [%s,%s,%s]",
-					hash.Hashable(signatureRequest.MessageToSign()).String(),
-					hash.Hashable(sigma).String(),
-					party.UniqueID(),
-				)
-
-				c.tx.TokenRequest.AppendSignature(sigma)
-				continue
+			var sigma []byte
+			if multisig.IsMultisig(party) && c.tx.settlement {
+				// the payout of a closed dispute needs the local co-owners only
+				sigma, err = c.signLocally(signatureRequest)
+			} else if multisig.IsMultisig(party) {
+				sigma, err = c.requestMultisigSignature(context, signatureRequest)
+			} else {
+				sigma, err = c.requestSignature(context, signatureRequest, false)
 			}
-			logger.Debugf("collecting signature on request (transfer) from [%s], it is not me, connect to party!", party.UniqueID())
-
-			session, err := context.GetSession(context.Initiator(), party)
-			if err != nil {
-				return nil, errors.Wrap(err, "failed getting session")
-			}
-			// Wait to receive a content back
-			ch := session.Receive()
-
-			signatureRequestRaw, err := json.Marshal(signatureRequest)
 			if err != nil {
 				return nil, err
 			}
-			err = session.Send(signatureRequestRaw)
-			if err != nil {
-				return nil, errors.Wrap(err, "failed sending transaction content")
-			}
-
-			var msg *view.Message
-			select {
-			case msg = <-ch:
-				logger.Debugf("collect signatures on transfer: reply received from [%s]", party)
-			case <-time.After(60 * time.Second):
-				return nil, errors.Errorf("Timeout from party %s", party)
-			}
-			if msg.Status == view.ERROR {
-				return nil, errors.New(string(msg.Payload))
-			}
-
-			sigma := msg.Payload
-
-			verifier, err := c.tx.TokenService().SigService().GetVerifier(party)
-			if err != nil {
-				return nil, errors.Wrapf(err, "failed getting verifier for [%s]", party)
-			}
-			err = verifier.Verify(signatureRequest.MessageToSign(), sigma)
-			if err != nil {
-				return nil, errors.Wrapf(err, "failed verifying signature from [%s]", party)
-			}
-
-			logger.Debugf("signature verified [%s,%s,%s]",
-				hash.Hashable(signatureRequest.MessageToSign()).String(),
-				hash.Hashable(sigma).String(),
-				party.UniqueID(),
-			)
-
 			c.tx.TokenRequest.AppendSignature(sigma)
 		}
 	}
@@ -264,44 +249,150 @@ func (c *collectEndorsementsView) requestSignaturesOnTransfers(context view.Cont
 	return distributionList, nil
 }
 
-func (c *collectEndorsementsView) callChaincode(context view.Context) (*fabric.Envelope, error) {
-	requestRaw, err := c.tx.TokenRequest.RequestToBytes()
+// requestSignature returns the signature of the signer of the passed request, signing locally if the signer is me.
+// If withTx is true, the transaction is sent to the remote signer before the first signature request,
+// so that it can inspect it before endorsing.
+func (c *collectEndorsementsView) requestSignature(context view.Context, signatureRequest *signatureRequest, withTx bool) ([]byte, error) {
+	party := signatureRequest.Signer
+	logger.Debugf("collecting signature on request (transfer) from [%s]", party.UniqueID())
+
+	var si token.Signer
+	var err error
+	if htlc.IsScript(party) {
+		// the signer of an htlc script is registered by the party claiming or reclaiming it, that is me
+		si, err = c.tx.TokenService().SigService().GetSigner(party)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting signer for htlc script")
+		}
+	} else if w := token.GetManagementService(context, token.WithChannel(c.tx.Channel())).WalletManager().OwnerWalletByIdentity(party); w != nil {
+		si, err = w.GetSigner(party)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if si != nil {
+		logger.Debugf("collecting signature on request (transfer) from [%s], it is me!", party.UniqueID())
+		// Sign
+		logger.Debugf("signing tx-id [%s,nonce=%s]", c.tx.ID(), base64.StdEncoding.EncodeToString(c.tx.Id.Nonce))
+		sigma, err := si.Sign(signatureRequest.MessageToSign())
+		if err != nil {
+			return nil, err
+		}
+		logger.Debugf("signature verified (me) [%s,%s,%s]",
+			hash.Hashable(signatureRequest.MessageToSign()).String(),
+			hash.Hashable(sigma).String(),
+			party.UniqueID(),
+		)
+		return sigma, nil
+	}
+	logger.Debugf("collecting signature on request (transfer) from [%s], it is not me, connect to party!", party.UniqueID())
+
+	session, err := c.session(context, party)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed marshalling request")
+		return nil, errors.Wrap(err, "failed getting session")
 	}
+	// Wait to receive a content back
+	ch := session.Receive()
 
-	logger.Debugf("call chaincode for endorsement [nonce=%s]", base64.StdEncoding.EncodeToString(c.tx.Id.Nonce))
+	if withTx && !c.txSent[session.Info().ID] {
+		txRaw, err := c.tx.BytesFor(party)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed marshalling transaction content")
+		}
+		if err := session.Send(txRaw); err != nil {
+			return nil, errors.Wrap(err, "failed sending transaction content")
+		}
+		if c.txSent == nil {
+			c.txSent = map[string]bool{}
+		}
+		c.txSent[session.Info().ID] = true
+	}
 
-	env, err := fabric.GetChannel(context, c.tx.Network(), c.tx.Channel()).Chaincode(c.tx.Namespace()).Endorse(
-		"invoke", requestRaw,
-	).WithInvokerIdentity(c.tx.Signer).WithTxID(c.tx.Payload.Id).Call()
+	signatureRequestRaw, err := json.Marshal(signatureRequest)
 	if err != nil {
 		return nil, err
 	}
+	err = session.Send(signatureRequestRaw)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed sending transaction content")
+	}
 
-	err = c.tx.setEnvelope(env)
+	msg, err := c.receive(ch, party, defaultSignatureTimeout)
 	if err != nil {
 		return nil, err
 	}
+	logger.Debugf("collect signatures on transfer: reply received from [%s]", party)
+	if msg.Status == view.ERROR {
+		return nil, errors.New(string(msg.Payload))
+	}
+
+	sigma := msg.Payload
+
+	verifier, err := c.tx.TokenService().SigService().GetVerifier(party)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting verifier for [%s]", party)
+	}
+	err = verifier.Verify(signatureRequest.MessageToSign(), sigma)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed verifying signature from [%s]", party)
+	}
+
+	logger.Debugf("signature verified [%s,%s,%s]",
+		hash.Hashable(signatureRequest.MessageToSign()).String(),
+		hash.Hashable(sigma).String(),
+		party.UniqueID(),
+	)
+	return sigma, nil
+}
 
-	return env, nil
+// requestMultisigSignature asks all the co-owners of the multisig identity signing the passed request for their signature,
+// and aggregates them. Co-owners that fail to sign are skipped, as long as the threshold is met.
+// Co-owners that are multisig identities themselves are asked recursively.
+func (c *collectEndorsementsView) requestMultisigSignature(context view.Context, signatureRequest *signatureRequest) ([]byte, error) {
+	id := &multisig.Identity{}
+	if err := id.Deserialize(signatureRequest.Signer); err != nil {
+		return nil, errors.WithMessage(err, "failed deserializing multisig identity")
+	}
+	sig := &multisig.Signature{Signatures: make([][]byte, len(id.Identities))}
+	var collected uint64
+	for i, coOwner := range id.Identities {
+		sr := *signatureRequest
+		sr.Signer = coOwner
+		var sigma []byte
+		var err error
+		if multisig.IsMultisig(coOwner) {
+			sigma, err = c.requestMultisigSignature(context, &sr)
+		} else {
+			sigma, err = c.requestSignature(context, &sr, true)
+		}
+		if err != nil {
+			logger.Warnf("failed collecting signature of co-owner [%s]: [%s]", coOwner, err)
+			continue
+		}
+		sig.Signatures[i] = sigma
+		collected++
+	}
+	if collected < id.Threshold {
+		return nil, errors.Errorf("not enough co-owners signed, expected at least [%d], got [%d]", id.Threshold, collected)
+	}
+	return sig.Serialize()
 }
 
-func (c *collectEndorsementsView) distributeEnv(context view.Context, env *fabric.Envelope, distributionList []view.Identity) error {
-	if env == nil {
-		return errors.New("fabric transaction envelope is empty")
+// signLocally signs the passed request with the signer registered for its signer
+func (c *collectEndorsementsView) signLocally(signatureRequest *signatureRequest) ([]byte, error) {
+	si, err := c.tx.TokenService().SigService().GetSigner(signatureRequest.Signer)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting signer for [%s]", signatureRequest.Signer)
 	}
+	return si.Sign(signatureRequest.MessageToSign())
+}
 
+func (c *collectEndorsementsView) distribute(context view.Context, distributionList []view.Identity) error {
 	// double check that the transaction is valid
 	if err := c.tx.Verify(); err != nil {
 		return errors.Wrap(err, "failed verifying transaction content before distributing it")
 	}
 
-	txRaw, err := c.tx.Bytes()
-	if err != nil {
-		return errors.Wrap(err, "failed marshalling transaction content")
-	}
-
 	// Compress distributionList
 	type distributionListEntry struct {
 		IsMe     bool
@@ -309,7 +400,7 @@ func (c *collectEndorsementsView) distributeEnv(context view.Context, env *fabri
 		ID       view.Identity
 	}
 	var distributionListCompressed []distributionListEntry
-	for _, party := range distributionList {
+	for _, party := range expandMultisigs(expandScripts(distributionList)) {
 		if party.IsNone() {
 			// In the case of a redeem
 			continue
@@ -351,54 +442,57 @@ func (c *collectEndorsementsView) distributeEnv(context view.Context, env *fabri
 			logger.Debugf("This is me [%s], endorse locally", entry.ID.UniqueID())
 
 			// Inform the vault about the transaction
-			ch := fabric.GetChannel(context, c.tx.Network(), c.tx.Channel())
-			rws, err := ch.Vault().GetRWSet(c.tx.ID(), env.Results())
-			if err != nil {
-				return errors.WithMessagef(err, "failed getting rwset for tx [%s]", c.tx.ID())
-			}
-			rws.Done()
-
-			rawEnv, err := env.Bytes()
-			if err != nil {
-				return errors.WithMessagef(err, "failed marshalling tx env [%s]", c.tx.ID())
-			}
-			if err := ch.Vault().StoreEnvelope(env.TxID(), rawEnv); err != nil {
-				return errors.WithMessagef(err, "failed storing tx env [%s]", c.tx.ID())
+			if err := c.tx.store(context); err != nil {
+				return err
 			}
+			c.tx.appendToHistory()
 
 			continue
 		} else {
 			logger.Debugf("This is not me [%s], ask endorse", entry.ID.UniqueID())
 		}
 
-		session, err := context.GetSession(context.Initiator(), entry.ID)
-		if err != nil {
-			return errors.Wrap(err, "failed getting session")
+		if err := c.distributeTo(context, entry.ID); err != nil {
+			if !c.tx.settlement {
+				return err
+			}
+			// the payout of a closed dispute does not depend on the counterparty being reachable
+			logger.Warnf("failed distributing settlement [%s] to [%s]: [%s]", c.tx.ID(), entry.ID.UniqueID(), err)
 		}
-		// Wait to receive a content back
-		ch := session.Receive()
+	}
 
-		// Send the content
-		err = session.Send(txRaw)
-		if err != nil {
-			return errors.Wrap(err, "failed sending transaction content")
-		}
+	return nil
+}
 
-		var msg *view.Message
-		select {
-		case msg = <-ch:
-			logger.Debugf("collect ack on distributed env: reply received from [%s]", entry.ID)
-		case <-time.After(240 * time.Second):
-			return errors.Errorf("Timeout from party %s", entry.ID)
-		}
-		if msg.Status == view.ERROR {
-			return errors.New(string(msg.Payload))
-		}
-		// TODO: Check ack
+func (c *collectEndorsementsView) distributeTo(context view.Context, party view.Identity) error {
+	session, err := c.session(context, party)
+	if err != nil {
+		return errors.Wrap(err, "failed getting session")
+	}
+	// Wait to receive a content back
+	ch := session.Receive()
 
-		logger.Debugf("collectEndorsementsView: collected signature from %s", entry.ID)
+	// Send the content, without the private memos of the other parties
+	txRaw, err := c.tx.BytesFor(party)
+	if err != nil {
+		return errors.Wrap(err, "failed marshalling transaction content")
+	}
+	err = session.Send(txRaw)
+	if err != nil {
+		return errors.Wrap(err, "failed sending transaction content")
+	}
+
+	msg, err := c.receive(ch, party, defaultDistributionTimeout)
+	if err != nil {
+		return err
+	}
+	logger.Debugf("collect ack on distributed env: reply received from [%s]", party)
+	if msg.Status == view.ERROR {
+		return errors.New(string(msg.Payload))
 	}
+	// TODO: Check ack
 
+	logger.Debugf("collectEndorsementsView: collected signature from %s", party)
 	return nil
 }
 
@@ -406,12 +500,114 @@ func (c *collectEndorsementsView) requestBytes() ([]byte, error) {
 	return c.tx.TokenRequest.MarshallToSign()
 }
 
+// endorseWithTimeout has the transaction endorsed by its backend giving up after the step timeout, if any, or the deadline.
+// The endorsement works on a copy of the transaction, so that an endorsement completing after the timeout,
+// when the transaction has been aborted already, is discarded.
+func (c *collectEndorsementsView) endorseWithTimeout(context view.Context) error {
+	backend, err := c.tx.backend()
+	if err != nil {
+		return err
+	}
+	timeout, err := c.timeout(0)
+	if err != nil {
+		return err
+	}
+	if timeout == 0 {
+		return backend.Endorse(context, c.tx)
+	}
+	payload := *c.tx.Payload
+	endorsed := &Transaction{
+		Payload:    &payload,
+		sp:         c.tx.sp,
+		opts:       c.tx.opts,
+		swap:       c.tx.swap,
+		settlement: c.tx.settlement,
+	}
+	// computing the id updates the payload, do it before the endorsement runs concurrently with the flow
+	txID := c.tx.ID()
+	ch := make(chan error, 1)
+	go func() {
+		ch <- backend.Endorse(context, endorsed)
+	}()
+	select {
+	case err := <-ch:
+		if err != nil {
+			return err
+		}
+		c.tx.Payload = endorsed.Payload
+		return nil
+	case <-time.After(timeout):
+		go func() {
+			if err := <-ch; err == nil {
+				logger.Warnf("discarding endorsement of transaction [%s] completed after the timeout", txID)
+			}
+		}()
+		return errors.Errorf("timeout waiting for the endorsement of transaction [%s]", txID)
+	}
+}
+
+// timeout returns how long the current step can take: the step timeout set with WithStepTimeout,
+// or the passed default, bounded by the deadline. It returns an error if the deadline has been exceeded.
+// A zero result means that the step is not bounded.
+func (c *collectEndorsementsView) timeout(defaultTimeout time.Duration) (time.Duration, error) {
+	timeout := defaultTimeout
+	if c.tx.opts != nil && c.tx.opts.stepTimeout > 0 {
+		timeout = c.tx.opts.stepTimeout
+	}
+	return c.tx.boundedTimeout(timeout)
+}
+
+// receive waits for a message from the passed party for at most the timeout of the current step
+func (c *collectEndorsementsView) receive(ch <-chan *view.Message, party view.Identity, defaultTimeout time.Duration) (*view.Message, error) {
+	timeout, err := c.timeout(defaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case msg := <-ch:
+		return msg, nil
+	case <-time.After(timeout):
+		return nil, errors.Errorf("Timeout from party %s", party)
+	}
+}
+
+// session returns the session with the passed party and tracks it
+func (c *collectEndorsementsView) session(context view.Context, party view.Identity) (view.Session, error) {
+	session, err := context.GetSession(context.Initiator(), party)
+	if err != nil {
+		return nil, err
+	}
+	c.track(session)
+	return session, nil
+}
+
+func (c *collectEndorsementsView) track(session view.Session) {
+	if c.sessions == nil {
+		c.sessions = map[string]view.Session{}
+	}
+	c.sessions[session.Info().ID] = session
+}
+
+// abort releases the tokens locked by the transaction and notifies the counterparties contacted so far,
+// so that their views stop waiting for this flow
+func (c *collectEndorsementsView) abort(cause error) {
+	logger.Warnf("aborting transaction [%s]: [%s]", c.tx.ID(), cause)
+	c.tx.Release()
+	msg := []byte(fmt.Sprintf("transaction [%s] aborted: %s", c.tx.ID(), cause))
+	for _, session := range c.sessions {
+		if err := session.SendError(msg); err != nil {
+			logger.Warnf("failed notifying abort of transaction [%s] to [%s]: [%s]", c.tx.ID(), session.Info().Caller, err)
+		}
+	}
+}
+
 type receiveTransactionView struct {
 	network string
+	timeout time.Duration
 }
 
 func NewReceiveTransactionView(network string) *receiveTransactionView {
-	return &receiveTransactionView{network: network}
+	return &receiveTransactionView{network: network, timeout: defaultDistributionTimeout}
 }
 
 func (f *receiveTransactionView) Call(context view.Context) (interface{}, error) {
@@ -428,7 +624,7 @@ func (f *receiveTransactionView) Call(context view.Context) (interface{}, error)
 			return nil, err
 		}
 		return tx, nil
-	case <-time.After(240 * time.Second):
+	case <-time.After(f.timeout):
 		return nil, errors.New("timeout reached")
 	}
 }
@@ -448,11 +644,15 @@ func (s *endorseView) Call(context view.Context) (interface{}, error) {
 	for range requestsToBeSigned {
 		logger.Debugf("Receiving signature request...")
 		sessionChannel := session.Receive()
+		timeout, err := s.tx.boundedTimeout(defaultSignatureTimeout)
+		if err != nil {
+			return nil, err
+		}
 		var msg *view.Message
 		select {
 		case msg = <-sessionChannel:
 			logger.Debug("message received from %s", session.Info().Caller)
-		case <-time.After(60 * time.Second):
+		case <-time.After(timeout):
 			return nil, errors.Errorf("Timeout from party %s", session.Info().Caller)
 		}
 		if msg.Status == view.ERROR {
@@ -461,10 +661,17 @@ func (s *endorseView) Call(context view.Context) (interface{}, error) {
 
 		// TODO: check what is signed...
 		signatureRequest := &signatureRequest{}
-		err := json.Unmarshal(msg.Payload, signatureRequest)
+		err = json.Unmarshal(msg.Payload, signatureRequest)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed unmarshalling signature request")
 		}
+		if err := s.checkSignatureRequest(signatureRequest); err != nil {
+			return nil, err
+		}
+		if !signatureRequest.Deadline.IsZero() {
+			// bound the next steps by the deadline of the initiator
+			s.tx.Deadline = signatureRequest.Deadline
+		}
 		if !fabric.GetFabricNetworkService(context, s.tx.Network()).LocalMembership().IsMe(signatureRequest.Signer) {
 			return nil, errors.Errorf("identity [%s] is not me", signatureRequest.Signer.UniqueID())
 		}
@@ -486,38 +693,31 @@ func (s *endorseView) Call(context view.Context) (interface{}, error) {
 	// Receive transaction with envelope
 	logger.Debugf("Receive transaction with envelope...")
 	// TODO: this might also happen multiple times because of the pseudonym. Avoid this by identity resolution at the sender
-	tx, err := ReceiveTransaction(context)
+	timeout, err := s.tx.boundedTimeout(defaultDistributionTimeout)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := receiveTransaction(context, timeout)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed receiving transaction")
 	}
-
-	// Process Fabric Envelope
-	logger.Debugf("Processes Fabric Envelope with ID [%s]", tx.ID())
-	env := tx.Payload.FabricEnvelope
-	if env == nil {
-		return nil, errors.Errorf("expected fabric envelope")
+	if err := tx.registerScripts(); err != nil {
+		return nil, err
+	}
+	if err := tx.registerMultisigs(); err != nil {
+		return nil, err
 	}
 
+	// Process the endorsed transaction
+	logger.Debugf("Processes endorsed transaction with ID [%s]", tx.ID())
 	err = tx.storeTransient()
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed storing transient")
 	}
-
-	ch := fabric.GetChannel(context, tx.Network(), tx.Channel())
-	rws, err := ch.Vault().GetRWSet(tx.ID(), env.Results())
-	if err != nil {
-		return nil, errors.WithMessagef(err, "failed getting rwset for tx [%s]", tx.ID())
-	}
-	rws.Done()
-
-	// TODO: remove this
-	rawEnv, err := env.Bytes()
-	if err != nil {
-		return nil, errors.WithMessagef(err, "failed marshalling tx env [%s]", tx.ID())
-	}
-	if err := ch.Vault().StoreEnvelope(env.TxID(), rawEnv); err != nil {
-		return nil, errors.WithMessagef(err, "failed storing tx env [%s]", tx.ID())
+	if err := tx.store(context); err != nil {
+		return nil, err
 	}
+	tx.appendToHistory()
 
 	// Send the proposal response back
 	logger.Debugf("Send the ack")
@@ -532,7 +732,7 @@ func (s *endorseView) Call(context view.Context) (interface{}, error) {
 func (s *endorseView) requestsToBeSigned() ([]*token.Transfer, error) {
 	var res []*token.Transfer
 	for _, transfer := range s.tx.TokenRequest.Transfers() {
-		for _, sender := range transfer.Senders {
+		for _, sender := range expandMultisigs(transfer.Senders) {
 			if s.tx.TokenService().WalletManager().OwnerWalletByIdentity(sender) != nil {
 				res = append(res, transfer)
 			}
@@ -541,6 +741,22 @@ func (s *endorseView) requestsToBeSigned() ([]*token.Transfer, error) {
 	return res, nil
 }
 
+// checkSignatureRequest checks, for transactions declaring swaps, that the request to be signed
+// is the one negotiated and validated locally
+func (s *endorseView) checkSignatureRequest(sr *signatureRequest) error {
+	if len(s.tx.TokenRequest.Actions.Swaps) == 0 {
+		return nil
+	}
+	expected, err := s.tx.TokenRequest.MarshallToSign()
+	if err != nil {
+		return errors.Wrap(err, "failed marshalling request to sign")
+	}
+	if !bytes.Equal(expected, sr.Request) || string(sr.TxID) != s.tx.ID() {
+		return errors.Errorf("signature request does not match the negotiated swap [%s]", s.tx.ID())
+	}
+	return nil
+}
+
 func NewEndorseView(tx *Transaction) *endorseView {
 	return &endorseView{tx: tx}
 }
@@ -0,0 +1,201 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	api3 "github.com/hyperledger-labs/fabric-smart-client/platform/fabric/api"
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	registry2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/registry"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
+)
+
+type fakeTxManager struct {
+	api3.TransactionManager
+}
+
+func (f *fakeTxManager) ComputeTxID(id *api3.TxID) string {
+	return "tx1"
+}
+
+type fakeFNS struct {
+	api3.FabricNetworkService
+}
+
+func (f *fakeFNS) TransactionManager() api3.TransactionManager {
+	return &fakeTxManager{}
+}
+
+func (f *fakeFNS) Channel(id string) (api3.Channel, error) {
+	return &fakeChannel{}, nil
+}
+
+type fakeChannel struct {
+	api3.Channel
+}
+
+func (f *fakeChannel) MetadataService() api3.MetadataService {
+	return &fakeMetadataService{}
+}
+
+type fakeMetadataService struct {
+	api3.MetadataService
+}
+
+func (f *fakeMetadataService) StoreTransient(txid string, transientMap api3.TransientMap) error {
+	return nil
+}
+
+type fakeFNSProvider struct{}
+
+func (f *fakeFNSProvider) FabricNetworkService(id string) (api3.FabricNetworkService, error) {
+	return &fakeFNS{}, nil
+}
+
+// fakeTMS has no wallets, every party is remote
+type fakeTMS struct {
+	api2.TokenManagerService
+}
+
+func (f *fakeTMS) IssuerWalletByIdentity(identity view.Identity) api2.IssuerWallet {
+	return nil
+}
+
+type fakeTMSProvider struct{}
+
+func (f *fakeTMSProvider) GetTokenManagerService(network string, channel string, namespace string, publicParamsFetcher api2.PublicParamsFetcher) (api2.TokenManagerService, error) {
+	return &fakeTMS{}, nil
+}
+
+type fakeNormalizer struct{}
+
+func (f *fakeNormalizer) Normalize(opt *token.ServiceOptions) *token.ServiceOptions {
+	return opt
+}
+
+// fakeSelectorManager records the transactions whose tokens are unlocked
+type fakeSelectorManager struct {
+	unlocked []string
+}
+
+func (f *fakeSelectorManager) NewSelector(id string) (token.Selector, error) {
+	return nil, nil
+}
+
+func (f *fakeSelectorManager) Unlock(txID string) error {
+	f.unlocked = append(f.unlocked, txID)
+	return nil
+}
+
+func (f *fakeSelectorManager) SelectorManager(network string, channel string, namespace string) token.SelectorManager {
+	return f
+}
+
+type viewContext = view.Context
+
+// fakeContext gives the passed sessions to the parties they are keyed by
+type fakeContext struct {
+	viewContext
+	sp       view2.ServiceProvider
+	sessions map[string]*fakeSession
+}
+
+func (f *fakeContext) GetService(v interface{}) (interface{}, error) {
+	return f.sp.GetService(v)
+}
+
+func (f *fakeContext) Initiator() view.View {
+	return nil
+}
+
+func (f *fakeContext) GetSession(caller view.View, party view.Identity) (view.Session, error) {
+	return f.sessions[string(party)], nil
+}
+
+// slowBackend endorses a transaction after delay, then signals done
+type slowBackend struct {
+	chaincodeBackend
+	delay time.Duration
+	done  chan struct{}
+}
+
+func (s *slowBackend) Endorse(context view.Context, tx *Transaction) error {
+	time.Sleep(s.delay)
+	tx.Payload.EndorserTransaction = []byte("endorsed")
+	close(s.done)
+	return nil
+}
+
+func newEndorsementTest(t *testing.T, backend string, timeout time.Duration) (*Transaction, *fakeContext, *fakeSelectorManager) {
+	registry := registry2.New()
+	selectors := &fakeSelectorManager{}
+	assert.NoError(t, registry.RegisterService(&fakeFNSProvider{}))
+	assert.NoError(t, registry.RegisterService(token.NewManagementServiceProvider(registry, &fakeTMSProvider{}, &fakeNormalizer{}, nil, nil, selectors, nil)))
+	tx := &Transaction{
+		Payload: &Payload{
+			Channel:      "ch",
+			Transient:    fabric.TransientMap{},
+			TokenRequest: token.NewRequest(nil, "tx1"),
+			Backend:      backend,
+		},
+		sp:   registry,
+		opts: &txOptions{timeout: timeout},
+	}
+	return tx, &fakeContext{sp: registry, sessions: map[string]*fakeSession{}}, selectors
+}
+
+func TestCollectEndorsementsDeadline(t *testing.T) {
+	// the issuer does not answer before the deadline
+	tx, context, selectors := newEndorsementTest(t, "", 50*time.Millisecond)
+	tx.TokenRequest.Metadata.Issues = append(tx.TokenRequest.Metadata.Issues, api2.IssueMetadata{Issuer: view.Identity("issuer")})
+	issuer := newFakeSession("sigma", time.Hour)
+	context.sessions["issuer"] = issuer
+
+	start := time.Now()
+	_, err := NewCollectEndorsementsView(tx).Call(context)
+	assert.EqualError(t, err, "Timeout from party "+view.Identity("issuer").String())
+	assert.WithinDuration(t, start.Add(50*time.Millisecond), time.Now(), time.Second, "the wait is bounded by the deadline, not by the default signature timeout")
+	assert.False(t, tx.Deadline.IsZero())
+
+	// the tokens are released and the issuer is notified of the abort
+	assert.Equal(t, []string{"tx1"}, selectors.unlocked)
+	assert.Len(t, issuer.errors, 1)
+	assert.Contains(t, string(issuer.errors[0]), "transaction [tx1] aborted: Timeout from party")
+}
+
+func TestCollectEndorsementsLateEndorsement(t *testing.T) {
+	late := &slowBackend{delay: 200 * time.Millisecond, done: make(chan struct{})}
+	RegisterBackend("late", late)
+
+	tx, context, selectors := newEndorsementTest(t, "late", 50*time.Millisecond)
+	_, err := NewCollectEndorsementsView(tx).Call(context)
+	assert.EqualError(t, err, "timeout waiting for the endorsement of transaction [tx1]")
+	assert.Equal(t, []string{"tx1"}, selectors.unlocked)
+
+	// the endorsement completing after the abort does not change the transaction
+	<-late.done
+	assert.Nil(t, tx.Payload.EndorserTransaction)
+
+	// an endorsement in time is kept
+	onTime := &slowBackend{done: make(chan struct{})}
+	RegisterBackend("ontime", onTime)
+	tx, context, _ = newEndorsementTest(t, "ontime", time.Second)
+	c := NewCollectEndorsementsView(tx)
+	assert.NoError(t, c.endorseWithTimeout(context))
+	assert.Equal(t, []byte("endorsed"), tx.Payload.EndorserTransaction)
+
+	// once the deadline is exceeded, no step starts
+	tx.Deadline = time.Now().Add(-time.Second)
+	assert.EqualError(t, c.endorseWithTimeout(context), "deadline exceeded for transaction [tx1]")
+}
@@ -6,8 +6,13 @@ SPDX-License-Identifier: Apache-2.0
 package ttxcc
 
 import (
+	"sync"
+
 	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/ownerdb"
 )
 
 type finalityView struct {
@@ -16,11 +21,60 @@ type finalityView struct {
 }
 
 func (f *finalityView) Call(context view.Context) (interface{}, error) {
+	f.tx.watchFinality(context)
 	fs := fabric.GetChannel(context, f.tx.Network(), f.tx.Channel()).Finality()
+	var err error
 	if len(f.endpoints) != 0 {
-		return nil, fs.IsFinalForParties(f.tx.ID(), f.endpoints...)
+		err = fs.IsFinalForParties(f.tx.ID(), f.endpoints...)
+	} else {
+		err = fs.IsFinal(f.tx.ID())
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.tx.setHistoryStatus(ownerdb.Confirmed)
+	return nil, nil
+}
+
+// finalityWatches are the transactions whose final status is being watched
+var finalityWatches sync.Map
+
+// watchFinality waits, in background, for the final status of the transaction to be known to the vault, and records it
+// in the owner history. If the transaction is invalid, a TxInvalidated event is raised, valid transactions are notified
+// by the vault processor once committed. A transaction is watched at most once at a time.
+func (t *Transaction) watchFinality(context view.Context) {
+	key := t.Network() + ":" + t.Channel() + ":" + t.ID()
+	if _, watched := finalityWatches.LoadOrStore(key, true); watched {
+		return
 	}
-	return nil, fs.IsFinal(f.tx.ID())
+	ch := fabric.GetChannel(context, t.Network(), t.Channel())
+	go func() {
+		defer finalityWatches.Delete(key)
+
+		err := ch.Finality().IsFinal(t.ID())
+		vc, _, vErr := ch.Vault().Status(t.ID())
+		if vErr != nil {
+			logger.Warnf("failed getting status of [%s]: [%s]", t.ID(), vErr)
+			return
+		}
+		switch vc {
+		case fabric.Valid:
+			t.setHistoryStatus(ownerdb.Confirmed)
+		case fabric.Invalid:
+			t.setHistoryStatus(ownerdb.Deleted)
+			histories, err := t.histories(false)
+			if err != nil {
+				logger.Warnf("failed computing wallets involved in [%s]: [%s]", t.ID(), err)
+			}
+			var walletIDs []string
+			for walletID := range histories {
+				walletIDs = append(walletIDs, walletID)
+			}
+			t.TokenService().EventService().Notify(&token.Event{Type: token.TxInvalidated, TxID: t.ID(), WalletIDs: walletIDs})
+		default:
+			logger.Warnf("final status of [%s] unknown: [%v]", t.ID(), err)
+		}
+	}()
 }
 
 func NewFinalityView(tx *Transaction) *finalityView {
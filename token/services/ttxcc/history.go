@@ -0,0 +1,201 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/ownerdb"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// walletHistory collects what a transaction moves to and from one of my owner wallets
+type walletHistory struct {
+	wallet         *token.OwnerWallet
+	amounts        map[string]*big.Int
+	counterparties []view.Identity
+	memos          map[string][]byte
+}
+
+// appendToHistory records, in the owner db of each of my owner wallets involved in this transaction,
+// the amount of each token type received or sent. Failures are logged, they do not stop the transaction.
+func (t *Transaction) appendToHistory() {
+	histories, err := t.histories(true)
+	if err != nil {
+		logger.Warnf("failed computing history records for [%s]: [%s]", t.ID(), err)
+		return
+	}
+	for _, h := range histories {
+		db, err := t.ownerDB(h.wallet)
+		if err != nil {
+			logger.Warnf("failed getting owner db for wallet [%s]: [%s]", h.wallet.ID(), err)
+			return
+		}
+		var records []*ownerdb.TransactionRecord
+		for typ, amount := range h.amounts {
+			if amount.Sign() == 0 {
+				continue
+			}
+			records = append(records, &ownerdb.TransactionRecord{
+				Type:           typ,
+				Amount:         amount,
+				Counterparties: h.counterparties,
+				Memos:          h.memos,
+			})
+		}
+		if len(records) == 0 {
+			continue
+		}
+		if err := db.Append(t.ID(), records); err != nil {
+			logger.Warnf("failed appending history records for [%s] to wallet [%s]: [%s]", t.ID(), h.wallet.ID(), err)
+		}
+	}
+}
+
+// setHistoryStatus sets the status of this transaction in the owner db of each of my owner wallets involved in it
+func (t *Transaction) setHistoryStatus(status ownerdb.Status) {
+	histories, err := t.histories(false)
+	if err != nil {
+		logger.Warnf("failed computing wallets involved in [%s]: [%s]", t.ID(), err)
+		return
+	}
+	for _, h := range histories {
+		db, err := t.ownerDB(h.wallet)
+		if err != nil {
+			logger.Warnf("failed getting owner db for wallet [%s]: [%s]", h.wallet.ID(), err)
+			return
+		}
+		if err := db.SetStatus(t.ID(), status); err != nil {
+			logger.Warnf("failed setting status of [%s] for wallet [%s]: [%s]", t.ID(), h.wallet.ID(), err)
+		}
+	}
+}
+
+// histories returns, for each of my owner wallets owning an input or an output of this transaction,
+// the net amount per token type and the counterparties.
+// Amounts are computed only if withAmounts is true, in that case the inputs must still be in the vault.
+func (t *Transaction) histories(withAmounts bool) (map[string]*walletHistory, error) {
+	outputs, err := t.Outputs()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting outputs")
+	}
+	inputs, err := t.Inputs()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting inputs")
+	}
+
+	wm := t.TokenService().WalletManager()
+	histories := map[string]*walletHistory{}
+	var owners []view.Identity
+	history := func(owner view.Identity) *walletHistory {
+		if owner.IsNone() {
+			return nil
+		}
+		owners = append(owners, owner)
+		w := wm.OwnerWalletByIdentity(owner)
+		if w == nil {
+			return nil
+		}
+		h, ok := histories[w.ID()]
+		if !ok {
+			h = &walletHistory{wallet: w, amounts: map[string]*big.Int{}, memos: map[string][]byte{}}
+			histories[w.ID()] = h
+		}
+		return h
+	}
+	add := func(h *walletHistory, typ string, q string, sign int) error {
+		quantity, err := token2.ToQuantity(q, 64)
+		if err != nil {
+			return errors.WithMessagef(err, "invalid quantity [%s]", q)
+		}
+		amount, ok := h.amounts[typ]
+		if !ok {
+			amount = big.NewInt(0)
+			h.amounts[typ] = amount
+		}
+		if sign < 0 {
+			amount.Sub(amount, quantity.ToBigInt())
+		} else {
+			amount.Add(amount, quantity.ToBigInt())
+		}
+		return nil
+	}
+
+	for i := 0; i < outputs.Count(); i++ {
+		output := outputs.At(i)
+		h := history(output.Owner)
+		if h == nil || !withAmounts {
+			continue
+		}
+		if err := add(h, output.Type, output.Quantity, 1); err != nil {
+			return nil, err
+		}
+		for k, v := range output.Memos {
+			h.memos[k] = v
+		}
+	}
+	var spent []*token2.Id
+	var spenders []*walletHistory
+	for i := 0; i < inputs.Count(); i++ {
+		input := inputs.At(i)
+		h := history(input.Owner)
+		if h == nil || !withAmounts {
+			continue
+		}
+		spent = append(spent, input.Id)
+		spenders = append(spenders, h)
+	}
+	if len(spent) != 0 {
+		toks, err := t.TokenService().Vault().NewQueryEngine().GetTokens(spent...)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed getting spent tokens")
+		}
+		for i, tok := range toks {
+			if err := add(spenders[i], tok.Type, tok.Quantity, -1); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	requestMemos, err := t.TokenRequest.Memos()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting memos")
+	}
+	for _, h := range histories {
+		for k, v := range requestMemos {
+			h.memos[k] = v
+		}
+		for _, owner := range owners {
+			if h.wallet.Contains(owner) || containsIdentity(h.counterparties, owner) {
+				continue
+			}
+			h.counterparties = append(h.counterparties, owner)
+		}
+	}
+	return histories, nil
+}
+
+func (t *Transaction) ownerDB(w *token.OwnerWallet) (*ownerdb.OwnerDB, error) {
+	s, err := t.sp.GetService(&ownerdb.Manager{})
+	if err != nil {
+		return nil, errors.WithMessage(err, "owner db manager not found")
+	}
+	return s.(*ownerdb.Manager).OwnerDB(w)
+}
+
+func containsIdentity(ids []view.Identity, id view.Identity) bool {
+	for _, e := range ids {
+		if e.Equal(id) {
+			return true
+		}
+	}
+	return false
+}
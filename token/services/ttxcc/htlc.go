@@ -0,0 +1,173 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/pkg/errors"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/htlc"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// Lock adds a transfer of value tokens of type typ, owned by wallet, to an htlc script.
+// The recipient can claim the tokens by revealing the preimage of hash before timeout elapses,
+// afterwards the tokens can be reclaimed by wallet.
+func (t *Transaction) Lock(wallet *token.OwnerWallet, typ string, value uint64, recipient view.Identity, timeout time.Duration, hash []byte, opts ...token.TransferOption) (view.Identity, error) {
+	sender, err := wallet.GetRecipientIdentity()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting sender identity, wallet [%s]", wallet.ID())
+	}
+	script := &htlc.Script{
+		Sender:    sender,
+		Recipient: recipient,
+		Hash:      hash,
+		Deadline:  time.Now().Add(timeout),
+	}
+	if err := script.Validate(time.Now()); err != nil {
+		return nil, errors.WithMessage(err, "invalid htlc script")
+	}
+	owner, err := script.Serialize()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed serializing htlc script")
+	}
+
+	// the audit info of the script carries those of both parties
+	senderAuditInfo, err := wallet.GetAuditInfo(sender)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting audit info for sender, wallet [%s]", wallet.ID())
+	}
+	sigService := view2.GetSigService(t.sp)
+	recipientAuditInfo, err := sigService.GetAuditInfo(recipient)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting audit info for recipient [%s]", recipient)
+	}
+	scriptInfo, err := (&htlc.ScriptInfo{Sender: senderAuditInfo, Recipient: recipientAuditInfo}).Serialize()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed serializing htlc script info")
+	}
+	if err := sigService.RegisterAuditInfo(owner, scriptInfo); err != nil {
+		return nil, errors.WithMessage(err, "failed registering audit info for htlc script")
+	}
+
+	if err := t.Transfer(wallet, typ, []uint64{value}, []view.Identity{owner}, opts...); err != nil {
+		return nil, errors.WithMessage(err, "failed adding lock transfer")
+	}
+	return owner, nil
+}
+
+// Claim adds a transfer that spends the token with the passed id, locked by an htlc script, to the recipient
+// of the script, held by wallet. The preimage of the script hash must be provided before the deadline.
+func (t *Transaction) Claim(wallet *token.OwnerWallet, id *token2.Id, preimage []byte) error {
+	return t.spendScript(wallet, id, preimage)
+}
+
+// Reclaim adds a transfer that spends the token with the passed id, locked by an htlc script, back to the sender
+// of the script, held by wallet. It is possible only once the deadline has passed.
+func (t *Transaction) Reclaim(wallet *token.OwnerWallet, id *token2.Id) error {
+	return t.spendScript(wallet, id, nil)
+}
+
+func (t *Transaction) spendScript(wallet *token.OwnerWallet, id *token2.Id, preimage []byte) error {
+	tokens, err := t.TokenService().Vault().NewQueryEngine().GetTokens(id)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting token [%s]", id)
+	}
+	if len(tokens) != 1 {
+		return errors.Errorf("token [%s] not found", id)
+	}
+	tok := tokens[0]
+	script := &htlc.Script{}
+	if err := script.Deserialize(tok.Owner.Raw); err != nil {
+		return errors.WithMessagef(err, "token [%s] is not locked by an htlc script", id)
+	}
+
+	owner := script.Sender
+	if preimage != nil {
+		if !bytes.Equal(htlc.Image(preimage), script.Hash) {
+			return errors.New("preimage does not match the htlc hash")
+		}
+		owner = script.Recipient
+	}
+	if !wallet.Contains(owner) {
+		return errors.Errorf("wallet [%s] cannot spend token [%s]", wallet.ID(), id)
+	}
+
+	// sign on behalf of the script with the key of owner
+	signer, err := wallet.GetSigner(owner)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting signer for [%s]", owner)
+	}
+	sigService := view2.GetSigService(t.sp)
+	verifier := &htlc.Verifier{
+		Script: script,
+		GetVerifier: func(id view.Identity) (api2.Verifier, error) {
+			return sigService.GetVerifier(id)
+		},
+	}
+	if err := sigService.RegisterSigner(tok.Owner.Raw, &htlc.Signer{Signer: signer, Preimage: preimage}, verifier); err != nil {
+		return errors.WithMessage(err, "failed registering signer for htlc script")
+	}
+
+	q, err := token2.ToQuantity(tok.Quantity, 64)
+	if err != nil {
+		return errors.WithMessagef(err, "failed parsing quantity of token [%s]", id)
+	}
+	return t.Transfer(wallet, tok.Type, []uint64{q.ToBigInt().Uint64()}, []view.Identity{owner}, token.WithTokenIDs(id))
+}
+
+// expandScripts replaces the htlc scripts in the passed list with their parties
+func expandScripts(parties []view.Identity) []view.Identity {
+	var res []view.Identity
+	for _, party := range parties {
+		if !htlc.IsScript(party) {
+			res = append(res, party)
+			continue
+		}
+		script := &htlc.Script{}
+		if err := script.Deserialize(party); err != nil {
+			logger.Warnf("failed deserializing htlc script [%s]", err)
+			continue
+		}
+		res = append(res, script.Sender, script.Recipient)
+	}
+	return res
+}
+
+// registerScripts registers the audit info of the outputs locked by htlc scripts of which this node is a party,
+// so that those outputs can be claimed or reclaimed later
+func (t *Transaction) registerScripts() error {
+	outputs, err := t.Outputs()
+	if err != nil {
+		return errors.WithMessage(err, "failed getting outputs")
+	}
+	wm := t.TokenService().WalletManager()
+	sigService := view2.GetSigService(t.sp)
+	for i := 0; i < outputs.Count(); i++ {
+		output := outputs.At(i)
+		if !htlc.IsScript(output.Owner) {
+			continue
+		}
+		script := &htlc.Script{}
+		if err := script.Deserialize(output.Owner); err != nil {
+			return errors.WithMessage(err, "failed deserializing htlc script")
+		}
+		if wm.OwnerWalletByIdentity(script.Sender) == nil && wm.OwnerWalletByIdentity(script.Recipient) == nil {
+			continue
+		}
+		if err := sigService.RegisterAuditInfo(output.Owner, output.OwnerAuditInfo); err != nil {
+			return errors.WithMessage(err, "failed registering audit info for htlc script")
+		}
+	}
+	return nil
+}
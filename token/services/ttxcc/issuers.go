@@ -0,0 +1,51 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/translator"
+)
+
+// IssuingValidator tells if an identity can issue tokens. With the NamespaceBackend, the initiator and the approvers
+// of the token namespace check the issues of a token request with it, see WithIssuingValidator and NewApproveView.
+type IssuingValidator = translator.IssuingValidator
+
+// MSPIssuers is an IssuingValidator accepting the issuers whose X.509 identity belongs to one of the listed MSPs
+type MSPIssuers []string
+
+func (m MSPIssuers) Validate(creator view.Identity, tokenType string) error {
+	si := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(creator, si); err != nil {
+		return errors.Wrapf(err, "failed unmarshalling issuer identity")
+	}
+	for _, mspID := range m {
+		if si.Mspid == mspID {
+			return nil
+		}
+	}
+	return errors.Errorf("issuer of msp [%s] cannot issue tokens", si.Mspid)
+}
+
+// noIssuers is the IssuingValidator used when none is configured, it rejects all issues
+type noIssuers struct{}
+
+func (n *noIssuers) Validate(creator view.Identity, tokenType string) error {
+	return errors.Errorf("no issuing validator configured, issuer [%s] cannot be accepted", creator)
+}
+
+// issuingValidator returns the IssuingValidator set with WithIssuingValidator, if any, otherwise one rejecting all issues
+func (t *Transaction) issuingValidator() IssuingValidator {
+	if t.opts == nil || t.opts.issuingValidator == nil {
+		return &noIssuers{}
+	}
+	return t.opts.issuingValidator
+}
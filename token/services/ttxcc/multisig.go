@@ -0,0 +1,294 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/multisig"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// TransferToMultisig adds a transfer of value tokens of type typ, owned by wallet, to a multisig identity
+// whose tokens can be spent with the signatures of at least threshold of the passed co-owners.
+// It returns the multisig identity.
+func (t *Transaction) TransferToMultisig(wallet *token.OwnerWallet, typ string, value uint64, threshold uint64, coOwners []view.Identity, opts ...token.TransferOption) (view.Identity, error) {
+	id := &multisig.Identity{Threshold: threshold, Identities: coOwners}
+	if err := id.Validate(); err != nil {
+		return nil, errors.WithMessage(err, "invalid multisig identity")
+	}
+	owner, err := id.Serialize()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed serializing multisig identity")
+	}
+
+	// the audit info of the multisig identity carries those of all the co-owners
+	sigService := view2.GetSigService(t.sp)
+	info := &multisig.Info{}
+	for _, coOwner := range coOwners {
+		auditInfo, err := sigService.GetAuditInfo(coOwner)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting audit info for co-owner [%s]", coOwner)
+		}
+		info.AuditInfos = append(info.AuditInfos, auditInfo)
+	}
+	raw, err := info.Serialize()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed serializing multisig info")
+	}
+	if err := sigService.RegisterAuditInfo(owner, raw); err != nil {
+		return nil, errors.WithMessage(err, "failed registering audit info for multisig identity")
+	}
+
+	if err := t.Transfer(wallet, typ, []uint64{value}, []view.Identity{owner}, opts...); err != nil {
+		return nil, errors.WithMessage(err, "failed adding transfer to multisig identity")
+	}
+	return owner, nil
+}
+
+// TransferFromMultisig adds a transfer that spends the token with the passed id, owned by a multisig identity
+// of which wallet holds a co-owner, to the passed owners. The rest, if any, goes back to the multisig identity.
+// The signatures of the co-owners are collected by the view returned by NewCollectEndorsementsView.
+func (t *Transaction) TransferFromMultisig(wallet *token.OwnerWallet, id *token2.Id, values []uint64, owners []view.Identity) error {
+	tok, q, err := t.spendMultisig(wallet, id)
+	if err != nil {
+		return err
+	}
+
+	// the rest goes back to the co-owners
+	sum := token2.NewQuantityFromUInt64(0)
+	for _, v := range values {
+		sum = sum.Add(token2.NewQuantityFromUInt64(v))
+	}
+	if sum.Cmp(q) > 0 {
+		return errors.Errorf("insufficient funds, token [%s] carries [%s], requested [%s]", id, q.Decimal(), sum.Decimal())
+	}
+	if rest := q.Sub(sum); rest.Cmp(token2.NewQuantityFromUInt64(0)) > 0 {
+		values = append(values, rest.ToBigInt().Uint64())
+		owners = append(owners, tok.Owner.Raw)
+	}
+	return t.Transfer(wallet, tok.Type, values, owners, token.WithTokenIDs(id))
+}
+
+// SettleFromMultisig adds a transfer that pays out the token with the passed id, the deposit of a channel whose
+// dispute is closed, to the passed owners. The values must add up to the deposit and follow the balances fixed by
+// the dispute. The validator accepts the transfer with the signatures of the co-owners held by wallet alone,
+// therefore the other co-owners are neither asked to sign nor required to be reachable.
+func (t *Transaction) SettleFromMultisig(wallet *token.OwnerWallet, id *token2.Id, values []uint64, owners []view.Identity) error {
+	tok, q, err := t.spendMultisig(wallet, id)
+	if err != nil {
+		return err
+	}
+	sum := token2.NewQuantityFromUInt64(0)
+	for _, v := range values {
+		sum = sum.Add(token2.NewQuantityFromUInt64(v))
+	}
+	if sum.Cmp(q) != 0 {
+		return errors.Errorf("the settlement of token [%s] must pay out [%s], got [%s]", id, q.Decimal(), sum.Decimal())
+	}
+	if err := t.Transfer(wallet, tok.Type, values, owners, token.WithTokenIDs(id), token.WithSettlement()); err != nil {
+		return err
+	}
+	t.settlement = true
+	return nil
+}
+
+// spendMultisig loads the token with the passed id, owned by a multisig identity of which wallet holds a co-owner,
+// and registers the signer of the co-owners held by wallet for it. It returns the token and its quantity.
+func (t *Transaction) spendMultisig(wallet *token.OwnerWallet, id *token2.Id) (*token2.Token, token2.Quantity, error) {
+	tokens, err := t.TokenService().Vault().NewQueryEngine().GetTokens(id)
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed getting token [%s]", id)
+	}
+	if len(tokens) != 1 {
+		return nil, nil, errors.Errorf("token [%s] not found", id)
+	}
+	tok := tokens[0]
+	if !multisig.IsMultisig(tok.Owner.Raw) {
+		return nil, nil, errors.Errorf("token [%s] is not owned by a multisig identity", id)
+	}
+
+	// sign on behalf of the multisig identity with the co-owners held by wallet
+	signer, err := localSigner(wallet, tok.Owner.Raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	if signer == nil {
+		return nil, nil, errors.Errorf("wallet [%s] holds no co-owner of token [%s]", wallet.ID(), id)
+	}
+	sigService := view2.GetSigService(t.sp)
+	verifier, err := multisig.GetVerifier(tok.Owner.Raw, func(id view.Identity) (api2.Verifier, error) {
+		return sigService.GetVerifier(id)
+	})
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed getting verifier for multisig identity")
+	}
+	if err := sigService.RegisterSigner(tok.Owner.Raw, signer, verifier); err != nil {
+		return nil, nil, errors.WithMessage(err, "failed registering signer for multisig identity")
+	}
+
+	q, err := token2.ToQuantity(tok.Quantity, 64)
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed parsing quantity of token [%s]", id)
+	}
+	return tok, q, nil
+}
+
+// localSigner returns a signer for the passed owner built from the identities held by wallet,
+// recursing into multisig identities. It returns nil if wallet cannot contribute any signature.
+func localSigner(wallet *token.OwnerWallet, owner view.Identity) (api2.Signer, error) {
+	if !multisig.IsMultisig(owner) {
+		if !wallet.Contains(owner) {
+			return nil, nil
+		}
+		signer, err := wallet.GetSigner(owner)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting signer for co-owner [%s]", owner)
+		}
+		return signer, nil
+	}
+	id := &multisig.Identity{}
+	if err := id.Deserialize(owner); err != nil {
+		return nil, errors.WithMessage(err, "failed deserializing multisig identity")
+	}
+	signers := make([]api2.Signer, len(id.Identities))
+	found := false
+	for i, coOwner := range id.Identities {
+		signer, err := localSigner(wallet, coOwner)
+		if err != nil {
+			return nil, err
+		}
+		if signer != nil {
+			signers[i] = signer
+			found = true
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	return &multisig.Signer{Signers: signers}, nil
+}
+
+// JointWallet tracks the tokens owned by the multisig identities of which the wrapped owner wallet holds a co-owner
+type JointWallet struct {
+	wallet *token.OwnerWallet
+	tms    *token.ManagementService
+}
+
+// GetJointWallet returns the joint wallet on top of the owner wallet whose id is the passed id.
+// If the passed id is empty, the default owner wallet is used.
+func GetJointWallet(sp view2.ServiceProvider, id string) *JointWallet {
+	tms := token.GetManagementService(sp)
+	w := tms.WalletManager().OwnerWallet(id)
+	if w == nil {
+		panic(fmt.Sprintf("cannot find wallet [%s] for default channel", id))
+	}
+	return &JointWallet{wallet: w, tms: tms}
+}
+
+func (j *JointWallet) ID() string {
+	return j.wallet.ID()
+}
+
+// Contains returns true if the passed identity is a multisig identity with a co-owner in the wrapped wallet,
+// possibly through nested multisig identities
+func (j *JointWallet) Contains(identity view.Identity) bool {
+	return holdsCoOwner(identity, func(id view.Identity) bool {
+		return j.wallet.Contains(id)
+	})
+}
+
+// ListTokens returns the unspent tokens owned jointly by the wrapped wallet
+func (j *JointWallet) ListTokens(opts ...token.ListTokensOption) (*token2.UnspentTokens, error) {
+	options := &token.ListTokensOptions{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	source, err := j.tms.Vault().NewQueryEngine().ListUnspentTokens()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed listing unspent tokens")
+	}
+	unspentTokens := &token2.UnspentTokens{}
+	for _, t := range source.Tokens {
+		if len(options.TokenType) != 0 && t.Type != options.TokenType {
+			continue
+		}
+		if !j.Contains(t.Owner.Raw) {
+			continue
+		}
+		unspentTokens.Tokens = append(unspentTokens.Tokens, t)
+	}
+	return unspentTokens, nil
+}
+
+// holdsCoOwner returns true if the passed identity is a multisig identity with a co-owner,
+// possibly through nested multisig identities, for which isMine returns true
+func holdsCoOwner(identity view.Identity, isMine func(id view.Identity) bool) bool {
+	if !multisig.IsMultisig(identity) {
+		return false
+	}
+	id := &multisig.Identity{}
+	if err := id.Deserialize(identity); err != nil {
+		return false
+	}
+	for _, coOwner := range id.Identities {
+		if isMine(coOwner) || holdsCoOwner(coOwner, isMine) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandMultisigs replaces the multisig identities in the passed list with their co-owners, recursively
+func expandMultisigs(parties []view.Identity) []view.Identity {
+	var res []view.Identity
+	for _, party := range parties {
+		if !multisig.IsMultisig(party) {
+			res = append(res, party)
+			continue
+		}
+		id := &multisig.Identity{}
+		if err := id.Deserialize(party); err != nil {
+			logger.Warnf("failed deserializing multisig identity [%s]", err)
+			continue
+		}
+		res = append(res, expandMultisigs(id.Identities)...)
+	}
+	return res
+}
+
+// registerMultisigs registers the audit info of the outputs owned by multisig identities of which
+// this node holds a co-owner, so that those outputs can be spent later
+func (t *Transaction) registerMultisigs() error {
+	outputs, err := t.Outputs()
+	if err != nil {
+		return errors.WithMessage(err, "failed getting outputs")
+	}
+	wm := t.TokenService().WalletManager()
+	sigService := view2.GetSigService(t.sp)
+	isMine := func(id view.Identity) bool {
+		return wm.OwnerWalletByIdentity(id) != nil
+	}
+	for i := 0; i < outputs.Count(); i++ {
+		output := outputs.At(i)
+		if !holdsCoOwner(output.Owner, isMine) {
+			continue
+		}
+		if err := sigService.RegisterAuditInfo(output.Owner, output.OwnerAuditInfo); err != nil {
+			return errors.WithMessage(err, "failed registering audit info for multisig identity")
+		}
+	}
+	return nil
+}
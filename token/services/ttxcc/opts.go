@@ -5,13 +5,24 @@ SPDX-License-Identifier: Apache-2.0
 */
 package ttxcc
 
-import "github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+import (
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+)
 
 type txOptions struct {
-	auditor   view.Identity
-	network   string
-	channel   string
-	namespace string
+	auditor     view.Identity
+	network     string
+	channel     string
+	namespace   string
+	timeout     time.Duration
+	stepTimeout time.Duration
+	backend     string
+	approvers   []view.Identity
+	// issuingValidator checks the issues of the token request when the NamespaceBackend is used
+	issuingValidator IssuingValidator
 }
 
 func compile(opts ...TxOption) (*txOptions, error) {
@@ -53,3 +64,54 @@ func WithNamespace(namespace string) TxOption {
 		return nil
 	}
 }
+
+// WithTimeout sets the overall deadline, counted from the start of the collection of the endorsements,
+// by which the endorsements must be collected and the transaction distributed.
+// If the deadline is exceeded, the flow aborts. Zero means no deadline.
+func WithTimeout(timeout time.Duration) TxOption {
+	return func(o *txOptions) error {
+		o.timeout = timeout
+		return nil
+	}
+}
+
+// WithStepTimeout sets how long each step of the collection of the endorsements can take,
+// such as waiting for the signature of a party or the endorsement of the chaincode.
+// Zero means the default of each step.
+func WithStepTimeout(timeout time.Duration) TxOption {
+	return func(o *txOptions) error {
+		o.stepTimeout = timeout
+		return nil
+	}
+}
+
+// WithBackend sets the backend, registered under the passed name, the token request reaches the ledger with.
+// By default, the token request is endorsed by the token chaincode.
+func WithBackend(name string) TxOption {
+	return func(o *txOptions) error {
+		o.backend = name
+		return nil
+	}
+}
+
+// WithNamespaceApprovers selects the NamespaceBackend: the token request is written directly in the transaction
+// and approved by the passed parties, who must run the view returned by NewApproveView
+func WithNamespaceApprovers(approvers ...view.Identity) TxOption {
+	return func(o *txOptions) error {
+		if len(approvers) == 0 {
+			return errors.New("no approvers passed")
+		}
+		o.backend = NamespaceBackend
+		o.approvers = approvers
+		return nil
+	}
+}
+
+// WithIssuingValidator sets how the issues of the token request are checked when the NamespaceBackend writes them
+// in the transaction. Without one, a token request carrying issues cannot use the NamespaceBackend.
+func WithIssuingValidator(validator IssuingValidator) TxOption {
+	return func(o *txOptions) error {
+		o.issuingValidator = validator
+		return nil
+	}
+}
@@ -8,6 +8,8 @@ package ttxcc
 import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/owner/ownerdb"
 )
 
 type orderingView struct {
@@ -19,8 +21,17 @@ func NewOrderingView(tx *Transaction) *orderingView {
 }
 
 func (o *orderingView) Call(context view.Context) (interface{}, error) {
-	if err := fabric.GetDefaultNetwork(context).Ordering().Broadcast(o.tx.Payload.FabricEnvelope); err != nil {
+	backend, err := o.tx.backend()
+	if err != nil {
+		return nil, err
+	}
+	if err := backend.Broadcast(context, o.tx); err != nil {
+		return nil, err
+	}
+	o.tx.watchFinality(context)
+	if err := fabric.GetChannel(context, o.tx.Network(), o.tx.Channel()).Finality().IsFinal(o.tx.ID()); err != nil {
 		return nil, err
 	}
-	return nil, fabric.GetChannel(context, o.tx.Network(), o.tx.Channel()).Finality().IsFinal(o.tx.ID())
+	o.tx.setHistoryStatus(ownerdb.Confirmed)
+	return nil, nil
 }
@@ -0,0 +1,406 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/kvs"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+const (
+	// PaymentRequestMemo is the key of the request memo carrying the hash of the payment request a transaction pays
+	PaymentRequestMemo = "ttxcc.payment.request"
+
+	paymentRequestPrefix  = "ttxcc.payment.request"
+	paymentPaidPrefix     = "ttxcc.payment.paid"
+	paymentReceivedPrefix = "ttxcc.payment.received"
+)
+
+// paymentLock serializes the checks against paying, or accepting the payment of, the same payment request twice
+var paymentLock sync.Mutex
+
+// PaymentRequest asks to pay Amount tokens of type Type to a recipient identity of the issuing wallet.
+// It is signed by the recipient identity, and it can be paid once before Expiry without interacting with the recipient.
+// Paying once is enforced by the kvs of the paying and of the recipient node only, the hash of the request is not
+// checked on-chain.
+type PaymentRequest struct {
+	Channel       string
+	Type          string
+	Amount        uint64
+	RecipientData *RecipientData
+	// Endpoint is the long-term identity of the recipient node, the payment transaction is distributed to it
+	Endpoint  view.Identity
+	Expiry    time.Time
+	Nonce     []byte
+	Signature []byte
+}
+
+// NewPaymentRequest returns a payment request, signed by a fresh recipient identity of the passed wallet,
+// for amount tokens of type typ, valid for the passed duration.
+// The request is stored, so that the payment can be recognized by NewAcceptPaymentView.
+// Only the network and channel options are considered.
+func NewPaymentRequest(context view.Context, wallet *token.OwnerWallet, typ string, amount uint64, validity time.Duration, opts ...TxOption) (*PaymentRequest, error) {
+	if amount == 0 {
+		return nil, errors.New("payment request amount must be positive")
+	}
+	txOpts, err := compile(opts...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed compiling tx options")
+	}
+	tms := token.GetManagementService(context, token.WithNetwork(txOpts.network), token.WithChannel(txOpts.channel))
+	recipient, err := wallet.GetRecipientIdentity()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting recipient identity, wallet [%s]", wallet.ID())
+	}
+	auditInfo, err := wallet.GetAuditInfo(recipient)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting recipient identity audit info, wallet [%s]", wallet.ID())
+	}
+	metadata, err := wallet.GetTokenMetadata(recipient)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting recipient identity metadata, wallet [%s]", wallet.ID())
+	}
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "failed generating nonce")
+	}
+
+	request := &PaymentRequest{
+		Channel: tms.Channel(),
+		Type:    typ,
+		Amount:  amount,
+		RecipientData: &RecipientData{
+			Identity:  recipient,
+			AuditInfo: auditInfo,
+			Metadata:  metadata,
+		},
+		Endpoint: context.Me(),
+		Expiry:   time.Now().Add(validity),
+		Nonce:    nonce,
+	}
+	msg, err := request.MessageToSign()
+	if err != nil {
+		return nil, err
+	}
+	signer, err := wallet.GetSigner(recipient)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting signer for recipient identity, wallet [%s]", wallet.ID())
+	}
+	request.Signature, err = signer.Sign(msg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed signing payment request")
+	}
+
+	// Update the Endpoint Resolver
+	if err := view2.GetEndpointService(context).Bind(context.Me(), recipient); err != nil {
+		return nil, err
+	}
+	hash, err := request.Hash()
+	if err != nil {
+		return nil, err
+	}
+	if err := kvs.GetService(context).Put(paymentKey(paymentRequestPrefix, hash), request); err != nil {
+		return nil, errors.Wrap(err, "failed storing payment request")
+	}
+	return request, nil
+}
+
+// PaymentRequestFromText parses the text form of a payment request, see Text
+func PaymentRequestFromText(text string) (*PaymentRequest, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid payment request text")
+	}
+	request := &PaymentRequest{}
+	if err := request.FromBytes(raw); err != nil {
+		return nil, errors.Wrap(err, "failed unmarshalling payment request")
+	}
+	return request, nil
+}
+
+func (r *PaymentRequest) Bytes() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+func (r *PaymentRequest) FromBytes(raw []byte) error {
+	return json.Unmarshal(raw, r)
+}
+
+// Text returns a url-safe text form of the request, suitable for links and QR codes
+func (r *PaymentRequest) Text() (string, error) {
+	raw, err := r.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// MessageToSign returns the content of the request covered by the signature of the recipient
+func (r *PaymentRequest) MessageToSign() ([]byte, error) {
+	msg := *r
+	msg.Signature = nil
+	return json.Marshal(&msg)
+}
+
+// Hash identifies the request, the transaction paying it carries the hash in the PaymentRequestMemo memo
+func (r *PaymentRequest) Hash() ([]byte, error) {
+	msg, err := r.MessageToSign()
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(msg)
+	return h[:], nil
+}
+
+// Verify checks that the request is well-formed, signed by its recipient, and not expired at the passed time.
+// The recipient identity must be known to the passed token management service.
+func (r *PaymentRequest) Verify(tms *token.ManagementService, now time.Time) error {
+	return r.verify(tms.SigService().GetVerifier, now)
+}
+
+func (r *PaymentRequest) verify(getVerifier func(view.Identity) (token.Verifier, error), now time.Time) error {
+	if r.Amount == 0 || len(r.Type) == 0 {
+		return errors.New("payment request must have a type and a positive amount")
+	}
+	if r.RecipientData == nil || r.RecipientData.Identity.IsNone() {
+		return errors.New("payment request has no recipient")
+	}
+	if len(r.Nonce) == 0 {
+		return errors.New("payment request has no nonce")
+	}
+	if !now.Before(r.Expiry) {
+		return errors.Errorf("payment request expired at [%s]", r.Expiry)
+	}
+	msg, err := r.MessageToSign()
+	if err != nil {
+		return err
+	}
+	verifier, err := getVerifier(r.RecipientData.Identity)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting verifier for [%s]", r.RecipientData.Identity)
+	}
+	if err := verifier.Verify(msg, r.Signature); err != nil {
+		return errors.Wrap(err, "invalid payment request signature")
+	}
+	return nil
+}
+
+// paymentRecord tracks the transaction paying a payment request, on the payer and on the recipient side
+type paymentRecord struct {
+	TxID string
+}
+
+type payView struct {
+	wallet  string
+	request *PaymentRequest
+	opts    []TxOption
+}
+
+// NewPayView returns a view that pays the passed payment request with tokens of the passed wallet.
+// The transaction carries the hash of the request in the PaymentRequestMemo memo,
+// and a request is paid at most once by this node. Another node can pay the same request again, then it is up
+// to the recipient, running NewAcceptPaymentView, to refuse the second payment.
+// The view returns the id of the transaction.
+func NewPayView(wallet string, request *PaymentRequest, opts ...TxOption) *payView {
+	return &payView{wallet: wallet, request: request, opts: opts}
+}
+
+func (p *payView) Call(context view.Context) (interface{}, error) {
+	if p.request.RecipientData == nil {
+		return nil, errors.New("payment request has no recipient")
+	}
+	tms := token.GetManagementService(context, token.WithChannel(p.request.Channel))
+	recipient := p.request.RecipientData.Identity
+	if err := tms.WalletManager().RegisterRecipientIdentity(recipient, p.request.RecipientData.AuditInfo, p.request.RecipientData.Metadata); err != nil {
+		return nil, errors.WithMessage(err, "failed registering recipient identity")
+	}
+	if err := p.request.Verify(tms, time.Now()); err != nil {
+		return nil, errors.WithMessage(err, "invalid payment request")
+	}
+	hash, err := p.request.Hash()
+	if err != nil {
+		return nil, err
+	}
+	// Update the Endpoint Resolver
+	if err := view2.GetEndpointService(context).Bind(p.request.Endpoint, recipient); err != nil {
+		return nil, err
+	}
+
+	tx, err := NewAnonymousTransaction(context, append([]TxOption{WithChannel(p.request.Channel)}, p.opts...)...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed creating transaction")
+	}
+	if err := reservePayment(context, paymentPaidPrefix, hash, tx.ID()); err != nil {
+		return nil, err
+	}
+	if err := p.pay(context, tx, hash); err != nil {
+		releaseFailedPayment(context, paymentPaidPrefix, hash, tx)
+		return nil, err
+	}
+	return tx.ID(), nil
+}
+
+func (p *payView) pay(context view.Context, tx *Transaction, hash []byte) error {
+	if err := tx.SetMemo(PaymentRequestMemo, hash); err != nil {
+		return errors.WithMessage(err, "failed binding payment request")
+	}
+	if err := tx.Transfer(GetWalletForChannel(context, p.request.Channel, p.wallet), p.request.Type, []uint64{p.request.Amount}, []view.Identity{p.request.RecipientData.Identity}); err != nil {
+		return errors.WithMessage(err, "failed adding payment")
+	}
+	if _, err := context.RunView(NewCollectEndorsementsView(tx)); err != nil {
+		return errors.WithMessage(err, "failed collecting endorsements")
+	}
+	if _, err := context.RunView(NewOrderingView(tx)); err != nil {
+		return errors.WithMessage(err, "failed ordering transaction")
+	}
+	return nil
+}
+
+type acceptPaymentView struct{}
+
+// NewAcceptPaymentView returns the view run by the recipient in response to NewPayView.
+// It checks that the transaction pays in full a valid payment request created by this node, not paid by another
+// transaction, and waits for its finality. The view returns the transaction.
+func NewAcceptPaymentView() *acceptPaymentView {
+	return &acceptPaymentView{}
+}
+
+func (a *acceptPaymentView) Call(context view.Context) (interface{}, error) {
+	tx, err := ReceiveTransaction(context)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed receiving transaction")
+	}
+	request, err := PaidRequest(context, tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := request.Verify(tx.TokenService(), time.Now()); err != nil {
+		return nil, errors.WithMessage(err, "invalid payment request")
+	}
+	if err := checkPayment(tx, request); err != nil {
+		return nil, err
+	}
+	hash, err := request.Hash()
+	if err != nil {
+		return nil, err
+	}
+	if err := reservePayment(context, paymentReceivedPrefix, hash, tx.ID()); err != nil {
+		return nil, err
+	}
+
+	if _, err := context.RunView(NewAcceptView(tx)); err != nil {
+		releaseFailedPayment(context, paymentReceivedPrefix, hash, tx)
+		return nil, errors.WithMessage(err, "failed accepting transaction")
+	}
+	if _, err := context.RunView(NewFinalityView(tx)); err != nil {
+		releaseFailedPayment(context, paymentReceivedPrefix, hash, tx)
+		return nil, errors.WithMessagef(err, "transaction [%s] not committed", tx.ID())
+	}
+	return tx, nil
+}
+
+// PaidRequest returns the payment request, created by this node, that the passed transaction pays
+func PaidRequest(sp view2.ServiceProvider, tx *Transaction) (*PaymentRequest, error) {
+	memos, err := tx.TokenRequest.Memos()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting memos")
+	}
+	hash, ok := memos[PaymentRequestMemo]
+	if !ok {
+		return nil, errors.Errorf("transaction [%s] does not pay a payment request", tx.ID())
+	}
+	key := paymentKey(paymentRequestPrefix, hash)
+	if !kvs.GetService(sp).Exists(key) {
+		return nil, errors.Errorf("transaction [%s] pays an unknown payment request", tx.ID())
+	}
+	request := &PaymentRequest{}
+	if err := kvs.GetService(sp).Get(key, request); err != nil {
+		return nil, errors.Wrap(err, "failed loading payment request")
+	}
+	return request, nil
+}
+
+// checkPayment checks that the outputs of the passed transaction give the recipient of the request at least its amount
+func checkPayment(tx *Transaction, request *PaymentRequest) error {
+	outputs, err := tx.Outputs()
+	if err != nil {
+		return errors.WithMessage(err, "failed getting outputs")
+	}
+	paid := token2.NewZeroQuantity(64)
+	for i := 0; i < outputs.Count(); i++ {
+		output := outputs.At(i)
+		if output.Type != request.Type || !bytes.Equal(output.Owner, request.RecipientData.Identity) {
+			continue
+		}
+		q, err := token2.ToQuantity(output.Quantity, 64)
+		if err != nil {
+			return errors.WithMessagef(err, "invalid quantity [%s]", output.Quantity)
+		}
+		paid = paid.Add(q)
+	}
+	if paid.Cmp(token2.NewQuantityFromUInt64(request.Amount)) < 0 {
+		return errors.Errorf("transaction [%s] pays [%s] of type [%s], expected [%d]", tx.ID(), paid.Decimal(), request.Type, request.Amount)
+	}
+	return nil
+}
+
+// reservePayment records, under the passed prefix, that the request with the passed hash is paid by the passed transaction.
+// It fails if the request is already paid by another transaction.
+func reservePayment(sp view2.ServiceProvider, prefix string, hash []byte, txID string) error {
+	paymentLock.Lock()
+	defer paymentLock.Unlock()
+
+	key := paymentKey(prefix, hash)
+	kvss := kvs.GetService(sp)
+	if kvss.Exists(key) {
+		record := &paymentRecord{}
+		if err := kvss.Get(key, record); err != nil {
+			return errors.Wrap(err, "failed loading payment record")
+		}
+		if len(record.TxID) != 0 && record.TxID != txID {
+			return errors.Errorf("payment request already paid by [%s]", record.TxID)
+		}
+	}
+	if err := kvss.Put(key, &paymentRecord{TxID: txID}); err != nil {
+		return errors.Wrap(err, "failed storing payment record")
+	}
+	return nil
+}
+
+// releaseFailedPayment releases the request with the passed hash, reserved under the passed prefix, so that it can be
+// paid again, unless the passed transaction got to the ordering service and it is not invalid
+func releaseFailedPayment(sp view2.ServiceProvider, prefix string, hash []byte, tx *Transaction) {
+	vc, _, err := fabric.GetChannel(sp, tx.Network(), tx.Channel()).Vault().Status(tx.ID())
+	if err != nil || (vc != fabric.Unknown && vc != fabric.Invalid) {
+		return
+	}
+	paymentLock.Lock()
+	defer paymentLock.Unlock()
+
+	if err := kvs.GetService(sp).Put(paymentKey(prefix, hash), &paymentRecord{}); err != nil {
+		logger.Warnf("failed releasing payment record: [%s]", err)
+	}
+}
+
+func paymentKey(prefix string, hash []byte) string {
+	return kvs.CreateCompositeKeyOrPanic(prefix, []string{hex.EncodeToString(hash)})
+}
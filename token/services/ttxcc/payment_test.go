@@ -0,0 +1,196 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/kvs"
+	registry2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/registry"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+)
+
+// hashSigner signs with the hash of the identity and of the message, it is enough to detect tampering
+type hashSigner struct {
+	id view.Identity
+}
+
+func (h *hashSigner) Sign(message []byte) ([]byte, error) {
+	sig := sha256.Sum256(append(append([]byte{}, h.id...), message...))
+	return sig[:], nil
+}
+
+func (h *hashSigner) Verify(message, sigma []byte) error {
+	expected, _ := h.Sign(message)
+	if !bytes.Equal(expected, sigma) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func getHashVerifier(id view.Identity) (token.Verifier, error) {
+	if id.Equal(view.Identity("unknown")) {
+		return nil, errors.New("identity not found")
+	}
+	return &hashSigner{id: id}, nil
+}
+
+func signedPaymentRequest(t *testing.T, expiry time.Time) *PaymentRequest {
+	request := &PaymentRequest{
+		Channel:       "ch",
+		Type:          "USD",
+		Amount:        10,
+		RecipientData: &RecipientData{Identity: view.Identity("alice")},
+		Endpoint:      view.Identity("alice-node"),
+		Expiry:        expiry,
+		Nonce:         []byte("nonce"),
+	}
+	msg, err := request.MessageToSign()
+	assert.NoError(t, err)
+	request.Signature, err = (&hashSigner{id: view.Identity("alice")}).Sign(msg)
+	assert.NoError(t, err)
+	return request
+}
+
+func TestPaymentRequestVerify(t *testing.T) {
+	now := time.Now()
+	request := signedPaymentRequest(t, now.Add(time.Minute))
+	assert.NoError(t, request.verify(getHashVerifier, now))
+
+	// the text form carries the signature
+	text, err := request.Text()
+	assert.NoError(t, err)
+	parsed, err := PaymentRequestFromText(text)
+	assert.NoError(t, err)
+	assert.NoError(t, parsed.verify(getHashVerifier, now))
+	_, err = PaymentRequestFromText("not base64!")
+	assert.Error(t, err)
+
+	// expired
+	assert.EqualError(t, request.verify(getHashVerifier, request.Expiry), "payment request expired at ["+request.Expiry.String()+"]")
+	assert.Error(t, request.verify(getHashVerifier, now.Add(time.Hour)))
+
+	// tampered
+	hash, err := request.Hash()
+	assert.NoError(t, err)
+	for name, tamper := range map[string]func(r *PaymentRequest){
+		"amount":    func(r *PaymentRequest) { r.Amount = 1000 },
+		"type":      func(r *PaymentRequest) { r.Type = "EUR" },
+		"expiry":    func(r *PaymentRequest) { r.Expiry = r.Expiry.Add(time.Hour) },
+		"endpoint":  func(r *PaymentRequest) { r.Endpoint = view.Identity("mallory-node") },
+		"recipient": func(r *PaymentRequest) { r.RecipientData.Identity = view.Identity("mallory") },
+	} {
+		tampered := signedPaymentRequest(t, request.Expiry)
+		tamper(tampered)
+		assert.EqualError(t, tampered.verify(getHashVerifier, now), "invalid payment request signature: signature mismatch", name)
+		tamperedHash, err := tampered.Hash()
+		assert.NoError(t, err)
+		assert.NotEqual(t, hash, tamperedHash, name)
+	}
+	tampered := signedPaymentRequest(t, request.Expiry)
+	tampered.Signature[0]++
+	assert.EqualError(t, tampered.verify(getHashVerifier, now), "invalid payment request signature: signature mismatch")
+	// the signature is not part of the hash
+	tamperedHash, err := tampered.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, hash, tamperedHash)
+
+	// malformed
+	malformed := signedPaymentRequest(t, request.Expiry)
+	malformed.Amount = 0
+	assert.EqualError(t, malformed.verify(getHashVerifier, now), "payment request must have a type and a positive amount")
+	malformed = signedPaymentRequest(t, request.Expiry)
+	malformed.RecipientData = nil
+	assert.EqualError(t, malformed.verify(getHashVerifier, now), "payment request has no recipient")
+	malformed = signedPaymentRequest(t, request.Expiry)
+	malformed.Nonce = nil
+	assert.EqualError(t, malformed.verify(getHashVerifier, now), "payment request has no nonce")
+	malformed = signedPaymentRequest(t, request.Expiry)
+	malformed.RecipientData.Identity = view.Identity("unknown")
+	assert.EqualError(t, malformed.verify(getHashVerifier, now), "failed getting verifier for ["+view.Identity("unknown").String()+"]: identity not found")
+}
+
+type fakeConfig struct{}
+
+func (f *fakeConfig) GetString(key string) string {
+	return "memory"
+}
+
+func (f *fakeConfig) GetDuration(key string) time.Duration {
+	return 0
+}
+
+func (f *fakeConfig) GetBool(key string) bool {
+	return false
+}
+
+func (f *fakeConfig) GetStringSlice(key string) []string {
+	return nil
+}
+
+func (f *fakeConfig) IsSet(key string) bool {
+	return false
+}
+
+func (f *fakeConfig) UnmarshalKey(key string, rawVal interface{}) error {
+	*(rawVal.(*kvs.Opts)) = kvs.Opts{}
+	return nil
+}
+
+func (f *fakeConfig) ConfigFileUsed() string {
+	return ""
+}
+
+func (f *fakeConfig) GetPath(key string) string {
+	return ""
+}
+
+func (f *fakeConfig) TranslatePath(path string) string {
+	return ""
+}
+
+func newKVSRegistry(t *testing.T) view2.ServiceProvider {
+	registry := registry2.New()
+	assert.NoError(t, registry.RegisterService(&fakeConfig{}))
+	kvss, err := kvs.New("memory", "", registry)
+	assert.NoError(t, err)
+	assert.NoError(t, registry.RegisterService(kvss))
+	return registry
+}
+
+func TestReservePayment(t *testing.T) {
+	sp := newKVSRegistry(t)
+	hash, err := signedPaymentRequest(t, time.Now()).Hash()
+	assert.NoError(t, err)
+
+	assert.NoError(t, reservePayment(sp, paymentPaidPrefix, hash, "tx1"))
+	// the same transaction can reserve again, for instance when resubmitted
+	assert.NoError(t, reservePayment(sp, paymentPaidPrefix, hash, "tx1"))
+	// a replayed request is rejected
+	assert.EqualError(t, reservePayment(sp, paymentPaidPrefix, hash, "tx2"), "payment request already paid by [tx1]")
+	// paying and receiving are tracked apart, a node can pay a request it also receives
+	assert.NoError(t, reservePayment(sp, paymentReceivedPrefix, hash, "tx1"))
+	assert.EqualError(t, reservePayment(sp, paymentReceivedPrefix, hash, "tx2"), "payment request already paid by [tx1]")
+
+	// another request is not affected
+	other, err := signedPaymentRequest(t, time.Now().Add(time.Second)).Hash()
+	assert.NoError(t, err)
+	assert.NoError(t, reservePayment(sp, paymentPaidPrefix, other, "tx2"))
+
+	// a released request can be paid again
+	assert.NoError(t, kvs.GetService(sp).Put(paymentKey(paymentPaidPrefix, hash), &paymentRecord{}))
+	assert.NoError(t, reservePayment(sp, paymentPaidPrefix, hash, "tx3"))
+	assert.EqualError(t, reservePayment(sp, paymentPaidPrefix, hash, "tx1"), "payment request already paid by [tx3]")
+}
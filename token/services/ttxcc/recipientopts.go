@@ -0,0 +1,149 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"sync"
+	"time"
+
+	session2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/session"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultRecipientTimeout = 30 * time.Second
+	// maxRecipientBatch is the maximum number of recipient identities that can be requested at once
+	maxRecipientBatch = 100
+)
+
+// ReusePolicy tells for how long, and how many times, a recipient identity obtained from a party can be used
+// instead of requesting a new one
+type ReusePolicy struct {
+	// MaxUses is how many times an identity can be used, zero means no limit
+	MaxUses int
+	// MaxAge is how long after being obtained an identity can be used, zero means no limit
+	MaxAge time.Duration
+}
+
+type recipientOptions struct {
+	timeout    time.Duration
+	retries    int
+	retryDelay time.Duration
+	reuse      *ReusePolicy
+}
+
+func compileRecipientOptions(opts ...RecipientOption) (*recipientOptions, error) {
+	options := &recipientOptions{timeout: defaultRecipientTimeout}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	return options, nil
+}
+
+type RecipientOption func(*recipientOptions) error
+
+// WithRecipientTimeout sets how long to wait for the counterparty to send its recipient identities
+func WithRecipientTimeout(timeout time.Duration) RecipientOption {
+	return func(o *recipientOptions) error {
+		if timeout <= 0 {
+			return errors.Errorf("invalid timeout [%s]", timeout)
+		}
+		o.timeout = timeout
+		return nil
+	}
+}
+
+// WithRecipientRetries sets how many times the request is sent again, after waiting delay, if it fails
+func WithRecipientRetries(retries int, delay time.Duration) RecipientOption {
+	return func(o *recipientOptions) error {
+		if retries < 0 {
+			return errors.Errorf("invalid number of retries [%d]", retries)
+		}
+		o.retries = retries
+		o.retryDelay = delay
+		return nil
+	}
+}
+
+// WithReusePolicy allows the reuse of recipient identities recently obtained from the same party.
+// By default, a new recipient identity is requested each time.
+func WithReusePolicy(policy *ReusePolicy) RecipientOption {
+	return func(o *recipientOptions) error {
+		o.reuse = policy
+		return nil
+	}
+}
+
+// sendAndReceive sends the passed request on a session returned by getSession and waits for the response,
+// retrying as configured. The session of a failed attempt is closed, then getSession returns a new one for the
+// next attempt. This way, a late response to a failed attempt is dropped instead of being read as the response
+// to a later one.
+func (o *recipientOptions) sendAndReceive(getSession func() (view.Session, error), request []byte) ([]byte, error) {
+	var err error
+	for attempt := 0; attempt <= o.retries; attempt++ {
+		if attempt > 0 {
+			logger.Debugf("recipient request failed [%s], retry [%d/%d] in [%s]", err, attempt, o.retries, o.retryDelay)
+			time.Sleep(o.retryDelay)
+		}
+		var session view.Session
+		if session, err = getSession(); err != nil {
+			continue
+		}
+		var payload []byte
+		if err = session.Send(request); err == nil {
+			if payload, err = session2.ReadMessageWithTimeout(session, o.timeout); err == nil {
+				return payload, nil
+			}
+		}
+		session.Close()
+	}
+	return nil, err
+}
+
+var recipients = &recipientCache{entries: map[string]*cachedRecipient{}}
+
+type cachedRecipient struct {
+	identity view.Identity
+	obtained time.Time
+	uses     int
+}
+
+// recipientCache holds the last recipient identity obtained from each party
+type recipientCache struct {
+	lock    sync.Mutex
+	entries map[string]*cachedRecipient
+}
+
+func recipientCacheKey(channel string, other view.Identity) string {
+	return channel + ":" + other.UniqueID()
+}
+
+// get returns the cached identity for the passed key, if the passed policy allows to use it once more
+func (c *recipientCache) get(key string, policy *ReusePolicy, now time.Time) view.Identity {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if (policy.MaxUses != 0 && entry.uses >= policy.MaxUses) || (policy.MaxAge != 0 && now.Sub(entry.obtained) > policy.MaxAge) {
+		delete(c.entries, key)
+		return nil
+	}
+	entry.uses++
+	return entry.identity
+}
+
+func (c *recipientCache) put(key string, id view.Identity, now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.entries[key] = &cachedRecipient{identity: id, obtained: now, uses: 1}
+}
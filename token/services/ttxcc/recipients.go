@@ -50,6 +50,8 @@ func (r *ExchangeRecipientRequest) FromBytes(raw []byte) error {
 type RecipientRequest struct {
 	Channel  string
 	WalletID []byte
+	// Count is the number of recipient identities requested, zero means one
+	Count int `json:",omitempty"`
 }
 
 func (r *RecipientRequest) Bytes() ([]byte, error) {
@@ -63,70 +65,125 @@ func (r *RecipientRequest) FromBytes(raw []byte) error {
 type requestPseudonymView struct {
 	Channel string
 	Other   view.Identity
+	Count   int
+	Options *recipientOptions
 }
 
-func RequestRecipientIdentity(context view.Context, other view.Identity) (view.Identity, error) {
-	pseudonymBoxed, err := context.RunView(&requestPseudonymView{Other: other})
+// RequestRecipientIdentity requests a recipient identity to the passed party.
+// If a reuse policy is passed, an identity recently obtained from the same party is returned, when allowed by the policy.
+func RequestRecipientIdentity(context view.Context, other view.Identity, opts ...RecipientOption) (view.Identity, error) {
+	options, err := compileRecipientOptions(opts...)
 	if err != nil {
 		return nil, err
 	}
-	return pseudonymBoxed.(view.Identity), nil
+	key := recipientCacheKey("", other)
+	if options.reuse != nil {
+		if id := recipients.get(key, options.reuse, time.Now()); id != nil {
+			logger.Debugf("reuse recipient [%s] of [%s]", id, other)
+			return id, nil
+		}
+	}
+	pseudonymsBoxed, err := context.RunView(&requestPseudonymView{Other: other, Count: 1, Options: options})
+	if err != nil {
+		return nil, err
+	}
+	id := pseudonymsBoxed.([]view.Identity)[0]
+	if options.reuse != nil {
+		recipients.put(key, id, time.Now())
+	}
+	return id, nil
+}
+
+// RequestRecipientIdentities requests n recipient identities to the passed party in one round trip,
+// to be used for instance as the owners of the outputs of a multi-output transfer
+func RequestRecipientIdentities(context view.Context, other view.Identity, n int, opts ...RecipientOption) ([]view.Identity, error) {
+	if n <= 0 || n > maxRecipientBatch {
+		return nil, errors.Errorf("invalid number of recipient identities [%d], must be in (0,%d]", n, maxRecipientBatch)
+	}
+	options, err := compileRecipientOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	pseudonymsBoxed, err := context.RunView(&requestPseudonymView{Other: other, Count: n, Options: options})
+	if err != nil {
+		return nil, err
+	}
+	return pseudonymsBoxed.([]view.Identity), nil
 }
 
 func (f requestPseudonymView) Call(context view.Context) (interface{}, error) {
-	logger.Debugf("request recipient to [%s] for channel [%s]", f.Other, f.Channel)
+	logger.Debugf("request [%d] recipients to [%s] for channel [%s]", f.Count, f.Other, f.Channel)
 	ts := token.GetManagementService(context, token.WithChannel(f.Channel))
+	options := f.Options
+	if options == nil {
+		options = &recipientOptions{timeout: defaultRecipientTimeout}
+	}
+	count := f.Count
+	if count == 0 {
+		count = 1
+	}
 
 	if w := ts.WalletManager().OwnerWalletByIdentity(f.Other); w != nil {
-		recipient, err := w.GetRecipientIdentity()
-		if err != nil {
-			return nil, err
+		ids := make([]view.Identity, count)
+		for i := range ids {
+			recipient, err := w.GetRecipientIdentity()
+			if err != nil {
+				return nil, err
+			}
+			ids[i] = recipient
 		}
-		return recipient, nil
+		return ids, nil
 	} else {
-		session, err := context.GetSession(context.Initiator(), f.Other)
-		if err != nil {
-			return nil, err
-		}
-
 		// Ask for identity
 		rr := &RecipientRequest{
 			Channel:  f.Channel,
 			WalletID: f.Other,
 		}
+		if count > 1 {
+			rr.Count = count
+		}
 		rrRaw, err := rr.Bytes()
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed marshalling recipient request")
 		}
-		err = session.Send(rrRaw)
-		if err != nil {
-			return nil, err
-		}
 
 		// Wait to receive a view identity
-		ch := session.Receive()
-		var payload []byte
-		select {
-		case msg := <-ch:
-			payload = msg.Payload
-		case <-time.After(60 * time.Second):
-			return nil, errors.New("time out reached")
+		payload, err := options.sendAndReceive(func() (view.Session, error) {
+			return context.GetSession(context.Initiator(), f.Other)
+		}, rrRaw)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed requesting recipient identity to [%s]", f.Other)
 		}
 
-		recipientData := &RecipientData{}
-		if err := recipientData.FromBytes(payload); err != nil {
-			return nil, err
-		}
-		if err := ts.WalletManager().RegisterRecipientIdentity(recipientData.Identity, recipientData.AuditInfo, recipientData.Metadata); err != nil {
-			return nil, err
+		var recipientsData []*RecipientData
+		if count > 1 {
+			if err := json.Unmarshal(payload, &recipientsData); err != nil {
+				return nil, errors.Wrapf(err, "failed unmarshalling recipient identities")
+			}
+			if len(recipientsData) != count {
+				return nil, errors.Errorf("expected [%d] recipient identities, got [%d]", count, len(recipientsData))
+			}
+		} else {
+			recipientData := &RecipientData{}
+			if err := recipientData.FromBytes(payload); err != nil {
+				return nil, err
+			}
+			recipientsData = []*RecipientData{recipientData}
 		}
 
-		// Update the Endpoint Resolver
-		if err := view2.GetEndpointService(context).Bind(f.Other, recipientData.Identity); err != nil {
-			return nil, err
+		ids := make([]view.Identity, len(recipientsData))
+		for i, recipientData := range recipientsData {
+			if err := ts.WalletManager().RegisterRecipientIdentity(recipientData.Identity, recipientData.AuditInfo, recipientData.Metadata); err != nil {
+				return nil, err
+			}
+
+			// Update the Endpoint Resolver
+			if err := view2.GetEndpointService(context).Bind(f.Other, recipientData.Identity); err != nil {
+				return nil, err
+			}
+			ids[i] = recipientData.Identity
 		}
-
-		return recipientData.Identity, nil
+		return ids, nil
 	}
 }
 
@@ -144,30 +201,45 @@ func (s *respondPseudonymView) Call(context view.Context) (interface{}, error) {
 	if err := rr.FromBytes(payload); err != nil {
 		return nil, errors.Wrapf(err, "failed unmarshalling recipient request")
 	}
+	if rr.Count < 0 || rr.Count > maxRecipientBatch {
+		return nil, errors.Errorf("invalid number of recipient identities [%d], must be in [0,%d]", rr.Count, maxRecipientBatch)
+	}
 
 	wallet := s.Wallet
 	if len(wallet) == 0 && len(rr.WalletID) != 0 {
 		wallet = string(rr.WalletID)
 	}
 	w := GetWalletForChannel(context, rr.Channel, wallet)
-	recipientIdentity, err := w.GetRecipientIdentity()
-	if err != nil {
-		return nil, err
-	}
-	auditInfo, err := w.GetAuditInfo(recipientIdentity)
-	if err != nil {
-		return nil, err
+	count := rr.Count
+	if count == 0 {
+		count = 1
 	}
-	metadata, err := w.GetTokenMetadata(recipientIdentity)
-	if err != nil {
-		return nil, err
+	recipientsData := make([]*RecipientData, count)
+	for i := range recipientsData {
+		recipientIdentity, err := w.GetRecipientIdentity()
+		if err != nil {
+			return nil, err
+		}
+		auditInfo, err := w.GetAuditInfo(recipientIdentity)
+		if err != nil {
+			return nil, err
+		}
+		metadata, err := w.GetTokenMetadata(recipientIdentity)
+		if err != nil {
+			return nil, err
+		}
+		recipientsData[i] = &RecipientData{
+			Identity:  recipientIdentity,
+			AuditInfo: auditInfo,
+			Metadata:  metadata,
+		}
 	}
-	recipientData := &RecipientData{
-		Identity:  recipientIdentity,
-		AuditInfo: auditInfo,
-		Metadata:  metadata,
+	var recipientDataRaw []byte
+	if rr.Count == 0 {
+		recipientDataRaw, err = recipientsData[0].Bytes()
+	} else {
+		recipientDataRaw, err = json.Marshal(recipientsData)
 	}
-	recipientDataRaw, err := recipientData.Bytes()
 	if err != nil {
 		return nil, err
 	}
@@ -180,18 +252,21 @@ func (s *respondPseudonymView) Call(context view.Context) (interface{}, error) {
 
 	// Update the Endpoint Resolver
 	resolver := view2.GetEndpointService(context)
-	err = resolver.Bind(context.Me(), recipientIdentity)
-	if err != nil {
-		return nil, err
+	for _, recipientData := range recipientsData {
+		if err := resolver.Bind(context.Me(), recipientData.Identity); err != nil {
+			return nil, err
+		}
 	}
 
-	return recipientIdentity, nil
+	return recipientsData[0].Identity, nil
 }
 
 func NewRespondRequestRecipientIdentityView() view.View {
 	return &respondPseudonymView{}
 }
 
+// RespondRequestRecipientIdentity responds to RequestRecipientIdentity and RequestRecipientIdentities.
+// In the case of a batch request, the first of the identities sent is returned.
 func RespondRequestRecipientIdentity(context view.Context) (view.Identity, error) {
 	id, err := context.RunView(NewRespondRequestRecipientIdentityView())
 	if err != nil {
@@ -205,6 +280,7 @@ type exchangePseudonymView struct {
 	Channel string
 	Wallet  string
 	Other   view.Identity
+	Options *recipientOptions
 }
 
 func (f *exchangePseudonymView) Call(context view.Context) (interface{}, error) {
@@ -223,11 +299,6 @@ func (f *exchangePseudonymView) Call(context view.Context) (interface{}, error)
 
 		return []view.Identity{me, other}, nil
 	} else {
-		session, err := context.GetSession(context.Initiator(), f.Other)
-		if err != nil {
-			return nil, err
-		}
-
 		ch := fabric.GetChannel(context, f.Network, f.Channel)
 		ts := token.GetManagementService(context, token.WithChannel(ch.Name()))
 		w := ts.WalletManager().OwnerWallet(f.Wallet)
@@ -257,14 +328,17 @@ func (f *exchangePseudonymView) Call(context view.Context) (interface{}, error)
 		if err != nil {
 			return nil, err
 		}
-		if err := session.Send(requestRaw); err != nil {
-			return nil, err
-		}
 
 		// Wait to receive a view identity
-		payload, err := session2.ReadMessageWithTimeout(session, 30*time.Second)
+		options := f.Options
+		if options == nil {
+			options = &recipientOptions{timeout: defaultRecipientTimeout}
+		}
+		payload, err := options.sendAndReceive(func() (view.Session, error) {
+			return context.GetSession(context.Initiator(), f.Other)
+		}, requestRaw)
 		if err != nil {
-			return nil, err
+			return nil, errors.WithMessagef(err, "failed exchanging recipient identities with [%s]", f.Other)
 		}
 
 		recipientData := &RecipientData{}
@@ -362,11 +436,18 @@ func (s *respondExchangePseudonymView) Call(context view.Context) (interface{},
 	return []view.Identity{me, other}, nil
 }
 
-func ExchangeRecipientIdentitiesInitiator(context view.Context, myWalletID string, recipient view.Identity) (view.Identity, view.Identity, error) {
+// ExchangeRecipientIdentitiesInitiator exchanges recipient identities with the passed party.
+// Reuse policies do not apply, fresh identities are always exchanged.
+func ExchangeRecipientIdentitiesInitiator(context view.Context, myWalletID string, recipient view.Identity, opts ...RecipientOption) (view.Identity, view.Identity, error) {
+	options, err := compileRecipientOptions(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
 	ids, err := context.RunView(&exchangePseudonymView{
 		Channel: "",
 		Wallet:  myWalletID,
 		Other:   recipient,
+		Options: options,
 	})
 	if err != nil {
 		return nil, nil, err
@@ -0,0 +1,173 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+)
+
+// fakeSession answers each request with reply, after delay. A reply arriving once the session is closed is dropped.
+type fakeSession struct {
+	reply    []byte
+	delay    time.Duration
+	sendErr  error
+	incoming chan *view.Message
+	closed   bool
+	// errors are the payloads passed to SendError
+	errors [][]byte
+}
+
+func newFakeSession(reply string, delay time.Duration) *fakeSession {
+	return &fakeSession{reply: []byte(reply), delay: delay, incoming: make(chan *view.Message, 10)}
+}
+
+func (f *fakeSession) Info() view.SessionInfo {
+	return view.SessionInfo{Closed: f.closed}
+}
+
+func (f *fakeSession) Send(payload []byte) error {
+	if f.sendErr != nil {
+		return f.sendErr
+	}
+	go func() {
+		time.Sleep(f.delay)
+		f.incoming <- &view.Message{Status: view.OK, Payload: f.reply}
+	}()
+	return nil
+}
+
+func (f *fakeSession) SendError(payload []byte) error {
+	f.errors = append(f.errors, payload)
+	return nil
+}
+
+func (f *fakeSession) Receive() <-chan *view.Message {
+	return f.incoming
+}
+
+func (f *fakeSession) Close() {
+	f.closed = true
+}
+
+// sessions returns a getSession handing out the passed sessions in order
+func sessions(ss ...*fakeSession) (func() (view.Session, error), *int) {
+	opened := 0
+	return func() (view.Session, error) {
+		if opened >= len(ss) {
+			return nil, errors.New("no more sessions")
+		}
+		opened++
+		return ss[opened-1], nil
+	}, &opened
+}
+
+func TestRecipientOptions(t *testing.T) {
+	options, err := compileRecipientOptions()
+	assert.NoError(t, err)
+	assert.Equal(t, defaultRecipientTimeout, options.timeout)
+	assert.Equal(t, 0, options.retries)
+	assert.Nil(t, options.reuse)
+
+	policy := &ReusePolicy{MaxUses: 2}
+	options, err = compileRecipientOptions(
+		WithRecipientTimeout(time.Second),
+		WithRecipientRetries(3, time.Millisecond),
+		WithReusePolicy(policy),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, options.timeout)
+	assert.Equal(t, 3, options.retries)
+	assert.Equal(t, time.Millisecond, options.retryDelay)
+	assert.Equal(t, policy, options.reuse)
+
+	_, err = compileRecipientOptions(WithRecipientTimeout(0))
+	assert.EqualError(t, err, "invalid timeout [0s]")
+	_, err = compileRecipientOptions(WithRecipientRetries(-1, 0))
+	assert.EqualError(t, err, "invalid number of retries [-1]")
+}
+
+func TestSendAndReceive(t *testing.T) {
+	options := &recipientOptions{timeout: 50 * time.Millisecond}
+
+	// the response arrives in time
+	s := newFakeSession("alice", 0)
+	getSession, opened := sessions(s)
+	payload, err := options.sendAndReceive(getSession, []byte("request"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("alice"), payload)
+	assert.Equal(t, 1, *opened)
+	assert.False(t, s.closed)
+
+	// without retries, a timeout fails the request and closes the session
+	s = newFakeSession("alice", time.Second)
+	getSession, opened = sessions(s)
+	_, err = options.sendAndReceive(getSession, []byte("request"))
+	assert.EqualError(t, err, "time out reached")
+	assert.Equal(t, 1, *opened)
+	assert.True(t, s.closed)
+
+	// each retry uses a new session, the late response to the first attempt is not read
+	options = &recipientOptions{timeout: 50 * time.Millisecond, retries: 2, retryDelay: time.Millisecond}
+	late := newFakeSession("stale", 200*time.Millisecond)
+	failing := newFakeSession("", 0)
+	failing.sendErr = errors.New("connection refused")
+	s = newFakeSession("fresh", 0)
+	getSession, opened = sessions(late, failing, s)
+	payload, err = options.sendAndReceive(getSession, []byte("request"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("fresh"), payload)
+	assert.Equal(t, 3, *opened)
+	assert.True(t, late.closed)
+	assert.True(t, failing.closed)
+	assert.False(t, s.closed)
+
+	// retries are bounded, the last error is returned
+	getSession, opened = sessions(newFakeSession("", time.Second), newFakeSession("", time.Second))
+	options = &recipientOptions{timeout: 10 * time.Millisecond, retries: 1}
+	_, err = options.sendAndReceive(getSession, []byte("request"))
+	assert.EqualError(t, err, "time out reached")
+	assert.Equal(t, 2, *opened)
+
+	// a session that cannot be opened counts as a failed attempt
+	getSession, _ = sessions()
+	_, err = options.sendAndReceive(getSession, []byte("request"))
+	assert.EqualError(t, err, "no more sessions")
+}
+
+func TestRecipientCache(t *testing.T) {
+	cache := &recipientCache{entries: map[string]*cachedRecipient{}}
+	key := recipientCacheKey("ch", view.Identity("bob"))
+	assert.NotEqual(t, key, recipientCacheKey("other", view.Identity("bob")))
+	now := time.Now()
+
+	assert.Nil(t, cache.get(key, &ReusePolicy{}, now))
+
+	// the number of uses is bounded, obtaining the identity is its first use
+	cache.put(key, view.Identity("bob1"), now)
+	policy := &ReusePolicy{MaxUses: 3}
+	assert.Equal(t, view.Identity("bob1"), cache.get(key, policy, now))
+	assert.Equal(t, view.Identity("bob1"), cache.get(key, policy, now))
+	assert.Nil(t, cache.get(key, policy, now))
+	assert.Nil(t, cache.get(key, &ReusePolicy{}, now), "an exhausted entry is evicted")
+
+	// the age is bounded
+	cache.put(key, view.Identity("bob2"), now)
+	policy = &ReusePolicy{MaxAge: time.Minute}
+	assert.Equal(t, view.Identity("bob2"), cache.get(key, policy, now.Add(time.Minute)))
+	assert.Nil(t, cache.get(key, policy, now.Add(time.Minute+time.Second)))
+
+	// no limit
+	cache.put(key, view.Identity("bob3"), now)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, view.Identity("bob3"), cache.get(key, &ReusePolicy{}, now.Add(time.Hour)))
+	}
+}
@@ -0,0 +1,326 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package ttxcc
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	session2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/session"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
+)
+
+const swapAck = "ack"
+
+// SwapTerms are the terms of an atomic swap as seen by the initiator
+type SwapTerms struct {
+	GiveType     string
+	GiveQuantity uint64
+	// Counterparty is the recipient identity of the counterparty, it receives the give leg
+	Counterparty view.Identity
+	WantType     string
+	WantQuantity uint64
+	// Recipient is the recipient identity of the initiator, it receives the want leg
+	Recipient *RecipientData
+	// GiveTransfer is the index of the transfer action of the give leg
+	GiveTransfer int
+}
+
+// Swap adds to the transaction the leg of an atomic swap in which giveQty tokens of type giveType, owned by myWallet,
+// are exchanged with wantQty tokens of type wantType, owned by the counterparty.
+// The leg of the counterparty is negotiated by running the view returned by NewSwapInitiatorView.
+func (t *Transaction) Swap(myWallet *token.OwnerWallet, giveType string, giveQty uint64, counterparty view.Identity, wantType string, wantQty uint64, opts ...token.TransferOption) error {
+	if t.swap != nil {
+		return errors.New("a swap has already been added to the transaction")
+	}
+	if giveQty == 0 || wantQty == 0 {
+		return errors.New("swap quantities must be positive")
+	}
+	recipient, err := myWallet.GetRecipientIdentity()
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting recipient identity")
+	}
+	auditInfo, err := myWallet.GetAuditInfo(recipient)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting recipient identity audit info, wallet [%s]", myWallet.ID())
+	}
+	metadata, err := myWallet.GetTokenMetadata(recipient)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting recipient identity metadata, wallet [%s]", myWallet.ID())
+	}
+	terms := &SwapTerms{
+		GiveType:     giveType,
+		GiveQuantity: giveQty,
+		Counterparty: counterparty,
+		WantType:     wantType,
+		WantQuantity: wantQty,
+		Recipient: &RecipientData{
+			Identity:  recipient,
+			AuditInfo: auditInfo,
+			Metadata:  metadata,
+		},
+		GiveTransfer: len(t.TokenRequest.Actions.Transfers),
+	}
+	if err := t.Transfer(myWallet, giveType, []uint64{giveQty}, []view.Identity{counterparty}, opts...); err != nil {
+		return errors.WithMessagef(err, "failed adding give leg")
+	}
+	t.swap = terms
+	return nil
+}
+
+// ReceiveSwap receives a transaction and the terms of the swap proposed by the initiator
+func ReceiveSwap(context view.Context) (*Transaction, *SwapTerms, error) {
+	txBoxed, err := context.RunView(NewReceiveTransactionView(""))
+	if err != nil {
+		return nil, nil, err
+	}
+	tx := txBoxed.(*Transaction)
+
+	payload, err := session2.ReadMessageWithTimeout(context.Session(), 120*time.Second)
+	if err != nil {
+		return nil, nil, err
+	}
+	terms := &SwapTerms{}
+	if err := json.Unmarshal(payload, terms); err != nil {
+		return nil, nil, errors.Wrap(err, "failed unmarshalling swap terms")
+	}
+	if terms.Recipient == nil {
+		return nil, nil, errors.New("invalid swap terms, missing recipient")
+	}
+	recipient := terms.Recipient
+	if err := tx.TokenService().WalletManager().RegisterRecipientIdentity(recipient.Identity, recipient.AuditInfo, recipient.Metadata); err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed registering recipient identity")
+	}
+	return tx, terms, nil
+}
+
+type swapInitiatorView struct {
+	tx *Transaction
+}
+
+// NewSwapInitiatorView returns a view that asks the counterparty of the swap added with Transaction.Swap to add its leg.
+// The view checks the leg of the counterparty and declares the swap in the token request.
+func NewSwapInitiatorView(tx *Transaction) *swapInitiatorView {
+	return &swapInitiatorView{tx: tx}
+}
+
+func (s *swapInitiatorView) Call(context view.Context) (interface{}, error) {
+	terms := s.tx.swap
+	if terms == nil {
+		return nil, errors.New("no swap added to the transaction")
+	}
+	party := terms.Counterparty
+	session, err := context.GetSession(context.Initiator(), party)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed getting session")
+	}
+
+	// Send transaction and terms
+	txRaw, err := s.tx.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling transaction")
+	}
+	if err := session.Send(txRaw); err != nil {
+		return nil, errors.Wrap(err, "failed sending transaction")
+	}
+	if err := session.Send(marshalOrPanic(terms)); err != nil {
+		return nil, errors.Wrap(err, "failed sending swap terms")
+	}
+
+	// Receive the transaction with the leg of the counterparty
+	msg, err := receiveWithTimeout(session, party)
+	if err != nil {
+		return nil, err
+	}
+	txPayload := &Payload{
+		Transient: map[string][]byte{},
+	}
+	if err := json.Unmarshal(msg, txPayload); err != nil {
+		return nil, errors.Wrap(err, "failed unmarshalling reply")
+	}
+	txPayload.TokenRequest.SetTokenService(s.tx.TokenService())
+	if err := txPayload.TokenRequest.Verify(); err != nil {
+		return nil, errors.Wrap(err, "failed verifying response")
+	}
+	wantTransfer, err := checkCounterpartyLeg(s.tx.TokenRequest, txPayload.TokenRequest, terms)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "invalid swap leg from [%s]", party)
+	}
+
+	// Append and declare the swap
+	if err := s.tx.appendPayload(txPayload); err != nil {
+		return nil, errors.Wrap(err, "failed appending payload")
+	}
+	if err := txPayload.TokenRequest.BindTo(context, party); err != nil {
+		return nil, errors.Wrapf(err, "failed binding to [%s]", party.String())
+	}
+	if err := s.tx.TokenRequest.DeclareSwap(terms.GiveTransfer, 0, wantTransfer, 0); err != nil {
+		return nil, errors.WithMessagef(err, "failed declaring swap")
+	}
+
+	// Send the final transaction and wait for the counterparty to accept it
+	txRaw, err = s.tx.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling transaction")
+	}
+	if err := session.Send(txRaw); err != nil {
+		return nil, errors.Wrap(err, "failed sending transaction")
+	}
+	msg, err = receiveWithTimeout(session, party)
+	if err != nil {
+		return nil, err
+	}
+	if string(msg) != swapAck {
+		return nil, errors.Errorf("swap not accepted by [%s]", party)
+	}
+	return s.tx, nil
+}
+
+type swapResponderView struct {
+	tx     *Transaction
+	terms  *SwapTerms
+	wallet *token.OwnerWallet
+}
+
+// NewSwapResponderView returns a view that checks the leg of the initiator, adds the leg of the passed wallet,
+// and accepts the final transaction only if the declared swap matches the passed terms.
+func NewSwapResponderView(tx *Transaction, terms *SwapTerms, wallet *token.OwnerWallet) *swapResponderView {
+	return &swapResponderView{tx: tx, terms: terms, wallet: wallet}
+}
+
+func (s *swapResponderView) Call(context view.Context) (interface{}, error) {
+	if err := s.tx.Verify(); err != nil {
+		return nil, errors.WithMessagef(err, "failed verifying transaction")
+	}
+	if err := checkLeg(s.tx.TokenRequest, s.terms.GiveTransfer, s.terms.Counterparty, s.terms.GiveType, s.terms.GiveQuantity); err != nil {
+		return nil, errors.WithMessagef(err, "invalid swap leg of the initiator")
+	}
+	if s.tx.TokenService().WalletManager().OwnerWalletByIdentity(s.terms.Counterparty) == nil {
+		return nil, errors.Errorf("swap counterparty [%s] is not me", s.terms.Counterparty)
+	}
+
+	// Add my leg and send back
+	wantTransfer := len(s.tx.TokenRequest.Actions.Transfers)
+	if err := s.tx.Transfer(s.wallet, s.terms.WantType, []uint64{s.terms.WantQuantity}, []view.Identity{s.terms.Recipient.Identity}); err != nil {
+		return nil, errors.WithMessagef(err, "failed adding want leg")
+	}
+	raw, err := s.tx.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling transaction")
+	}
+	session := context.Session()
+	if err := session.Send(raw); err != nil {
+		return nil, errors.Wrap(err, "failed sending back transaction")
+	}
+
+	// Receive the final transaction and check the declared swap
+	txBoxed, err := context.RunView(NewReceiveTransactionView(s.tx.Network()))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed receiving final transaction")
+	}
+	final := txBoxed.(*Transaction)
+	if final.ID() != s.tx.ID() {
+		return nil, errors.Errorf("final transaction id [%s] does not match [%s]", final.ID(), s.tx.ID())
+	}
+	if err := checkUnchanged(s.tx.TokenRequest.Actions, final.TokenRequest.Actions); err != nil {
+		return nil, err
+	}
+	swaps, err := final.TokenRequest.Swaps()
+	if err != nil {
+		return nil, err
+	}
+	if len(swaps) != 1 ||
+		!legMatches(swaps[0].Give, s.terms.GiveTransfer, s.terms.GiveType, s.terms.GiveQuantity) ||
+		!legMatches(swaps[0].Want, wantTransfer, s.terms.WantType, s.terms.WantQuantity) {
+		return nil, errors.New("declared swap does not match the negotiated terms")
+	}
+
+	// Adopt the final request, the endorsement will only sign it
+	s.tx.TokenRequest = final.TokenRequest
+	if err := session.Send([]byte(swapAck)); err != nil {
+		return nil, errors.Wrap(err, "failed sending ack")
+	}
+	return s.tx, nil
+}
+
+// checkCounterpartyLeg checks that the counterparty appended a single transfer with the want leg
+// and left the rest of the request untouched. It returns the index of the want leg transfer.
+func checkCounterpartyLeg(mine, theirs *token.Request, terms *SwapTerms) (int, error) {
+	if len(theirs.Actions.Transfers) != len(mine.Actions.Transfers)+1 {
+		return 0, errors.Errorf("expected one more transfer, got [%d] from [%d]", len(theirs.Actions.Transfers), len(mine.Actions.Transfers))
+	}
+	if err := checkUnchanged(mine.Actions, &api2.TokenRequest{
+		Issues:    theirs.Actions.Issues,
+		Transfers: theirs.Actions.Transfers[:len(mine.Actions.Transfers)],
+		Swaps:     theirs.Actions.Swaps,
+	}); err != nil {
+		return 0, err
+	}
+	wantTransfer := len(mine.Actions.Transfers)
+	if err := checkLeg(theirs, wantTransfer, terms.Recipient.Identity, terms.WantType, terms.WantQuantity); err != nil {
+		return 0, err
+	}
+	return wantTransfer, nil
+}
+
+// checkLeg checks that the first output of the passed transfer carries quantity tokens of type typ to recipient
+func checkLeg(request *token.Request, transfer int, recipient view.Identity, typ string, quantity uint64) error {
+	if transfer < 0 || transfer >= len(request.Metadata.Transfers) {
+		return errors.Errorf("transfer [%d] not found", transfer)
+	}
+	receivers := request.Metadata.Transfers[transfer].Receivers
+	if len(receivers) == 0 || !receivers[0].Equal(recipient) {
+		return errors.Errorf("first output of transfer [%d] is not for [%s]", transfer, recipient)
+	}
+	leg, err := request.SwapLeg(transfer, 0)
+	if err != nil {
+		return err
+	}
+	if !legMatches(leg, transfer, typ, quantity) {
+		return errors.Errorf("first output of transfer [%d] does not carry [%d] of type [%s]", transfer, quantity, typ)
+	}
+	return nil
+}
+
+func legMatches(leg *api2.SwapLeg, transfer int, typ string, quantity uint64) bool {
+	return leg != nil && leg.Transfer == transfer && leg.Output == 0 && leg.Type == typ && leg.Quantity == quantity
+}
+
+// checkUnchanged checks that the issues and the transfers of expected are a prefix of the ones of actual
+func checkUnchanged(expected, actual *api2.TokenRequest) error {
+	if len(actual.Issues) != len(expected.Issues) || len(actual.Transfers) < len(expected.Transfers) {
+		return errors.New("the actions of the transaction have been changed")
+	}
+	for i := range expected.Issues {
+		if !bytes.Equal(expected.Issues[i], actual.Issues[i]) {
+			return errors.Errorf("issue [%d] has been changed", i)
+		}
+	}
+	for i := range expected.Transfers {
+		if !bytes.Equal(expected.Transfers[i], actual.Transfers[i]) {
+			return errors.Errorf("transfer [%d] has been changed", i)
+		}
+	}
+	return nil
+}
+
+func receiveWithTimeout(session view.Session, party view.Identity) ([]byte, error) {
+	select {
+	case msg := <-session.Receive():
+		if msg.Status == view.ERROR {
+			return nil, errors.New(string(msg.Payload))
+		}
+		return msg.Payload, nil
+	case <-time.After(60 * time.Second):
+		return nil, errors.Errorf("Timeout from party %s", party)
+	}
+}
@@ -7,10 +7,12 @@ package ttxcc
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric/services/endorser"
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
@@ -28,12 +30,22 @@ type Payload struct {
 	TokenRequest *token.Request
 
 	FabricEnvelope *fabric.Envelope
+
+	// Backend is the name of the backend the token request reaches the ledger with, empty means the chaincode backend
+	Backend string `json:",omitempty"`
+	// EndorserTransaction carries the token request when the namespace backend is used
+	EndorserTransaction []byte `json:",omitempty"`
+	// Deadline is the time by which the collection of the endorsements must complete, zero if there is none
+	Deadline time.Time
 }
 
 type Transaction struct {
 	*Payload
 	sp   view2.ServiceProvider
 	opts *txOptions
+	swap *SwapTerms
+	// settlement is true if the transaction pays out the deposit of a closed dispute
+	settlement bool
 }
 
 func NewAnonymousTransaction(sp view.Context, opts ...TxOption) (*Transaction, error) {
@@ -61,26 +73,31 @@ func NewTransaction(sp view.Context, signer view.Identity, opts ...TxOption) (*T
 		token.WithNamespace(txOpts.namespace),
 	)
 
-	id := &fabric.TxID{Creator: signer}
-	tr, err := tms.NewRequest(fabric.GetFabricNetworkService(sp, tms.Network()).TransactionManager().ComputeTxID(id))
-	if err != nil {
-		return nil, errors.WithMessage(err, "failed init token request")
-	}
-
 	tx := &Transaction{
 		Payload: &Payload{
 			Signer:         signer,
-			TokenRequest:   tr,
 			FabricEnvelope: nil,
-			Id:             *id,
+			Id:             fabric.TxID{Creator: signer},
 			Network:        tms.Network(),
 			Channel:        tms.Channel(),
 			Namespace:      tms.Namespace(),
 			Transient:      map[string][]byte{},
+			Backend:        txOpts.backend,
 		},
 		sp:   sp,
 		opts: txOpts,
 	}
+	backend, err := tx.backend()
+	if err != nil {
+		return nil, err
+	}
+	if err := backend.Init(sp, tx); err != nil {
+		return nil, errors.WithMessage(err, "failed init transaction backend")
+	}
+	tx.TokenRequest, err = tms.NewRequest(tx.ID())
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed init token request")
+	}
 	sp.OnError(tx.Release)
 	return tx, nil
 }
@@ -114,10 +131,60 @@ func NewTransactionFromBytes(sp view.Context, network string, raw []byte) (*Tran
 	return tx, err
 }
 
+// Wrap returns a transaction whose token request is carried by the passed endorser transaction, next to the
+// namespaces of other applications. The NamespaceBackend is used. If the endorser transaction carries a token
+// request already, as when it is received once endorsed, the request is loaded from it.
+func Wrap(context view.Context, etx *endorser.Transaction, opts ...TxOption) (*Transaction, error) {
+	txOpts, err := compile(opts...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed compiling tx options")
+	}
+	txOpts.backend = NamespaceBackend
+
+	tms := token.GetManagementService(
+		context,
+		token.WithNetwork(etx.Network()),
+		token.WithChannel(etx.Channel()),
+		token.WithNamespace(txOpts.namespace),
+	)
+	creator := etx.Transaction.Creator()
+	tx := &Transaction{
+		Payload: &Payload{
+			Signer:    creator,
+			Id:        fabric.TxID{Nonce: etx.Transaction.Nonce(), Creator: creator},
+			Network:   tms.Network(),
+			Channel:   tms.Channel(),
+			Namespace: tms.Namespace(),
+			Transient: map[string][]byte{},
+			Backend:   NamespaceBackend,
+		},
+		sp:   context,
+		opts: txOpts,
+	}
+	if tx.ID() != etx.ID() {
+		return nil, errors.Errorf("invalid endorser transaction, transaction ids do not match [%s][%s]", etx.ID(), tx.ID())
+	}
+	tx.TokenRequest, err = loadRequest(tms, etx)
+	if err != nil {
+		return nil, err
+	}
+	if err := (&namespaceBackend{}).setTransaction(tx, etx); err != nil {
+		return nil, err
+	}
+	context.OnError(tx.Release)
+	return tx, nil
+}
+
 func ReceiveTransaction(context view.Context) (*Transaction, error) {
+	return receiveTransaction(context, defaultDistributionTimeout)
+}
+
+func receiveTransaction(context view.Context, timeout time.Duration) (*Transaction, error) {
 	logger.Debugf("receive a new transaction...")
 
-	txBoxed, err := context.RunView(NewReceiveTransactionView(""))
+	v := NewReceiveTransactionView("")
+	v.timeout = timeout
+	txBoxed, err := context.RunView(v)
 	if err != nil {
 		return nil, err
 	}
@@ -131,6 +198,12 @@ func ReceiveTransaction(context view.Context) (*Transaction, error) {
 	return cctx, nil
 }
 
+// EndorserTransaction returns the endorser transaction carrying the token request when the NamespaceBackend is used.
+// Once the endorsements are collected, it carries the token request and the approvals of the token namespace.
+func (t *Transaction) EndorserTransaction(context view.Context) (*endorser.Transaction, error) {
+	return (&namespaceBackend{}).transaction(context, t)
+}
+
 func (t *Transaction) ID() string {
 	return fabric.GetFabricNetworkService(t.sp, t.Network()).TransactionManager().ComputeTxID(&t.Payload.Id)
 }
@@ -151,6 +224,48 @@ func (t *Transaction) Bytes() ([]byte, error) {
 	return json.Marshal(t.Payload)
 }
 
+// BytesFor returns the serialization of this transaction to send to the passed party.
+// The content of the private memos whose recipient is not the party is removed.
+func (t *Transaction) BytesFor(party view.Identity) ([]byte, error) {
+	if len(t.TokenRequest.Metadata.Memos) == 0 {
+		return t.Bytes()
+	}
+	resolver := view2.GetEndpointService(t.sp)
+	longTerm, _, _, err := resolver.Resolve(party)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot resolve long term identity for [%s]", party.UniqueID())
+	}
+	request := t.TokenRequest.FilterMemos(func(recipient view.Identity) bool {
+		if recipient.Equal(party) {
+			return true
+		}
+		id, _, _, err := resolver.Resolve(recipient)
+		return err == nil && id.Equal(longTerm)
+	})
+
+	payload := *t.Payload
+	payload.TokenRequest = request
+	if t.Payload.Transient.Exists("zkat") {
+		raw, err := request.MetadataToBytes()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed marshalling token request metadata")
+		}
+		payload.Transient = map[string][]byte{}
+		for k, v := range t.Payload.Transient {
+			payload.Transient[k] = v
+		}
+		if err := payload.Transient.Set("zkat", raw); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(&payload)
+}
+
+// SetMemo attaches the passed memo to the token request as a whole
+func (t *Transaction) SetMemo(key string, value []byte) error {
+	return t.TokenRequest.SetMemo(key, value)
+}
+
 func (t *Transaction) Issue(wallet *token.IssuerWallet, receiver view.Identity, typ string, q uint64) error {
 	_, err := t.TokenRequest.Issue(wallet, receiver, typ, q)
 	return err
@@ -165,6 +280,11 @@ func (t *Transaction) Redeem(wallet *token.OwnerWallet, typ string, value uint64
 	return t.TokenRequest.Redeem(wallet, typ, value, opts...)
 }
 
+// RedeemValues redeems the passed values from the passed wallet, the rest goes back to the wallet
+func (t *Transaction) RedeemValues(wallet *token.OwnerWallet, typ string, values []uint64, opts ...token.TransferOption) error {
+	return t.TokenRequest.RedeemValues(wallet, typ, values, opts...)
+}
+
 func (t *Transaction) Outputs() (*token.OutputStream, error) {
 	return t.TokenRequest.Outputs()
 }
@@ -215,6 +335,35 @@ func (t *Transaction) storeTransient() error {
 	return ch.Vault().StoreTransient(t.ID(), fabric.TransientMap(t.Payload.Transient))
 }
 
+func (t *Transaction) backend() (Backend, error) {
+	return getBackend(t.Payload.Backend)
+}
+
+// store stores the transaction in the local vault, once endorsed
+func (t *Transaction) store(context view.Context) error {
+	backend, err := t.backend()
+	if err != nil {
+		return err
+	}
+	return backend.Store(context, t)
+}
+
+// boundedTimeout returns the passed timeout bounded by the deadline of the transaction, if any.
+// It returns an error if the deadline has been exceeded. A zero result means that the wait is not bounded.
+func (t *Transaction) boundedTimeout(timeout time.Duration) (time.Duration, error) {
+	if t.Deadline.IsZero() {
+		return timeout, nil
+	}
+	remaining := time.Until(t.Deadline)
+	if remaining <= 0 {
+		return 0, errors.Errorf("deadline exceeded for transaction [%s]", t.ID())
+	}
+	if timeout == 0 || remaining < timeout {
+		timeout = remaining
+	}
+	return timeout, nil
+}
+
 func (t *Transaction) setEnvelope(envelope *fabric.Envelope) error {
 	t.Payload.Id.Nonce = envelope.Nonce()
 	t.Payload.Id.Creator = envelope.Creator()
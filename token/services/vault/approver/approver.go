@@ -30,21 +30,30 @@ type Verifier interface {
 
 type SignatureProvider = func(id view.Identity, verifier Verifier) error
 
+// RequestValidator verifies serialized token requests carrying their own signatures
+type RequestValidator interface {
+	UnmarshallAndVerify(ledger token.Ledger, binding string, raw []byte) ([]interface{}, error)
+}
+
 type approver struct {
-	vault     Vault
-	validator translator.Validator
-	TxID      string
-	rwset     translator.RWSet
-	namespace string
+	vault            Vault
+	validator        translator.Validator
+	issuingValidator translator.IssuingValidator
+	TxID             string
+	rwset            translator.RWSet
+	namespace        string
 }
 
-func NewTokenRWSetApprover(validator translator.Validator, vault Vault, txID string, RWSet translator.RWSet, namespace string) *approver {
+// NewTokenRWSetApprover returns an approver of the passed rws, the issues of the token request are checked
+// with the passed IssuingValidator
+func NewTokenRWSetApprover(validator translator.Validator, issuingValidator translator.IssuingValidator, vault Vault, txID string, RWSet translator.RWSet, namespace string) *approver {
 	return &approver{
-		vault:     vault,
-		TxID:      txID,
-		rwset:     RWSet,
-		validator: validator,
-		namespace: namespace,
+		vault:            vault,
+		TxID:             txID,
+		rwset:            RWSet,
+		validator:        validator,
+		issuingValidator: issuingValidator,
+		namespace:        namespace,
 	}
 }
 
@@ -65,24 +74,54 @@ func (v *approver) Validate(sp SignatureProvider, tokenRequest *token.Request) e
 	}
 	qe.Done()
 
-	logger.Debugf("verify rws for tx [%d]", v.TxID)
+	tokenRequestRaw, err := tokenRequest.RequestToBytes()
+	if err != nil {
+		return errors.Wrap(err, "failed serializing token request")
+	}
+	if err := v.checkRWSet(actions, tokenRequestRaw); err != nil {
+		return err
+	}
+
+	logger.Debugf("approve token request for tx [%d] done", v.TxID)
+	return nil
+}
+
+// ValidateRequest validates the passed serialized token request, whose signatures are checked against the request itself,
+// and checks that the rws contains exactly what the request prescribes
+func (v *approver) ValidateRequest(validator RequestValidator, tokenRequestRaw []byte) error {
+	logger.Debugf("verify token request for tx [%s]", v.TxID)
+	qe, err := v.vault.NewQueryExecutor()
+	if err != nil {
+		return errors.Wrap(err, "failed getting query executor")
+	}
+	defer qe.Done()
+	actions, err := validator.UnmarshallAndVerify(&backend{qe: qe, namespace: v.namespace}, v.TxID, tokenRequestRaw)
+	if err != nil {
+		return errors.Wrap(err, "failed verifying token request")
+	}
+	qe.Done()
+
+	if err := v.checkRWSet(actions, tokenRequestRaw); err != nil {
+		return err
+	}
+	logger.Debugf("approve token request for tx [%s] done", v.TxID)
+	return nil
+}
+
+// checkRWSet regenerates the rws from the passed actions and compares it with the one to approve
+func (v *approver) checkRWSet(actions []interface{}, tokenRequestRaw []byte) error {
+	logger.Debugf("verify rws for tx [%s]", v.TxID)
 	rwset, err := v.vault.NewRWSet(getRandomId())
 	if err != nil {
 		return errors.Wrap(err, "failed creating new rws")
 	}
-	// TODO: use a proper issuerValidator
-	issuingValidator := &allIssuersValid{}
-	translator := translator.New(issuingValidator, v.TxID, rwset, v.namespace)
+	translator := translator.New(v.issuingValidator, v.TxID, rwset, v.namespace)
 	for _, action := range actions {
 		err = translator.Write(action)
 		if err != nil {
 			return errors.Wrap(err, "failed writing token action")
 		}
 	}
-	tokenRequestRaw, err := tokenRequest.RequestToBytes()
-	if err != nil {
-		return errors.Wrap(err, "failed serializing token request")
-	}
 	err = translator.CommitTokenRequest(tokenRequestRaw)
 	if err != nil {
 		return errors.Wrap(err, "failed writing token request")
@@ -91,14 +130,6 @@ func (v *approver) Validate(sp SignatureProvider, tokenRequest *token.Request) e
 	if err := rwset.Equals(v.rwset, v.namespace); err != nil {
 		return errors.Wrap(err, "invalid rws, regenerate rws does not match")
 	}
-
-	logger.Debugf("approve token request for tx [%d] done", v.TxID)
-	return nil
-}
-
-type allIssuersValid struct{}
-
-func (i *allIssuersValid) Validate(creator view.Identity, tokenType string) error {
 	return nil
 }
 
@@ -15,6 +15,8 @@ import (
 	"github.com/pkg/errors"
 )
 
+const certificationPrefix = "token-sdk.certifier.certification"
+
 type Channel interface {
 	Name() string
 	Vault() *fabric.Vault
@@ -31,30 +33,12 @@ func NewStorage(sp view.ServiceProvider, channel Channel, namespace string) *Sto
 }
 
 func (v *Storage) Exists(id *token.Id) bool {
-	k := kvs.CreateCompositeKeyOrPanic(
-		"token-sdk.certifier.certification",
-		[]string{
-			v.channel.Name(),
-			v.namespace,
-			id.TxId,
-			strconv.FormatUint(uint64(id.Index), 10),
-		},
-	)
-	return kvs.GetService(v.sp).Exists(k)
+	return kvs.GetService(v.sp).Exists(v.certificationKey(id))
 }
 
 func (v *Storage) Store(certifications map[*token.Id][]byte) error {
 	for id, certification := range certifications {
-		k := kvs.CreateCompositeKeyOrPanic(
-			"token-sdk.certifier.certification",
-			[]string{
-				v.channel.Name(),
-				v.namespace,
-				id.TxId,
-				strconv.FormatUint(uint64(id.Index), 10),
-			},
-		)
-		if err := kvs.GetService(v.sp).Put(k, certification); err != nil {
+		if err := kvs.GetService(v.sp).Put(v.certificationKey(id), certification); err != nil {
 			return err
 		}
 	}
@@ -63,15 +47,7 @@ func (v *Storage) Store(certifications map[*token.Id][]byte) error {
 
 func (v *Storage) Get(ids []*token.Id, callback func(*token.Id, []byte) error) error {
 	for _, id := range ids {
-		k := kvs.CreateCompositeKeyOrPanic(
-			"token-sdk.certifier.certification",
-			[]string{
-				v.channel.Name(),
-				v.namespace,
-				id.TxId,
-				strconv.FormatUint(uint64(id.Index), 10),
-			},
-		)
+		k := v.certificationKey(id)
 		var certification []byte
 		if err := kvs.GetService(v.sp).Get(k, &certification); err != nil {
 			return errors.WithMessagef(err, "failed getting certification from storage for [%s]", k)
@@ -82,3 +58,16 @@ func (v *Storage) Get(ids []*token.Id, callback func(*token.Id, []byte) error) e
 	}
 	return nil
 }
+
+func (v *Storage) attributes(id *token.Id) []string {
+	return []string{
+		v.channel.Name(),
+		v.namespace,
+		id.TxId,
+		strconv.FormatUint(uint64(id.Index), 10),
+	}
+}
+
+func (v *Storage) certificationKey(id *token.Id) string {
+	return kvs.CreateCompositeKeyOrPanic(certificationPrefix, v.attributes(id))
+}
@@ -36,6 +36,9 @@ const (
 	TokenRequestKeyPrefix              = "token_request"
 	OwnerSeparator                     = "/"
 	SerialNumber                       = "sn"
+	RevokedKeyPrefix                   = "revoked"
+	DisputeKeyPrefix                   = "dispute"
+	BalanceKeyPrefix                   = "balance"
 )
 
 func GetTokenIdFromKey(key string) (*token2.Id, error) {
@@ -106,6 +109,11 @@ func CreateSetupBundleKey() (string, error) {
 	return CreateCompositeKey(TokenKeyPrefix, []string{TokenSetupKeyPrefix, "bundle"})
 }
 
+// CreateBalanceKey returns the key of the cached balance of the passed owner wallet for the passed token type
+func CreateBalanceKey(walletID, tokenType string) (string, error) {
+	return CreateCompositeKey(BalanceKeyPrefix, []string{walletID, tokenType})
+}
+
 func CreateTokenRequestKey(txID string) (string, error) {
 	return CreateCompositeKey(TokenKeyPrefix, []string{TokenRequestKeyPrefix, txID})
 }
@@ -142,6 +150,16 @@ func CreateIssuedHistoryTokenKey(txID string, index int) (string, error) {
 	return CreateCompositeKey(IssuedHistoryTokenKeyPrefix, []string{txID, strconv.Itoa(index)})
 }
 
+// CreateRevokedCertificationKey returns the key under which the revocation of the certification of a token is recorded
+func CreateRevokedCertificationKey(txID string, index int) (string, error) {
+	return CreateCompositeKey(RevokedKeyPrefix, []string{txID, strconv.Itoa(index)})
+}
+
+// CreateDisputeKey returns the key under which the dispute on an off-chain channel is recorded
+func CreateDisputeKey(channelID string) (string, error) {
+	return CreateCompositeKey(DisputeKeyPrefix, []string{channelID})
+}
+
 /*
 func GetSNFromKey(key string) (string, error) {
 	_, components, err := SplitCompositeKey(key)
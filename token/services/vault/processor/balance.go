@@ -0,0 +1,129 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package processor
+
+import (
+	"sort"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/pkg/errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// State is the part of the vault the balances are read from and written to
+type State interface {
+	GetState(namespace string, key string, opts ...fabric.GetStateOpt) ([]byte, error)
+	SetState(namespace string, key string, value []byte) error
+}
+
+// Rescan returns the balance of the passed wallet for the passed token type computed from the tokens in the vault,
+// it is used when the balance is not cached yet
+type Rescan func(walletID, typ string) (token2.Quantity, error)
+
+type balanceKey struct {
+	wallet string
+	typ    string
+}
+
+// Balances accumulates the changes a transaction makes to the balances of the owner wallets,
+// to apply them to the cached balances at once
+type Balances struct {
+	received map[balanceKey]token2.Quantity
+	spent    map[balanceKey]token2.Quantity
+}
+
+func NewBalances() *Balances {
+	return &Balances{
+		received: map[balanceKey]token2.Quantity{},
+		spent:    map[balanceKey]token2.Quantity{},
+	}
+}
+
+// Received records that the passed wallet received the passed token
+func (b *Balances) Received(walletID string, tok *token2.Token) error {
+	return b.add(b.received, walletID, tok)
+}
+
+// Spent records that the passed wallet spent the passed token
+func (b *Balances) Spent(walletID string, tok *token2.Token) error {
+	return b.add(b.spent, walletID, tok)
+}
+
+func (b *Balances) add(m map[balanceKey]token2.Quantity, walletID string, tok *token2.Token) error {
+	if len(walletID) == 0 {
+		return nil
+	}
+	q, err := token2.ToQuantity(tok.Quantity, keys.Precision)
+	if err != nil {
+		return errors.Wrapf(err, "invalid quantity [%s]", tok.Quantity)
+	}
+	k := balanceKey{wallet: walletID, typ: tok.Type}
+	if sum, ok := m[k]; ok {
+		q = sum.Add(q)
+	}
+	m[k] = q
+	return nil
+}
+
+// Apply updates the balances cached in the passed state, the balances not cached yet are computed with the passed rescan.
+// It fails if a wallet spends more than its balance, which means the cache is corrupted.
+func (b *Balances) Apply(ns string, state State, rescan Rescan) error {
+	var bks []balanceKey
+	for k := range b.received {
+		bks = append(bks, k)
+	}
+	for k := range b.spent {
+		if _, ok := b.received[k]; !ok {
+			bks = append(bks, k)
+		}
+	}
+	sort.Slice(bks, func(i, j int) bool {
+		if bks[i].wallet != bks[j].wallet {
+			return bks[i].wallet < bks[j].wallet
+		}
+		return bks[i].typ < bks[j].typ
+	})
+
+	for _, k := range bks {
+		key, err := keys.CreateBalanceKey(k.wallet, k.typ)
+		if err != nil {
+			return errors.Wrapf(err, "failed creating balance key for [%s:%s]", k.wallet, k.typ)
+		}
+		raw, err := state.GetState(ns, key)
+		if err != nil {
+			return errors.Wrapf(err, "failed getting balance of [%s:%s]", k.wallet, k.typ)
+		}
+		var balance token2.Quantity
+		if len(raw) != 0 {
+			balance, err = token2.ToQuantity(string(raw), keys.Precision)
+			if err != nil {
+				return errors.Wrapf(err, "invalid balance of [%s:%s]", k.wallet, k.typ)
+			}
+		} else {
+			logger.Debugf("balance of [%s:%s] not cached, rescan", k.wallet, k.typ)
+			balance, err = rescan(k.wallet, k.typ)
+			if err != nil {
+				return errors.WithMessagef(err, "failed rescanning balance of [%s:%s]", k.wallet, k.typ)
+			}
+		}
+		if q, ok := b.received[k]; ok {
+			balance = balance.Add(q)
+		}
+		if q, ok := b.spent[k]; ok {
+			if balance.Cmp(q) < 0 {
+				return errors.Errorf("balance of [%s:%s] is lower than the spent quantity [%s<%s]", k.wallet, k.typ, balance.Decimal(), q.Decimal())
+			}
+			balance = balance.Sub(q)
+		}
+		logger.Debugf("balance of [%s:%s] is now [%s]", k.wallet, k.typ, balance.Decimal())
+		if err := state.SetState(ns, key, []byte(balance.Decimal())); err != nil {
+			return errors.Wrapf(err, "failed setting balance of [%s:%s]", k.wallet, k.typ)
+		}
+	}
+	return nil
+}
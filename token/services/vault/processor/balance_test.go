@@ -0,0 +1,140 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package processor
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+type mapState map[string][]byte
+
+func (m mapState) GetState(namespace string, key string, opts ...fabric.GetStateOpt) ([]byte, error) {
+	return m[namespace+key], nil
+}
+
+func (m mapState) SetState(namespace string, key string, value []byte) error {
+	m[namespace+key] = value
+	return nil
+}
+
+type ownedToken struct {
+	wallet string
+	tok    *token2.Token
+}
+
+func cachedBalance(t *testing.T, state mapState, wallet, typ string) uint64 {
+	key, err := keys.CreateBalanceKey(wallet, typ)
+	assert.NoError(t, err)
+	raw := state["ns"+key]
+	if len(raw) == 0 {
+		return 0
+	}
+	v, err := strconv.ParseUint(string(raw), 10, 64)
+	assert.NoError(t, err)
+	return v
+}
+
+func TestBalances(t *testing.T) {
+	wallets := []string{"alice", "bob"}
+	types := []string{"USD", "EUR"}
+	state := mapState{}
+	var unspent []*ownedToken
+
+	r := rand.New(rand.NewSource(0))
+	for tx := 0; tx < 200; tx++ {
+		balances := NewBalances()
+		// spend some tokens
+		var left []*ownedToken
+		for _, ot := range unspent {
+			if r.Intn(3) == 0 {
+				assert.NoError(t, balances.Spent(ot.wallet, ot.tok))
+				continue
+			}
+			left = append(left, ot)
+		}
+		unspent = left
+		// and receive new ones, tokens of foreign owners are ignored
+		for i := 0; i < r.Intn(4); i++ {
+			ot := &ownedToken{
+				wallet: wallets[r.Intn(len(wallets))],
+				tok: &token2.Token{
+					Type:     types[r.Intn(len(types))],
+					Quantity: token2.NewQuantityFromUInt64(uint64(r.Intn(100) + 1)).Hex(),
+				},
+			}
+			unspent = append(unspent, ot)
+			assert.NoError(t, balances.Received(ot.wallet, ot.tok))
+		}
+		assert.NoError(t, balances.Received("", &token2.Token{Type: "USD", Quantity: "0x10"}))
+		assert.NoError(t, balances.Apply("ns", state, noRescan()))
+
+		// the cached balances match a full rescan
+		for _, wallet := range wallets {
+			for _, typ := range types {
+				var sum uint64
+				for _, ot := range unspent {
+					if ot.wallet == wallet && ot.tok.Type == typ {
+						q, err := token2.ToQuantity(ot.tok.Quantity, keys.Precision)
+						assert.NoError(t, err)
+						v, err := strconv.ParseUint(q.Decimal(), 10, 64)
+						assert.NoError(t, err)
+						sum += v
+					}
+				}
+				assert.Equal(t, sum, cachedBalance(t, state, wallet, typ), "tx [%d], wallet [%s], type [%s]", tx, wallet, typ)
+			}
+		}
+	}
+}
+
+// noRescan reports a zero balance for wallets not cached yet
+func noRescan() Rescan {
+	return func(walletID, typ string) (token2.Quantity, error) {
+		return token2.NewZeroQuantity(keys.Precision), nil
+	}
+}
+
+func TestBalancesRescan(t *testing.T) {
+	state := mapState{}
+	rescanned := 0
+	rescan := func(walletID, typ string) (token2.Quantity, error) {
+		rescanned++
+		return token2.NewQuantityFromUInt64(50), nil
+	}
+
+	// a balance not cached yet is computed from the tokens in the vault
+	balances := NewBalances()
+	assert.NoError(t, balances.Spent("alice", &token2.Token{Type: "USD", Quantity: "0x14"}))
+	assert.NoError(t, balances.Apply("ns", state, rescan))
+	assert.Equal(t, uint64(30), cachedBalance(t, state, "alice", "USD"))
+	assert.Equal(t, 1, rescanned)
+
+	// once cached, the vault is not scanned again
+	balances = NewBalances()
+	assert.NoError(t, balances.Received("alice", &token2.Token{Type: "USD", Quantity: "0x0a"}))
+	assert.NoError(t, balances.Apply("ns", state, rescan))
+	assert.Equal(t, uint64(40), cachedBalance(t, state, "alice", "USD"))
+	assert.Equal(t, 1, rescanned)
+
+	// spending more than the cached balance means the cache is corrupted
+	balances = NewBalances()
+	assert.NoError(t, balances.Spent("alice", &token2.Token{Type: "USD", Quantity: "0x64"}))
+	assert.Error(t, balances.Apply("ns", state, rescan))
+	assert.Equal(t, uint64(40), cachedBalance(t, state, "alice", "USD"))
+}
+
+func TestBalancesInvalidQuantity(t *testing.T) {
+	balances := NewBalances()
+	assert.Error(t, balances.Received("alice", &token2.Token{Type: "USD", Quantity: "not a quantity"}))
+}
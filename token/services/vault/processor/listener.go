@@ -0,0 +1,128 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package processor
+
+import (
+	"sync"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// TokenListener is notified of the tokens owned by this node that a transaction adds to the vault.
+// The notification happens while the transaction is being committed, therefore a listener
+// must check the status of the transaction before using the tokens.
+type TokenListener interface {
+	OnNewTokens(txID string, ids []*token2.Id)
+}
+
+var (
+	listenersLock sync.RWMutex
+	listeners     = map[string][]TokenListener{}
+)
+
+// AddTokenListener registers a listener for the new tokens in the passed channel and namespace
+func AddTokenListener(channel, namespace string, listener TokenListener) {
+	listenersLock.Lock()
+	defer listenersLock.Unlock()
+
+	k := channel + ":" + namespace
+	listeners[k] = append(listeners[k], listener)
+}
+
+func notifyNewTokens(channel, namespace, txID string, ids []*token2.Id) {
+	if len(ids) == 0 {
+		return
+	}
+	listenersLock.RLock()
+	ls := listeners[channel+":"+namespace]
+	listenersLock.RUnlock()
+
+	for _, l := range ls {
+		l.OnNewTokens(txID, ids)
+	}
+}
+
+// walletEvents collects the tokens that a transaction gives to and takes from the owner wallets of this node
+type walletEvents struct {
+	txID     string
+	wallets  []string
+	received map[string][]*token2.Id
+	spent    map[string][]*token2.Id
+}
+
+func newWalletEvents(txID string) *walletEvents {
+	return &walletEvents{
+		txID:     txID,
+		received: map[string][]*token2.Id{},
+		spent:    map[string][]*token2.Id{},
+	}
+}
+
+func (e *walletEvents) addReceived(walletID string, id *token2.Id) {
+	e.add(e.received, walletID, id)
+}
+
+func (e *walletEvents) addSpent(walletID string, id *token2.Id) {
+	e.add(e.spent, walletID, id)
+}
+
+func (e *walletEvents) add(tokens map[string][]*token2.Id, walletID string, id *token2.Id) {
+	if len(walletID) == 0 {
+		return
+	}
+	if _, ok := e.received[walletID]; !ok {
+		if _, ok := e.spent[walletID]; !ok {
+			e.wallets = append(e.wallets, walletID)
+		}
+	}
+	tokens[walletID] = append(tokens[walletID], id)
+}
+
+// notifyOnCommit raises, in background, the events once the transaction has been committed as valid to the vault
+// of the passed channel. Invalid transactions are notified by the views that wait for their finality.
+func (e *walletEvents) notifyOnCommit(ch *fabric.Channel, es *token.EventService) {
+	go e.notifyIfValid(func(txID string) (fabric.ValidationCode, error) {
+		err := ch.Finality().IsFinal(txID)
+		vc, _, vErr := ch.Vault().Status(txID)
+		if vErr != nil {
+			return vc, vErr
+		}
+		if vc != fabric.Valid {
+			logger.Debugf("transaction [%s] not final: [%v]", txID, err)
+		}
+		return vc, nil
+	}, es)
+}
+
+// notifyIfValid raises the events if the final status of the transaction, as returned by the passed function, is valid
+func (e *walletEvents) notifyIfValid(status func(txID string) (fabric.ValidationCode, error), es *token.EventService) {
+	vc, err := status(e.txID)
+	if err != nil {
+		logger.Warnf("failed getting status of [%s]: [%s]", e.txID, err)
+		return
+	}
+	if vc != fabric.Valid {
+		logger.Debugf("transaction [%s] not committed as valid, status [%v]", e.txID, vc)
+		return
+	}
+	e.notify(es)
+}
+
+// notify raises the token events of each wallet, followed by the commit of the transaction
+func (e *walletEvents) notify(es *token.EventService) {
+	for _, walletID := range e.wallets {
+		if ids := e.spent[walletID]; len(ids) != 0 {
+			es.Notify(&token.Event{Type: token.TokensSpent, TxID: e.txID, WalletIDs: []string{walletID}, Tokens: ids})
+		}
+		if ids := e.received[walletID]; len(ids) != 0 {
+			es.Notify(&token.Event{Type: token.TokensReceived, TxID: e.txID, WalletIDs: []string{walletID}, Tokens: ids})
+		}
+	}
+	es.Notify(&token.Event{Type: token.TxCommitted, TxID: e.txID, WalletIDs: e.wallets})
+}
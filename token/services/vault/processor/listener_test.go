@@ -0,0 +1,104 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package processor
+
+import (
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	api2 "github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+type fakeTMSProvider struct{}
+
+func (f *fakeTMSProvider) GetTokenManagerService(network string, channel string, namespace string, publicParamsFetcher api2.PublicParamsFetcher) (api2.TokenManagerService, error) {
+	return nil, nil
+}
+
+type fakeNormalizer struct{}
+
+func (f *fakeNormalizer) Normalize(opt *token.ServiceOptions) *token.ServiceOptions {
+	return opt
+}
+
+type recordingListener struct {
+	events []*token.Event
+}
+
+func (r *recordingListener) OnEvent(event *token.Event) {
+	r.events = append(r.events, event)
+}
+
+func newEventService() *token.EventService {
+	return token.NewManagementServiceProvider(nil, &fakeTMSProvider{}, &fakeNormalizer{}, nil, nil, nil, nil).GetManagementService().EventService()
+}
+
+func tokenID(txID string, index uint32) *token2.Id {
+	return &token2.Id{TxId: txID, Index: index}
+}
+
+func TestWalletEventsNotify(t *testing.T) {
+	events := newWalletEvents("tx1")
+	events.addSpent("alice", tokenID("tx0", 0))
+	events.addReceived("bob", tokenID("tx1", 0))
+	events.addReceived("alice", tokenID("tx1", 1))
+	events.addSpent("alice", tokenID("tx0", 1))
+	// tokens of other parties are not notified
+	events.addReceived("", tokenID("tx1", 2))
+
+	es := newEventService()
+	tx, alice, bob := &recordingListener{}, &recordingListener{}, &recordingListener{}
+	es.SubscribeTx("tx1", tx)
+	es.SubscribeWallet("alice", alice)
+	es.SubscribeWallet("bob", bob)
+	events.notify(es)
+
+	// each wallet gets its spent and received tokens, then the commit of the transaction, once
+	assert.Equal(t, []*token.Event{
+		{Type: token.TokensSpent, TxID: "tx1", WalletIDs: []string{"alice"}, Tokens: []*token2.Id{tokenID("tx0", 0), tokenID("tx0", 1)}},
+		{Type: token.TokensReceived, TxID: "tx1", WalletIDs: []string{"alice"}, Tokens: []*token2.Id{tokenID("tx1", 1)}},
+		{Type: token.TxCommitted, TxID: "tx1", WalletIDs: []string{"alice", "bob"}},
+	}, alice.events)
+	assert.Equal(t, []*token.Event{
+		{Type: token.TokensReceived, TxID: "tx1", WalletIDs: []string{"bob"}, Tokens: []*token2.Id{tokenID("tx1", 0)}},
+		{Type: token.TxCommitted, TxID: "tx1", WalletIDs: []string{"alice", "bob"}},
+	}, bob.events)
+	assert.Len(t, tx.events, 4)
+	assert.Equal(t, token.TxCommitted, tx.events[3].Type)
+}
+
+func TestWalletEventsNotifyIfValid(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		vc     fabric.ValidationCode
+		err    error
+		events int
+	}{
+		{"valid", fabric.Valid, nil, 2},
+		{"invalid", fabric.Invalid, nil, 0},
+		{"unknown", fabric.Unknown, nil, 0},
+		{"status error", fabric.Valid, errors.New("vault unavailable"), 0},
+	} {
+		events := newWalletEvents("tx1")
+		events.addReceived("alice", tokenID("tx1", 0))
+		es := newEventService()
+		alice := &recordingListener{}
+		es.SubscribeWallet("alice", alice)
+
+		var queried []string
+		events.notifyIfValid(func(txID string) (fabric.ValidationCode, error) {
+			queried = append(queried, txID)
+			return c.vc, c.err
+		}, es)
+		assert.Equal(t, []string{"tx1"}, queried, c.name)
+		assert.Len(t, alice.events, c.events, c.name)
+	}
+}
@@ -14,7 +14,10 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/htlc"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/multisig"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
 var logger = flogging.MustGetLogger("token-sdk.vault.processor")
@@ -111,15 +114,21 @@ func (r *RWSetProcessor) tokenRequest(req fabric.Request, tx fabric.ProcessTrans
 		return err
 	}
 
+	events := newWalletEvents(txID)
+	balances := NewBalances()
 	if tms.PublicParametersManager().GraphHiding() {
 		// Delete inputs
 		for _, id := range metadata.SpentTokenID() {
+			if err := r.spend(tms, ns, id, rws, events, balances); err != nil {
+				return err
+			}
 			if err := r.deleteFabToken(ns, id.TxId, int(id.Index), rws); err != nil {
 				return err
 			}
 		}
 	}
 
+	var mine []*token2.Id
 	for i := 0; i < rws.NumWrites(ns); i++ {
 		key, val, err := rws.GetWriteAt(ns, i)
 		if err != nil {
@@ -154,6 +163,9 @@ func (r *RWSetProcessor) tokenRequest(req fabric.Request, tx fabric.ProcessTrans
 
 		// This is a delete, add a delete for fabtoken
 		if len(val) == 0 {
+			if err := r.spend(tms, ns, &token2.Id{TxId: components[0], Index: uint32(index)}, rws, events, balances); err != nil {
+				return err
+			}
 			if err := r.deleteFabToken(ns, components[0], index, rws); err != nil {
 				return err
 			}
@@ -177,7 +189,7 @@ func (r *RWSetProcessor) tokenRequest(req fabric.Request, tx fabric.ProcessTrans
 			continue
 		}
 
-		if tms.WalletManager().OwnerWalletByIdentity(tok.Owner.Raw) != nil {
+		if isMine(tms, tok.Owner.Raw) {
 			logger.Debugf("transaction [%s], found a token and it is mine", txID)
 			// Add a lookup key to identity quickly that this token belongs to this
 			mineTokenID, err := keys.CreateTokenMineKey(components[0], index)
@@ -193,6 +205,14 @@ func (r *RWSetProcessor) tokenRequest(req fabric.Request, tx fabric.ProcessTrans
 			if err := r.storeFabToken(ns, txID, index, tok, rws, tokenInfoRaw); err != nil {
 				return err
 			}
+			id := &token2.Id{TxId: txID, Index: uint32(index)}
+			mine = append(mine, id)
+			if w := tms.WalletManager().OwnerWalletByIdentity(tok.Owner.Raw); w != nil {
+				events.addReceived(w.ID(), id)
+				if err := balances.Received(w.ID(), tok); err != nil {
+					return err
+				}
+			}
 		} else {
 			logger.Debugf("transaction [%s], found a token and I must be the auditor", txID)
 			if err := r.storeAuditToken(ns, txID, index, tok, rws, tokenInfoRaw); err != nil {
@@ -202,14 +222,63 @@ func (r *RWSetProcessor) tokenRequest(req fabric.Request, tx fabric.ProcessTrans
 
 		if !issuer.IsNone() && tms.WalletManager().IssuerWalletByIdentity(issuer) != nil {
 			logger.Debugf("transaction [%s], found a token and I have issued it", txID)
-			if err := r.storeIssuedHistoryToken(ns, txID, index, tok, rws, tokenInfoRaw, issuer); err != nil {
+			if err := r.storeIssuedHistoryToken(ns, txID, index, tok, rws, tokenInfoRaw, issuer, false); err != nil {
 				return err
 			}
 		}
 
 		logger.Debugf("Done parsing write key [%s]", key)
 	}
+
+	// Redeemed tokens are not written to the ledger, the issuers they go back to learn about them from the memos of the request
+	redeemed, err := r.redeemedTokens(tms, ns, txID, rws, transientMap.Get("zkat"))
+	if err != nil {
+		return errors.WithMessagef(err, "transaction [%s], failed getting redeemed tokens", txID)
+	}
+	for _, rt := range redeemed {
+		if tms.WalletManager().IssuerWalletByIdentity(rt.Issuer) == nil {
+			continue
+		}
+		logger.Debugf("transaction [%s], found a token redeemed to me", txID)
+		if err := r.storeIssuedHistoryToken(ns, txID, rt.Index, rt.Token, rws, rt.TokenInfo, rt.Issuer, true); err != nil {
+			return err
+		}
+	}
+	if err := balances.Apply(ns, rws, r.rescan(tms, ch, ns)); err != nil {
+		return errors.WithMessagef(err, "transaction [%s], failed updating balances", txID)
+	}
 	logger.Debugf("transaction [%s] is known, extract tokens, done!", txID)
+	notifyNewTokens(tx.Channel(), ns, txID, mine)
+	events.notifyOnCommit(ch, tms.EventService())
 
 	return nil
 }
+
+// isMine returns true if the passed owner belongs to one of the owner wallets.
+// A token locked by an htlc script is mine if either the sender or the recipient of the script is,
+// a token owned by a multisig identity is mine if any of the co-owners is.
+func isMine(tms *token.ManagementService, owner []byte) bool {
+	switch {
+	case htlc.IsScript(owner):
+		script := &htlc.Script{}
+		if err := script.Deserialize(owner); err != nil {
+			logger.Warnf("failed deserializing htlc script [%s]", err)
+			return false
+		}
+		return isMine(tms, script.Sender) || isMine(tms, script.Recipient)
+	case multisig.IsMultisig(owner):
+		id := &multisig.Identity{}
+		if err := id.Deserialize(owner); err != nil {
+			logger.Warnf("failed deserializing multisig identity [%s]", err)
+			return false
+		}
+		for _, coOwner := range id.Identities {
+			if isMine(tms, coOwner) {
+				return true
+			}
+		}
+		return false
+	default:
+		return tms.WalletManager().OwnerWalletByIdentity(owner) != nil
+	}
+}
@@ -13,7 +13,9 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/keys"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/vault/query"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 )
 
@@ -34,6 +36,93 @@ func (r *RWSetProcessor) deleteFabToken(ns string, txID string, index int, rws *
 	return nil
 }
 
+// ownerWallet returns the id of the owner wallet holding the passed token, together with the token,
+// if the token is stored in the vault
+func (r *RWSetProcessor) ownerWallet(tms *token.ManagementService, ns string, txID string, index int, rws *fabric.RWSet) (string, *token2.Token) {
+	outputID, err := keys.CreateFabtokenKey(txID, index)
+	if err != nil {
+		return "", nil
+	}
+	raw, err := rws.GetState(ns, outputID)
+	if err != nil || len(raw) == 0 {
+		return "", nil
+	}
+	tok := &token2.Token{}
+	if err := json.Unmarshal(raw, tok); err != nil {
+		logger.Warnf("failed unmarshalling token [%s:%d]: [%s]", txID, index, err)
+		return "", nil
+	}
+	w := tms.WalletManager().OwnerWalletByIdentity(tok.Owner.Raw)
+	if w == nil {
+		return "", nil
+	}
+	return w.ID(), tok
+}
+
+// spend records, in the passed events and balances, that the owner wallet holding the passed token spent it
+func (r *RWSetProcessor) spend(tms *token.ManagementService, ns string, id *token2.Id, rws *fabric.RWSet, events *walletEvents, balances *Balances) error {
+	walletID, tok := r.ownerWallet(tms, ns, id.TxId, int(id.Index), rws)
+	if len(walletID) == 0 {
+		return nil
+	}
+	events.addSpent(walletID, id)
+	return balances.Spent(walletID, tok)
+}
+
+// redeemedTokens returns the outputs of the token request, written by the passed transaction, redeemed back to an issuer
+func (r *RWSetProcessor) redeemedTokens(tms *token.ManagementService, ns string, txID string, rws *fabric.RWSet, metadataRaw []byte) ([]*token.RedeemedToken, error) {
+	key, err := keys.CreateTokenRequestKey(txID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed creating token request key for [%s]", txID)
+	}
+	requestRaw, err := rws.GetState(ns, key, fabric.FromIntermediate)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting token request of [%s]", txID)
+	}
+	if len(requestRaw) == 0 {
+		return nil, nil
+	}
+	request, err := tms.NewRequestFromBytes(txID, requestRaw, metadataRaw)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed unmarshalling token request of [%s]", txID)
+	}
+	return request.RedeemedTokens()
+}
+
+// rescan returns a Rescan that computes the balances from the unspent tokens committed to the vault of the passed channel.
+// The vault is scanned once, at the first call.
+func (r *RWSetProcessor) rescan(tms *token.ManagementService, ch *fabric.Channel, ns string) Rescan {
+	var balances map[balanceKey]token2.Quantity
+	return func(walletID, typ string) (token2.Quantity, error) {
+		if balances == nil {
+			unspent, err := query.NewEngine(ch, ns).ListUnspentTokens()
+			if err != nil {
+				return nil, errors.WithMessage(err, "failed listing unspent tokens")
+			}
+			balances = map[balanceKey]token2.Quantity{}
+			for _, tok := range unspent.Tokens {
+				w := tms.WalletManager().OwnerWalletByIdentity(tok.Owner.Raw)
+				if w == nil {
+					continue
+				}
+				q, err := token2.ToQuantity(tok.Quantity, keys.Precision)
+				if err != nil {
+					return nil, errors.Wrapf(err, "invalid quantity [%s]", tok.Quantity)
+				}
+				k := balanceKey{wallet: w.ID(), typ: tok.Type}
+				if sum, ok := balances[k]; ok {
+					q = sum.Add(q)
+				}
+				balances[k] = q
+			}
+		}
+		if q, ok := balances[balanceKey{wallet: walletID, typ: typ}]; ok {
+			return q, nil
+		}
+		return token2.NewZeroQuantity(keys.Precision), nil
+	}
+}
+
 func (r *RWSetProcessor) storeFabToken(ns string, txID string, index int, tok *token2.Token, rws *fabric.RWSet, infoRaw []byte) error {
 	outputID, err := keys.CreateFabtokenKey(txID, index)
 	if err != nil {
@@ -52,7 +141,9 @@ func (r *RWSetProcessor) storeFabToken(ns string, txID string, index int, tok *t
 	return nil
 }
 
-func (r *RWSetProcessor) storeIssuedHistoryToken(ns string, txID string, index int, tok *token2.Token, rws *fabric.RWSet, infoRaw []byte, issuer view.Identity) error {
+// storeIssuedHistoryToken appends the passed token to the history of the passed issuer.
+// If redeemed is true, the token has been redeemed back to the issuer.
+func (r *RWSetProcessor) storeIssuedHistoryToken(ns string, txID string, index int, tok *token2.Token, rws *fabric.RWSet, infoRaw []byte, issuer view.Identity, redeemed bool) error {
 	outputID, err := keys.CreateIssuedHistoryTokenKey(txID, index)
 	if err != nil {
 		return errors.Wrapf(err, "error creating output ID: [%s,%d]", txID, index)
@@ -68,6 +159,7 @@ func (r *RWSetProcessor) storeIssuedHistoryToken(ns string, txID string, index i
 		Issuer: &token2.Owner{
 			Raw: issuer,
 		},
+		Redeemed: redeemed,
 	}
 	raw := MarshalOrPanic(issuedToken)
 
@@ -76,10 +168,10 @@ func (r *RWSetProcessor) storeIssuedHistoryToken(ns string, txID string, index i
 		return errors.Wrapf(err, "invalid quantity [%s]", tok.Quantity)
 	}
 
-	logger.Debugf("transaction [%s], append issued history token [%s,%s][%s,%v]",
+	logger.Debugf("transaction [%s], append issued history token [%s,%s][%s,%v], redeemed [%v]",
 		txID,
 		tok.Type, q.Decimal(),
-		outputID, string(raw),
+		outputID, string(raw), redeemed,
 	)
 
 	if err := rws.SetState(ns, outputID, raw); err != nil {
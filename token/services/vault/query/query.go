@@ -58,39 +58,83 @@ func (e *Engine) IsMine(id *token.Id) (bool, error) {
 
 func (e *Engine) ListUnspentTokens() (*token.UnspentTokens, error) {
 	logger.Debugf("List token...")
+	tokens := make([]*token.UnspentToken, 0)
+	if err := e.scanUnspentTokens(func(tok *token.UnspentToken) bool {
+		tokens = append(tokens, tok)
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	return &token.UnspentTokens{Tokens: tokens}, nil
+}
+
+// UnspentTokensPage returns, in vault order, the unspent tokens accepted by filter, skipping the first offset of them.
+// A zero limit selects all the remaining tokens. The scan stops as soon as the page is full, more tells if
+// there are accepted tokens after the page.
+func (e *Engine) UnspentTokensPage(filter func(*token.UnspentToken) bool, offset, limit int) (*token.UnspentTokens, bool, error) {
+	return unspentTokensPage(e.scanUnspentTokens, filter, offset, limit)
+}
+
+func unspentTokensPage(scan func(func(*token.UnspentToken) bool) error, filter func(*token.UnspentToken) bool, offset, limit int) (*token.UnspentTokens, bool, error) {
+	if offset < 0 || limit < 0 {
+		return nil, false, errors.Errorf("invalid page [%d,%d]", offset, limit)
+	}
+	tokens := make([]*token.UnspentToken, 0)
+	skipped, more := 0, false
+	if err := scan(func(tok *token.UnspentToken) bool {
+		if !filter(tok) {
+			return true
+		}
+		if skipped < offset {
+			skipped++
+			return true
+		}
+		if limit != 0 && len(tokens) == limit {
+			more = true
+			return false
+		}
+		tokens = append(tokens, tok)
+		return true
+	}); err != nil {
+		return nil, false, err
+	}
+	return &token.UnspentTokens{Tokens: tokens}, more, nil
+}
+
+// scanUnspentTokens passes the unspent tokens in the vault, in key order, to callback until it returns false
+func (e *Engine) scanUnspentTokens(callback func(*token.UnspentToken) bool) error {
 	startKey, err := keys.CreateCompositeKey(keys.FabTokenKeyPrefix, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	endKey := startKey + string(keys.MaxUnicodeRuneValue)
 
 	logger.Debugf("New query executor")
 	qe, err := e.channel.Vault().NewQueryExecutor()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer qe.Done()
 
 	logger.Debugf("Get range query scan iterator... [%s,%s]", startKey, endKey)
 	iterator, err := qe.GetStateRangeScanIterator(e.namespace, startKey, endKey)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer iterator.Close()
 
 	logger.Debugf("scan range")
-	tokens := make([]*token.UnspentToken, 0)
 	for {
 		next, err := iterator.Next()
 		switch {
 		case err != nil:
 			logger.Errorf("scan failed [%s]", err)
-			return nil, err
+			return err
 
 		case next == nil:
 			logger.Debugf("done")
 			// nil response from iterator indicates end of query results
-			return &token.UnspentTokens{Tokens: tokens}, nil
+			return nil
 
 		case len(next.Raw) == 0:
 			// logger.Debugf("nil content for key [%s]", next.Key)
@@ -101,27 +145,26 @@ func (e *Engine) ListUnspentTokens() (*token.UnspentTokens, error) {
 
 			output, err := UnmarshallFabtoken(next.Raw)
 			if err != nil {
-				return nil, errors.Wrapf(err, "failed to retrieve unspent tokens for [%s]", next.Key)
+				return errors.Wrapf(err, "failed to retrieve unspent tokens for [%s]", next.Key)
 			}
 
-			// show only tokens which are owned by transactor
-			logger.Debugf("adding token with ID [%s] to list of unspent tokens", next.Key)
 			id, err := keys.GetTokenIdFromKey(next.Key)
 			if err != nil {
-				return nil, err
+				return err
 			}
 			// Convert quantity to decimal
 			q, err := token.ToQuantity(output.Quantity, keys.Precision)
 			if err != nil {
-				return nil, err
+				return err
+			}
+			if !callback(&token.UnspentToken{
+				Owner:    output.Owner,
+				Type:     output.Type,
+				Quantity: q.Decimal(),
+				Id:       id,
+			}) {
+				return nil
 			}
-			tokens = append(tokens,
-				&token.UnspentToken{
-					Owner:    output.Owner,
-					Type:     output.Type,
-					Quantity: q.Decimal(),
-					Id:       id,
-				})
 		}
 	}
 }
@@ -228,6 +271,55 @@ func (e *Engine) ListHistoryIssuedTokens() (*token.IssuedTokens, error) {
 	}
 }
 
+// Balance returns the balance of the passed wallet for the passed token type, as cached by the token processor.
+// If the balance is not cached yet, or the type is empty to sum the balances of all types, the unspent tokens
+// owned by the wallet are summed instead.
+func (e *Engine) Balance(wallet api.Wallet, tokenType string) (token.Quantity, error) {
+	var cached []byte
+	if len(tokenType) != 0 {
+		key, err := keys.CreateBalanceKey(wallet.ID(), tokenType)
+		if err != nil {
+			return nil, err
+		}
+		qe, err := e.channel.Vault().NewQueryExecutor()
+		if err != nil {
+			return nil, err
+		}
+		cached, err = qe.GetState(e.namespace, key)
+		qe.Done()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed getting balance of wallet [%s] for type [%s]", wallet.ID(), tokenType)
+		}
+	}
+	return walletBalance(cached, wallet, tokenType, e.ListUnspentTokens)
+}
+
+func walletBalance(cached []byte, wallet api.Wallet, tokenType string, listUnspentTokens func() (*token.UnspentTokens, error)) (token.Quantity, error) {
+	if len(cached) != 0 {
+		return token.ToQuantity(string(cached), keys.Precision)
+	}
+	logger.Debugf("balance of wallet [%s] for type [%s] not cached, sum unspent tokens", wallet.ID(), tokenType)
+	unspent, err := listUnspentTokens()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed listing unspent tokens of wallet [%s]", wallet.ID())
+	}
+	sum := token.NewZeroQuantity(keys.Precision)
+	for _, tok := range unspent.Tokens {
+		if len(tokenType) != 0 && tok.Type != tokenType {
+			continue
+		}
+		if !wallet.Contains(tok.Owner.Raw) {
+			continue
+		}
+		q, err := token.ToQuantity(tok.Quantity, keys.Precision)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid quantity [%s]", tok.Quantity)
+		}
+		sum = sum.Add(q)
+	}
+	return sum, nil
+}
+
 func (e *Engine) PublicParams() ([]byte, error) {
 	qe, err := e.channel.Vault().NewQueryExecutor()
 	if err != nil {
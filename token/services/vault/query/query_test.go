@@ -0,0 +1,147 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package query
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/api"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+type fakeWallet struct {
+	api.Wallet
+	owners []string
+}
+
+func (f *fakeWallet) ID() string {
+	return "alice"
+}
+
+func (f *fakeWallet) Contains(identity view.Identity) bool {
+	for _, owner := range f.owners {
+		if owner == string(identity) {
+			return true
+		}
+	}
+	return false
+}
+
+func unspentToken(owner, typ string, q uint64) *token.UnspentToken {
+	return &token.UnspentToken{
+		Owner:    &token.Owner{Raw: []byte(owner)},
+		Type:     typ,
+		Quantity: token.NewQuantityFromUInt64(q).Decimal(),
+	}
+}
+
+func TestWalletBalance(t *testing.T) {
+	wallet := &fakeWallet{owners: []string{"alice1", "alice2"}}
+	scans := 0
+	listUnspentTokens := func() (*token.UnspentTokens, error) {
+		scans++
+		return &token.UnspentTokens{Tokens: []*token.UnspentToken{
+			unspentToken("alice1", "USD", 10),
+			unspentToken("alice2", "USD", 5),
+			unspentToken("alice2", "EUR", 7),
+			unspentToken("bob", "USD", 100),
+		}}, nil
+	}
+
+	// a cached balance is returned as is, no token is scanned
+	q, err := walletBalance([]byte("42"), wallet, "USD", listUnspentTokens)
+	assert.NoError(t, err)
+	assert.Equal(t, "42", q.Decimal())
+	assert.Equal(t, 0, scans)
+
+	// on a cache miss, the unspent tokens of the wallet of the passed type are summed
+	q, err = walletBalance(nil, wallet, "USD", listUnspentTokens)
+	assert.NoError(t, err)
+	assert.Equal(t, "15", q.Decimal())
+	assert.Equal(t, 1, scans)
+
+	q, err = walletBalance(nil, wallet, "GBP", listUnspentTokens)
+	assert.NoError(t, err)
+	assert.Equal(t, "0", q.Decimal())
+
+	// an empty type sums all types
+	q, err = walletBalance(nil, wallet, "", listUnspentTokens)
+	assert.NoError(t, err)
+	assert.Equal(t, "22", q.Decimal())
+
+	_, err = walletBalance(nil, wallet, "USD", func() (*token.UnspentTokens, error) {
+		return nil, errors.New("vault unavailable")
+	})
+	assert.EqualError(t, err, "failed listing unspent tokens of wallet [alice]: vault unavailable")
+}
+
+func TestUnspentTokensPage(t *testing.T) {
+	var vault []*token.UnspentToken
+	for i := 0; i < 5; i++ {
+		vault = append(vault, unspentToken("alice", "USD", uint64(i)), unspentToken("bob", "USD", 100))
+	}
+	scanned := 0
+	scan := func(callback func(*token.UnspentToken) bool) error {
+		scanned = 0
+		for _, tok := range vault {
+			scanned++
+			if !callback(tok) {
+				return nil
+			}
+		}
+		return nil
+	}
+	alice := func(tok *token.UnspentToken) bool {
+		return string(tok.Owner.Raw) == "alice"
+	}
+	quantities := func(tokens *token.UnspentTokens) []string {
+		var res []string
+		for _, tok := range tokens.Tokens {
+			res = append(res, tok.Quantity)
+		}
+		return res
+	}
+
+	// the offset counts the accepted tokens only, the scan stops right after the page
+	tokens, more, err := unspentTokensPage(scan, alice, 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, quantities(tokens))
+	assert.True(t, more)
+	assert.Equal(t, 7, scanned)
+
+	// the last page
+	tokens, more, err = unspentTokensPage(scan, alice, 3, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3", "4"}, quantities(tokens))
+	assert.False(t, more)
+
+	// a zero limit selects all the remaining tokens
+	tokens, more, err = unspentTokensPage(scan, alice, 2, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2", "3", "4"}, quantities(tokens))
+	assert.False(t, more)
+
+	// paging past the end returns an empty page
+	tokens, more, err = unspentTokensPage(scan, alice, 5, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, tokens.Tokens)
+	assert.False(t, more)
+
+	_, _, err = unspentTokensPage(scan, alice, -1, 2)
+	assert.EqualError(t, err, "invalid page [-1,2]")
+	_, _, err = unspentTokensPage(scan, alice, 0, -2)
+	assert.EqualError(t, err, "invalid page [0,-2]")
+
+	_, _, err = unspentTokensPage(func(func(*token.UnspentToken) bool) error {
+		return errors.New("vault unavailable")
+	}, alice, 0, 2)
+	assert.EqualError(t, err, "vault unavailable")
+}
@@ -13,11 +13,14 @@ import (
 )
 
 type Output struct {
-	ActionIndex  int
-	Owner        view.Identity
-	EnrollmentID string
-	Type         string
-	Quantity     string
+	ActionIndex    int
+	Owner          view.Identity
+	OwnerAuditInfo []byte
+	EnrollmentID   string
+	Type           string
+	Quantity       string
+	// Memos are the application memos attached to the output that this party can see
+	Memos map[string][]byte
 }
 
 type Input struct {
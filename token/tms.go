@@ -84,6 +84,7 @@ type ManagementService struct {
 	certificationClientProvider CertificationClientProvider
 	selectorManagerProvider     SelectorManagerProvider
 	signatureService            *SignatureService
+	eventService                *EventService
 }
 
 func (t *ManagementService) String() string {
@@ -159,6 +160,11 @@ func (t *ManagementService) SigService() *SignatureService {
 	return t.signatureService
 }
 
+// EventService returns the service to subscribe to the events of the transactions and wallets of this TMS
+func (t *ManagementService) EventService() *EventService {
+	return t.eventService
+}
+
 func GetManagementService(sp ServiceProvider, opts ...ServiceOption) *ManagementService {
 	return GetManagementServiceProvider(sp).GetManagementService(opts...)
 }
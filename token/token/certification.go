@@ -0,0 +1,61 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package token
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CertificationEnvelope wraps the driver-specific certification of a token with its validity period.
+// The envelope is not signed, drivers certify the expiry together with the token so that validators can enforce it.
+type CertificationEnvelope struct {
+	// IssuedAt is the unix time, in seconds, at which the certifier issued the certification
+	IssuedAt int64 `json:"issued_at"`
+	// ExpiresAt is the unix time, in seconds, after which the certification is no longer valid
+	ExpiresAt int64 `json:"expires_at"`
+	// Certification is the driver-specific certification
+	Certification []byte `json:"certification"`
+}
+
+// NewCertificationEnvelope returns an envelope for the passed certification valid from issuedAt for the passed duration
+func NewCertificationEnvelope(certification []byte, issuedAt time.Time, validity time.Duration) *CertificationEnvelope {
+	return &CertificationEnvelope{
+		IssuedAt:      issuedAt.Unix(),
+		ExpiresAt:     issuedAt.Add(validity).Unix(),
+		Certification: certification,
+	}
+}
+
+func (e *CertificationEnvelope) Serialize() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+func (e *CertificationEnvelope) Deserialize(raw []byte) error {
+	if err := json.Unmarshal(raw, e); err != nil {
+		return errors.Wrap(err, "failed unmarshalling certification envelope")
+	}
+	if e.ExpiresAt <= e.IssuedAt {
+		return errors.Errorf("invalid certification envelope, expiry [%d] not after issuance [%d]", e.ExpiresAt, e.IssuedAt)
+	}
+	return nil
+}
+
+// Expired returns true if the certification is no longer valid at the passed time
+func (e *CertificationEnvelope) Expired(now time.Time) bool {
+	return now.Unix() >= e.ExpiresAt
+}
+
+// RenewAt returns the time after which the certification should be renewed.
+// If renewBefore is not positive, the last quarter of the validity period is used.
+func (e *CertificationEnvelope) RenewAt(renewBefore time.Duration) time.Time {
+	if renewBefore <= 0 {
+		renewBefore = time.Duration(e.ExpiresAt-e.IssuedAt) * time.Second / 4
+	}
+	return time.Unix(e.ExpiresAt, 0).Add(-renewBefore)
+}
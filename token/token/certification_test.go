@@ -0,0 +1,40 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package token_test
+
+import (
+	"testing"
+	"time"
+
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertificationEnvelope(t *testing.T) {
+	issuedAt := time.Unix(1000, 0)
+	e := token2.NewCertificationEnvelope([]byte("certification"), issuedAt, 100*time.Second)
+	raw, err := e.Serialize()
+	assert.NoError(t, err)
+
+	e2 := &token2.CertificationEnvelope{}
+	assert.NoError(t, e2.Deserialize(raw))
+	assert.Equal(t, e, e2)
+
+	assert.False(t, e2.Expired(issuedAt))
+	assert.False(t, e2.Expired(issuedAt.Add(99*time.Second)))
+	assert.True(t, e2.Expired(issuedAt.Add(100*time.Second)))
+
+	assert.Equal(t, time.Unix(1075, 0), e2.RenewAt(0))
+	assert.Equal(t, time.Unix(1090, 0), e2.RenewAt(10*time.Second))
+
+	e2.ExpiresAt = e2.IssuedAt
+	raw, err = e2.Serialize()
+	assert.NoError(t, err)
+	assert.Error(t, (&token2.CertificationEnvelope{}).Deserialize(raw))
+	assert.Error(t, (&token2.CertificationEnvelope{}).Deserialize([]byte("garbage")))
+}
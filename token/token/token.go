@@ -49,6 +49,8 @@ type IssuedToken struct {
 	Quantity string `protobuf:"bytes,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
 
 	Issuer *Owner
+	// Redeemed is true if the token has been redeemed back to the issuer rather than issued by it
+	Redeemed bool `json:",omitempty"`
 }
 
 type IssuedTokens struct {
@@ -82,6 +84,17 @@ func (it *IssuedTokens) Count() int {
 	return len(it.Tokens)
 }
 
+// Issued returns the tokens issued, leaving out those redeemed back to the issuer
+func (it *IssuedTokens) Issued() *IssuedTokens {
+	res := &IssuedTokens{Tokens: []*IssuedToken{}}
+	for _, token := range it.Tokens {
+		if !token.Redeemed {
+			res.Tokens = append(res.Tokens, token)
+		}
+	}
+	return res
+}
+
 // UnspentToken is used to specify a token returned by ListRequest
 type UnspentToken struct {
 	// Id is used to uniquely identify the token in the ledger
@@ -6,10 +6,19 @@ SPDX-License-Identifier: Apache-2.0
 package token
 
 import (
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+
 	tokenapi "github.com/hyperledger-labs/fabric-token-sdk/token/api"
 )
 
+// MaxTxTimeSkew is how far the timestamp of a transaction can be from the clock of the validating node
+const MaxTxTimeSkew = 5 * time.Minute
+
 type Verifier interface {
 	Verify(message, sigma []byte) error
 }
@@ -18,6 +27,12 @@ type Ledger interface {
 	GetState(key string) ([]byte, error)
 }
 
+// TxTimestampLedger is implemented by the ledgers that know the timestamp of the transaction being validated,
+// such as the chaincode stub. Ledgers that do not know it, such as the vault of an approver, use the local clock.
+type TxTimestampLedger interface {
+	GetTxTimestamp() (*timestamp.Timestamp, error)
+}
+
 type SignatureProvider interface {
 	HasBeenSignedBy(id view.Identity, verifier Verifier) error
 }
@@ -27,7 +42,11 @@ type Validator struct {
 }
 
 func (c *Validator) Verify(ledger Ledger, sp SignatureProvider, binding string, tr *Request) ([]interface{}, error) {
-	actions, err := c.backend.VerifyTokenRequest(ledger, &signatureProvider{sp: sp}, binding, tr.Actions)
+	txTime, err := txTime(ledger)
+	if err != nil {
+		return nil, err
+	}
+	actions, err := c.backend.VerifyTokenRequest(ledger, &signatureProvider{sp: sp}, binding, txTime, tr.Actions)
 	if err != nil {
 		return nil, err
 	}
@@ -40,9 +59,13 @@ func (c *Validator) Verify(ledger Ledger, sp SignatureProvider, binding string,
 }
 
 func (c *Validator) UnmarshallAndVerify(ledger Ledger, binding string, raw []byte) ([]interface{}, error) {
+	txTime, err := txTime(ledger)
+	if err != nil {
+		return nil, err
+	}
 	actions, err := c.backend.VerifyTokenRequestFromRaw(func(key string) ([]byte, error) {
 		return ledger.GetState(key)
-	}, binding, raw)
+	}, binding, txTime, raw)
 	if err != nil {
 		return nil, err
 	}
@@ -54,6 +77,54 @@ func (c *Validator) UnmarshallAndVerify(ledger Ledger, binding string, raw []byt
 	return res, nil
 }
 
+// Owner returns the owner of the passed ledger token, if the driver supports it
+func (c *Validator) Owner(raw []byte) (view.Identity, error) {
+	ov, ok := c.backend.(tokenapi.OwnerVerifier)
+	if !ok {
+		return nil, errors.New("the token driver does not support owner verification")
+	}
+	return ov.Owner(raw)
+}
+
+// VerifySignature checks that sigma is a signature of the passed owner on message, if the driver supports it
+func (c *Validator) VerifySignature(owner view.Identity, message, sigma []byte) error {
+	ov, ok := c.backend.(tokenapi.OwnerVerifier)
+	if !ok {
+		return errors.New("the token driver does not support owner verification")
+	}
+	return ov.VerifySignature(owner, message, sigma)
+}
+
+// CheckTxTime returns an error if the passed transaction timestamp is more than MaxTxTimeSkew away from now.
+// The timestamp is chosen by the client submitting the transaction, the bound keeps it from moving deadlines.
+func CheckTxTime(t time.Time, now time.Time) error {
+	if t.Before(now.Add(-MaxTxTimeSkew)) || t.After(now.Add(MaxTxTimeSkew)) {
+		return errors.Errorf("transaction timestamp [%s] is more than [%s] away from the local time [%s]", t, MaxTxTimeSkew, now)
+	}
+	return nil
+}
+
+// txTime returns the timestamp of the transaction being validated, if the ledger knows it, the local time otherwise.
+// The timestamp must be within MaxTxTimeSkew of the local time.
+func txTime(ledger Ledger) (time.Time, error) {
+	tl, ok := ledger.(TxTimestampLedger)
+	if !ok {
+		return time.Now(), nil
+	}
+	ts, err := tl.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed getting transaction timestamp")
+	}
+	t, err := ptypes.Timestamp(ts)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid transaction timestamp")
+	}
+	if err := CheckTxTime(t, time.Now()); err != nil {
+		return time.Time{}, err
+	}
+	return t, nil
+}
+
 type signatureProvider struct {
 	sp SignatureProvider
 }
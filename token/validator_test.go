@@ -0,0 +1,52 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+)
+
+type timestampLedger struct {
+	ts time.Time
+}
+
+func (l *timestampLedger) GetState(key string) ([]byte, error) {
+	return nil, nil
+}
+
+func (l *timestampLedger) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	return ptypes.TimestampProto(l.ts)
+}
+
+func TestTxTime(t *testing.T) {
+	now := time.Now()
+
+	// a timestamp close to the local clock is accepted
+	ts, err := txTime(&timestampLedger{ts: now.Add(-time.Minute)})
+	assert.NoError(t, err)
+	assert.True(t, ts.Equal(now.Add(-time.Minute)))
+
+	// a timestamp set back, or forward, too much is rejected
+	_, err = txTime(&timestampLedger{ts: now.Add(-MaxTxTimeSkew - time.Minute)})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "away from the local time")
+	_, err = txTime(&timestampLedger{ts: now.Add(MaxTxTimeSkew + time.Minute)})
+	assert.Error(t, err)
+}
+
+func TestCheckTxTime(t *testing.T) {
+	now := time.Now()
+	assert.NoError(t, CheckTxTime(now, now))
+	assert.NoError(t, CheckTxTime(now.Add(MaxTxTimeSkew), now))
+	assert.NoError(t, CheckTxTime(now.Add(-MaxTxTimeSkew), now))
+	assert.Error(t, CheckTxTime(now.Add(MaxTxTimeSkew+time.Second), now))
+	assert.Error(t, CheckTxTime(now.Add(-MaxTxTimeSkew-time.Second), now))
+}
@@ -22,6 +22,12 @@ func (q *QueryEngine) ListUnspentTokens() (*token2.UnspentTokens, error) {
 	return q.qe.ListUnspentTokens()
 }
 
+// UnspentTokensPage returns, in vault order, the unspent tokens accepted by filter, skipping the first offset of them.
+// A zero limit selects all the remaining tokens. more tells if there are accepted tokens after the page.
+func (q *QueryEngine) UnspentTokensPage(filter func(*token2.UnspentToken) bool, offset, limit int) (*token2.UnspentTokens, bool, error) {
+	return q.qe.UnspentTokensPage(filter, offset, limit)
+}
+
 func (q *QueryEngine) ListAuditTokens(ids ...*token2.Id) ([]*token2.Token, error) {
 	return q.qe.ListAuditTokens(ids...)
 }
@@ -30,6 +36,12 @@ func (q *QueryEngine) ListHistoryIssuedTokens() (*token2.IssuedTokens, error) {
 	return q.qe.ListHistoryIssuedTokens()
 }
 
+// Balance returns the sum of the unspent tokens of the passed type owned by the passed wallet.
+// An empty type sums the tokens of all types.
+func (q *QueryEngine) Balance(wallet *OwnerWallet, tokenType string) (token2.Quantity, error) {
+	return q.qe.Balance(wallet.w, tokenType)
+}
+
 func (q *QueryEngine) PublicParams() ([]byte, error) {
 	return q.qe.PublicParams()
 }
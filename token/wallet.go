@@ -107,6 +107,40 @@ func (t *WalletManager) CertifierWalletByIdentity(identity view.Identity) *Certi
 	return &CertifierWallet{w: w}
 }
 
+// RegisterOwnerWallet registers, under the passed id, a new owner wallet whose long-term identity,
+// X.509 or Idemix depending on the token driver, is stored in the MSP folder at the passed path.
+// The wallet survives restarts.
+// No key is generated: the MSP folder must be prepared beforehand, by enrolling with the CA of the organization
+// for X.509, or by obtaining a credential from the Idemix issuer.
+func (t *WalletManager) RegisterOwnerWallet(id string, mspID string, path string) error {
+	return t.ts.RegisterOwnerWallet(id, mspID, path)
+}
+
+// RemoveOwnerWallet makes the owner wallet with the passed id no longer available.
+// The key material of the wallet is not deleted.
+func (t *WalletManager) RemoveOwnerWallet(id string) error {
+	return t.ts.RemoveOwnerWallet(id)
+}
+
+// OwnerWalletIDs returns the ids of the owner wallets in use or registered at runtime, sorted
+func (t *WalletManager) OwnerWalletIDs() ([]string, error) {
+	return t.ts.OwnerWalletIDs()
+}
+
+// ExportOwnerWallet returns the key material of the passed owner wallet encrypted under the passed passphrase.
+// Only the wallets registered with RegisterOwnerWallet, or imported, can be exported. The wallets of the node
+// configuration are not: their MSP folders are managed, and backed up, with the configuration of the node.
+func (t *WalletManager) ExportOwnerWallet(id string, passphrase []byte) ([]byte, error) {
+	return t.ts.ExportOwnerWallet(id, passphrase)
+}
+
+// ImportOwnerWallet registers the owner wallet exported by ExportOwnerWallet, possibly on another node,
+// storing its key material in the folder at the passed path, that must not exist or be empty.
+// It returns the id of the wallet.
+func (t *WalletManager) ImportOwnerWallet(raw []byte, passphrase []byte, path string) (string, error) {
+	return t.ts.ImportOwnerWallet(raw, passphrase, path)
+}
+
 type Signer interface {
 	// Sign signs message bytes and returns the signature or an error on failure.
 	Sign(message []byte) ([]byte, error)
@@ -200,6 +234,19 @@ func (o *OwnerWallet) ListTokens(opts ...ListTokensOption) (*token2.UnspentToken
 	return o.w.ListTokens(compiledOpts)
 }
 
+// Restore recovers the recipient identities of this wallet that own unspent tokens in the vault,
+// stopping after gapLimit consecutive unused identities. It returns the number of identities recovered.
+func (o *OwnerWallet) Restore(gapLimit int) (int, error) {
+	return o.w.Restore(gapLimit)
+}
+
+// Balance returns the sum of the unspent tokens of the passed type owned by this wallet, an empty type sums all types.
+// The balance is maintained by the vault as tokens are received and spent, the tokens are scanned only
+// when the balance is not cached yet or all types are summed.
+func (o *OwnerWallet) Balance(tokenType string) (token2.Quantity, error) {
+	return o.w.Balance(tokenType)
+}
+
 type IssuerWallet struct {
 	w api2.IssuerWallet
 }
@@ -220,6 +267,8 @@ func (i *IssuerWallet) GetSigner(identity view.Identity) (Signer, error) {
 	return i.w.GetSigner(identity)
 }
 
+// HistoryTokens returns the tokens issued by this wallet, and those redeemed back to it, flagged as redeemed.
+// Use IssuedTokens.Issued to select the issued ones.
 func (i *IssuerWallet) HistoryTokens(opts ...ListTokensOption) (*token2.IssuedTokens, error) {
 	compiledOpts, err := compileListTokensOption(opts...)
 	if err != nil {
@@ -228,6 +277,15 @@ func (i *IssuerWallet) HistoryTokens(opts ...ListTokensOption) (*token2.IssuedTo
 	return i.w.HistoryTokens(compiledOpts)
 }
 
+// RedeemedTokens returns the tokens redeemed back to this wallet
+func (i *IssuerWallet) RedeemedTokens(opts ...ListTokensOption) (*token2.IssuedTokens, error) {
+	compiledOpts, err := compileListTokensOption(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return i.w.RedeemedTokens(compiledOpts)
+}
+
 func compileListTokensOption(opts ...ListTokensOption) (*api2.ListTokensOptions, error) {
 	txOptions := &ListTokensOptions{}
 	for _, opt := range opts {